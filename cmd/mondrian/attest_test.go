@@ -0,0 +1,305 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+)
+
+// loadHeadAttestation returns the attestation at the head of dir's chain
+func loadHeadAttestation(t *testing.T, dir string) *evidence.Attestation {
+	t.Helper()
+	chainManager := evidence.NewChainManager(getEvidenceDir(dir))
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	attestation, err := chainManager.FindAttestation(chain, chain.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return attestation
+}
+
+func TestAttestExplicitSubject(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "logs" {}` + "\n"})
+	runMondrian(t, binary, dir, "init")
+
+	digest := strings.Repeat("4f", 32)
+	image := "ghcr.io/acme/api@sha256:" + digest
+	output, code := runMondrian(t, binary, dir, "attest", "--subject", image)
+	if code != 0 || !strings.Contains(output, "Subject: ghcr.io/acme/api") {
+		t.Fatalf("attest exited %d with %q, want the subject reported", code, output)
+	}
+
+	subjects := loadHeadAttestation(t, dir).Subject
+	if len(subjects) < 2 || subjects[0].Name != "ghcr.io/acme/api" || subjects[0].Digest["sha256"] != digest {
+		t.Fatalf("subjects = %+v, want the image first", subjects)
+	}
+	if subjects[1].Name != "main.tf" {
+		t.Errorf("context subject = %+v, want main.tf after the image", subjects[1])
+	}
+
+	// A malformed subject fails before anything is attested
+	if output, code := runMondrian(t, binary, dir, "attest", "--subject", "ghcr.io/acme/api:latest"); code != 1 || !strings.Contains(output, "Invalid --subject") {
+		t.Errorf("malformed subject exited %d with %q, want 1", code, output)
+	}
+	if head := loadHeadAttestation(t, dir); head.Subject[0].Name != "ghcr.io/acme/api" {
+		t.Errorf("a rejected attest changed the chain head")
+	}
+}
+
+func TestAttestSBOM(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"main.tf":    `resource "aws_s3_bucket" "logs" {}` + "\n",
+		"Dockerfile": "FROM node:20-alpine\nUSER node\n",
+	})
+	runMondrian(t, binary, dir, "init")
+
+	if output, code := runMondrian(t, binary, dir, "attest", "--sbom"); code != 0 || !strings.Contains(output, "SBOM file:") {
+		t.Fatalf("attest --sbom exited %d with %q", code, output)
+	}
+
+	var sbom *evidence.Subject
+	for _, subject := range loadHeadAttestation(t, dir).Subject {
+		if strings.HasPrefix(subject.Name, "sbom-") {
+			sbom = &subject
+		}
+	}
+	if sbom == nil {
+		t.Fatal("attestation has no SBOM subject")
+	}
+	data, err := os.ReadFile(filepath.Join(getEvidenceDir(dir), sbom.Name))
+	if err != nil {
+		t.Fatalf("SBOM not written next to the attestation: %v", err)
+	}
+	if hash := sha256.Sum256(data); hex.EncodeToString(hash[:]) != sbom.Digest["sha256"] {
+		t.Errorf("subject digest does not match the SBOM file")
+	}
+	if !strings.Contains(string(data), `"pkg:docker/node@20-alpine"`) {
+		t.Errorf("SBOM lacks the base image:\n%s", data)
+	}
+}
+
+func TestAttestFailsWhenTSAUnavailable(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "logs" {}` + "\n"})
+	runMondrian(t, binary, dir, "init")
+
+	tsa := httptest.NewServer(http.NotFoundHandler())
+	tsa.Close()
+	output, code := runMondrian(t, binary, dir, "attest", "--tsa", tsa.URL)
+	if code != 1 || !strings.Contains(output, "Error time-stamping attestation") {
+		t.Errorf("attest exited %d with %q, want a time-stamping failure", code, output)
+	}
+	chain, err := evidence.NewChainManager(getEvidenceDir(dir)).LoadChain()
+	if err == nil && chain.Length != 0 {
+		t.Errorf("chain has %d attestations, want none saved without the requested time-stamp", chain.Length)
+	}
+}
+
+func TestAttestDryRun(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "logs" {}` + "\n"})
+	runMondrian(t, binary, dir, "init")
+	evidenceDir := getEvidenceDir(dir)
+
+	output, code := runMondrian(t, binary, dir, "attest", "--dry-run")
+	if code != 0 {
+		t.Fatalf("attest --dry-run exited %d: %s", code, output)
+	}
+	var signed evidence.SignedAttestation
+	if err := json.Unmarshal([]byte(output), &signed); err != nil {
+		t.Fatalf("dry run printed more than the signed attestation: %v\n%s", err, output)
+	}
+	publicKey, err := os.ReadFile(getPublicKeyPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := evidence.ParsePublicKeyPEM(string(publicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := evidence.VerifySignedAttestation(&signed, key); err != nil {
+		t.Errorf("dry-run attestation is not signed with the project key: %v", err)
+	}
+	if entries, _ := os.ReadDir(evidenceDir); len(entries) != 0 {
+		t.Errorf("dry run wrote %d files to %s", len(entries), evidenceDir)
+	}
+
+	// An existing chain is left as it was
+	if _, code := runMondrian(t, binary, dir, "attest"); code != 0 {
+		t.Fatalf("attest exited %d", code)
+	}
+	before, err := os.ReadDir(evidenceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainBefore, _ := os.ReadFile(filepath.Join(evidenceDir, "chain.json"))
+	if _, code := runMondrian(t, binary, dir, "attest", "--dry-run"); code != 0 {
+		t.Fatalf("attest --dry-run exited %d", code)
+	}
+	after, _ := os.ReadDir(evidenceDir)
+	chainAfter, _ := os.ReadFile(filepath.Join(evidenceDir, "chain.json"))
+	if len(after) != len(before) || string(chainAfter) != string(chainBefore) {
+		t.Errorf("dry run changed the evidence directory")
+	}
+}
+
+func TestAttestRecordsGitContext(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := newTestRepo(t, map[string]string{"main.tf": `resource "aws_s3_bucket" "logs" {}` + "\n"})
+	runTestGit(t, dir, "remote", "add", "origin", "https://github.com/acme/infra.git")
+	head := strings.TrimSpace(runTestGit(t, dir, "rev-parse", "HEAD"))
+	runMondrian(t, binary, dir, "init")
+
+	if output, code := runMondrian(t, binary, dir, "attest"); code != 0 {
+		t.Fatalf("attest exited %d with %q", code, output)
+	}
+	predicate := loadHeadAttestation(t, dir).Predicate
+	if predicate.Repository != "github.com/acme/infra" || predicate.Branch != "main" || predicate.Commit != head {
+		t.Errorf("attested %q %q %q, want github.com/acme/infra main %s", predicate.Repository, predicate.Branch, predicate.Commit, head)
+	}
+}
+
+func TestAttestSubjectsDigestScannedContent(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	runMondrian(t, binary, dir, "init")
+
+	mainDigest := func() string {
+		t.Helper()
+		for _, subject := range loadHeadAttestation(t, dir).Subject {
+			if subject.Name == "main.tf" {
+				return subject.Digest["sha256"]
+			}
+		}
+		t.Fatal("main.tf is not a subject")
+		return ""
+	}
+
+	// Editing main.tf in place changes its subject
+	for _, content := range []string{"resource \"aws_s3_bucket\" \"logs\" {}\n", "resource \"aws_s3_bucket\" \"logs\" {\n  acl = \"private\"\n}\n"} {
+		writeTestFiles(t, dir, map[string]string{"main.tf": content})
+		if output, code := runMondrian(t, binary, dir, "attest"); code != 0 {
+			t.Fatalf("attest exited %d with %q", code, output)
+		}
+		if hash := sha256.Sum256([]byte(content)); mainDigest() != hex.EncodeToString(hash[:]) {
+			t.Errorf("main.tf subject = %s, want the SHA-256 of its content", mainDigest())
+		}
+	}
+}
+
+func TestAttestSeparateChainDirs(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "logs" {}` + "\n"})
+	runMondrian(t, binary, dir, "init")
+
+	attest := func(chainDir string) {
+		t.Helper()
+		if output, code := runMondrian(t, binary, dir, "attest", "--chain-dir", chainDir); code != 0 {
+			t.Fatalf("attest --chain-dir %s exited %d with %q", chainDir, code, output)
+		}
+	}
+	attest(filepath.Join(".mondrian", "staging"))
+	attest(filepath.Join(".mondrian", "staging"))
+	attest(filepath.Join(dir, ".mondrian", "prod"))
+
+	load := func(name string) *evidence.EvidenceChain {
+		t.Helper()
+		chain, err := evidence.NewChainManager(filepath.Join(dir, ".mondrian", name)).LoadChain()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return chain
+	}
+	staging, prod := load("staging"), load("prod")
+	if staging.Length != 2 || prod.Length != 1 {
+		t.Fatalf("staging has %d attestations and prod %d, want 2 and 1", staging.Length, prod.Length)
+	}
+
+	// Each chain links only its own attestations
+	if prod.Attestations[0].ParentHash != "" {
+		t.Errorf("prod genesis has parent %s, want none", prod.Attestations[0].ParentHash)
+	}
+	if staging.Attestations[1].ParentHash != staging.Attestations[0].Hash {
+		t.Errorf("second staging attestation is not linked to the first")
+	}
+	for _, entry := range staging.Attestations {
+		if entry.Hash == prod.Head {
+			t.Errorf("prod attestation %s is in the staging chain", prod.Head)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(getEvidenceDir(dir), "chain.json")); !os.IsNotExist(err) {
+		t.Errorf("attesting to other chain dirs wrote the default chain")
+	}
+
+	for _, chainDir := range []string{filepath.Join(".mondrian", "staging"), filepath.Join(".mondrian", "prod")} {
+		if output, code := runMondrian(t, binary, dir, "verify", "--chain-dir", chainDir); code != 0 {
+			t.Errorf("verify --chain-dir %s exited %d with %q", chainDir, code, output)
+		}
+	}
+}
+
+func TestAttestEncryptTo(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": "variable \"db\" {\n  default = \"hunter2-hunter2-hunter2\"\n}\n"})
+	if output, code := runMondrian(t, binary, dir, "init", "--encryption"); code != 0 {
+		t.Fatalf("init --encryption exited %d: %s", code, output)
+	}
+	keyPath := filepath.Join(".mondrian", "keys", "encryption.pem")
+	if output, code := runMondrian(t, binary, dir, "attest", "--encrypt-to", filepath.Join(".mondrian", "keys", "encryption.pub")); code != 0 {
+		t.Fatalf("attest --encrypt-to exited %d: %s", code, output)
+	}
+
+	chain, err := evidence.NewChainManager(getEvidenceDir(dir)).LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(getEvidenceDir(dir), chain.Attestations[0].FilePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") || !strings.Contains(string(data), evidence.EncryptionScheme) {
+		t.Errorf("saved attestation is not encrypted:\n%s", data)
+	}
+
+	if output, code := runMondrian(t, binary, dir, "verify", "--decryption-key", keyPath); code != 0 || !strings.Contains(output, "Verification PASSED") {
+		t.Errorf("verify --decryption-key exited %d with %q, want it to pass", code, output)
+	}
+	if output, code := runMondrian(t, binary, dir, "verify"); code != 1 || !strings.Contains(output, "no decryption key was given") {
+		t.Errorf("verify without the key exited %d with %q, want the attestation unreadable", code, output)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestChainLog(t *testing.T) {
+	binary := buildMondrian(t)
+	wd, chain := newTestProject(t, newTestSigner(t), 3)
+	hashes := []string{chain.Attestations[2].Hash, chain.Attestations[1].Hash, chain.Attestations[0].Hash}
+
+	output, code := runMondrian(t, binary, wd, "chain", "log")
+	if code != 0 {
+		t.Fatalf("chain log exited %d with %q", code, output)
+	}
+	// Newest first, each pointing at the one listed after it
+	last := -1
+	for i, hash := range hashes {
+		at := strings.Index(output, "attestation "+hash)
+		if at < 0 {
+			t.Fatalf("entry %d (%s) missing from:\n%s", 3-i, hash, output)
+		}
+		if at < last {
+			t.Errorf("entry %d is listed out of order:\n%s", 3-i, output)
+		}
+		last = at
+	}
+	entries := strings.Split(output, "\n\n")
+	if len(entries) != 3 || !strings.Contains(entries[0], "(#3)") || !strings.Contains(entries[0], "Parent: "+hashes[1][:12]) {
+		t.Errorf("newest entry = %q, want #3 with its parent's short hash", entries[0])
+	}
+	if !strings.Contains(entries[2], "Parent: (genesis)") || !strings.Contains(entries[2], "Status: pass") {
+		t.Errorf("oldest entry = %q, want the genesis attestation", entries[2])
+	}
+
+	output, _ = runMondrian(t, binary, wd, "chain", "log", "--oneline")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("--oneline printed %d lines, want 3:\n%s", len(lines), output)
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, hashes[i][:12]+" ") || !strings.HasSuffix(line, "pass 0 files") {
+			t.Errorf("line %d = %q, want %s first", i+1, line, hashes[i][:12])
+		}
+	}
+
+	output, _ = runMondrian(t, binary, wd, "chain", "log", "--format", "json")
+	var logged []chainLogEntry
+	if err := json.Unmarshal([]byte(output), &logged); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+	for i, entry := range logged {
+		if entry.Position != 3-i || entry.Hash != hashes[i] || entry.Files != 0 {
+			t.Errorf("entry %d = #%d %s with %d files, want #%d %s", i, entry.Position, entry.Hash, entry.Files, 3-i, hashes[i])
+		}
+	}
+
+	if _, code := runMondrian(t, binary, wd, "chain", "log", "--format", "yaml"); code != 1 {
+		t.Errorf("unknown format exited %d, want 1", code)
+	}
+}
+
+func TestChainLogEmpty(t *testing.T) {
+	binary := buildMondrian(t)
+	wd, _ := newTestProject(t, newTestSigner(t), 0)
+
+	if output, code := runMondrian(t, binary, wd, "chain", "log"); code != 0 || !strings.Contains(output, "No attestations in the evidence chain yet") {
+		t.Errorf("empty chain exited %d with %q", code, output)
+	}
+	if output, code := runMondrian(t, binary, wd, "chain", "log", "--format", "json"); code != 0 || strings.TrimSpace(output) != "[]" {
+		t.Errorf("empty chain as JSON exited %d with %q, want []", code, output)
+	}
+}
@@ -0,0 +1,623 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+// newTestRepo initializes a git repository on branch main in a temporary
+// directory, committing files as its first commit
+func newTestRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	runTestGit(t, dir, "init", "--quiet", "--initial-branch", "main")
+	runTestGit(t, dir, "config", "user.email", "ci@example.com")
+	runTestGit(t, dir, "config", "user.name", "CI")
+	runTestGit(t, dir, "config", "commit.gpgsign", "false")
+	writeTestFiles(t, dir, files)
+	runTestGit(t, dir, "add", "--all")
+	runTestGit(t, dir, "commit", "--quiet", "--message", "initial")
+	return dir
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGitChangedFiles(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{
+		"main.tf":        "# main\n",
+		"modules/s3.tf":  "# s3\n",
+		"modules/iam.tf": "# iam\n",
+		"old/removed.tf": "# removed\n",
+		"docs/README.md": "# docs\n",
+	})
+
+	// A PR branch that changes one file and deletes another
+	runTestGit(t, dir, "checkout", "--quiet", "-b", "pr")
+	writeTestFiles(t, dir, map[string]string{"modules/s3.tf": "# s3 changed\n"})
+	runTestGit(t, dir, "rm", "--quiet", "old/removed.tf")
+	runTestGit(t, dir, "commit", "--quiet", "--all", "--message", "pr")
+
+	// main moving on after the branch point isn't part of the PR
+	runTestGit(t, dir, "checkout", "--quiet", "main")
+	writeTestFiles(t, dir, map[string]string{"modules/iam.tf": "# iam on main\n"})
+	runTestGit(t, dir, "commit", "--quiet", "--all", "--message", "main")
+	runTestGit(t, dir, "checkout", "--quiet", "pr")
+
+	// Uncommitted and untracked changes are part of it
+	writeTestFiles(t, dir, map[string]string{
+		"main.tf":        "# main changed\n",
+		"modules/vpc.tf": "# new\n",
+	})
+
+	changed, err := gitChangedFiles(dir, "main", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(changed)
+	if want := []string{"main.tf", "modules/s3.tf", "modules/vpc.tf"}; !slices.Equal(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+
+	// Paths are relative to a subdirectory, which limits them to it
+	changed, err = gitChangedFiles(filepath.Join(dir, "modules"), "main", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(changed)
+	if want := []string{"s3.tf", "vpc.tf"}; !slices.Equal(changed, want) {
+		t.Errorf("changed in modules = %v, want %v", changed, want)
+	}
+}
+
+func TestGitChangedFilesStaged(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.tf": "# main\n", "s3.tf": "# s3\n"})
+
+	writeTestFiles(t, dir, map[string]string{"main.tf": "# staged\n", "s3.tf": "# unstaged\n", "new.tf": "# untracked\n"})
+	runTestGit(t, dir, "add", "main.tf")
+
+	changed, err := gitChangedFiles(dir, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"main.tf"}; !slices.Equal(changed, want) {
+		t.Errorf("staged = %v, want %v", changed, want)
+	}
+}
+
+func TestGitChangedFilesErrors(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.tf": "# main\n"})
+
+	if _, err := gitChangedFiles(dir, "origin/does-not-exist", false); err == nil || !strings.Contains(err.Error(), "cannot resolve origin/does-not-exist") {
+		t.Errorf("err = %v, want an unresolvable base ref", err)
+	}
+	if _, err := gitChangedFiles(t.TempDir(), "main", false); err == nil || !strings.Contains(err.Error(), "not inside a git repository") {
+		t.Errorf("err = %v, want not inside a git repository", err)
+	}
+}
+
+func TestScanChangedFiles(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{
+		"main.tf":    `resource "aws_s3_bucket" "logs" {}` + "\n",
+		"network.tf": `resource "aws_vpc" "main" {}` + "\n",
+		"README.md":  "# infra\n",
+	})
+	runTestGit(t, dir, "checkout", "--quiet", "-b", "pr")
+	writeTestFiles(t, dir, map[string]string{
+		"network.tf": `resource "aws_vpc" "main" { cidr_block = "10.0.0.0/16" }` + "\n",
+		"README.md":  "# infra changed\n",
+	})
+	runTestGit(t, dir, "commit", "--quiet", "--all", "--message", "pr")
+
+	checkBase = "main"
+	t.Cleanup(func() { checkBase = "" })
+
+	files := scanChangedFiles(context.Background(), dir, nil)
+	if len(files) != 1 || files["network.tf"] == "" {
+		t.Errorf("scanned %v, want just network.tf", slices.Collect(maps.Keys(files)))
+	}
+
+	// Without the base ref or a repository, the caller scans everything
+	checkBase = "origin/missing"
+	if files := scanChangedFiles(context.Background(), dir, nil); files != nil {
+		t.Errorf("scanned %v with an unresolvable base, want nil", files)
+	}
+	checkBase = "main"
+	if files := scanChangedFiles(context.Background(), t.TempDir(), nil); files != nil {
+		t.Errorf("scanned %v outside a repository, want nil", files)
+	}
+}
+
+// buildMondrian compiles the CLI into a temporary directory
+func buildMondrian(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds the mondrian binary")
+	}
+	binary := filepath.Join(t.TempDir(), "mondrian")
+	if output, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, output)
+	}
+	return binary
+}
+
+// runMondrian runs the CLI in dir, returning its standard output and exit code
+func runMondrian(t *testing.T, binary, dir string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return string(output), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("mondrian %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return string(output), 0
+}
+
+func TestCheckChangedSinceAnnotateNew(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "legacy" {
+  acl = "public-read"
+}
+`,
+		"README.md": "# infra\n",
+	})
+	binary := buildMondrian(t)
+
+	// main's known finding is attested, which makes it the baseline
+	for _, args := range [][]string{{"init"}, {"attest"}} {
+		if output, code := runMondrian(t, binary, dir, args...); code != 0 {
+			t.Fatalf("mondrian %s exited %d\n%s", args[0], code, output)
+		}
+	}
+	runTestGit(t, dir, "add", "--all")
+	runTestGit(t, dir, "commit", "--quiet", "--message", "attest")
+
+	// The PR touches the file with the known finding and adds a new one
+	runTestGit(t, dir, "checkout", "--quiet", "-b", "pr")
+	writeTestFiles(t, dir, map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "legacy" {
+  acl = "public-read"
+
+  tags = {
+    Team = "platform"
+  }
+}
+`,
+		"network.tf": `resource "aws_security_group" "web" {
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+`,
+	})
+	runTestGit(t, dir, "add", "--all")
+	runTestGit(t, dir, "commit", "--quiet", "--message", "add web security group")
+
+	output, code := runMondrian(t, binary, dir, "check", "--changed-since", "main", "--annotate-new")
+	if code != 1 {
+		t.Errorf("exit code %d, want 1 for a new finding\n%s", code, output)
+	}
+	annotations := strings.Split(strings.TrimSpace(output), "\n")
+	if len(annotations) != 1 || !strings.HasPrefix(annotations[0], "::error file=network.tf,line=5,title=sg-no-open-ingress::") {
+		t.Errorf("annotations = %q, want one for the new security group only", annotations)
+	}
+
+	// New findings below --fail-on are annotated as warnings without failing
+	output, code = runMondrian(t, binary, dir, "check", "--changed-since", "main", "--annotate-new", "--fail-on", "critical")
+	if code != 0 || !strings.HasPrefix(output, "::warning file=network.tf,line=5,") {
+		t.Errorf("--fail-on critical exited %d with %q, want 0 and a warning", code, output)
+	}
+
+	// A PR that only changes docs has nothing new to fail on
+	runTestGit(t, dir, "checkout", "--quiet", "-b", "docs", "main")
+	writeTestFiles(t, dir, map[string]string{"README.md": "# infra, documented\n"})
+	runTestGit(t, dir, "commit", "--quiet", "--all", "--message", "docs")
+
+	if output, code := runMondrian(t, binary, dir, "check", "--changed-since", "main", "--annotate-new"); code != 0 || strings.Contains(output, "::") {
+		t.Errorf("docs-only PR exited %d with %q, want 0 and no annotations", code, output)
+	}
+}
+
+func TestLatestAttestationHashWithoutChain(t *testing.T) {
+	wd, _ := newTestProject(t, newTestSigner(t), 0)
+
+	if hash := latestAttestationHash(wd); hash != "" {
+		t.Errorf("head = %q before anything was attested, want none", hash)
+	}
+	// Reading the baseline must not start a chain a later attest would extend
+	if _, err := os.Stat(filepath.Join(getEvidenceDir(wd), "chain.json")); !os.IsNotExist(err) {
+		t.Errorf("looking up the baseline created chain.json")
+	}
+}
+
+func TestLoadBaselineAttestation(t *testing.T) {
+	wd, chain := newTestProject(t, newTestSigner(t), 2)
+
+	hash := latestAttestationHash(wd)
+	if hash != chain.Head {
+		t.Fatalf("head = %s, want %s", hash, chain.Head)
+	}
+	if baseline := loadBaselineAttestation(wd, hash); baseline.Hash != hash {
+		t.Errorf("baseline = %s, want %s", baseline.Hash, hash)
+	}
+}
+
+func TestCheckBaselineFromAttestation(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "legacy" {
+  acl = "public-read"
+}
+`})
+	for _, args := range [][]string{{"init"}, {"attest"}} {
+		if output, code := runMondrian(t, binary, dir, args...); code != 0 {
+			t.Fatalf("mondrian %s exited %d\n%s", args[0], code, output)
+		}
+	}
+	hash := latestAttestationHash(dir)
+
+	writeTestFiles(t, dir, map[string]string{"network.tf": `resource "aws_security_group" "web" {
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+`})
+	output, code := runMondrian(t, binary, dir, "check", "--format", "json", "--baseline-from-attestation", hash[:12])
+	if code != 1 {
+		t.Errorf("exit code %d, want 1 for the finding the attestation lacks", code)
+	}
+	var results []policy.CheckResult
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+	failed := make(map[string]bool)
+	knownBucket := false
+	for _, result := range results {
+		if result.Status != "pass" {
+			failed[result.File] = true
+		}
+		if result.RuleName == "s3-no-public-buckets" && result.Metadata["baseline"] == true {
+			knownBucket = true
+		}
+	}
+	if len(failed) != 1 || !failed["network.tf"] || !knownBucket {
+		t.Errorf("failures in %v with the bucket known: %v, want only network.tf failing", slices.Collect(maps.Keys(failed)), knownBucket)
+	}
+
+	if output, code := runMondrian(t, binary, dir, "check", "--baseline-from-attestation", "0000000000"); code != 1 {
+		t.Errorf("unknown baseline exited %d with %q, want 1", code, output)
+	}
+}
+
+func TestScanRootsNamespacesFiles(t *testing.T) {
+	wd := t.TempDir()
+	outside := t.TempDir()
+	writeTestFiles(t, wd, map[string]string{
+		"infra/main.tf":         "# infra\n",
+		"deploy/main.tf":        "# deploy\n",
+		"k8s/base/service.yaml": "kind: Service\n",
+		"unscanned/main.tf":     "# not a root\n",
+	})
+	writeTestFiles(t, outside, map[string]string{"shared.tf": "# shared\n"})
+
+	files := scanRoots(context.Background(), wd, []string{"infra", "deploy/", "k8s/base", outside})
+	want := []string{"deploy/main.tf", "infra/main.tf", "k8s/base/service.yaml", filepath.ToSlash(filepath.Join(filepath.Clean(outside), "shared.tf"))}
+	got := slices.Sorted(maps.Keys(files))
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("scanned %v, want %v", got, want)
+	}
+	// Files with the same name in different roots both survive the merge
+	if files["infra/main.tf"] != "# infra\n" || files["deploy/main.tf"] != "# deploy\n" {
+		t.Errorf("same-named files collided: %v", files)
+	}
+
+	// Without roots the working directory is scanned as before
+	if files := scanRoots(context.Background(), wd, nil); files["unscanned/main.tf"] == "" || files["infra/main.tf"] == "" {
+		t.Errorf("scanned %v without roots, want all of wd", slices.Collect(maps.Keys(files)))
+	}
+}
+
+func TestCheckMultipleRoots(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	bucket := `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`
+	writeTestFiles(t, dir, map[string]string{"infra/main.tf": bucket, "deploy/main.tf": bucket, "other/main.tf": bucket})
+
+	output, code := runMondrian(t, binary, dir, "check", "--format", "json", "infra", "deploy")
+	if code != 1 {
+		t.Errorf("exit code %d, want 1 for the public buckets", code)
+	}
+	var results []policy.CheckResult
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+	flagged := make(map[string]bool)
+	for _, result := range results {
+		if result.RuleName == "s3-no-public-buckets" && result.Status != "pass" {
+			flagged[result.File] = true
+		}
+	}
+	if len(flagged) != 2 || !flagged["infra/main.tf"] || !flagged["deploy/main.tf"] {
+		t.Errorf("flagged %v, want the bucket in each root and none outside them", slices.Collect(maps.Keys(flagged)))
+	}
+
+	if output, code := runMondrian(t, binary, dir, "check", "infra", "missing"); code != 1 || !strings.Contains(output, "Error scanning missing: not a directory") {
+		t.Errorf("missing root exited %d with %q, want 1", code, output)
+	}
+}
+
+func TestCheckIncludeExclude(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	bucket := `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`
+	writeTestFiles(t, dir, map[string]string{
+		"main.tf":               bucket,
+		"infra/main.tf":         bucket,
+		"infra/testdata/bad.tf": bucket,
+	})
+
+	output, code := runMondrian(t, binary, dir, "check", "--format", "json", "--include", "infra/**", "--exclude", "**/testdata/**")
+	if code != 1 {
+		t.Errorf("exit code %d, want 1 for the public bucket", code)
+	}
+	var results []policy.CheckResult
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+	files := make(map[string]bool)
+	for _, result := range results {
+		if result.File != "" {
+			files[result.File] = true
+		}
+	}
+	if len(files) != 1 || !files["infra/main.tf"] {
+		t.Errorf("checked %v, want only infra/main.tf", slices.Collect(maps.Keys(files)))
+	}
+
+	if output, code := runMondrian(t, binary, dir, "check", "--exclude", "**/*.tf"); code != 0 {
+		t.Errorf("excluding every file exited %d with %q, want 0", code, output)
+	}
+	// A glob that matches nothing passes, an invalid one is an error
+	if _, code := runMondrian(t, binary, dir, "check", "--include", "env/[z-a]"); code != 1 {
+		t.Errorf("invalid glob exited %d, want 1", code)
+	}
+}
+
+func TestCheckNDJSON(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`})
+
+	output, code := runMondrian(t, binary, dir, "check", "--format", "json")
+	var want []policy.CheckResult
+	if err := json.Unmarshal([]byte(output), &want); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+
+	// One object per line, the same results as the buffered JSON
+	output, ndjsonCode := runMondrian(t, binary, dir, "check", "--format", "ndjson")
+	if ndjsonCode != code {
+		t.Errorf("ndjson exited %d, json %d", ndjsonCode, code)
+	}
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want one per result (%d)", len(lines), len(want))
+	}
+	for i, line := range lines {
+		var result policy.CheckResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("line %d %q: %v", i+1, line, err)
+		}
+		if result.RuleName != want[i].RuleName || result.Status != want[i].Status || result.Line != want[i].Line {
+			t.Errorf("line %d = %s %s:%d, want %s %s:%d", i+1, result.RuleName, result.Status, result.Line, want[i].RuleName, want[i].Status, want[i].Line)
+		}
+	}
+}
+
+func TestCheckUpdateBaseline(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	bucket := `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`
+	writeTestFiles(t, dir, map[string]string{"main.tf": bucket})
+
+	if output, code := runMondrian(t, binary, dir, "check", "--update-baseline"); code != 0 || !strings.Contains(output, "📌 Recorded") {
+		t.Fatalf("--update-baseline exited %d with %q", code, output)
+	}
+	if output, code := runMondrian(t, binary, dir, "check"); code != 0 || !strings.Contains(output, "known findings suppressed") {
+		t.Errorf("baselined finding exited %d with %q, want it suppressed", code, output)
+	}
+
+	// A new finding still fails, and fixing the old one is reported
+	writeTestFiles(t, dir, map[string]string{"main.tf": "", "network.tf": `resource "aws_security_group" "ssh" {
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+`})
+	output, code := runMondrian(t, binary, dir, "check")
+	if code != 1 {
+		t.Errorf("new finding exited %d, want 1", code)
+	}
+	if !strings.Contains(output, "baselined findings are resolved") {
+		t.Errorf("output %q does not report the fixed finding", output)
+	}
+}
+
+func TestCheckHTMLReport(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`})
+
+	report := filepath.Join(dir, "report.html")
+	if output, code := runMondrian(t, binary, dir, "check", "--format", "html", "--out", report); code != 1 || output != "" {
+		t.Errorf("exited %d writing %q to stdout, want 1 and the report only in the file", code, output)
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<h2 class="fail">s3-no-public-buckets</h2>`) {
+		t.Error("report has no failing section for the public bucket")
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "logs" {}` + "\n"})
+
+	// Expiring before the scan starts abandons it without reporting results
+	output, code := runMondrian(t, binary, dir, "check", "--timeout", "1ns")
+	if code != 1 || strings.Contains(output, "Scanning") {
+		t.Errorf("timed out check exited %d with %q, want 1 before any files are checked", code, output)
+	}
+
+	runMondrian(t, binary, dir, "init")
+	if _, code := runMondrian(t, binary, dir, "attest", "--timeout", "1ns"); code != 1 {
+		t.Errorf("timed out attest exited %d, want 1", code)
+	}
+	if _, err := os.Stat(filepath.Join(getEvidenceDir(dir), "chain.json")); !os.IsNotExist(err) {
+		t.Errorf("timed out attest wrote a chain")
+	}
+}
+
+func TestCheckConfigDiscovery(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := newTestRepo(t, map[string]string{
+		"mondrian.yaml":       "rules:\n  - sg-no-open-ingress\n",
+		"strict.yaml":         "rules:\n  - s3-no-public-buckets\n",
+		"modules/web/main.tf": "resource \"aws_s3_bucket\" \"assets\" {\n  acl = \"public-read\"\n}\n",
+	})
+	subdir := filepath.Join(dir, "modules", "web")
+
+	// From a subdirectory the project config, which skips the bucket rule,
+	// still applies
+	if output, code := runMondrian(t, binary, subdir, "check"); code != 0 {
+		t.Errorf("check in a subdirectory exited %d with %q, want the parent config applied", code, output)
+	}
+	if output, code := runMondrian(t, binary, subdir, "check", "--config", filepath.Join(dir, "strict.yaml")); code != 1 || !strings.Contains(output, "s3-no-public-buckets") {
+		t.Errorf("--config exited %d with %q, want the public bucket flagged", code, output)
+	}
+	if output, code := runMondrian(t, binary, subdir, "check", "--config", filepath.Join(dir, "missing.yaml")); code != 1 || strings.Contains(output, "Scanning") {
+		t.Errorf("missing --config exited %d with %q, want 1 before checking", code, output)
+	}
+}
+
+func TestCheckBaselineCompare(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	bucket := func(name, acl string) string {
+		return "resource \"aws_s3_bucket\" \"" + name + "\" {\n  acl = \"" + acl + "\"\n}\n"
+	}
+	writeTestFiles(t, dir, map[string]string{"main.tf": bucket("logs", "public-read") + bucket("assets", "public-read")})
+	runMondrian(t, binary, dir, "init")
+	runMondrian(t, binary, dir, "attest")
+	reference := loadHeadAttestation(t, dir)
+
+	check := func(ref string) (string, int) {
+		t.Helper()
+		return runMondrian(t, binary, dir, "check", "--rule", "s3-no-public-buckets", "--baseline-compare", ref)
+	}
+
+	// Unchanged: the known findings are reported but don't fail
+	output, code := check("#1")
+	if code != 0 || !strings.Contains(output, "No regressions from baseline "+shortHash(reference.Hash)) || !strings.Contains(output, "aws_s3_bucket.logs") {
+		t.Errorf("unchanged run exited %d with %q, want it to pass reporting the findings", code, output)
+	}
+
+	// Improved
+	writeTestFiles(t, dir, map[string]string{"main.tf": bucket("logs", "private") + bucket("assets", "public-read")})
+	if output, code := check(reference.Hash[:12]); code != 0 || !strings.Contains(output, "No regressions") {
+		t.Errorf("improved run exited %d with %q, want it to pass", code, output)
+	}
+
+	// Regressed, though there are no more findings than before
+	writeTestFiles(t, dir, map[string]string{"main.tf": bucket("logs", "private") + bucket("assets", "public-read") + bucket("uploads", "public-read-write")})
+	chain, err := evidence.NewChainManager(getEvidenceDir(dir)).LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, code = check(filepath.Join(getEvidenceDir(dir), chain.Attestations[0].FilePath))
+	if code != 1 || !strings.Contains(output, "1 regressions from baseline") || !strings.Contains(output, "aws_s3_bucket.uploads") {
+		t.Errorf("regressed run exited %d with %q, want it to fail on the new bucket", code, output)
+	}
+
+	if _, code := check("#2"); code != 1 {
+		t.Errorf("an out of range chain position exited %d, want 1", code)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"mondrian.yaml": "rules:\n  - s3-no-public-buckets\nfail_on: medium\n",
+		"typo.yaml":     "rules: []\nseverty:\n  s3-no-public-buckets: high\n",
+	})
+
+	if output, code := runMondrian(t, binary, dir, "config", "validate"); code != 0 || !strings.Contains(output, "mondrian.yaml is valid") {
+		t.Errorf("valid config exited %d with %q", code, output)
+	}
+	output, code := runMondrian(t, binary, dir, "config", "validate", filepath.Join(dir, "typo.yaml"))
+	if code != 1 || !strings.Contains(output, `line 2: unknown key "severty", did you mean "severity"?`) {
+		t.Errorf("misspelled key exited %d with %q, want it pinpointed", code, output)
+	}
+	if _, code := runMondrian(t, binary, dir, "config", "validate", filepath.Join(dir, "missing.yaml")); code != 1 {
+		t.Errorf("missing config exited %d, want 1", code)
+	}
+
+	// check refuses to run with an invalid config
+	if output, code := runMondrian(t, binary, dir, "check", "--config", filepath.Join(dir, "typo.yaml")); code != 1 || !strings.Contains(output, "severty") {
+		t.Errorf("check with an invalid config exited %d with %q", code, output)
+	}
+}
+
+func TestConfigSchema(t *testing.T) {
+	binary := buildMondrian(t)
+	output, code := runMondrian(t, binary, t.TempDir(), "config", "schema")
+	var schema map[string]interface{}
+	if code != 0 || json.Unmarshal([]byte(output), &schema) != nil || schema["title"] == nil {
+		t.Errorf("config schema exited %d with %q, want the JSON Schema", code, output)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+)
+
+func TestResolveAttestation(t *testing.T) {
+	wd, chain := newTestProject(t, newTestSigner(t), 3)
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"#1", chain.Attestations[0].Hash},
+		{"#3", chain.Head},
+		{chain.Attestations[1].Hash, chain.Attestations[1].Hash},
+		{filepath.Join(getEvidenceDir(wd), chain.Attestations[2].FilePath), chain.Attestations[2].Hash},
+	}
+	for _, tt := range tests {
+		if attestation := resolveAttestation(wd, tt.ref); attestation.Hash != tt.want {
+			t.Errorf("resolveAttestation(%q) = %s, want %s", tt.ref, attestation.Hash, tt.want)
+		}
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`})
+	runMondrian(t, binary, dir, "init")
+	if output, code := runMondrian(t, binary, dir, "attest"); code != 0 {
+		t.Fatalf("attest exited %d: %s", code, output)
+	}
+
+	// The bucket is made private, and an open security group appears
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "assets" {
+  acl = "private"
+}
+
+resource "aws_security_group" "web" {
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+`})
+	if output, code := runMondrian(t, binary, dir, "attest"); code != 0 {
+		t.Fatalf("attest exited %d: %s", code, output)
+	}
+
+	output, code := runMondrian(t, binary, dir, "diff", "#1", "#2", "--format", "json")
+	if code != 0 {
+		t.Fatalf("diff exited %d: %s", code, output)
+	}
+	var diff evidence.AttestationDiff
+	if err := json.Unmarshal([]byte(output), &diff); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+	changes := make(map[string]string)
+	for _, rule := range diff.Rules {
+		changes[rule.Rule] = rule.Change
+	}
+	if changes["s3-no-public-buckets"] != evidence.RuleNewlyPassing || changes["sg-no-open-ingress"] != evidence.RuleNewlyFailing {
+		t.Errorf("changes = %v, want the S3 rule newly passing and the SG rule newly failing", changes)
+	}
+
+	output, _ = runMondrian(t, binary, dir, "diff", "#1", "#2")
+	for _, want := range []string{"✅ s3-no-public-buckets", "- resolved: ", "❌ sg-no-open-ingress", "+ new: "} {
+		if !strings.Contains(output, want) {
+			t.Errorf("text diff lacks %q:\n%s", want, output)
+		}
+	}
+
+	if _, code := runMondrian(t, binary, dir, "diff", "#1", "#2", "--format", "sarif"); code != 1 {
+		t.Errorf("unknown format exited %d, want 1", code)
+	}
+}
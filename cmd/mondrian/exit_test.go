@@ -0,0 +1,89 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+func TestExitCode(t *testing.T) {
+	pass := policy.CheckResult{RuleName: "s3-no-public-buckets", Status: "pass"}
+	warn := policy.CheckResult{RuleName: "tf-required-tags", Status: "warn", Severity: policy.SeverityLow, File: "main.tf", Line: 1, Message: "missing Owner"}
+	fail := policy.CheckResult{RuleName: "s3-no-public-buckets", Status: "fail", Severity: policy.SeverityHigh, File: "main.tf", Line: 2, Message: "public bucket"}
+
+	tests := []struct {
+		name    string
+		results []policy.CheckResult
+		opts    exitOptions
+		want    int
+	}{
+		{"no results", nil, exitOptions{}, 0},
+		{"passing", []policy.CheckResult{pass}, exitOptions{}, 0},
+		{"warning", []policy.CheckResult{pass, warn}, exitOptions{}, 0},
+		{"failure", []policy.CheckResult{pass, fail}, exitOptions{}, 1},
+		{"no-fail with a failure", []policy.CheckResult{warn, fail}, exitOptions{NoFail: true}, 0},
+		{"no-fail with a warning", []policy.CheckResult{warn}, exitOptions{NoFail: true}, 0},
+		{"fail-on-warn with a warning", []policy.CheckResult{pass, warn}, exitOptions{FailOnWarn: true}, 1},
+		{"fail-on-warn with a failure", []policy.CheckResult{fail}, exitOptions{FailOnWarn: true}, 1},
+		{"fail-on-warn passing", []policy.CheckResult{pass}, exitOptions{FailOnWarn: true}, 0},
+		{"failure in the baseline", []policy.CheckResult{fail}, exitOptions{Baseline: []policy.CheckResult{fail}}, 0},
+		{"new failure since the baseline", []policy.CheckResult{warn, fail}, exitOptions{Baseline: []policy.CheckResult{warn}}, 1},
+		{"new warning since the baseline with fail-on-warn", []policy.CheckResult{warn, fail}, exitOptions{FailOnWarn: true, Baseline: []policy.CheckResult{fail}}, 1},
+		{"new failure since the baseline with no-fail", []policy.CheckResult{fail}, exitOptions{NoFail: true, Baseline: []policy.CheckResult{}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.results, tt.opts); got != tt.want {
+				t.Errorf("exitCode = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckExitFlags(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"main.tf": "resource \"aws_s3_bucket\" \"b\" {\n  acl = \"public-read\"\n}\n",
+		// The untagged bucket is a low severity warning
+		"mondrian.yaml": "settings:\n  tf-required-tags:\n    tags: [Owner]\n",
+	})
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"--rule", "s3-no-public-buckets"}, 1},
+		{[]string{"--rule", "s3-no-public-buckets", "--no-fail"}, 0},
+		{[]string{"--rule", "tf-required-tags"}, 0},
+		{[]string{"--rule", "tf-required-tags", "--fail-on-warn"}, 1},
+		{[]string{"--rule", "tf-required-tags", "--fail-on", "low"}, 1},
+		{[]string{"--rule", "tf-required-tags", "--fail-on", "low", "--no-fail"}, 0},
+	}
+	for _, tt := range tests {
+		args := append([]string{"check"}, tt.args...)
+		if output, code := runMondrian(t, binary, dir, args...); code != tt.want {
+			t.Errorf("mondrian %v exited %d, want %d\n%s", args, code, tt.want, output)
+		}
+	}
+
+	if _, code := runMondrian(t, binary, dir, "check", "--no-fail", "--fail-on-warn"); code == 0 {
+		t.Error("--no-fail with --fail-on-warn was accepted")
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHooksInstall(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := newTestRepo(t, map[string]string{"README.md": "# infra\n"})
+	hookPath := filepath.Join(dir, ".git", "hooks", "pre-commit")
+
+	if output, code := runMondrian(t, binary, dir, "hooks", "install", "--fail-on", "high"); code != 0 {
+		t.Fatalf("hooks install exited %d with %q", code, output)
+	}
+	info, err := os.Stat(hookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		t.Errorf("hook mode %v is not executable", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(hookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if script := string(data); !strings.Contains(script, hookMarker) || !strings.Contains(script, "check --changed-only --staged --fail-on high") {
+		t.Errorf("hook script = %q, want a marked check of staged files failing on high", script)
+	}
+
+	// An existing hook is only replaced with --force
+	if _, code := runMondrian(t, binary, dir, "hooks", "install"); code != 1 {
+		t.Errorf("reinstall without --force exited %d, want 1", code)
+	}
+	if _, code := runMondrian(t, binary, dir, "hooks", "install", "--force"); code != 0 {
+		t.Errorf("reinstall with --force exited %d, want 0", code)
+	}
+
+	// The hook rejects a commit staging a failing file
+	writeTestFiles(t, dir, map[string]string{"main.tf": `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`})
+	runTestGit(t, dir, "add", "main.tf")
+	commit := exec.Command("git", "commit", "--quiet", "--message", "public bucket")
+	commit.Dir = dir
+	if output, err := commit.CombinedOutput(); err == nil {
+		t.Errorf("commit with a public bucket succeeded: %s", output)
+	}
+}
+
+func TestHooksUninstall(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := newTestRepo(t, map[string]string{"README.md": "# infra\n"})
+	hookPath := filepath.Join(dir, ".git", "hooks", "pre-commit")
+
+	runMondrian(t, binary, dir, "hooks", "install")
+	if output, code := runMondrian(t, binary, dir, "hooks", "uninstall"); code != 0 {
+		t.Fatalf("hooks uninstall exited %d with %q", code, output)
+	}
+	if _, err := os.Stat(hookPath); !os.IsNotExist(err) {
+		t.Errorf("hook still exists after uninstall")
+	}
+
+	// A hook Mondrian didn't write is left alone
+	if err := os.WriteFile(hookPath, []byte("#!/bin/sh\nmake lint\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, code := runMondrian(t, binary, dir, "hooks", "uninstall"); code != 1 {
+		t.Errorf("removing another tool's hook exited %d, want 1", code)
+	}
+	if _, err := os.Stat(hookPath); err != nil {
+		t.Errorf("another tool's hook was removed: %v", err)
+	}
+	if _, code := runMondrian(t, binary, dir, "hooks", "uninstall", "--force"); code != 0 {
+		t.Errorf("uninstall --force exited %d, want 0", code)
+	}
+
+	if _, code := runMondrian(t, binary, t.TempDir(), "hooks", "install"); code != 1 {
+		t.Errorf("install outside a repository exited %d, want 1", code)
+	}
+}
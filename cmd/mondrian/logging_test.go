@@ -0,0 +1,121 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(&consoleHandler{out: &out, level: slog.LevelDebug, mu: &sync.Mutex{}})
+
+	logger.Debug("Ran rule", "rule", "s3-public-read", "results", 2)
+	logger.Info("Scanning")
+	logger.Warn("Rekor upload failed", "err", errors.New("connection refused"))
+	logger.With("path", "chain.json").Error("Unreadable", "reason", "")
+
+	want := `debug: Ran rule rule=s3-public-read results=2
+Scanning
+⚠️  Rekor upload failed err="connection refused"
+❌ Unreadable path=chain.json reason=""
+`
+	if out.String() != want {
+		t.Errorf("logged\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestConsoleHandlerLevels(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  []slog.Level
+	}{
+		{slog.LevelDebug, []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}},
+		{slog.LevelInfo, []slog.Level{slog.LevelInfo, slog.LevelWarn, slog.LevelError}},
+		{slog.LevelError, []slog.Level{slog.LevelError}},
+	}
+	for _, tt := range tests {
+		handler := &consoleHandler{out: &bytes.Buffer{}, level: tt.level, mu: &sync.Mutex{}}
+		var enabled []slog.Level
+		for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+			if handler.Enabled(context.Background(), level) {
+				enabled = append(enabled, level)
+			}
+		}
+		if !slices.Equal(enabled, tt.want) {
+			t.Errorf("at %s enabled %v, want %v", tt.level, enabled, tt.want)
+		}
+	}
+}
+
+// runMondrianStderr runs binary like runMondrian, returning what it logged to
+// stderr instead of its output
+func runMondrianStderr(t *testing.T, binary, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			t.Fatalf("mondrian %s: %v", strings.Join(args, " "), err)
+		}
+	}
+	return stderr.String()
+}
+
+func TestLogLevelFlags(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	// Outside a git repository --changed-only warns and scans every file
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"aws_s3_bucket\" \"b\" {\n  acl = \"private\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"check", "--changed-only"}
+
+	stderr := runMondrianStderr(t, binary, dir, args...)
+	if strings.Contains(stderr, "debug:") {
+		t.Errorf("default level logged debug lines:\n%s", stderr)
+	}
+	if !strings.Contains(stderr, "⚠️  --changed-only:") {
+		t.Errorf("default level did not log the warning:\n%s", stderr)
+	}
+
+	stderr = runMondrianStderr(t, binary, dir, append(args, "--verbose")...)
+	for _, line := range []string{"debug: Loading policy config", "debug: Ran rule rule=s3-no-public-buckets"} {
+		if !strings.Contains(stderr, line) {
+			t.Errorf("--verbose did not log %q:\n%s", line, stderr)
+		}
+	}
+
+	if stderr = runMondrianStderr(t, binary, dir, append(args, "--quiet")...); stderr != "" {
+		t.Errorf("--quiet logged:\n%s", stderr)
+	}
+	if stderr = runMondrianStderr(t, binary, dir, append(args, "--verbose", "--quiet")...); !strings.Contains(stderr, "verbose") || strings.Contains(stderr, "debug:") {
+		t.Errorf("--verbose with --quiet logged:\n%s", stderr)
+	}
+}
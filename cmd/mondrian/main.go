@@ -17,15 +17,36 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
+	"os/user"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/miqcie/mondrian/internal/evidence"
 	"github.com/miqcie/mondrian/internal/policy"
+	"github.com/miqcie/mondrian/internal/server"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var rootCmd = &cobra.Command{
@@ -35,25 +56,99 @@ var rootCmd = &cobra.Command{
 
 Complete documentation is available at https://github.com/miqcie/mondrian`,
 	Version: "v0.1.0",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		setupLogging()
+		recordInvocation(cmd, args)
+		
+		// An explicit config that doesn't exist is an error, not an empty config
+		if configFile != "" {
+			if _, err := os.Stat(configFile); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Config file %s not found\n", configFile)
+				exit(1)
+			}
+		}
+	},
 }
 
 var checkCmd = &cobra.Command{
-	Use:   "check",
+	Use:   "check [path...]",
 	Short: "Run policy checks against current environment",
-	Long:  `Check runs all configured policies against the current repository, infrastructure, and environment.`,
+	Long: `Check runs all configured policies against the current repository, infrastructure, and environment.
+
+Pass one or more paths to scan several roots in one run; findings are
+reported relative to the current directory.
+
+Check exits 1 when any finding fails. --no-fail always exits 0 for
+report-only pipelines, and --fail-on-warn exits 1 on warnings too. Neither
+changes the findings themselves, so 'mondrian attest' still records their
+real status.
+
+--repo clones a remote repository (shallowly, at --ref when given) into a
+temporary directory and checks it instead, so one machine can scan many
+repositories. Paths are then relative to the clone.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if checkAnnotateNew {
+			if cmd.Flags().Changed("format") && checkFormat != "github" {
+				fmt.Fprintf(os.Stderr, "❌ --annotate-new emits GitHub annotations and cannot be combined with --format %s\n", checkFormat)
+				exit(1)
+			}
+			checkFormat = "github"
+		}
+		if checkFormat == "text" {
+			fmt.Println("🔍 Running Mondrian policy checks...")
+		}
+		runPolicyChecks(args)
+	},
+}
+
+var (
+	checkFormat       string
+	checkBaseline     string
+	checkCompare      string
+	checkChangedSince string
+	checkChangedOnly  bool
+	checkBase         string
+	checkAnnotateNew  bool
+	checkRedact       bool
+	checkFailOn       string
+	checkInclude      []string
+	checkRules        []string
+	checkExclude      []string
+	checkUpdateBase   bool
+	runTimeout        time.Duration
+	checkOut          string
+	checkStaged       bool
+	checkNoFail       bool
+	checkFailOnWarn   bool
+	checkRepo         string
+	checkRef          string
+	checkWebhook      string
+	checkWebhookFmt   string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [path...]",
+	Short: "Re-run policy checks whenever relevant files change",
+	Long: `Watch runs the policy checks, then re-runs them and redraws the results each
+time a relevant file under the scanned paths is saved. Bursts of saves are
+coalesced into a single run. Findings never make watch exit; it runs until
+interrupted.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("🔍 Running Mondrian policy checks...")
-		runPolicyChecks()
+		watchPolicyChecks(args)
 	},
 }
 
+var watchDebounce time.Duration
+
 var attestCmd = &cobra.Command{
-	Use:   "attest",
+	Use:   "attest [path...]",
 	Short: "Generate signed attestation for current state",
 	Long:  `Attest creates a signed attestation documenting the current state and policy check results.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("📝 Generating attestation...")
-		generateAttestation()
+		if !attestDryRun {
+			fmt.Println("📝 Generating attestation...")
+		}
+		generateAttestation(args)
 	},
 }
 
@@ -62,11 +157,194 @@ var verifyCmd = &cobra.Command{
 	Short: "Verify attestation chain and print proof",
 	Long:  `Verify validates the attestation chain and prints a human-readable proof bundle.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if verifyWatch {
+			watchEvidence()
+			return
+		}
 		fmt.Println("✅ Verifying evidence chain...")
 		verifyEvidence()
 	},
 }
 
+var (
+	verifyWatch    bool
+	verifyInterval time.Duration
+	verifyWebhook  string
+	verifyMaxAge   time.Duration
+	verifyRekor    bool
+	verifyExport   string
+	verifyBundle   string
+	verifySince    string
+)
+
+var (
+	attestSubjects []string
+	attestSBOM     bool
+	attestRekor    bool
+	attestRekorURL string
+	attestKeyless  bool
+	attestTSA      string
+	attestDryRun   bool
+	attestEncryptTo string
+)
+
+var (
+	servePort    int
+	serveAPI     bool
+	serveAPIRoot string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the Mondrian invocation audit log",
+	Long:  `Audit works with the hash-chained log of Mondrian invocations recorded when --audit is set.`,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the audit log hash chain",
+	Long:  `Verify checks that no audit log entry has been modified or removed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("🔍 Verifying audit log...")
+		verifyAuditLog()
+	},
+}
+
+var auditEnabled bool
+
+// configFile is the --config override of the mondrian.yaml lookup
+var configFile string
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Inspect the available policy rules",
+	Long:  `Rules groups commands that describe the policy rules Mondrian can run.`,
+}
+
+var rulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every rule with its description",
+	Long: `List prints the name, default severity and description of every
+registered rule, and whether mondrian.yaml enables it. Names can be passed to
+'mondrian check --rule' or listed under rules in mondrian.yaml.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listRules()
+	},
+}
+
+var (
+	rulesFormat     string
+	rulesFrameworks bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the mondrian.yaml policy config",
+	Long:  `Config groups commands that check and describe the mondrian.yaml policy config.`,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check mondrian.yaml against its schema and the registered rules",
+	Long: `Validate loads the policy config, by default the mondrian.yaml that check
+would use, and reports every mistake with its line and key.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		validateConfig(args)
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for mondrian.yaml",
+	Long: `Schema prints the JSON Schema mondrian.yaml is validated against, for
+editor completion, e.g. with a "# yaml-language-server: $schema=" comment.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Stdout.Write(policy.ConfigSchema)
+	},
+}
+
+var chainCmd = &cobra.Command{
+	Use:   "chain",
+	Short: "Maintain the evidence chain",
+	Long: `Chain groups maintenance operations on the evidence chain in .mondrian/evidence,
+or the directory given with --chain-dir.`,
+}
+
+var chainRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Rebuild chain.json from the attestation files on disk",
+	Long: `Repair rebuilds chain.json from the attestation files in the evidence
+directory, ordered by timestamp. The previous chain.json is kept as chain.json.bak.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("🔧 Repairing evidence chain...")
+		repairChain()
+	},
+}
+
+var chainProofCmd = &cobra.Command{
+	Use:   "proof <hash>",
+	Short: "Print a Merkle inclusion proof for one attestation",
+	Long: `Proof prints, as JSON, the chain's Merkle root and the sibling hashes that
+link the attestation with this hash (or unique prefix) to it. Anyone holding
+the published root can check the proof without the rest of the chain.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printInclusionProof(args[0])
+	},
+}
+
+var chainLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the evidence chain as a history, newest first",
+	Long: `Log prints each attestation in the evidence chain like a git commit, newest
+first: its hash, its parent's hash, when it was made, its overall status and
+how many files it covered.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		printChainLog()
+	},
+}
+
+var (
+	chainLogOneline bool
+	chainLogFormat  string
+)
+
+// chainDirUsage documents --chain-dir, shared by every command that reads or
+// writes the evidence chain
+const chainDirUsage = "Evidence directory holding the chain, e.g. .mondrian/evidence/prod to keep a separate chain per environment"
+
+// decryptionKeyPath is the key chosen with --decryption-key for attestations
+// encrypted at rest, see newChainManager
+var decryptionKeyPath string
+
+// decryptionKeyUsage documents --decryption-key, shared by every command that
+// reads attestations
+const decryptionKeyUsage = "X25519 private key PEM that decrypts attestations encrypted with 'attest --encrypt-to', e.g. .mondrian/keys/encryption.pem (default $MONDRIAN_DECRYPTION_KEY)"
+
+// trustEmbeddedKeys is the --trust-embedded-keys opt-out of pinning chain
+// verification to the project key, see loadTrustedKey
+var trustEmbeddedKeys bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <before> <after>",
+	Short: "Compare the policy results of two attestations",
+	Long: `Diff shows which rules newly failed, newly passed or changed severity
+between two attestations, with the findings that were resolved or introduced.
+
+Each attestation is an attestation file path, a chain position such as #3
+(as shown by 'mondrian verify'), or a hash prefix from the evidence chain.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		diffAttestations(args[0], args[1])
+	},
+}
+
+var diffFormat string
+
+var initEncryption bool
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize Mondrian in current repository",
@@ -77,11 +355,54 @@ var initCmd = &cobra.Command{
 	},
 }
 
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage the git pre-commit hook",
+	Long:  `Hooks installs or removes a git pre-commit hook that runs the policy checks on staged files.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a pre-commit hook running 'mondrian check' on staged files",
+	Long: `Install writes a pre-commit hook that runs 'mondrian check --changed-only
+--staged', so commits introducing failing findings are rejected. An existing
+hook is only replaced with --force.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		installHook()
+	},
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the pre-commit hook installed by 'mondrian hooks install'",
+	Run: func(cmd *cobra.Command, args []string) {
+		uninstallHook()
+	},
+}
+
+var (
+	hooksFailOn string
+	hooksForce  bool
+)
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start web server for evidence viewer",
-	Long:  `Serve starts a local web server to view attestation chains and evidence bundles.`,
+	Long: `Serve starts a local web server to view attestation chains and evidence bundles.
+The chain is also served as JSON at /api/chain; offset and limit query
+parameters page through long chains, e.g. /api/chain?offset=100&limit=50.
+
+With --api it instead serves the evidence API for many repositories. Pipelines
+POST the signed attestations written by 'mondrian attest' to /attestations;
+each is verified and appended to its repository's chain under --api-root, and
+GET /repos/{repository}/chain returns the chain. A repository's attestations
+must be posted in chain order.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if serveAPI {
+			fmt.Println("🌐 Starting evidence API...")
+			startAPIServer()
+			return
+		}
 		fmt.Println("🌐 Starting evidence viewer...")
 		startServer()
 	},
@@ -90,278 +411,2724 @@ var serveCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(attestCmd)
+	rootCmd.AddCommand(watchCmd)
 	rootCmd.AddCommand(verifyCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesListCmd)
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text, json")
+	rulesListCmd.Flags().StringVar(&rulesFormat, "format", "text", "Output format: text, json")
+	rulesListCmd.Flags().BoolVar(&rulesFrameworks, "frameworks", false, "Show the CIS and SOC 2 controls each rule supports, and which rules cover each control")
+	rootCmd.AddCommand(chainCmd)
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	chainCmd.AddCommand(chainRepairCmd)
+	chainCmd.AddCommand(chainProofCmd)
+	chainCmd.AddCommand(chainLogCmd)
+	chainCmd.PersistentFlags().StringVar(&chainDir, "chain-dir", defaultChainDir, chainDirUsage)
+	chainCmd.PersistentFlags().StringVar(&decryptionKeyPath, "decryption-key", "", decryptionKeyUsage)
+	diffCmd.Flags().StringVar(&decryptionKeyPath, "decryption-key", "", decryptionKeyUsage)
+	chainLogCmd.Flags().BoolVar(&chainLogOneline, "oneline", false, "Print each attestation on a single line")
+	chainLogCmd.Flags().StringVar(&chainLogFormat, "format", "text", "Output format: text, json")
+	
+	serveCmd.Flags().IntVar(&servePort, "port", 8080, "Port for the evidence viewer (listens on localhost)")
+	serveCmd.Flags().StringVar(&chainDir, "chain-dir", defaultChainDir, chainDirUsage)
+	serveCmd.Flags().StringVar(&decryptionKeyPath, "decryption-key", "", decryptionKeyUsage)
+	serveCmd.Flags().BoolVar(&serveAPI, "api", false, "Serve the multi-repository evidence API instead of the viewer")
+	serveCmd.Flags().StringVar(&serveAPIRoot, "api-root", filepath.Join(".mondrian", "api"), "Directory holding one chain per repository for --api")
+	serveCmd.MarkFlagsMutuallyExclusive("api", "chain-dir")
+	serveCmd.MarkFlagsMutuallyExclusive("api", "decryption-key")
+	
+	initCmd.Flags().BoolVar(&initEncryption, "encryption", false, "Also generate an X25519 key pair for encrypting attestations at rest, see 'attest --encrypt-to'")
+	
+	attestCmd.Flags().StringVar(&chainDir, "chain-dir", defaultChainDir, chainDirUsage)
+	attestCmd.Flags().StringArrayVar(&attestSubjects, "subject", nil, "Primary subject as name@sha256:digest, e.g. an image digest (repeatable)")
+	attestCmd.Flags().BoolVar(&attestSBOM, "sbom", false, "Write a CycloneDX SBOM of referenced providers, base images and actions next to the attestation and add it as a subject")
+	attestCmd.Flags().BoolVar(&attestKeyless, "keyless", false, "Sign with a Sigstore Fulcio certificate for the GitHub Actions OIDC identity instead of a stored key")
+	attestCmd.Flags().BoolVar(&attestRekor, "rekor", false, "Upload the signed attestation to a Rekor transparency log")
+	attestCmd.Flags().StringVar(&attestRekorURL, "rekor-url", evidence.DefaultRekorURL, "Rekor instance used with --rekor")
+	attestCmd.Flags().BoolVar(&attestDryRun, "dry-run", false, "Build and sign the attestation and print it to stdout without saving it, chaining it or uploading it to Rekor")
+	attestCmd.Flags().StringVar(&attestEncryptTo, "encrypt-to", "", "Encrypt the saved attestation file to this X25519 public key PEM, e.g. .mondrian/keys/encryption.pub; hashes and the chain still cover the plaintext (default $MONDRIAN_ENCRYPT_TO)")
+	attestCmd.Flags().DurationVar(&runTimeout, "timeout", 0, "Abandon scanning and checks that take longer than this, e.g. 10m (no limit by default)")
+	attestCmd.Flags().StringVar(&attestTSA, "tsa", "", "RFC 3161 time-stamp authority URL to time-stamp the signed attestation with, e.g. https://freetsa.org/tsr")
+	
+	checkCmd.Flags().StringVar(&checkBaseline, "baseline-from-attestation", "", "Only fail on findings not recorded in the chain attestation with this hash (or prefix)")
+	checkCmd.Flags().StringVar(&checkCompare, "baseline-compare", "", "Report every finding but only fail on regressions from this attestation (file, #N chain position or hash prefix): findings it lacks or recorded at a lower severity")
+	checkCmd.Flags().StringVar(&checkChangedSince, "changed-since", "", "Only report findings in files changed since this git ref (e.g. origin/main)")
+	checkCmd.Flags().BoolVar(&checkChangedOnly, "changed-only", false, "PR mode: only scan files changed since --base; cross-file rules see just those files")
+	checkCmd.Flags().BoolVar(&checkStaged, "staged", false, "With --changed-only, scan the files staged for commit instead of the changes since --base (used by the pre-commit hook)")
+	checkCmd.Flags().StringVar(&checkBase, "base", "", "Base ref for --changed-only (default origin/<PR target branch> in GitHub Actions or GitLab CI, else origin/main)")
+	checkCmd.Flags().BoolVar(&checkUpdateBase, "update-baseline", false, "Record every current finding in .mondrian/baseline.json so later runs only fail on new findings")
+	checkCmd.Flags().BoolVar(&checkAnnotateNew, "annotate-new", false, "PR mode: emit GitHub annotations only for findings not in the baseline (defaults to the chain head)")
+	checkCmd.Flags().StringVar(&checkFailOn, "fail-on", "", "Minimum severity that fails the check: "+strings.Join(policy.Severities, ", ")+" (default fail_on from mondrian.yaml, else "+policy.DefaultFailOn+")")
+	checkCmd.Flags().StringArrayVar(&checkInclude, "include", nil, "Only check files matching this glob, e.g. 'infra/**' (repeatable)")
+	checkCmd.Flags().StringArrayVar(&checkExclude, "exclude", nil, "Skip files matching this glob, e.g. '**/testdata/**' (repeatable, wins over --include)")
+	checkCmd.Flags().StringArrayVar(&checkRules, "rule", nil, "Only run the rule with this name, see 'mondrian rules list' (repeatable)")
+	checkCmd.Flags().BoolVar(&checkRedact, "redact", false, "Hash file paths and strip resource names and line content for sharing reports externally")
+	checkCmd.Flags().DurationVar(&runTimeout, "timeout", 0, "Abandon scanning and checks that take longer than this, e.g. 10m (no limit by default)")
+	checkCmd.Flags().StringVar(&checkOut, "out", "", "Write the results to this file instead of stdout, e.g. --format html --out report.html")
+	checkCmd.Flags().BoolVar(&checkNoFail, "no-fail", false, "Report findings but always exit 0; attestations still record the real status")
+	checkCmd.Flags().BoolVar(&checkFailOnWarn, "fail-on-warn", false, "Exit 1 on warnings as well as failures")
+	checkCmd.MarkFlagsMutuallyExclusive("no-fail", "fail-on-warn")
+	checkCmd.MarkFlagsMutuallyExclusive("baseline-compare", "baseline-from-attestation")
+	checkCmd.MarkFlagsMutuallyExclusive("baseline-compare", "annotate-new")
+	checkCmd.Flags().StringVar(&checkRepo, "repo", "", "Clone this git repository URL into a temporary directory and check it instead of the current directory")
+	checkCmd.Flags().StringVar(&checkWebhook, "webhook", "", "URL to POST a summary of the failed rules to when the check fails; delivery failures only log a warning")
+	checkCmd.Flags().StringVar(&checkWebhookFmt, "webhook-format", "generic", "Payload posted to --webhook: "+strings.Join(webhookFormats, ", ")+" (an incoming webhook message)")
+	checkCmd.Flags().StringVar(&checkRef, "ref", "", "Branch, tag or full commit SHA to check out with --repo (default the remote's default branch)")
+	checkCmd.PersistentFlags().StringVar(&checkFormat, "format", "text", "Output format: "+strings.Join(checkFormats, ", "))
+	
+	hooksInstallCmd.Flags().StringVar(&hooksFailOn, "fail-on", "", "Minimum severity that blocks a commit: "+strings.Join(policy.Severities, ", ")+" (default fail_on from mondrian.yaml)")
+	hooksInstallCmd.Flags().BoolVar(&hooksForce, "force", false, "Replace an existing pre-commit hook")
+	hooksUninstallCmd.Flags().BoolVar(&hooksForce, "force", false, "Remove the pre-commit hook even if Mondrian did not install it")
+	
+	// watch shares check's scan filters
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 300*time.Millisecond, "How long files must be quiet after a change before checks re-run")
+	watchCmd.Flags().StringVar(&checkFailOn, "fail-on", "", "Minimum severity reported as failing: "+strings.Join(policy.Severities, ", ")+" (default fail_on from mondrian.yaml, else "+policy.DefaultFailOn+")")
+	watchCmd.Flags().StringArrayVar(&checkInclude, "include", nil, "Only check files matching this glob, e.g. 'infra/**' (repeatable)")
+	watchCmd.Flags().StringArrayVar(&checkExclude, "exclude", nil, "Skip files matching this glob, e.g. '**/testdata/**' (repeatable, wins over --include)")
+	watchCmd.Flags().StringArrayVar(&checkRules, "rule", nil, "Only run the rule with this name, see 'mondrian rules list' (repeatable)")
+	
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Policy config to use instead of the mondrian.yaml found in the current directory or a parent up to the repository root")
+	rootCmd.PersistentFlags().BoolVar(&logVerbose, "verbose", false, "Also log debug diagnostics (rules run, signing keys, chain writes) to stderr")
+	rootCmd.PersistentFlags().BoolVar(&logQuiet, "quiet", false, "Only log errors to stderr, hiding warnings")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	rootCmd.PersistentFlags().BoolVar(&auditEnabled, "audit", false, "Append this invocation to the tamper-evident audit log in .mondrian/audit.log")
+	rootCmd.PersistentFlags().BoolVar(&trustEmbeddedKeys, "trust-embedded-keys", false, "Verify attestations against the public key each one embeds when there is no project key (.mondrian/keys/signing.pub) or PKCS#11/KMS key; anyone able to write the chain can then forge it")
+	
+	verifyCmd.Flags().StringVar(&chainDir, "chain-dir", defaultChainDir, chainDirUsage)
+	verifyCmd.Flags().StringVar(&decryptionKeyPath, "decryption-key", "", decryptionKeyUsage)
+	verifyCmd.Flags().BoolVar(&verifyWatch, "watch", false, "Continuously re-verify the chain and exit non-zero when tampering is detected")
+	verifyCmd.Flags().DurationVar(&verifyInterval, "interval", 5*time.Minute, "How often to re-verify the chain in --watch mode")
+	verifyCmd.Flags().DurationVar(&verifyMaxAge, "max-age", 0, "Fail if the head attestation is older than this duration (e.g. 24h)")
+	verifyCmd.Flags().BoolVar(&verifyRekor, "rekor", false, "Also require a valid Rekor inclusion proof for every attestation (needs network access)")
+	verifyCmd.Flags().StringVar(&verifyBundle, "bundle", "", "Verify a proof bundle written by --export instead of the local chain, against the public keys it contains (works offline)")
+	verifyCmd.Flags().StringVar(&verifyExport, "export", "", "After a successful verification, write a self-contained proof bundle (e.g. proof.zip) with the chain, attestations and the keys that signed them")
+	verifyCmd.Flags().StringVar(&verifySince, "since", "", "Only verify and list attestations made at or after this RFC 3339 time or this long ago, e.g. 2025-06-01T00:00:00Z or 24h (chain linkage is still checked in full)")
+	verifyCmd.Flags().StringVar(&verifyWebhook, "webhook", "", "URL to POST a JSON alert to when --watch detects tampering")
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		exit(1)
 	}
+	exit(0)
 }
 
-func runPolicyChecks() {
-	// Get current working directory
-	wd, err := os.Getwd()
-	if err != nil {
-		fmt.Printf("❌ Error getting current directory: %v\n", err)
-		os.Exit(1)
+var (
+	logVerbose bool
+	logQuiet   bool
+)
+
+// setupLogging routes warnings and diagnostics from every package through a
+// slog logger at the level chosen with --verbose or --quiet
+func setupLogging() {
+	level := slog.LevelInfo
+	if logVerbose {
+		level = slog.LevelDebug
+	} else if logQuiet {
+		level = slog.LevelError
+	}
+	slog.SetDefault(slog.New(&consoleHandler{out: os.Stderr, level: level, mu: &sync.Mutex{}}))
+}
+
+// consoleHandler writes log records in the CLI's own style, one per line:
+// warnings and errors marked like the rest of the output, debug lines
+// prefixed so they are easy to grep, and attributes as key=value pairs
+type consoleHandler struct {
+	out   io.Writer
+	level slog.Level
+	attrs []slog.Attr
+	mu    *sync.Mutex // shared by clones from WithAttrs
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	switch {
+	case record.Level >= slog.LevelError:
+		line.WriteString("❌ ")
+	case record.Level >= slog.LevelWarn:
+		line.WriteString("⚠️  ")
+	case record.Level < slog.LevelInfo:
+		line.WriteString("debug: ")
 	}
+	line.WriteString(record.Message)
 	
-	// Scan for relevant files
-	scanner := policy.NewFileScanner(wd)
-	files, err := scanner.ScanRelevantFiles()
-	if err != nil {
-		fmt.Printf("❌ Error scanning files: %v\n", err)
-		os.Exit(1)
+	writeAttr := func(attr slog.Attr) bool {
+		if !attr.Equal(slog.Attr{}) {
+			fmt.Fprintf(&line, " %s=%s", attr.Key, formatAttrValue(attr.Value.Resolve()))
+		}
+		return true
 	}
+	for _, attr := range h.attrs {
+		writeAttr(attr)
+	}
+	record.Attrs(writeAttr)
+	line.WriteByte('\n')
 	
-	if len(files) == 0 {
-		fmt.Println("ℹ️  No relevant files found (looking for .tf, .yml, .yaml files)")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, line.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is not used by mondrian; grouped attributes are flattened
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	return h
+}
+
+// formatAttrValue quotes values containing spaces so key=value pairs stay
+// unambiguous
+func formatAttrValue(value slog.Value) string {
+	text := value.String()
+	if text == "" || strings.ContainsAny(text, " \t\n\"=") {
+		return strconv.Quote(text)
+	}
+	return text
+}
+
+// invocation captures the running command for the audit log
+var invocation *evidence.AuditEntry
+
+// auditSecretFlags hold secrets, such as Slack incoming webhook URLs, that are
+// never written to the audit log
+var auditSecretFlags = []string{"webhook"}
+
+// auditURLFlags hold URLs that may embed credentials, which are dropped
+// before the URL is written to the audit log
+var auditURLFlags = []string{"repo", "rekor-url", "tsa"}
+
+func recordInvocation(cmd *cobra.Command, args []string) {
+	if !auditEnabled {
 		return
 	}
 	
-	fmt.Printf("🔍 Scanning %d files for policy violations...\n", len(files))
-	
-	// Run policy checks
-	engine := policy.NewPolicyEngine()
-	results := engine.RunChecks(files)
-	
-	// Display results
-	output := policy.FormatResults(results)
-	fmt.Print(output)
+	wd, _ := os.Getwd()
+	flags := make(map[string]string)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		switch {
+		case slices.Contains(auditSecretFlags, f.Name):
+			flags[f.Name] = "<redacted>"
+		case slices.Contains(auditURLFlags, f.Name):
+			flags[f.Name] = redactURL(f.Value.String())
+		default:
+			flags[f.Name] = f.Value.String()
+		}
+	})
 	
-	// Exit with error code if there are failures
-	for _, result := range results {
-		if result.Status == "fail" {
-			os.Exit(1)
+	invocation = &evidence.AuditEntry{
+		Timestamp:  time.Now().UTC(),
+		Command:    cmd.CommandPath(),
+		Args:       args,
+		Flags:      flags,
+		WorkingDir: wd,
+		User:       currentUser(),
+	}
+}
+
+// atExit holds cleanups, such as removing temporary directories, that must
+// run even when a command exits early
+var atExit []func()
+
+// exit records the invocation in the audit log, if enabled, before exiting
+func exit(code int) {
+	for _, cleanup := range atExit {
+		cleanup()
+	}
+	atExit = nil
+	if invocation != nil {
+		invocation.ExitCode = code
+		logPath := filepath.Join(invocation.WorkingDir, ".mondrian", "audit.log")
+		if err := evidence.AppendAuditEntry(logPath, *invocation); err != nil {
+			slog.Warn("Failed to write audit log", "err", err)
 		}
+		invocation = nil
 	}
+	os.Exit(code)
 }
 
-func generateAttestation() {
+func runPolicyChecks(roots []string) {
+	if !isValidFormat(checkFormat) {
+		fmt.Fprintf(os.Stderr, "❌ Unknown format %q (expected one of: %s)\n", checkFormat, strings.Join(checkFormats, ", "))
+		exit(1)
+	}
+	if checkFailOn != "" {
+		if err := policy.ValidateSeverity(checkFailOn); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --fail-on: %v\n", err)
+			exit(1)
+		}
+	}
+	// A partial run would drop every finding it didn't see from the baseline
+	partial := checkChangedOnly || checkChangedSince != "" || len(checkInclude) > 0 || len(checkExclude) > 0 || len(checkRules) > 0
+	if checkStaged && !checkChangedOnly {
+		fmt.Fprintln(os.Stderr, "❌ --staged only applies with --changed-only")
+		exit(1)
+	}
+	if checkUpdateBase && partial {
+		fmt.Fprintln(os.Stderr, "❌ --update-baseline records every finding, so it can't be combined with --changed-only, --changed-since, --include, --exclude or --rule")
+		exit(1)
+	}
+	if !slices.Contains(webhookFormats, checkWebhookFmt) {
+		fmt.Fprintf(os.Stderr, "❌ Unknown --webhook-format %q (expected one of: %s)\n", checkWebhookFmt, strings.Join(webhookFormats, ", "))
+		exit(1)
+	}
+	if checkRef != "" && checkRepo == "" {
+		fmt.Fprintln(os.Stderr, "❌ --ref only applies with --repo")
+		exit(1)
+	}
+	if checkRepo != "" && (checkChangedOnly || checkChangedSince != "" || checkUpdateBase) {
+		fmt.Fprintln(os.Stderr, "❌ --repo checks a shallow clone that is deleted afterwards, so it can't be combined with --changed-only, --changed-since or --update-baseline")
+		exit(1)
+	}
+	for _, pattern := range append(append([]string{}, checkInclude...), checkExclude...) {
+		if err := policy.ValidatePathGlob(pattern); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --include/--exclude: %v\n", err)
+			exit(1)
+		}
+	}
+	
 	// Get current working directory
 	wd, err := os.Getwd()
 	if err != nil {
 		fmt.Printf("❌ Error getting current directory: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 	
-	// Run policy checks first to get results
-	scanner := policy.NewFileScanner(wd)
-	files, err := scanner.ScanRelevantFiles()
-	if err != nil {
-		fmt.Printf("❌ Error scanning files: %v\n", err)
-		os.Exit(1)
-	}
+	ctx, cancel := runContext()
+	defer cancel()
 	
-	if len(files) == 0 {
-		fmt.Println("ℹ️  No relevant files found for attestation")
-		return
+	// Load the reference run before --repo moves into the clone, so a file
+	// path is relative to where check was run
+	var compare *evidence.Attestation
+	if checkCompare != "" {
+		compare = resolveAttestation(wd, checkCompare)
 	}
 	
-	fmt.Printf("📝 Generating attestation for %d files...\n", len(files))
+	// The policy config comes from where check was run, or --config, never
+	// from a --repo clone: a scanned repository's own mondrian.yaml could
+	// name plugins that would then run on this host
+	engine := newPolicyEngine(wd)
 	
-	// Run policy checks
-	engine := policy.NewPolicyEngine()
-	results := engine.RunChecks(files)
-	
-	// Create evidence directory
-	evidenceDir := filepath.Join(wd, ".mondrian", "attestations")
+	if checkRepo != "" {
+		// Results written with --out belong next to the caller, not the clone
+		if checkOut != "" && !filepath.IsAbs(checkOut) {
+			checkOut = filepath.Join(wd, checkOut)
+		}
+		if checkFormat == "text" {
+			fmt.Printf("📥 Cloning %s...\n", redactURL(checkRepo))
+		}
+		clone, err := cloneRepo(ctx, checkRepo, checkRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error cloning %s: %v\n", redactURL(checkRepo), err)
+			exit(1)
+		}
+		cleanup := func() {
+			os.Chdir(wd)
+			os.RemoveAll(clone)
+		}
+		atExit = append(atExit, cleanup)
+		defer cleanup()
+		
+		if err := os.Chdir(clone); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error entering clone: %v\n", err)
+			exit(1)
+		}
+		wd = clone
+	}
 	
-	// Initialize chain manager
-	chainManager := evidence.NewChainManager(evidenceDir)
-	chain, err := chainManager.LoadOrCreateChain()
-	if err != nil {
-		fmt.Printf("❌ Error loading evidence chain: %v\n", err)
-		os.Exit(1)
+	// Scan for relevant files, only those changed in the PR when asked to
+	var scanned map[string]string
+	if checkChangedOnly {
+		scanned = scanChangedFiles(ctx, wd, roots)
+	}
+	if scanned == nil {
+		scanned = scanRoots(ctx, wd, roots)
+	}
+	files := policy.FilterPaths(scanned, checkInclude, checkExclude)
+	if len(files) == 0 && checkFormat == "text" {
+		fmt.Println("ℹ️  No relevant files found (looking for .tf, .yml, .yaml files)")
+		return
 	}
 	
-	// Get file list
-	var fileList []string
-	for filename := range files {
-		fileList = append(fileList, filename)
+	if checkFormat == "text" {
+		fmt.Printf("🔍 Scanning %d files for policy violations...\n", len(files))
 	}
 	
-	// Get rule names
-	ruleNames := make([]string, len(engine.Rules))
-	for i, rule := range engine.Rules {
-		ruleNames[i] = rule.Name()
+	// Run policy checks over every file so cross-file rules keep their
+	// context, then narrow the findings to the files under review
+	if checkFailOn != "" {
+		engine.FailOn = checkFailOn
+	}
+	if len(checkRules) > 0 {
+		if err := engine.SelectRules(checkRules); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --rule: %v\n", err)
+			exit(1)
+		}
 	}
 	
-	// Create attestation metadata
-	metadata := evidence.AttestationMetadata{
-		Repository:   getRepositoryName(wd),
-		Branch:       getBranchName(),
-		Commit:       getCommitHash(),
-		Workflow:     getWorkflowContext(),
-		FilesScanned: fileList,
-		RulesUsed:    ruleNames,
-		ParentHash:   chain.Head, // Will be updated by chain manager
+	var changed map[string]bool
+	if checkChangedSince != "" {
+		paths, err := gitChangedFiles(wd, checkChangedSince, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error listing files changed since %s: %v\n", checkChangedSince, err)
+			exit(1)
+		}
+		changed = make(map[string]bool, len(paths))
+		for _, path := range paths {
+			changed[path] = true
+		}
+		if checkFormat == "text" {
+			fmt.Printf("🔀 %d files changed since %s\n", len(changed), checkChangedSince)
+		}
 	}
 	
-	// Create attestation
-	attestation := evidence.NewAttestation(results, metadata)
+	baselineHash := checkBaseline
+	if baselineHash == "" && checkAnnotateNew {
+		baselineHash = latestAttestationHash(wd)
+	}
+	var baseline *evidence.Attestation
+	if baselineHash != "" {
+		baseline = loadBaselineAttestation(wd, baselineHash)
+	}
 	
-	// Create signer
-	signer, err := evidence.NewSigner()
+	baselinePath := filepath.Join(wd, ".mondrian", policy.BaselineFileName)
+	if checkUpdateBase {
+		updateBaselineFile(baselinePath, runChecks(ctx, engine, files))
+		return
+	}
+	baselineFile, err := policy.LoadBaselineFile(baselinePath)
 	if err != nil {
-		fmt.Printf("❌ Error creating signer: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "❌ Error loading baseline: %v\n", err)
+		exit(1)
 	}
 	
-	// Sign attestation
-	signed, err := signer.SignAttestation(attestation)
-	if err != nil {
-		fmt.Printf("❌ Error signing attestation: %v\n", err)
-		os.Exit(1)
+	// review narrows and annotates findings. Each step looks at one result at
+	// a time, so it applies equally to results streamed as rules finish.
+	known, suppressed := 0, 0
+	review := func(results []policy.CheckResult) []policy.CheckResult {
+		if changed != nil {
+			results = policy.FilterByFiles(results, changed)
+		}
+		if baseline != nil {
+			var n int
+			results, n = policy.ApplyBaseline(results, baseline.Predicate.Results)
+			known += n
+		}
+		if baselineFile != nil {
+			var n int
+			results, n = baselineFile.Apply(results)
+			suppressed += n
+		}
+		// Redact last so baseline matching still sees the full findings
+		if checkRedact {
+			results = policy.Redact(results)
+		}
+		return results
 	}
 	
-	// Save signed attestation
-	timestamp := signed.Metadata.Timestamp.Format("20060102-150405")
-	filename := fmt.Sprintf("attestation-%s-%s.json", timestamp, signed.Metadata.KeyID[:8])
-	filePath := filename
+	out := io.Writer(os.Stdout)
+	if checkOut != "" {
+		file, err := os.Create(checkOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error creating %s: %v\n", checkOut, err)
+			exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
 	
-	if err := evidence.SaveSignedAttestation(signed, evidenceDir); err != nil {
-		fmt.Printf("❌ Error saving attestation: %v\n", err)
-		os.Exit(1)
+	// NDJSON is written as each rule finishes rather than once all have run
+	var results []policy.CheckResult
+	if checkFormat == "ndjson" {
+		engine.OnResult = func(result policy.CheckResult) {
+			reviewed := review([]policy.CheckResult{result})
+			output, err := policy.ToNDJSON(reviewed)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error formatting results: %v\n", err)
+				exit(1)
+			}
+			out.Write(output)
+			results = append(results, reviewed...)
+		}
+		runChecks(ctx, engine, files)
+	} else {
+		all := runChecks(ctx, engine, files)
+		
+		// Only a full run can tell that a baselined finding has been fixed
+		var resolved []policy.BaselineEntry
+		if baselineFile != nil && !partial {
+			resolved = baselineFile.Resolved(all)
+		}
+		results = review(all)
+		
+		if baseline != nil && checkFormat == "text" {
+			fmt.Printf("📌 Baseline %s: %d known findings won't fail this run\n", shortHash(baseline.Hash), known)
+		}
+		if baselineFile != nil && checkFormat == "text" {
+			fmt.Printf("📌 %s: %d known findings suppressed\n", filepath.Join(".mondrian", policy.BaselineFileName), suppressed)
+			if len(resolved) > 0 {
+				fmt.Printf("✨ %d baselined findings are resolved, run 'mondrian check --update-baseline' to drop them\n", len(resolved))
+			}
+		}
+		
+		// Display results
+		meta := policy.ReportMeta{
+			GeneratedAt:  time.Now(),
+			FilesScanned: len(files),
+			FailOn:       engine.FailOn,
+		}
+		if !checkRedact {
+			meta.Repository, meta.Branch, meta.Commit = evidence.DetectGitContext(wd)
+		}
+		output, err := formatResults(results, checkFormat, meta)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error formatting results: %v\n", err)
+			exit(1)
+		}
+		if _, err := out.Write(output); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error writing results: %v\n", err)
+			exit(1)
+		}
+		if checkOut != "" && checkFormat == "text" {
+			fmt.Printf("📄 Results written to %s\n", checkOut)
+		}
 	}
 	
-	// Add to evidence chain
-	if err := chainManager.AddAttestation(chain, attestation, filePath); err != nil {
-		fmt.Printf("❌ Error adding to evidence chain: %v\n", err)
-		os.Exit(1)
+	if checkWebhook != "" {
+		notifyCheckFailed(wd, results)
 	}
 	
-	// Display results
-	fmt.Printf("✅ Attestation generated and signed\n")
-	fmt.Printf("📁 Evidence directory: %s\n", evidenceDir)
-	fmt.Printf("🔑 Key ID: %s\n", signed.Metadata.KeyID[:16])
-	fmt.Printf("🔗 Chain length: %d attestations\n", chain.Length+1)
-	fmt.Printf("📊 Status: %s (%d checks)\n", attestation.Predicate.Summary.OverallStatus, attestation.Predicate.Summary.TotalChecks)
+	opts := exitOptions{NoFail: checkNoFail, FailOnWarn: checkFailOnWarn}
+	if compare != nil {
+		opts.Baseline = compare.Predicate.Results
+		if checkFormat == "text" {
+			printRegressions(policy.Regressions(results, opts.Baseline), compare)
+		}
+	}
+	if code := exitCode(results, opts); code != 0 {
+		exit(code)
+	}
 }
 
-func verifyEvidence() {
-	// Get current working directory
-	wd, err := os.Getwd()
-	if err != nil {
-		fmt.Printf("❌ Error getting current directory: %v\n", err)
-		os.Exit(1)
+// webhookFormats lists the payloads 'check --webhook' can post
+var webhookFormats = []string{"generic", "slack"}
+
+// checkFailedEvent is the generic payload 'check --webhook' posts
+type checkFailedEvent struct {
+	Event       string    `json:"event"`
+	Repository  string    `json:"repository,omitempty"`
+	Branch      string    `json:"branch,omitempty"`
+	Commit      string    `json:"commit,omitempty"`
+	FailedRules []string  `json:"failed_rules"`
+	Passed      int       `json:"passed"`
+	Failed      int       `json:"failed"`
+	Warnings    int       `json:"warnings"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// notifyCheckFailed posts a summary of the failed rules to --webhook when any
+// result fails. Alerting is best effort, so errors never change the outcome.
+func notifyCheckFailed(wd string, results []policy.CheckResult) {
+	event := checkFailedEvent{Event: "check_failed", CheckedAt: time.Now().UTC()}
+	for _, result := range results {
+		switch result.Status {
+		case "pass":
+			event.Passed++
+		case "warn":
+			event.Warnings++
+		case "fail":
+			event.Failed++
+			if !slices.Contains(event.FailedRules, result.RuleName) {
+				event.FailedRules = append(event.FailedRules, result.RuleName)
+			}
+		}
 	}
-	
-	// Evidence directory
-	evidenceDir := filepath.Join(wd, ".mondrian", "attestations")
-	
-	// Check if evidence directory exists
-	if _, err := os.Stat(evidenceDir); os.IsNotExist(err) {
-		fmt.Println("❌ No evidence directory found")
-		fmt.Printf("💡 Run 'mondrian attest' to generate attestations first\n")
-		os.Exit(1)
+	if event.Failed == 0 {
+		return
+	}
+	sort.Strings(event.FailedRules)
+	if !checkRedact {
+		event.Repository, event.Branch, event.Commit = evidence.DetectGitContext(wd)
+	}
+	
+	var payload interface{} = event
+	if checkWebhookFmt == "slack" {
+		payload = slackMessage(event)
+	}
+	if err := postJSON(checkWebhook, payload); err != nil {
+		slog.Warn("Failed to send webhook notification", "err", err)
+	}
+}
+
+// slackMessage renders the event as a Slack incoming webhook message, which
+// Mattermost, Discord (/slack) and Teams workflows accept as well
+func slackMessage(event checkFailedEvent) map[string]string {
+	text := "🚫 Mondrian policy check failed"
+	if event.Repository != "" {
+		text += " in " + event.Repository
+	}
+	if event.Branch != "" {
+		text += " on " + event.Branch
+	}
+	if event.Commit != "" {
+		text += " at " + event.Commit[:min(len(event.Commit), 7)]
+	}
+	
+	rules := make([]string, len(event.FailedRules))
+	for i, rule := range event.FailedRules {
+		rules[i] = "`" + rule + "`"
+	}
+	text += fmt.Sprintf(": %d failed, %d warnings, %d passed\nFailed rules: %s", event.Failed, event.Warnings, event.Passed, strings.Join(rules, ", "))
+	return map[string]string{"text": text}
+}
+
+// printRegressions lists the findings that are new or worse than in the
+// reference attestation of --baseline-compare
+func printRegressions(regressions []policy.CheckResult, compare *evidence.Attestation) {
+	if len(regressions) == 0 {
+		fmt.Printf("\n📈 No regressions from baseline %s\n", shortHash(compare.Hash))
+		return
+	}
+	fmt.Printf("\n📉 %d regressions from baseline %s:\n", len(regressions), shortHash(compare.Hash))
+	for _, result := range regressions {
+		fmt.Printf("   🆕 [%s] %s: %s\n", result.Severity, result.RuleName, describeFinding(result))
+	}
+}
+
+// exitOptions controls how findings map to the exit code of check
+type exitOptions struct {
+	// NoFail reports findings without failing, for report-only pipelines
+	NoFail bool
+	// FailOnWarn fails on warnings as well as failures
+	FailOnWarn bool
+	// Baseline, when set, limits failures to regressions from these results
+	// of an earlier run, see policy.Regressions
+	Baseline []policy.CheckResult
+}
+
+// exitCode returns the exit code for check: 1 when any result fails, or
+// warns with FailOnWarn, and always 0 with NoFail
+func exitCode(results []policy.CheckResult, opts exitOptions) int {
+	if opts.NoFail {
+		return 0
+	}
+	if opts.Baseline != nil {
+		results = policy.Regressions(results, opts.Baseline)
+	}
+	for _, result := range results {
+		if result.Status == "fail" || (opts.FailOnWarn && result.Status == "warn") {
+			return 1
+		}
+	}
+	return 0
+}
+
+// updateBaselineFile records the findings in results as the accepted baseline
+func updateBaselineFile(path string, results []policy.CheckResult) {
+	baseline := policy.NewBaselineFile(results)
+	if err := baseline.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing baseline: %v\n", err)
+		exit(1)
+	}
+	
+	out := os.Stdout
+	if checkFormat != "text" {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "📌 Recorded %d findings in %s, later runs will only fail on new findings\n", len(baseline.Findings), filepath.Join(".mondrian", policy.BaselineFileName))
+}
+
+// watchPolicyChecks re-runs the policy checks whenever a relevant file changes
+func watchPolicyChecks(roots []string) {
+	if watchDebounce <= 0 {
+		fmt.Println("❌ --debounce must be greater than zero")
+		exit(1)
+	}
+	if checkFailOn != "" {
+		if err := policy.ValidateSeverity(checkFailOn); err != nil {
+			fmt.Printf("❌ Invalid --fail-on: %v\n", err)
+			exit(1)
+		}
+	}
+	for _, pattern := range append(append([]string{}, checkInclude...), checkExclude...) {
+		if err := policy.ValidatePathGlob(pattern); err != nil {
+			fmt.Printf("❌ Invalid --include/--exclude: %v\n", err)
+			exit(1)
+		}
+	}
+	
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Printf("❌ Error starting file watcher: %v\n", err)
+		exit(1)
+	}
+	defer watcher.Close()
+	
+	dirs := []string{wd}
+	if len(roots) > 0 {
+		dirs = nil
+		for _, root := range roots {
+			if !filepath.IsAbs(root) {
+				root = filepath.Join(wd, root)
+			}
+			dirs = append(dirs, root)
+		}
+	}
+	for _, dir := range dirs {
+		if err := watchDirs(watcher, dir); err != nil {
+			fmt.Printf("❌ Error watching %s: %v\n", dir, err)
+			exit(1)
+		}
+	}
+	
+	run := func(changed []string) {
+		// The config is reloaded so edits to mondrian.yaml apply on the next run
+		engine := newPolicyEngine(wd)
+		if checkFailOn != "" {
+			engine.FailOn = checkFailOn
+		}
+		if len(checkRules) > 0 {
+			if err := engine.SelectRules(checkRules); err != nil {
+				fmt.Printf("❌ Invalid --rule: %v\n", err)
+				exit(1)
+			}
+		}
+		files := policy.FilterPaths(scanRoots(context.Background(), wd, roots), checkInclude, checkExclude)
+		results := runChecks(context.Background(), engine, files)
+		
+		// Clear the screen so each run replaces the last
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("🔍 Mondrian watch · %s · %d files\n", time.Now().Format("15:04:05"), len(files))
+		if len(changed) > 0 {
+			fmt.Printf("🔄 Changed: %s\n", strings.Join(changed, ", "))
+		}
+		fmt.Print(policy.FormatResults(results))
+		fmt.Println("\n👀 Watching for changes (Ctrl+C to stop)...")
+	}
+	run(nil)
+	
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		fmt.Println("\n👋 Stopped watching")
+		exit(0)
+	}()
+	
+	scanner := policy.NewFileScanner(wd)
+	changes := make(chan string)
+	go func() {
+		defer close(changes)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// Directories created after startup need watching too
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if !policy.IsSkippedDir(info.Name()) {
+							watchDirs(watcher, event.Name)
+						}
+						continue
+					}
+				}
+				if event.Has(fsnotify.Chmod) || !scanner.IsRelevantFile(event.Name) {
+					continue
+				}
+				name := event.Name
+				if rel, err := filepath.Rel(wd, name); err == nil {
+					name = filepath.ToSlash(rel)
+				}
+				changes <- name
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("File watcher error", "err", err)
+			}
+		}
+	}()
+	
+	debounce(changes, watchDebounce, run)
+}
+
+// watchDirs adds dir and every directory below it that a scan would enter
+func watchDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && policy.IsSkippedDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// debounce calls fire with the distinct names received on events once none
+// has arrived for wait, so a burst of saves triggers a single call. It
+// returns when events is closed, after firing for anything still pending.
+func debounce(events <-chan string, wait time.Duration, fire func(names []string)) {
+	pending := make(map[string]bool)
+	flush := func() {
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		pending = make(map[string]bool)
+		fire(names)
+	}
+	
+	timer := time.NewTimer(wait)
+	timer.Stop()
+	for {
+		select {
+		case name, ok := <-events:
+			if !ok {
+				timer.Stop()
+				if len(pending) > 0 {
+					flush()
+				}
+				return
+			}
+			pending[name] = true
+			timer.Reset(wait)
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// gitChangedFiles lists the files, relative to dir, that a change touches,
+// skipping deletions. With staged that is what is staged for commit.
+// Otherwise it is what a PR from the working tree onto ref would change:
+// everything that differs from the merge base of ref and HEAD, committed or
+// not, and untracked files. --changed-since and --changed-only share it.
+func gitChangedFiles(dir, ref string, staged bool) ([]string, error) {
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.Output()
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return output, err
+	}
+	
+	if _, err := git("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("not inside a git repository")
+	}
+	
+	// Staged files are diffed against HEAD, or the empty tree before the first commit
+	args := []string{"diff", "--name-only", "--relative", "--diff-filter=d"}
+	if staged {
+		args = append(args, "--cached")
+	} else {
+		if _, err := git("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("cannot resolve %s", ref)
+		}
+		// Shallow clones may lack the merge base, and diffing against ref
+		// itself still covers everything the PR changes
+		base := ref
+		if mergeBase, err := git("merge-base", ref, "HEAD"); err == nil {
+			base = strings.TrimSpace(string(mergeBase))
+		}
+		args = append(args, base)
+	}
+	diff, err := git(args...)
+	if err != nil {
+		return nil, err
+	}
+	if !staged {
+		untracked, err := git("ls-files", "--others", "--exclude-standard")
+		if err != nil {
+			return nil, err
+		}
+		diff = append(diff, untracked...)
+	}
+	
+	var changed []string
+	for _, line := range strings.Split(string(diff), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			changed = append(changed, line)
+		}
+	}
+	return changed, nil
+}
+
+// fullCommitSHA matches a full SHA-1 or SHA-256 commit ID
+var fullCommitSHA = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// cloneRepo shallowly clones repo into a new temporary directory, checked out
+// at ref when given, and returns the directory. Git never prompts for
+// credentials, so a private repository without them fails instead of hanging.
+func cloneRepo(ctx context.Context, repo, ref string) (string, error) {
+	dir, err := os.MkdirTemp("", "mondrian-repo-")
+	if err != nil {
+		return "", err
+	}
+	
+	// Branches and tags clone directly; a commit has to be fetched by ID
+	steps := [][]string{{"clone", "--quiet", "--depth", "1", "--", repo, dir}}
+	if ref != "" && fullCommitSHA.MatchString(ref) {
+		steps = [][]string{
+			{"init", "--quiet", dir},
+			{"-C", dir, "remote", "add", "origin", repo},
+			{"-C", dir, "fetch", "--quiet", "--depth", "1", "origin", ref},
+			{"-C", dir, "checkout", "--quiet", "FETCH_HEAD"},
+		}
+	} else if ref != "" {
+		steps = [][]string{{"clone", "--quiet", "--depth", "1", "--branch", ref, "--", repo, dir}}
+	}
+	
+	for _, args := range steps {
+		if err := runGit(ctx, args...); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// runGit runs git non-interactively, returning its error output as the error
+func runGit(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%s", message)
+		}
+		return err
+	}
+	return nil
+}
+
+// redactURL drops credentials embedded in a repository URL, such as
+// https://<token>@github.com/acme/infra.git, before it is printed
+func redactURL(repo string) string {
+	if u, err := url.Parse(repo); err == nil && u.User != nil {
+		u.User = nil
+		return u.String()
+	}
+	return repo
+}
+
+// scanChangedFiles reads the relevant files changed since the PR base, or
+// staged with --staged, limited to roots when given. It returns nil, after a
+// warning, when the changes cannot be determined so that the caller falls
+// back to a full scan.
+func scanChangedFiles(ctx context.Context, wd string, roots []string) map[string]string {
+	base := checkBase
+	if base == "" {
+		base = defaultBaseRef()
+	}
+	
+	changed, err := gitChangedFiles(wd, base, checkStaged)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("--changed-only: %v, scanning all files", err))
+		return nil
+	}
+	
+	scanner := policy.NewFileScanner(wd)
+	files, err := scanner.ScanPaths(ctx, changed)
+	if err != nil {
+		exitIfCanceled(ctx)
+		fmt.Printf("❌ Error scanning files: %v\n", err)
+		exit(1)
+	}
+	for _, warning := range scanner.Warnings {
+		slog.Warn(warning)
+	}
+	if checkFormat == "text" {
+		if checkStaged {
+			fmt.Printf("🔀 %d files staged for commit\n", len(changed))
+		} else {
+			fmt.Printf("🔀 %d files changed since %s\n", len(changed), base)
+		}
+	}
+	
+	// Roots are directories, which path globs match everything below
+	if len(roots) > 0 {
+		var prefixes []string
+		for _, root := range roots {
+			dir := root
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(wd, dir)
+			}
+			prefix, err := filepath.Rel(wd, dir)
+			if err != nil || strings.HasPrefix(prefix, "..") {
+				continue
+			}
+			if prefix == "." {
+				return files
+			}
+			prefixes = append(prefixes, filepath.ToSlash(prefix))
+		}
+		if len(prefixes) == 0 {
+			return map[string]string{}
+		}
+		files = policy.FilterPaths(files, prefixes, nil)
+	}
+	return files
+}
+
+// defaultBaseRef is the remote-tracking branch a CI merge request targets,
+// or origin/main outside CI
+func defaultBaseRef() string {
+	if branch := os.Getenv("GITHUB_BASE_REF"); branch != "" {
+		return "origin/" + branch
+	}
+	if branch := os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"); branch != "" {
+		return "origin/" + branch
+	}
+	return "origin/main"
+}
+
+// latestAttestationHash returns the chain head, or "" when nothing has been attested yet
+func latestAttestationHash(wd string) string {
+	chainManager := newChainManager(wd, getEvidenceDir(wd))
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading evidence chain: %v\n", err)
+		exit(1)
+	}
+	return chain.Head
+}
+
+// loadBaselineAttestation finds a verified attestation in the evidence chain to baseline against
+func loadBaselineAttestation(wd, hash string) *evidence.Attestation {
+	chainManager := newChainManager(wd, getEvidenceDir(wd))
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading evidence chain: %v\n", err)
+		exit(1)
+	}
+	
+	attestation, err := chainManager.FindAttestation(chain, hash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading baseline attestation: %v\n", err)
+		exit(1)
+	}
+	return attestation
+}
+
+// checkFormats lists the output formats supported by 'mondrian check'
+var checkFormats = []string{"text", "json", "ndjson", "sarif", "gitlab", "github", "html"}
+
+func isValidFormat(format string) bool {
+	for _, f := range checkFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// formatResults renders check results in the requested output format. meta
+// describes the run for formats that show it.
+func formatResults(results []policy.CheckResult, format string, meta policy.ReportMeta) ([]byte, error) {
+	switch format {
+	case "html":
+		return policy.ToHTML(results, meta)
+	case "json":
+		data, err := policy.ToJSON(results)
+		return append(data, '\n'), err
+	case "ndjson":
+		return policy.ToNDJSON(results)
+	case "sarif":
+		data, err := policy.ToSARIF(results)
+		return append(data, '\n'), err
+	case "gitlab":
+		data, err := policy.ToGitLabCodeQuality(results)
+		return append(data, '\n'), err
+	case "github":
+		return policy.ToGitHubAnnotations(results), nil
+	default:
+		return []byte(policy.FormatResults(results)), nil
+	}
+}
+
+func generateAttestation(roots []string) {
+	// Get current working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	// Parse explicit subjects before doing any work
+	var subjects []evidence.Subject
+	for _, value := range attestSubjects {
+		subject, err := evidence.ParseSubject(value)
+		if err != nil {
+			fmt.Printf("❌ Invalid --subject: %v\n", err)
+			exit(1)
+		}
+		subjects = append(subjects, subject)
+	}
+	
+	// Run policy checks first to get results. The timeout covers only the
+	// scan and checks, not signing or uploads.
+	ctx, cancel := runContext()
+	files := scanRoots(ctx, wd, roots)
+	if len(files) == 0 {
+		cancel()
+		fmt.Println("ℹ️  No relevant files found for attestation")
+		return
+	}
+	
+	if !attestDryRun {
+		fmt.Printf("📝 Generating attestation for %d files...\n", len(files))
+	}
+	
+	// Run policy checks
+	engine := newPolicyEngine(wd)
+	results := runChecks(ctx, engine, files)
+	cancel()
+	
+	// Create evidence directory
+	evidenceDir := getEvidenceDir(wd)
+	
+	// Initialize chain manager. A dry run must not create the chain file.
+	// Appending verifies nothing, so the chain isn't pinned to a trusted key.
+	chainManager := evidence.NewChainManager(evidenceDir)
+	loadChain := chainManager.LoadOrCreateChain
+	if attestDryRun {
+		loadChain = chainManager.LoadChain
+	}
+	chain, err := loadChain()
+	if err != nil {
+		fmt.Printf("❌ Error loading evidence chain: %v\n", err)
+		exit(1)
+	}
+	
+	// The SBOM is a subject, so it is written before the attestation is created
+	var sbomPath string
+	if attestSBOM {
+		sbom, err := evidence.GenerateSBOM(files)
+		if err != nil {
+			fmt.Printf("❌ Error generating SBOM: %v\n", err)
+			exit(1)
+		}
+		subject := evidence.SBOMSubject(sbom)
+		if !attestDryRun {
+			sbomPath, subject, err = evidence.SaveSBOM(sbom, evidenceDir)
+			if err != nil {
+				fmt.Printf("❌ Error saving SBOM: %v\n", err)
+				exit(1)
+			}
+		}
+		subjects = append(subjects, subject)
+	}
+	
+	// Get file list
+	var fileList []string
+	for filename := range files {
+		fileList = append(fileList, filename)
+	}
+	sort.Strings(fileList)
+	
+	// Get rule names
+	ruleNames := make([]string, len(engine.Rules))
+	for i, rule := range engine.Rules {
+		ruleNames[i] = rule.Name()
+	}
+	
+	// Create attestation metadata
+	repo, branch, commit := evidence.DetectGitContext(wd)
+	if repo == "" {
+		repo = filepath.Base(wd)
+	}
+	metadata := evidence.AttestationMetadata{
+		Repository:   repo,
+		Branch:       branch,
+		Commit:       commit,
+		Workflow:     getWorkflowContext(),
+		FilesScanned: fileList,
+		FileDigests:  evidence.DigestFiles(files),
+		RulesUsed:    ruleNames,
+		ParentHash:   chain.Head, // Will be updated by chain manager
+		Subjects:     subjects,
+	}
+	
+	// Create attestation
+	attestation := evidence.NewAttestation(results, metadata)
+	
+	// Load the encryption key before signing so a bad key fails fast
+	encryptor := loadEncryptor()
+	
+	// Create signer
+	var signer *evidence.Signer
+	if attestKeyless {
+		signer, err = evidence.NewSignerFromGitHubOIDC()
+		if err != nil {
+			fmt.Printf("❌ Error creating keyless signer: %v\n", err)
+			exit(1)
+		}
+	} else {
+		signer = loadSigner(wd)
+	}
+	defer signer.Close()
+	
+	// Sign attestation
+	signed, err := signer.SignAttestation(attestation)
+	if err != nil {
+		fmt.Printf("❌ Error signing attestation: %v\n", err)
+		exit(1)
+	}
+	
+	// A requested time-stamp is part of the attestation's proof, so a TSA
+	// failure fails the run before anything is saved
+	if attestTSA != "" {
+		fmt.Printf("🕰️  Requesting time-stamp (%s)...\n", attestTSA)
+		if err := evidence.Timestamp(signed, attestTSA); err != nil {
+			fmt.Printf("❌ Error time-stamping attestation: %v\n", err)
+			exit(1)
+		}
+	}
+	
+	if attestDryRun {
+		data, err := json.MarshalIndent(signed, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error serializing attestation: %v\n", err)
+			exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	
+	// Record the envelope in the transparency log. A Rekor outage must not lose
+	// the attestation, so it is saved and chained locally either way.
+	var rekorErr error
+	if attestRekor {
+		fmt.Printf("🌐 Uploading to Rekor (%s)...\n", attestRekorURL)
+		if _, _, rekorErr = evidence.UploadToRekor(signed, attestRekorURL); rekorErr != nil {
+			slog.Warn("Rekor upload failed", "err", rekorErr)
+		}
+	}
+	
+	// Save signed attestation
+	savedPath, err := evidence.SaveSignedAttestation(signed, evidenceDir, encryptor)
+	if err != nil {
+		fmt.Printf("❌ Error saving attestation: %v\n", err)
+		exit(1)
+	}
+	
+	filePath, err := filepath.Rel(evidenceDir, savedPath)
+	if err != nil {
+		filePath = filepath.Base(savedPath)
+	}
+	
+	// Add to evidence chain
+	if err := chainManager.AddAttestation(chain, attestation, filePath); err != nil {
+		fmt.Printf("❌ Error adding to evidence chain: %v\n", err)
+		exit(1)
+	}
+	
+	// Display results
+	fmt.Printf("✅ Attestation generated and signed\n")
+	fmt.Printf("🏷️  Attestation Hash: %s\n", attestation.Hash)
+	fmt.Printf("📁 Attestation file: %s\n", savedPath)
+	fmt.Printf("🔑 Key ID: %s\n", signed.Metadata.KeyID)
+	if len(signed.Metadata.CertificateChain) > 0 {
+		fmt.Printf("📜 Signed keyless with a Fulcio certificate (%d in chain)\n", len(signed.Metadata.CertificateChain))
+	}
+	for _, subject := range subjects {
+		fmt.Printf("🎯 Subject: %s\n", subject.Name)
+	}
+	if sbomPath != "" {
+		fmt.Printf("📦 SBOM file: %s\n", sbomPath)
+	}
+	fmt.Printf("🔗 Chain length: %d attestations\n", chain.Length)
+	fmt.Printf("📊 Status: %s (%d checks)\n", attestation.Predicate.Summary.OverallStatus, attestation.Predicate.Summary.TotalChecks)
+	if ts := signed.Metadata.TimestampToken; ts != nil {
+		fmt.Printf("🕰️  Time-stamped at %s by %s\n", ts.Time.Format(time.RFC3339), ts.URL)
+	}
+	if rekor := signed.Metadata.Rekor; rekor != nil {
+		fmt.Printf("🪵 Rekor log index: %d (%s)\n", rekor.LogIndex, rekor.UUID)
+	}
+	if rekorErr != nil {
+		fmt.Println("❌ Attestation was saved locally but is not in the transparency log; re-run 'mondrian attest --rekor' once Rekor is reachable")
+		exit(1)
+	}
+}
+
+func verifyEvidence() {
+	// Get current working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	var since time.Time
+	if verifySince != "" {
+		since, err = parseSince(verifySince, time.Now().UTC())
+		if err != nil {
+			fmt.Printf("❌ Invalid --since: %v\n", err)
+			exit(1)
+		}
+	}
+	
+	// Evidence directory, or the extracted proof bundle
+	evidenceDir := getEvidenceDir(wd)
+	var bundleKeys []*ecdsa.PublicKey
+	if verifyBundle != "" {
+		if verifyExport != "" {
+			fmt.Println("❌ --export cannot be combined with --bundle")
+			exit(1)
+		}
+		fmt.Printf("📦 Opening proof bundle %s...\n", verifyBundle)
+		evidenceDir, bundleKeys, err = evidence.OpenBundle(verifyBundle)
+		if err != nil {
+			fmt.Printf("❌ Invalid proof bundle: %v\n", err)
+			fmt.Printf("🚫 Verification FAILED\n")
+			exit(1)
+		}
+		bundleDir := evidenceDir
+		atExit = append(atExit, func() { os.RemoveAll(bundleDir) })
+		defer os.RemoveAll(bundleDir)
+	}
+	
+	// Check if evidence directory exists
+	if _, err := os.Stat(evidenceDir); os.IsNotExist(err) {
+		fmt.Println("❌ No evidence directory found")
+		fmt.Printf("💡 Run 'mondrian attest' to generate attestations first\n")
+		exit(1)
+	}
+	
+	fmt.Println("🔍 Verifying evidence chain...")
+	
+	// Initialize chain manager. Bundle signatures must each verify with one
+	// of the bundled keys rather than the project key.
+	var chainManager *evidence.ChainManager
+	if verifyBundle != "" {
+		chainManager = evidence.NewChainManager(evidenceDir)
+		chainManager.DecryptionKey = loadDecryptionKey()
+		chainManager.TrustedKeys = bundleKeys
+	} else {
+		chainManager = newChainManager(wd, evidenceDir)
+	}
+	
+	// Load existing chain
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		fmt.Printf("❌ Error loading evidence chain: %v\n", err)
+		exit(1)
+	}
+	
+	if chain.Length == 0 {
+		fmt.Println("ℹ️  No attestations found in evidence chain")
+		if verifyMaxAge > 0 {
+			fmt.Printf("❌ Freshness check failed: no attestations recorded within %s\n", verifyMaxAge)
+			exit(1)
+		}
+		return
+	}
+	
+	// Verify chain linkage, then each attestation's content and signature
+	fmt.Printf("🔗 Verifying chain integrity (%d attestations)...\n", chain.Length)
+	linkErr := chainManager.VerifyChain(chain)
+	anomalies := chainManager.VerifyEntriesSince(chain, since, 0)
+	
+	// With --since only the recent attestations are reported on
+	selected := 0
+	for _, entry := range chain.Attestations {
+		if !entry.Timestamp.Before(since) {
+			selected++
+		}
+	}
+	
+	problems := make(map[int]string)
+	for _, anomaly := range anomalies {
+		problems[anomaly.Position] = anomaly.Problem
+	}
+	
+	if verifyRekor {
+		fmt.Println("🌐 Checking Rekor inclusion proofs...")
+		for _, anomaly := range chainManager.VerifyRekorEntries(chain) {
+			if chain.Attestations[anomaly.Position].Timestamp.Before(since) {
+				continue
+			}
+			if _, bad := problems[anomaly.Position]; !bad {
+				problems[anomaly.Position] = anomaly.Problem
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+	}
+	
+	// Print the proof bundle
+	fmt.Println()
+	fmt.Println("📜 Proof Bundle:")
+	if skipped := chain.Length - selected; skipped > 0 {
+		fmt.Printf("   ⏭️  %d attestations before %s not shown\n", skipped, since.Format(time.RFC3339))
+	}
+	for i, entry := range chain.Attestations {
+		if entry.Timestamp.Before(since) {
+			continue
+		}
+		mark := "✅"
+		if _, bad := problems[i]; bad {
+			mark = "❌"
+		}
+		
+		fmt.Printf("   %s #%-3d %s [%s] %s\n",
+			mark,
+			i+1,
+			entry.Timestamp.Format("2006-01-02 15:04:05"),
+			entry.Status,
+			shortHash(entry.Hash))
+		if problem, bad := problems[i]; bad {
+			fmt.Printf("          ↳ %s\n", problem)
+		}
+	}
+	
+	fmt.Println()
+	fmt.Println("📊 Chain Summary:")
+	fmt.Println(chain.GetChainSummary())
+	fmt.Println()
+	fmt.Printf("🔑 Chain ID: %s\n", chain.ChainID)
+	fmt.Printf("🏷️  Genesis Hash: %s\n", shortHash(chain.Genesis))
+	fmt.Printf("🔝 Head Hash: %s\n", shortHash(chain.Head))
+	fmt.Println()
+	
+	var freshnessErr error
+	if verifyMaxAge > 0 {
+		freshnessErr = chain.CheckFreshness(verifyMaxAge, time.Now().UTC())
+		if freshnessErr == nil {
+			fmt.Printf("⏱️  Head attestation is within max age of %s\n", verifyMaxAge)
+		}
+	}
+	
+	if linkErr != nil || len(anomalies) > 0 || freshnessErr != nil {
+		if freshnessErr != nil {
+			fmt.Printf("❌ Freshness check failed: %v\n", freshnessErr)
+		}
+		if linkErr != nil {
+			fmt.Printf("❌ Chain verification failed: %v\n", linkErr)
+		}
+		if len(anomalies) > 0 {
+			fmt.Printf("❌ %d of %d attestations failed verification\n", len(anomalies), selected)
+		}
+		fmt.Printf("🚫 Verification FAILED\n")
+		exit(1)
+	}
+	
+	fmt.Printf("🎯 Verification PASSED - evidence chain is valid and tamper-evident\n")
+	
+	if verifyExport != "" {
+		if err := chainManager.ExportBundle(chain, verifyExport); err != nil {
+			fmt.Printf("❌ Error exporting proof bundle: %v\n", err)
+			exit(1)
+		}
+		fmt.Printf("📦 Proof bundle: %s\n", verifyExport)
+	}
+}
+
+// parseSince parses --since as an RFC 3339 time, or as a duration before now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time such as 2025-06-01T00:00:00Z nor a duration such as 24h", value)
+	}
+	return now.Add(-d), nil
+}
+
+func watchEvidence() {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	if verifyInterval <= 0 {
+		fmt.Println("❌ --interval must be greater than zero")
+		exit(1)
+	}
+	
+	evidenceDir := getEvidenceDir(wd)
+	if _, err := os.Stat(evidenceDir); os.IsNotExist(err) {
+		fmt.Println("❌ No evidence directory found")
+		fmt.Printf("💡 Run 'mondrian init' to set up the evidence directory\n")
+		exit(1)
+	}
+	chainManager := newChainManager(wd, evidenceDir)
+	
+	fmt.Printf("👀 Watching evidence chain in %s\n", evidenceDir)
+	
+	verified := func(chain *evidence.EvidenceChain) {
+		fmt.Printf("✅ %s chain verified (%d attestations)\n", time.Now().UTC().Format(time.RFC3339), chain.Length)
+	}
+	err = watchChain(chainManager, evidenceDir, verifyInterval, nil, verified)
+	if err == nil {
+		return
+	}
+	
+	fmt.Printf("❌ Tampering detected: %v\n", err)
+	if verifyWebhook != "" {
+		alert := map[string]interface{}{
+			"event":        "tamper_detected",
+			"evidence_dir": evidenceDir,
+			"error":        err.Error(),
+			"detected_at":  time.Now().UTC(),
+		}
+		if err := postJSON(verifyWebhook, alert); err != nil {
+			slog.Warn("Failed to send webhook alert", "err", err)
+		}
+	}
+	exit(1)
+}
+
+// chainSettle is how long the evidence directory must be quiet after a
+// change before --watch re-verifies the chain ahead of its next interval
+const chainSettle = time.Second
+
+// watchChain verifies the chain in evidenceDir, then again every interval and
+// soon after files there change, calling verified after every pass that
+// succeeds. It returns the first tampering detected, or nil once stop is
+// closed.
+func watchChain(chainManager *evidence.ChainManager, evidenceDir string, interval time.Duration, stop <-chan struct{}, verified func(*evidence.EvidenceChain)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("starting file watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watchDirs(watcher, evidenceDir); err != nil {
+		return fmt.Errorf("watching %s: %w", evidenceDir, err)
+	}
+	
+	// File events only bring a pass forward; the interval still catches
+	// changes fsnotify misses, such as on network filesystems
+	quit := make(chan struct{})
+	defer close(quit)
+	changes := make(chan string)
+	changed := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		for {
+			select {
+			case <-quit:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						watchDirs(watcher, event.Name)
+						continue
+					}
+				}
+				if event.Has(fsnotify.Chmod) {
+					continue
+				}
+				select {
+				case changes <- event.Name:
+				case <-quit:
+					return
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("File watcher error", "err", err)
+			}
+		}
+	}()
+	go debounce(changes, min(chainSettle, interval), func([]string) {
+		select {
+		case changed <- struct{}{}:
+		default: // a pass is already due
+		}
+	})
+	
+	return verifyChainEvery(chainManager, evidenceDir, interval, changed, stop, verified)
+}
+
+// verifyChainEvery verifies the chain in evidenceDir now, then on every tick
+// of interval and whenever changed receives, until tampering is detected or
+// stop is closed
+func verifyChainEvery(chainManager *evidence.ChainManager, evidenceDir string, interval time.Duration, changed <-chan struct{}, stop <-chan struct{}, verified func(*evidence.EvidenceChain)) error {
+	chainPath := filepath.Join(evidenceDir, "chain.json")
+	var last *evidence.EvidenceChain
+	check := func() error {
+		var lastLength int
+		var lastHead string
+		if last != nil {
+			lastLength, lastHead = last.Length, last.Head
+		}
+		chain, err := watchChainOnce(chainManager, chainPath, lastLength, lastHead)
+		if err != nil {
+			return err
+		}
+		if chain != nil {
+			last = chain
+			verified(chain)
+		}
+		return nil
+	}
+	
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := check(); err != nil {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		case <-changed:
+		}
+	}
+}
+
+// watchChainOnce verifies the chain and checks it only grew since the previous pass.
+// A nil chain means no chain has been written yet.
+func watchChainOnce(chainManager *evidence.ChainManager, chainPath string, lastLength int, lastHead string) (*evidence.EvidenceChain, error) {
+	if _, err := os.Stat(chainPath); os.IsNotExist(err) {
+		if lastLength > 0 {
+			return nil, fmt.Errorf("chain file removed: %s", chainPath)
+		}
+		return nil, nil
+	}
+	
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		return nil, err
+	}
+	
+	if err := chainManager.VerifyChain(chain); err != nil {
+		return nil, err
+	}
+	if anomalies := chainManager.VerifyEntries(chain, 0); len(anomalies) > 0 {
+		return nil, anomalies[0]
+	}
+	
+	// The chain is append-only: it must never shrink or rewrite a previously seen head
+	if chain.Length < lastLength {
+		return nil, fmt.Errorf("chain shrank from %d to %d attestations", lastLength, chain.Length)
+	}
+	if lastHead != "" {
+		found := false
+		for _, entry := range chain.Attestations {
+			if entry.Hash == lastHead {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("previously verified head %s is no longer in the chain", lastHead[:16])
+		}
+	}
+	
+	return chain, nil
+}
+
+// InclusionProof is the JSON printed by 'mondrian chain proof'
+type InclusionProof struct {
+	ChainID    string               `json:"chainId"`
+	MerkleRoot string               `json:"merkleRoot"`
+	Hash       string               `json:"hash"`
+	Proof      []evidence.ProofNode `json:"proof"`
+}
+
+func printInclusionProof(hash string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	chainManager := newChainManager(wd, getEvidenceDir(wd))
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading evidence chain: %v\n", err)
+		exit(1)
+	}
+	if err := chainManager.VerifyChain(chain); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Evidence chain failed verification: %v\n", err)
+		exit(1)
+	}
+	
+	var match string
+	for _, entry := range chain.Attestations {
+		if strings.HasPrefix(entry.Hash, hash) {
+			if match != "" {
+				fmt.Fprintf(os.Stderr, "❌ Attestation hash prefix %s is ambiguous\n", hash)
+				exit(1)
+			}
+			match = entry.Hash
+		}
+	}
+	if match == "" || hash == "" {
+		fmt.Fprintf(os.Stderr, "❌ No attestation with hash %s in chain\n", hash)
+		exit(1)
+	}
+	
+	proof, err := chain.InclusionProof(match)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error building inclusion proof: %v\n", err)
+		exit(1)
+	}
+	output, err := json.MarshalIndent(InclusionProof{
+		ChainID:    chain.ChainID,
+		MerkleRoot: chain.MerkleRoot(),
+		Hash:       match,
+		Proof:      proof,
+	}, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error formatting inclusion proof: %v\n", err)
+		exit(1)
+	}
+	fmt.Println(string(output))
+}
+
+// chainLogEntry is one attestation in 'mondrian chain log --format json'.
+// Files is -1 when the attestation file could not be read.
+type chainLogEntry struct {
+	evidence.ChainEntry
+	Position int `json:"position"`
+	Files    int `json:"files"`
+}
+
+// printChainLog prints the chain entries newest first
+func printChainLog() {
+	if chainLogFormat != "text" && chainLogFormat != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unknown format %q (expected one of: text, json)\n", chainLogFormat)
+		exit(1)
+	}
+	
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	chainManager := newChainManager(wd, getEvidenceDir(wd))
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading evidence chain: %v\n", err)
+		exit(1)
+	}
+	
+	entries := make([]chainLogEntry, 0, len(chain.Attestations))
+	for i := len(chain.Attestations) - 1; i >= 0; i-- {
+		entry := chainLogEntry{ChainEntry: chain.Attestations[i], Position: i + 1, Files: -1}
+		if attestation, _, err := chainManager.LoadAttestation(entry.FilePath); err == nil {
+			entry.Files = len(attestation.Predicate.FilesScanned)
+		}
+		entries = append(entries, entry)
+	}
+	
+	if chainLogFormat == "json" {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error serializing chain log: %v\n", err)
+			exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	
+	if len(entries) == 0 {
+		fmt.Println("📭 No attestations in the evidence chain yet - run 'mondrian attest' to create one")
+		return
+	}
+	
+	for i, entry := range entries {
+		files := "?"
+		if entry.Files >= 0 {
+			files = strconv.Itoa(entry.Files)
+		}
+		
+		if chainLogOneline {
+			fmt.Printf("%s %s %-4s %s files\n", logHash(entry.Hash), entry.Timestamp.Format("2006-01-02 15:04:05"), entry.Status, files)
+			continue
+		}
+		
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("attestation %s (#%d)\n", entry.Hash, entry.Position)
+		fmt.Printf("Parent: %s\n", logHash(entry.ParentHash))
+		fmt.Printf("Date:   %s\n", entry.Timestamp.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Status: %s\n", entry.Status)
+		fmt.Printf("Files:  %s\n", files)
+	}
+}
+
+// logHash abbreviates a hash the way git log --oneline does
+func logHash(hash string) string {
+	if hash == "" {
+		return "(genesis)"
+	}
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func repairChain() {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	evidenceDir := getEvidenceDir(wd)
+	if _, err := os.Stat(evidenceDir); os.IsNotExist(err) {
+		fmt.Println("❌ No evidence directory found")
+		fmt.Printf("💡 Run 'mondrian init' to set up the evidence directory\n")
+		exit(1)
+	}
+	
+	chainManager := newChainManager(wd, evidenceDir)
+	
+	// The previous chain may be the thing that's broken, so only use it for the summary
+	previous, err := chainManager.LoadChain()
+	if err != nil {
+		slog.Warn("Previous chain is unreadable", "err", err)
+		previous = &evidence.EvidenceChain{}
+	}
+	
+	backupPath, err := chainManager.BackupChain()
+	if err != nil {
+		fmt.Printf("❌ Error backing up chain: %v\n", err)
+		exit(1)
+	}
+	if backupPath != "" {
+		fmt.Printf("💾 Previous chain saved to %s\n", backupPath)
+	}
+	
+	// Relinked attestations are re-signed with the project key, if there is one
+	var signer *evidence.Signer
+	if _, err := os.Stat(getSigningKeyPath(wd)); err == nil || os.Getenv("MONDRIAN_PKCS11_MODULE") != "" || evidence.KMSKeyURIFromEnv() != "" {
+		signer = loadSigner(wd)
+		defer signer.Close()
+		
+		// Re-signed attestations must still verify against the pinned key
+		if chainManager.PublicKey != nil && !signer.GetPublicKey().Equal(chainManager.PublicKey) {
+			fmt.Printf("❌ Signing key %s does not match the trusted public key %s\n", signer.GetKeyID(), getPublicKeyPath(wd))
+			exit(1)
+		}
+	}
+	
+	chain, err := chainManager.ScanAndRepairChain(signer)
+	if err != nil {
+		fmt.Printf("❌ Error repairing chain: %v\n", err)
+		exit(1)
+	}
+	
+	if chain.Length == 0 {
+		fmt.Println("ℹ️  No attestation files found - wrote an empty chain")
+		return
+	}
+	
+	before := make(map[string]bool)
+	for _, entry := range previous.Attestations {
+		before[entry.Hash] = true
+	}
+	after := make(map[string]bool)
+	added := 0
+	for _, entry := range chain.Attestations {
+		after[entry.Hash] = true
+		if !before[entry.Hash] {
+			added++
+		}
+	}
+	removed := 0
+	for _, entry := range previous.Attestations {
+		if !after[entry.Hash] {
+			removed++
+		}
+	}
+	
+	fmt.Printf("✅ Rebuilt chain with %d attestations (%d added, %d removed)\n", chain.Length, added, removed)
+	if previous.Head != chain.Head {
+		fmt.Printf("🔗 Head: %s → %s\n", shortHash(previous.Head), shortHash(chain.Head))
+	} else {
+		fmt.Printf("🔗 Head: %s (unchanged)\n", shortHash(chain.Head))
+	}
+}
+
+func verifyAuditLog() {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	logPath := filepath.Join(wd, ".mondrian", "audit.log")
+	if _, err := os.Stat(logPath); os.IsNotExist(err) {
+		fmt.Println("ℹ️  No audit log found")
+		fmt.Printf("💡 Run commands with --audit to record them\n")
+		return
+	}
+	
+	entries, err := evidence.VerifyAuditLog(logPath)
+	if err != nil {
+		fmt.Printf("❌ Audit log verification failed: %v\n", err)
+		exit(1)
+	}
+	
+	if len(entries) == 0 {
+		fmt.Println("ℹ️  Audit log is empty")
+		return
+	}
+	
+	fmt.Printf("✅ Audit log verification passed (%d invocations)\n", len(entries))
+	start := 0
+	if len(entries) > 5 {
+		start = len(entries) - 5
+		fmt.Printf("   (showing last 5 of %d)\n", len(entries))
+	}
+	for _, entry := range entries[start:] {
+		fmt.Printf("   %s %-20s exit=%d user=%s\n",
+			entry.Timestamp.Format("2006-01-02 15:04:05"),
+			entry.Command,
+			entry.ExitCode,
+			entry.User)
+	}
+	fmt.Printf("🔝 Head Hash: %s\n", entries[len(entries)-1].Hash[:16]+"...")
+}
+
+// hookMarker identifies pre-commit hooks written by 'mondrian hooks install'
+const hookMarker = "# Installed by 'mondrian hooks install'"
+
+// preCommitHookPath returns where git looks for the pre-commit hook, which
+// honours core.hooksPath and linked worktrees
+func preCommitHookPath() string {
+	output, err := runCommand("git", "rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		fmt.Println("❌ Not inside a git repository")
+		exit(1)
+	}
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("❌ Error getting current directory: %v\n", err)
+			exit(1)
+		}
+		path = filepath.Join(wd, path)
+	}
+	return path
+}
+
+func installHook() {
+	if hooksFailOn != "" {
+		if err := policy.ValidateSeverity(hooksFailOn); err != nil {
+			fmt.Printf("❌ Invalid --fail-on: %v\n", err)
+			exit(1)
+		}
+	}
+	
+	hookPath := preCommitHookPath()
+	if _, err := os.Stat(hookPath); err == nil && !hooksForce {
+		fmt.Printf("❌ A pre-commit hook already exists at %s\n", hookPath)
+		fmt.Println("💡 Re-run with --force to replace it")
+		exit(1)
+	}
+	
+	// Prefer the binary doing the install, but fall back to PATH if it moves
+	binary := "mondrian"
+	if executable, err := os.Executable(); err == nil {
+		binary = executable
+	}
+	command := "\"$MONDRIAN\" check --changed-only --staged"
+	if hooksFailOn != "" {
+		command += " --fail-on " + hooksFailOn
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+%s; remove with 'mondrian hooks uninstall'
+MONDRIAN=%s
+[ -x "$MONDRIAN" ] || MONDRIAN=mondrian
+exec %s
+`, hookMarker, "'"+strings.ReplaceAll(binary, "'", `'\''`)+"'", command)
+	
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		fmt.Printf("❌ Error creating hooks directory: %v\n", err)
+		exit(1)
+	}
+	if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+		fmt.Printf("❌ Error writing pre-commit hook: %v\n", err)
+		exit(1)
+	}
+	// WriteFile keeps the mode of a file it replaces
+	if err := os.Chmod(hookPath, 0755); err != nil {
+		fmt.Printf("❌ Error making pre-commit hook executable: %v\n", err)
+		exit(1)
+	}
+	
+	fmt.Printf("🪝 Installed pre-commit hook at %s\n", hookPath)
+	fmt.Println("💡 Commits with failing findings in staged files will be rejected; bypass once with 'git commit --no-verify'")
+}
+
+func uninstallHook() {
+	hookPath := preCommitHookPath()
+	data, err := os.ReadFile(hookPath)
+	if os.IsNotExist(err) {
+		fmt.Println("ℹ️  No pre-commit hook installed")
+		return
+	}
+	if err != nil {
+		fmt.Printf("❌ Error reading pre-commit hook: %v\n", err)
+		exit(1)
+	}
+	if !strings.Contains(string(data), hookMarker) && !hooksForce {
+		fmt.Printf("❌ The pre-commit hook at %s was not installed by Mondrian\n", hookPath)
+		fmt.Println("💡 Re-run with --force to remove it anyway")
+		exit(1)
+	}
+	
+	if err := os.Remove(hookPath); err != nil {
+		fmt.Printf("❌ Error removing pre-commit hook: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("🗑️  Removed pre-commit hook %s\n", hookPath)
+}
+
+func initializeProject() {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
 	}
 	
-	fmt.Println("🔍 Verifying evidence chain...")
+	evidenceDir := getEvidenceDir(wd)
+	if err := os.MkdirAll(evidenceDir, 0755); err != nil {
+		fmt.Printf("❌ Error creating evidence directory: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("📁 Evidence directory: %s\n", evidenceDir)
 	
-	// Initialize chain manager
-	chainManager := evidence.NewChainManager(evidenceDir)
+	if initEncryption {
+		initializeEncryptionKey(wd)
+	}
 	
-	// Load existing chain
-	chain, err := chainManager.LoadOrCreateChain()
+	keyPath := getSigningKeyPath(wd)
+	if _, err := os.Stat(keyPath); err == nil {
+		signer, err := evidence.NewSignerFromFile(keyPath)
+		if err != nil {
+			fmt.Printf("❌ Existing signing key is unreadable: %v\n", err)
+			exit(1)
+		}
+		fmt.Printf("🔑 Using existing signing key %s (%s)\n", keyPath, signer.GetKeyID())
+		return
+	}
+	
+	signer, err := evidence.NewSigner()
 	if err != nil {
-		fmt.Printf("❌ Error loading evidence chain: %v\n", err)
-		os.Exit(1)
+		fmt.Printf("❌ Error generating signing key: %v\n", err)
+		exit(1)
+	}
+	if err := signer.SaveKey(keyPath); err != nil {
+		fmt.Printf("❌ Error saving signing key: %v\n", err)
+		exit(1)
+	}
+	publicKeyPath := strings.TrimSuffix(keyPath, ".pem") + ".pub"
+	if err := signer.SavePublicKey(publicKeyPath); err != nil {
+		fmt.Printf("❌ Error saving public key: %v\n", err)
+		exit(1)
 	}
 	
-	if chain.Length == 0 {
-		fmt.Println("ℹ️  No attestations found in evidence chain")
+	// Keep the private key out of version control
+	ignorePath := filepath.Join(filepath.Dir(keyPath), ".gitignore")
+	if err := os.WriteFile(ignorePath, []byte("*.pem\n"), 0644); err != nil {
+		slog.Warn("Could not write "+ignorePath, "err", err)
+	}
+	
+	fmt.Printf("🔑 Generated signing key %s (%s)\n", keyPath, signer.GetKeyID())
+	fmt.Printf("📤 Public key for verifiers: %s\n", publicKeyPath)
+	fmt.Println("✅ Mondrian initialized")
+}
+
+// initializeEncryptionKey generates the key pair for encrypting attestations
+// at rest, unless one exists. Attesting encrypts to the public key, while
+// the private key, kept out of version control, decrypts for verification.
+func initializeEncryptionKey(wd string) {
+	keyPath := filepath.Join(wd, ".mondrian", "keys", "encryption.pem")
+	publicKeyPath := strings.TrimSuffix(keyPath, ".pem") + ".pub"
+	if _, err := os.Stat(keyPath); err == nil {
+		fmt.Printf("🔒 Using existing encryption key %s\n", keyPath)
 		return
 	}
 	
-	// Verify chain integrity
-	fmt.Printf("🔗 Verifying chain integrity (%d attestations)...\n", chain.Length)
-	if err := chainManager.VerifyChain(chain); err != nil {
-		fmt.Printf("❌ Chain verification failed: %v\n", err)
-		os.Exit(1)
+	key, err := evidence.GenerateEncryptionKey()
+	if err != nil {
+		fmt.Printf("❌ Error generating encryption key: %v\n", err)
+		exit(1)
+	}
+	if err := evidence.SaveEncryptionKey(key, keyPath, publicKeyPath); err != nil {
+		fmt.Printf("❌ Error saving encryption key: %v\n", err)
+		exit(1)
 	}
 	
-	// Display chain summary
-	fmt.Println("✅ Evidence chain verification passed!")
-	fmt.Println()
-	fmt.Println("📊 Chain Summary:")
-	fmt.Println(chain.GetChainSummary())
-	fmt.Println()
-	fmt.Printf("🔑 Chain ID: %s\n", chain.ChainID)
-	fmt.Printf("🏷️  Genesis Hash: %s\n", chain.Genesis[:16]+"...")
-	fmt.Printf("🔝 Head Hash: %s\n", chain.Head[:16]+"...")
+	ignorePath := filepath.Join(filepath.Dir(keyPath), ".gitignore")
+	if err := os.WriteFile(ignorePath, []byte("*.pem\n"), 0644); err != nil {
+		slog.Warn("Could not write "+ignorePath, "err", err)
+	}
 	
-	// Show recent attestations
-	fmt.Println()
-	fmt.Println("📜 Recent Attestations:")
-	start := 0
-	if chain.Length > 5 {
-		start = chain.Length - 5
-		fmt.Printf("   (showing last 5 of %d)\n", chain.Length)
+	fmt.Printf("🔒 Generated encryption key %s\n", keyPath)
+	fmt.Printf("📤 Encrypt attestations with: mondrian attest --encrypt-to %s\n", publicKeyPath)
+}
+
+func startServer() {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	evidenceDir := getEvidenceDir(wd)
+	if _, err := os.Stat(evidenceDir); os.IsNotExist(err) {
+		fmt.Println("ℹ️  No evidence directory found - run 'mondrian init' and 'mondrian attest' to populate the viewer")
+	}
+	
+	addr := fmt.Sprintf("127.0.0.1:%d", servePort)
+	fmt.Printf("🔗 Evidence viewer listening on http://%s\n", addr)
+	
+	if err := http.ListenAndServe(addr, server.New(newChainManager(wd, evidenceDir)).Handler()); err != nil {
+		fmt.Printf("❌ Error running evidence viewer: %v\n", err)
+		exit(1)
+	}
+}
+
+func startAPIServer() {
+	if err := os.MkdirAll(serveAPIRoot, 0755); err != nil {
+		fmt.Printf("❌ Error creating API root: %v\n", err)
+		exit(1)
+	}
+	
+	addr := fmt.Sprintf("127.0.0.1:%d", servePort)
+	fmt.Printf("📥 Storing chains under %s\n", serveAPIRoot)
+	fmt.Printf("🔗 Evidence API listening on http://%s\n", addr)
+	
+	if err := http.ListenAndServe(addr, server.NewAPI(serveAPIRoot).Handler()); err != nil {
+		fmt.Printf("❌ Error running evidence API: %v\n", err)
+		exit(1)
+	}
+}
+
+// Helper functions for gathering context information
+
+// runChecks runs the engine, exiting when ctx ends the run early
+func runChecks(ctx context.Context, engine *policy.PolicyEngine, files map[string]string) []policy.CheckResult {
+	results, err := engine.RunChecks(ctx, files)
+	if err != nil {
+		exitIfCanceled(ctx)
+		fmt.Fprintf(os.Stderr, "❌ Error running policy checks: %v\n", err)
+		exit(1)
+	}
+	return results
+}
+
+// runContext bounds a scan and its checks by --timeout, and cancels them on Ctrl+C
+func runContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if runTimeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// exitIfCanceled reports a run cut short by --timeout or an interrupt and exits
+func exitIfCanceled(ctx context.Context) {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		fmt.Fprintf(os.Stderr, "❌ Timed out after %s: scanning and policy checks were abandoned\n", runTimeout)
+		exit(1)
+	case context.Canceled:
+		fmt.Fprintln(os.Stderr, "❌ Interrupted: scanning and policy checks were abandoned")
+		exit(130)
+	}
+}
+
+// scanFiles collects the policy-relevant files under wd
+func scanFiles(ctx context.Context, wd string) map[string]string {
+	scanner := policy.NewFileScanner(wd)
+	files, err := scanner.ScanRelevantFiles(ctx)
+	if err != nil {
+		exitIfCanceled(ctx)
+		fmt.Printf("❌ Error scanning files: %v\n", err)
+		exit(1)
+	}
+	
+	// Warnings go to stderr so they never corrupt machine-readable output
+	for _, warning := range scanner.Warnings {
+		slog.Warn(warning)
+	}
+	return files
+}
+
+// scanRoots scans each root and merges the files into one set keyed by path
+// relative to wd, so files from different roots cannot collide. Without
+// roots, wd itself is scanned.
+func scanRoots(ctx context.Context, wd string, roots []string) map[string]string {
+	if len(roots) == 0 {
+		return scanFiles(ctx, wd)
 	}
 	
-	for i := start; i < chain.Length; i++ {
-		entry := chain.Attestations[i]
-		status := "✅"
-		if entry.Status == "fail" {
-			status = "❌"
-		} else if entry.Status == "warn" {
-			status = "⚠️"
+	merged := make(map[string]string)
+	for _, root := range roots {
+		dir := root
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(wd, dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Printf("❌ Error scanning %s: not a directory\n", root)
+			exit(1)
 		}
 		
-		fmt.Printf("   %s %s [%s] %s\n", 
-			status, 
-			entry.Timestamp.Format("2006-01-02 15:04:05"),
-			entry.Status,
-			entry.RunID[:8]+"...")
+		prefix, err := filepath.Rel(wd, dir)
+		if err != nil || strings.HasPrefix(prefix, "..") {
+			prefix = filepath.Clean(root)
+		}
+		
+		for path, content := range scanFiles(ctx, dir) {
+			merged[filepath.ToSlash(filepath.Join(prefix, path))] = content
+		}
+	}
+	return merged
+}
+
+// diffAttestations prints how policy results changed between two attestations
+func diffAttestations(beforeRef, afterRef string) {
+	if diffFormat != "text" && diffFormat != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unknown format %q (expected one of: text, json)\n", diffFormat)
+		exit(1)
+	}
+	
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	before := resolveAttestation(wd, beforeRef)
+	after := resolveAttestation(wd, afterRef)
+	diff := evidence.DiffAttestations(before, after)
+	
+	if diffFormat == "json" {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error serializing diff: %v\n", err)
+			exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	
+	fmt.Printf("🔀 Comparing %s (%s) → %s (%s)\n",
+		shortHash(before.Hash), before.Timestamp.Format("2006-01-02 15:04:05"),
+		shortHash(after.Hash), after.Timestamp.Format("2006-01-02 15:04:05"))
+	
+	marks := map[string]string{
+		evidence.RuleNewlyFailing:    "❌",
+		evidence.RuleNewlyPassing:    "✅",
+		evidence.RuleSeverityChanged: "↕️ ",
+		evidence.RuleStatusChanged:   "↕️ ",
+		evidence.RuleFindingsChanged: "🔄",
+		evidence.RuleOnlyBefore:      "➖",
+		evidence.RuleOnlyAfter:       "➕",
+	}
+	
+	changed := 0
+	for _, rule := range diff.Rules {
+		if !rule.Changed() {
+			continue
+		}
+		changed++
+		
+		fmt.Printf("\n%s %s: %s\n", marks[rule.Change], rule.Rule, describeRuleChange(rule))
+		for _, result := range rule.Resolved {
+			fmt.Printf("   - resolved: %s\n", describeFinding(result))
+		}
+		for _, result := range rule.New {
+			fmt.Printf("   + new: %s\n", describeFinding(result))
+		}
+	}
+	
+	if len(diff.FilesOnlyBefore) > 0 || len(diff.FilesOnlyAfter) > 0 {
+		fmt.Printf("\n📁 File sets differ: %d files only in the earlier run, %d only in the later run; findings in them have no counterpart and are not listed\n",
+			len(diff.FilesOnlyBefore), len(diff.FilesOnlyAfter))
 	}
 	
 	fmt.Println()
-	fmt.Printf("🎯 Verification complete - evidence chain is valid and tamper-evident\n")
+	if changed == 0 {
+		fmt.Printf("✅ No rule outcomes changed (%d rules compared)\n", len(diff.Rules))
+		return
+	}
+	fmt.Printf("📊 %d of %d rules changed\n", changed, len(diff.Rules))
 }
 
-func initializeProject() {
-	fmt.Println("⚠️  Project initialization implementation coming soon...")
+// describeRuleChange summarizes a rule's before and after outcome
+func describeRuleChange(rule evidence.RuleDiff) string {
+	outcome := func(status, severity string) string {
+		if severity == "" {
+			return status
+		}
+		return fmt.Sprintf("%s [%s]", status, severity)
+	}
+	
+	switch rule.Change {
+	case evidence.RuleOnlyBefore:
+		return fmt.Sprintf("only in the earlier run (%s)", outcome(rule.BeforeStatus, rule.BeforeSeverity))
+	case evidence.RuleOnlyAfter:
+		return fmt.Sprintf("only in the later run (%s)", outcome(rule.AfterStatus, rule.AfterSeverity))
+	}
+	return fmt.Sprintf("%s → %s", outcome(rule.BeforeStatus, rule.BeforeSeverity), outcome(rule.AfterStatus, rule.AfterSeverity))
 }
 
-func startServer() {
-	fmt.Println("⚠️  Evidence viewer implementation coming soon...")
+func describeFinding(result policy.CheckResult) string {
+	if result.File == "" {
+		return result.Message
+	}
+	if result.Line > 0 {
+		return fmt.Sprintf("%s (%s:%d)", result.Message, result.File, result.Line)
+	}
+	return fmt.Sprintf("%s (%s)", result.Message, result.File)
 }
 
-// Helper functions for gathering context information
+// resolveAttestation loads an attestation from a file path, a #N chain
+// position or a hash prefix in the evidence chain
+func resolveAttestation(wd, ref string) *evidence.Attestation {
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		attestation, err := evidence.LoadAttestationFile(ref, loadDecryptionKey())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error loading attestation %s: %v\n", ref, err)
+			exit(1)
+		}
+		return attestation
+	}
+	
+	chainManager := newChainManager(wd, getEvidenceDir(wd))
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading evidence chain: %v\n", err)
+		exit(1)
+	}
+	
+	hash := ref
+	if position, err := strconv.Atoi(strings.TrimPrefix(ref, "#")); err == nil && strings.HasPrefix(ref, "#") {
+		if position < 1 || position > len(chain.Attestations) {
+			fmt.Fprintf(os.Stderr, "❌ Chain position %s is out of range (chain has %d attestations)\n", ref, len(chain.Attestations))
+			exit(1)
+		}
+		hash = chain.Attestations[position-1].Hash
+	}
+	
+	attestation, err := chainManager.FindAttestation(chain, hash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading attestation %s: %v\n", ref, err)
+		exit(1)
+	}
+	return attestation
+}
 
-func getRepositoryName(wd string) string {
-	// Try to get from git remote
-	if output, err := runCommand("git", "remote", "get-url", "origin"); err == nil {
-		return string(output)
+// ruleInfo describes a rule for 'mondrian rules list --format json'
+type ruleInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	Enabled     bool   `json:"enabled"`
+	
+	Frameworks map[string]string `json:"frameworks,omitempty"` // Compliance controls the rule supports
+}
+
+// listRules prints every registered rule with its severity after any override
+// in mondrian.yaml, and whether the config enables it
+func listRules() {
+	if rulesFormat != "text" && rulesFormat != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unknown format %q (expected one of: text, json)\n", rulesFormat)
+		exit(1)
+	}
+	
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	cfg, err := policy.LoadConfig(configPath(wd))
+	if err != nil {
+		fmt.Printf("❌ Error loading config: %v\n", err)
+		exit(1)
+	}
+	
+	registered, err := cfg.ConfiguredRules()
+	if err != nil {
+		fmt.Printf("❌ Error loading rules: %v\n", err)
+		exit(1)
+	}
+	
+	var rules []ruleInfo
+	for _, rule := range registered {
+		info := ruleInfo{
+			Name:        rule.Name(),
+			Description: rule.Description(),
+			Severity:    policy.RuleSeverity(rule),
+			Enabled:     true,
+			Frameworks:  policy.RuleFrameworks(rule),
+		}
+		if severity, ok := cfg.Severity[info.Name]; ok {
+			info.Severity = severity
+		}
+		if len(cfg.Rules) > 0 {
+			info.Enabled = false
+			for _, name := range cfg.Rules {
+				if name == info.Name {
+					info.Enabled = true
+				}
+			}
+		}
+		rules = append(rules, info)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	
+	if rulesFormat == "json" {
+		data, err := json.MarshalIndent(rules, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error serializing rules: %v\n", err)
+			exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	
+	names := make([]string, len(rules))
+	width := 0
+	for i, rule := range rules {
+		names[i] = rule.Name
+		if !rule.Enabled {
+			names[i] += " (disabled)"
+		}
+		if len(names[i]) > width {
+			width = len(names[i])
+		}
+	}
+	
+	fmt.Printf("📋 %d rules available:\n", len(rules))
+	for i, rule := range rules {
+		if !rulesFrameworks {
+			fmt.Printf("   %-*s  %-8s  %s\n", width, names[i], rule.Severity, rule.Description)
+			continue
+		}
+		var controls []string
+		for _, framework := range sortedKeys(rule.Frameworks) {
+			controls = append(controls, framework+" "+rule.Frameworks[framework])
+		}
+		fmt.Printf("   %-*s  %-8s  %s\n", width, names[i], rule.Severity, strings.Join(controls, " · "))
 	}
 	
-	// Fallback to directory name
-	return filepath.Base(wd)
+	if rulesFrameworks {
+		printControlCoverage(rules)
+	}
 }
 
-func getBranchName() string {
-	if output, err := runCommand("git", "branch", "--show-current"); err == nil {
-		branch := strings.TrimSpace(string(output))
-		if branch != "" {
-			return branch
+// printControlCoverage lists, for each framework control, the rules that
+// provide evidence for it
+func printControlCoverage(rules []ruleInfo) {
+	coverage := make(map[string]map[string][]string)
+	for _, rule := range rules {
+		for framework, value := range rule.Frameworks {
+			if coverage[framework] == nil {
+				coverage[framework] = make(map[string][]string)
+			}
+			for _, control := range strings.Split(value, ",") {
+				control = strings.TrimSpace(control)
+				coverage[framework][control] = append(coverage[framework][control], rule.Name)
+			}
 		}
 	}
-	return "unknown"
+	
+	fmt.Println("\n📐 Control coverage:")
+	for _, framework := range sortedKeys(coverage) {
+		fmt.Printf("   %s\n", framework)
+		for _, control := range sortedKeys(coverage[framework]) {
+			fmt.Printf("     %-8s %s\n", control, strings.Join(coverage[framework][control], ", "))
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configPath returns the --config path, or the mondrian.yaml found from wd up
+// to the repository root. Without either it names a file in wd that doesn't
+// exist, which LoadConfig treats as an empty config.
+func configPath(wd string) string {
+	if configFile != "" {
+		return configFile
+	}
+	if path := policy.FindConfig(wd); path != "" {
+		return path
+	}
+	return filepath.Join(wd, policy.ConfigFileName)
+}
+
+// validateConfig reports whether the policy config loads cleanly
+func validateConfig(args []string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	
+	path := configPath(wd)
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if _, err := os.Stat(path); err != nil {
+		fmt.Printf("❌ Config file %s not found\n", path)
+		exit(1)
+	}
+	
+	if _, err := policy.LoadConfig(path); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("✅ %s is valid\n", path)
+}
+
+func newPolicyEngine(wd string) *policy.PolicyEngine {
+	path := configPath(wd)
+	slog.Debug("Loading policy config", "path", path)
+	cfg, err := policy.LoadConfig(path)
+	if err != nil {
+		fmt.Printf("❌ Error loading config: %v\n", err)
+		exit(1)
+	}
+	engine, err := policy.NewPolicyEngineFromConfig(cfg)
+	if err != nil {
+		fmt.Printf("❌ Error configuring rules: %v\n", err)
+		exit(1)
+	}
+	
+	slog.Debug("Configured policy engine", "rules", len(engine.Rules), "failOn", cmp.Or(engine.FailOn, policy.DefaultFailOn), "allowlisted", len(engine.Allowlist.Entries))
+	
+	return engine
+}
+
+func getSigningKeyPath(wd string) string {
+	return filepath.Join(wd, ".mondrian", "keys", "signing.pem")
 }
 
-func getCommitHash() string {
-	if output, err := runCommand("git", "rev-parse", "HEAD"); err == nil {
-		hash := strings.TrimSpace(string(output))
-		if len(hash) >= 12 {
-			return hash[:12] // Short hash
+// loadSigner uses the PKCS#11 key configured via MONDRIAN_PKCS11_* when set,
+// then the KMS key named by MONDRIAN_KMS_KEY, then the project signing key
+// created by 'mondrian init', falling back to an ephemeral key when none exists
+func loadSigner(wd string) *evidence.Signer {
+	config, ok, err := evidence.PKCS11ConfigFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Error configuring PKCS#11 signing: %v\n", err)
+		exit(1)
+	}
+	if ok {
+		signer, err := evidence.NewSignerFromPKCS11(config)
+		if err != nil {
+			fmt.Printf("❌ Error loading PKCS#11 signing key: %v\n", err)
+			exit(1)
 		}
+		slog.Debug("Using PKCS#11 signing key", "keyId", signer.GetKeyID())
+		return signer
 	}
-	return "unknown"
+	
+	if keyURI := evidence.KMSKeyURIFromEnv(); keyURI != "" {
+		signer, err := evidence.NewSignerFromKMS(keyURI)
+		if err != nil {
+			fmt.Printf("❌ Error loading KMS signing key: %v\n", err)
+			exit(1)
+		}
+		slog.Debug("Using KMS signing key", "key", keyURI, "keyId", signer.GetKeyID())
+		return signer
+	}
+	
+	keyPath := getSigningKeyPath(wd)
+	if _, err := os.Stat(keyPath); err == nil {
+		signer, err := evidence.NewSignerFromFile(keyPath)
+		if err != nil {
+			fmt.Printf("❌ Error loading signing key: %v\n", err)
+			exit(1)
+		}
+		slog.Debug("Using project signing key", "path", keyPath, "keyId", signer.GetKeyID())
+		return signer
+	}
+	
+	signer, err := evidence.NewSigner()
+	if err != nil {
+		fmt.Printf("❌ Error creating signer: %v\n", err)
+		exit(1)
+	}
+	slog.Warn("No signing key found, using an ephemeral key (run 'mondrian init' to create one)")
+	return signer
+}
+
+// newChainManager opens the chain in evidenceDir, able to read attestations
+// encrypted at rest when a decryption key is configured. Attestations only
+// verify when signed by the project's trusted key, see loadTrustedKey.
+func newChainManager(wd, evidenceDir string) *evidence.ChainManager {
+	chainManager := evidence.NewChainManager(evidenceDir)
+	chainManager.DecryptionKey = loadDecryptionKey()
+	chainManager.PublicKey = loadTrustedKey(wd)
+	return chainManager
+}
+
+// getPublicKeyPath is where 'mondrian init' saves the public half of the signing key
+func getPublicKeyPath(wd string) string {
+	return strings.TrimSuffix(getSigningKeyPath(wd), ".pem") + ".pub"
+}
+
+// loadTrustedKey returns the key attestations must be signed with: the project
+// public key saved by 'mondrian init', else that of the configured PKCS#11 or
+// KMS key. Without one, each attestation would be checked against the key it
+// embeds, which anyone able to rewrite the chain controls, so that needs
+// --trust-embedded-keys and nil is returned.
+func loadTrustedKey(wd string) *ecdsa.PublicKey {
+	publicKeyPath := getPublicKeyPath(wd)
+	data, err := os.ReadFile(publicKeyPath)
+	if err == nil {
+		key, err := evidence.ParsePublicKeyPEM(string(data))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error loading public key %s: %v\n", publicKeyPath, err)
+			exit(1)
+		}
+		return key
+	}
+	if !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "❌ Error reading public key: %v\n", err)
+		exit(1)
+	}
+	
+	if _, ok, _ := evidence.PKCS11ConfigFromEnv(); ok || evidence.KMSKeyURIFromEnv() != "" {
+		signer := loadSigner(wd)
+		defer signer.Close()
+		return signer.GetPublicKey()
+	}
+	
+	if !trustEmbeddedKeys {
+		fmt.Fprintf(os.Stderr, "❌ No trusted public key: %s is missing and no PKCS#11 or KMS key is configured\n", publicKeyPath)
+		fmt.Fprintf(os.Stderr, "💡 Run 'mondrian init', or pass --trust-embedded-keys to accept the key each attestation embeds\n")
+		exit(1)
+	}
+	slog.Warn("Verifying attestations against their embedded keys; a rewritten chain re-signed with another key would pass")
+	return nil
+}
+
+// loadDecryptionKey reads the key named by --decryption-key, else by
+// MONDRIAN_DECRYPTION_KEY, or returns nil when neither is set
+func loadDecryptionKey() *ecdh.PrivateKey {
+	path := cmp.Or(decryptionKeyPath, os.Getenv("MONDRIAN_DECRYPTION_KEY"))
+	if path == "" {
+		return nil
+	}
+	key, err := evidence.LoadDecryptionKey(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading decryption key: %v\n", err)
+		exit(1)
+	}
+	return key
+}
+
+// loadEncryptor reads the recipient key named by --encrypt-to, else by
+// MONDRIAN_ENCRYPT_TO, or returns nil to save attestations unencrypted
+func loadEncryptor() *evidence.Encryptor {
+	path := cmp.Or(attestEncryptTo, os.Getenv("MONDRIAN_ENCRYPT_TO"))
+	if path == "" {
+		return nil
+	}
+	encryptor, err := evidence.LoadEncryptor(path)
+	if err != nil {
+		fmt.Printf("❌ Error loading encryption key: %v\n", err)
+		exit(1)
+	}
+	slog.Debug("Encrypting attestation", "path", path, "keyId", encryptor.GetKeyID())
+	return encryptor
+}
+
+// defaultChainDir is where the evidence chain lives unless --chain-dir says otherwise
+var defaultChainDir = filepath.Join(".mondrian", "evidence")
+
+// chainDir is the evidence directory chosen with --chain-dir, relative to the
+// working directory unless absolute
+var chainDir = defaultChainDir
+
+func getEvidenceDir(wd string) string {
+	if filepath.IsAbs(chainDir) {
+		return chainDir
+	}
+	return filepath.Join(wd, chainDir)
+}
+
+func postJSON(url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
 }
 
 func getWorkflowContext() string {
@@ -376,6 +3143,26 @@ func getWorkflowContext() string {
 	return "local"
 }
 
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 16 {
+		return hash[:16] + "..."
+	}
+	if hash == "" {
+		return "(none)"
+	}
+	return hash
+}
+
 func runCommand(name string, args ...string) ([]byte, error) {
 	cmd := exec.Command(name, args...)
 	return cmd.Output()
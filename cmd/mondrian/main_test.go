@@ -0,0 +1,104 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+func newTestSigner(t *testing.T) *evidence.Signer {
+	t.Helper()
+	signer, err := evidence.NewSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// writeTestChain attests n times to a new chain in evidenceDir the way
+// 'mondrian attest' does, signed by signer
+func writeTestChain(t *testing.T, evidenceDir string, signer *evidence.Signer, n int) (*evidence.ChainManager, *evidence.EvidenceChain) {
+	t.Helper()
+	chainManager := evidence.NewChainManager(evidenceDir)
+	chain, err := chainManager.LoadOrCreateChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		appendTestAttestation(t, chainManager, evidenceDir, chain, signer)
+	}
+	return chainManager, chain
+}
+
+// newTestProject lays out a project the way 'mondrian init' does, with the
+// public key of signer saved and, when n > 0, a chain of n attestations
+func newTestProject(t *testing.T, signer *evidence.Signer, n int) (string, *evidence.EvidenceChain) {
+	t.Helper()
+	wd := t.TempDir()
+	if err := os.MkdirAll(filepath.Dir(getPublicKeyPath(wd)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.SavePublicKey(getPublicKeyPath(wd)); err != nil {
+		t.Fatal(err)
+	}
+	evidenceDir := getEvidenceDir(wd)
+	if err := os.MkdirAll(evidenceDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	var chain *evidence.EvidenceChain
+	if n > 0 {
+		_, chain = writeTestChain(t, evidenceDir, signer, n)
+	}
+	return wd, chain
+}
+
+// appendTestAttestation signs an attestation extending chain and adds it
+func appendTestAttestation(t *testing.T, chainManager *evidence.ChainManager, evidenceDir string, chain *evidence.EvidenceChain, signer *evidence.Signer) *evidence.Attestation {
+	t.Helper()
+	results := []policy.CheckResult{{RuleName: "s3-public-read", Status: "pass", Message: "ok"}}
+	attestation := evidence.NewAttestation(results, evidence.AttestationMetadata{ParentHash: chain.Head})
+	signed, err := signer.SignAttestation(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedPath, err := evidence.SaveSignedAttestation(signed, evidenceDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chainManager.AddAttestation(chain, attestation, filepath.Base(savedPath)); err != nil {
+		t.Fatal(err)
+	}
+	return attestation
+}
+
+// chdir switches to dir for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+func TestRulesList(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+
+	output, code := runMondrian(t, binary, dir, "rules", "list")
+	if code != 0 {
+		t.Fatalf("rules list exited %d", code)
+	}
+	for _, name := range []string{"s3-no-public-buckets", "sg-no-open-ingress", "deploy-require-oidc"} {
+		if !strings.Contains(output, name) {
+			t.Errorf("rules list does not show built-in rule %s:\n%s", name, output)
+		}
+	}
+	for _, rule := range policy.NewPolicyEngine().Rules {
+		if !strings.Contains(output, rule.Description()) {
+			t.Errorf("rules list does not describe %s", rule.Name())
+		}
+	}
+
+	output, code = runMondrian(t, binary, dir, "rules", "list", "--format", "json")
+	if code != 0 {
+		t.Fatalf("rules list --format json exited %d", code)
+	}
+	var rules []ruleInfo
+	if err := json.Unmarshal([]byte(output), &rules); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+	if len(rules) != len(policy.NewPolicyEngine().Rules) {
+		t.Errorf("listed %d rules, want every registered rule", len(rules))
+	}
+	for _, rule := range rules {
+		if rule.Name == "s3-no-public-buckets" && (rule.Severity != policy.SeverityCritical || !rule.Enabled || rule.Description == "") {
+			t.Errorf("s3-no-public-buckets listed as %+v", rule)
+		}
+	}
+}
+
+func TestRulesListConfig(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{policy.ConfigFileName: "rules: [sg-no-open-ingress]\nseverity:\n  sg-no-open-ingress: low\n"})
+
+	output, code := runMondrian(t, binary, dir, "rules", "list", "--format", "json")
+	if code != 0 {
+		t.Fatalf("rules list exited %d", code)
+	}
+	var rules []ruleInfo
+	if err := json.Unmarshal([]byte(output), &rules); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+	for _, rule := range rules {
+		enabled := rule.Name == "sg-no-open-ingress"
+		if rule.Enabled != enabled {
+			t.Errorf("%s enabled = %v, want %v", rule.Name, rule.Enabled, enabled)
+		}
+		if enabled && rule.Severity != policy.SeverityLow {
+			t.Errorf("severity override not shown: %+v", rule)
+		}
+	}
+
+	if output, _ := runMondrian(t, binary, dir, "rules", "list"); !strings.Contains(output, "s3-no-public-buckets (disabled)") {
+		t.Errorf("text listing does not mark disabled rules:\n%s", output)
+	}
+	if _, code := runMondrian(t, binary, dir, "rules", "list", "--format", "yaml"); code != 1 {
+		t.Errorf("unknown format exited %d, want 1", code)
+	}
+}
+
+func TestRulesListFrameworks(t *testing.T) {
+	binary := buildMondrian(t)
+	dir := t.TempDir()
+
+	output, code := runMondrian(t, binary, dir, "rules", "list", "--frameworks")
+	if code != 0 {
+		t.Fatalf("rules list --frameworks exited %d", code)
+	}
+	var s3Line string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "s3-no-public-buckets ") {
+			s3Line = line
+		}
+	}
+	if !strings.Contains(s3Line, "CIS-AWS 2.1.4 · SOC2 CC6.1") {
+		t.Errorf("s3-no-public-buckets listed as %q, want its CIS and SOC 2 controls", s3Line)
+	}
+	_, coverage, ok := strings.Cut(output, "Control coverage:")
+	if !ok || !strings.Contains(coverage, "2.1.4    s3-no-public-buckets") {
+		t.Errorf("control coverage does not list s3-no-public-buckets under CIS-AWS 2.1.4:\n%s", coverage)
+	}
+
+	output, _ = runMondrian(t, binary, dir, "rules", "list", "--frameworks", "--format", "json")
+	var rules []ruleInfo
+	if err := json.Unmarshal([]byte(output), &rules); err != nil {
+		t.Fatalf("parsing %q: %v", output, err)
+	}
+	for _, rule := range rules {
+		if rule.Name == "s3-no-public-buckets" && rule.Frameworks["CIS-AWS"] != "2.1.4" {
+			t.Errorf("s3-no-public-buckets frameworks = %v, want CIS-AWS 2.1.4", rule.Frameworks)
+		}
+	}
+}
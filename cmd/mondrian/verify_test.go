@@ -0,0 +1,373 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+)
+
+// tamperTestAttestation rewrites the status inside an attestation's signed
+// payload, leaving its signature and hash as they were
+func tamperTestAttestation(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signed evidence.SignedAttestation
+	if err := json.Unmarshal(data, &signed); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := signed.Envelope.DecodeB64Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attestation evidence.Attestation
+	if err := json.Unmarshal(payload, &attestation); err != nil {
+		t.Fatal(err)
+	}
+	attestation.Predicate.Summary.OverallStatus = "pass-but-tampered"
+	if payload, err = json.Marshal(attestation); err != nil {
+		t.Fatal(err)
+	}
+	signed.Envelope.Payload = base64.StdEncoding.EncodeToString(payload)
+	if data, err = json.Marshal(signed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startWatch runs watchChain in the background, returning a channel of chain
+// lengths verified and one receiving its result
+func startWatch(t *testing.T, chainManager *evidence.ChainManager, evidenceDir string, interval time.Duration) (<-chan int, <-chan error) {
+	t.Helper()
+	stop := make(chan struct{})
+	verified := make(chan int, 16)
+	result := make(chan error, 1)
+	go func() {
+		result <- watchChain(chainManager, evidenceDir, interval, stop, func(chain *evidence.EvidenceChain) {
+			verified <- chain.Length
+		})
+	}()
+	t.Cleanup(func() { close(stop) })
+
+	select {
+	case <-verified:
+	case err := <-result:
+		t.Fatalf("watch ended before the first verification: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch never verified the chain")
+	}
+	return verified, result
+}
+
+func TestWatchChainDetectsTamperingWithinInterval(t *testing.T) {
+	evidenceDir := t.TempDir()
+	signer := newTestSigner(t)
+	chainManager, chain := writeTestChain(t, evidenceDir, signer, 3)
+	chainManager.PublicKey = signer.GetPublicKey()
+
+	const interval = 200 * time.Millisecond
+	_, result := startWatch(t, chainManager, evidenceDir, interval)
+
+	tamperTestAttestation(t, filepath.Join(evidenceDir, chain.Attestations[1].FilePath))
+	tampered := time.Now()
+
+	// Detection follows the change within one interval, plus the
+	// verification itself
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Fatal("watch stopped without reporting tampering")
+		}
+		if elapsed := time.Since(tampered); elapsed > 2*interval {
+			t.Errorf("tampering detected after %s, want within one %s interval", elapsed, interval)
+		}
+		if !strings.Contains(err.Error(), chain.Attestations[1].FilePath) {
+			t.Errorf("error %q does not name the tampered attestation", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tampering was not detected")
+	}
+}
+
+func TestVerifyChainEveryDetectsTamperingWithoutFileEvents(t *testing.T) {
+	evidenceDir := t.TempDir()
+	signer := newTestSigner(t)
+	chainManager, chain := writeTestChain(t, evidenceDir, signer, 2)
+	chainManager.PublicKey = signer.GetPublicKey()
+
+	// No file events are delivered, as on a filesystem fsnotify can't watch
+	const interval = 300 * time.Millisecond
+	stop := make(chan struct{})
+	defer close(stop)
+	verified := make(chan int, 16)
+	result := make(chan error, 1)
+	go func() {
+		result <- verifyChainEvery(chainManager, evidenceDir, interval, nil, stop, func(chain *evidence.EvidenceChain) {
+			verified <- chain.Length
+		})
+	}()
+	<-verified
+
+	tamperTestAttestation(t, filepath.Join(evidenceDir, chain.Attestations[0].FilePath))
+	tampered := time.Now()
+	select {
+	case err := <-result:
+		if err == nil || !strings.Contains(err.Error(), chain.Attestations[0].FilePath) {
+			t.Fatalf("err = %v, want the tampered attestation named", err)
+		}
+		if elapsed := time.Since(tampered); elapsed > interval+interval/2 {
+			t.Errorf("tampering detected after %s, want within one %s interval", elapsed, interval)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tampering was not detected without file events")
+	}
+}
+
+func TestWatchChainVerifiesDuringConstantWrites(t *testing.T) {
+	evidenceDir := t.TempDir()
+	signer := newTestSigner(t)
+	chainManager, _ := writeTestChain(t, evidenceDir, signer, 1)
+	chainManager.PublicKey = signer.GetPublicKey()
+
+	const interval = 200 * time.Millisecond
+	verified, result := startWatch(t, chainManager, evidenceDir, interval)
+
+	// Writes more frequent than the interval never let the directory
+	// settle, yet the chain is still verified every interval
+	deadline := time.After(5 * interval)
+	ticker := time.NewTicker(interval / 5)
+	defer ticker.Stop()
+	passes := 0
+	for n := 0; ; n++ {
+		select {
+		case <-ticker.C:
+			if err := os.WriteFile(filepath.Join(evidenceDir, "scratch.tmp"), []byte(strconv.Itoa(n)), 0644); err != nil {
+				t.Fatal(err)
+			}
+		case <-verified:
+			passes++
+		case err := <-result:
+			t.Fatalf("watch stopped: %v", err)
+		case <-deadline:
+			if passes < 3 {
+				t.Errorf("verified %d times in %s of constant writes, want about every %s", passes, 5*interval, interval)
+			}
+			return
+		}
+	}
+}
+
+func TestWatchChainAcceptsAppends(t *testing.T) {
+	evidenceDir := t.TempDir()
+	signer := newTestSigner(t)
+	chainManager, chain := writeTestChain(t, evidenceDir, signer, 1)
+	chainManager.PublicKey = signer.GetPublicKey()
+
+	verified, result := startWatch(t, chainManager, evidenceDir, 100*time.Millisecond)
+
+	appendTestAttestation(t, evidence.NewChainManager(evidenceDir), evidenceDir, chain, signer)
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case length := <-verified:
+			if length == 2 {
+				return
+			}
+		case err := <-result:
+			t.Fatalf("watch stopped on a valid append: %v", err)
+		case <-deadline:
+			t.Fatal("appended attestation was never verified")
+		}
+	}
+}
+
+func TestWatchChainDetectsOtherSigner(t *testing.T) {
+	evidenceDir := t.TempDir()
+	signer := newTestSigner(t)
+	chainManager, chain := writeTestChain(t, evidenceDir, signer, 1)
+	chainManager.PublicKey = signer.GetPublicKey()
+
+	_, result := startWatch(t, chainManager, evidenceDir, 100*time.Millisecond)
+
+	// A validly signed attestation from another key is still tampering
+	appendTestAttestation(t, evidence.NewChainManager(evidenceDir), evidenceDir, chain, newTestSigner(t))
+	select {
+	case err := <-result:
+		if err == nil || !strings.Contains(err.Error(), "signature verification failed") {
+			t.Errorf("err = %v, want a signature failure", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("attestation signed by another key was not detected")
+	}
+}
+
+func TestWatchChainOnceDetectsRewrittenHistory(t *testing.T) {
+	evidenceDir := t.TempDir()
+	signer := newTestSigner(t)
+	chainManager, chain := writeTestChain(t, evidenceDir, signer, 2)
+	chainPath := filepath.Join(evidenceDir, "chain.json")
+
+	if _, err := watchChainOnce(chainManager, chainPath, chain.Length+1, ""); err == nil || !strings.Contains(err.Error(), "shrank") {
+		t.Errorf("err = %v, want the chain to have shrunk", err)
+	}
+	if _, err := watchChainOnce(chainManager, chainPath, chain.Length, strings.Repeat("ab", 32)); err == nil {
+		t.Errorf("a chain without the previously verified head passed")
+	}
+
+	// A missing chain is only tampering once one has been seen
+	os.Remove(chainPath)
+	if chain, err := watchChainOnce(chainManager, chainPath, 0, ""); chain != nil || err != nil {
+		t.Errorf("no chain yet = %v, %v, want nil, nil", chain, err)
+	}
+	if _, err := watchChainOnce(chainManager, chainPath, 2, ""); err == nil {
+		t.Errorf("a removed chain passed")
+	}
+	if _, err := os.Stat(chainPath); !os.IsNotExist(err) {
+		t.Errorf("watching recreated the chain file")
+	}
+}
+
+func TestVerifyMaxAge(t *testing.T) {
+	binary := buildMondrian(t)
+	signer := newTestSigner(t)
+	wd, _ := newTestProject(t, signer, 0)
+
+	// An empty chain has nothing recent to show
+	if output, code := runMondrian(t, binary, wd, "verify", "--max-age", "24h"); code != 1 || !strings.Contains(output, "Freshness check failed") {
+		t.Errorf("empty chain exited %d with %q, want a freshness failure", code, output)
+	}
+
+	writeTestChain(t, getEvidenceDir(wd), signer, 1)
+	if output, code := runMondrian(t, binary, wd, "verify", "--max-age", "24h"); code != 0 || !strings.Contains(output, "within max age of 24h0m0s") {
+		t.Errorf("fresh head exited %d with %q, want 0", code, output)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if output, code := runMondrian(t, binary, wd, "verify", "--max-age", "5ms"); code != 1 || !strings.Contains(output, "Freshness check failed: head attestation is") {
+		t.Errorf("expired head exited %d with %q, want a freshness failure", code, output)
+	}
+}
+
+func TestVerifyBundleOffline(t *testing.T) {
+	binary := buildMondrian(t)
+	signer := newTestSigner(t)
+	wd, _ := newTestProject(t, signer, 2)
+	bundle := filepath.Join(t.TempDir(), "proof.zip")
+
+	if output, code := runMondrian(t, binary, wd, "verify", "--export", bundle); code != 0 || !strings.Contains(output, "Proof bundle: "+bundle) {
+		t.Fatalf("verify --export exited %d with %q", code, output)
+	}
+
+	// The bundle stands alone, verified from elsewhere once the project is gone
+	if err := os.RemoveAll(wd); err != nil {
+		t.Fatal(err)
+	}
+	elsewhere := t.TempDir()
+	output, code := runMondrian(t, binary, elsewhere, "verify", "--bundle", bundle)
+	if code != 0 || !strings.Contains(output, "Verification PASSED") {
+		t.Errorf("verify --bundle exited %d with %q, want it to pass", code, output)
+	}
+	if entries, _ := os.ReadDir(elsewhere); len(entries) != 0 {
+		t.Errorf("verifying the bundle wrote %d files to the working directory", len(entries))
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2025-06-01T00:00:00Z", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"2025-06-01T00:00:00.5Z", time.Date(2025, 6, 1, 0, 0, 0, 500000000, time.UTC)},
+		{"24h", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"90m", time.Date(2025, 6, 2, 10, 30, 0, 0, time.UTC)},
+		{"0s", now},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.value, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"-24h", "yesterday", "2025-06-01", ""} {
+		if _, err := parseSince(value, now); err == nil {
+			t.Errorf("parseSince(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestVerifySince(t *testing.T) {
+	binary := buildMondrian(t)
+	signer := newTestSigner(t)
+	wd, _ := newTestProject(t, signer, 0)
+	evidenceDir := getEvidenceDir(wd)
+
+	// Space the attestations out so the boundary falls between them
+	chainManager, chain := writeTestChain(t, evidenceDir, signer, 1)
+	for i := 0; i < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+		appendTestAttestation(t, chainManager, evidenceDir, chain, signer)
+	}
+
+	// An attestation made exactly at --since is included
+	boundary := chain.Attestations[1].Timestamp.Format(time.RFC3339Nano)
+	output, code := runMondrian(t, binary, wd, "verify", "--since", boundary)
+	if code != 0 || !strings.Contains(output, "Verification PASSED") {
+		t.Fatalf("verify --since %s exited %d with %q", boundary, code, output)
+	}
+	if !strings.Contains(output, "1 attestations before") || strings.Contains(output, "#1 ") ||
+		!strings.Contains(output, "#2 ") || !strings.Contains(output, "#3 ") {
+		t.Errorf("verify --since %s = %q, want #2 and #3 reported and #1 skipped", boundary, output)
+	}
+
+	output, code = runMondrian(t, binary, wd, "verify", "--since", "1h")
+	if code != 0 || strings.Contains(output, "not shown") || !strings.Contains(output, "#1 ") {
+		t.Errorf("verify --since 1h exited %d with %q, want every attestation reported", code, output)
+	}
+	output, code = runMondrian(t, binary, wd, "verify", "--since", "0s")
+	if code != 0 || !strings.Contains(output, "3 attestations before") || strings.Contains(output, "#3 ") {
+		t.Errorf("verify --since 0s exited %d with %q, want every attestation skipped", code, output)
+	}
+
+	if output, code := runMondrian(t, binary, wd, "verify", "--since", "last week"); code != 1 || !strings.Contains(output, "Invalid --since") {
+		t.Errorf("verify --since \"last week\" exited %d with %q, want it rejected", code, output)
+	}
+
+	// Linkage is still checked over the whole chain, filtered entries included
+	if err := os.Remove(filepath.Join(evidenceDir, chain.Attestations[0].FilePath)); err != nil {
+		t.Fatal(err)
+	}
+	output, code = runMondrian(t, binary, wd, "verify", "--since", boundary)
+	if code != 1 || !strings.Contains(output, "attestation file missing") {
+		t.Errorf("verify --since with an earlier attestation missing exited %d with %q, want it to fail", code, output)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// startDebounce runs debounce over events in the background, returning a
+// channel of the batches it fires and one closed when it returns
+func startDebounce(events <-chan string, wait time.Duration) (<-chan []string, <-chan struct{}) {
+	fired := make(chan []string, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		debounce(events, wait, func(names []string) { fired <- names })
+	}()
+	return fired, done
+}
+
+func receiveBatch(t *testing.T, fired <-chan []string) []string {
+	t.Helper()
+	select {
+	case names := <-fired:
+		return names
+	case <-time.After(5 * time.Second):
+		t.Fatal("debounce never fired")
+		return nil
+	}
+}
+
+func TestDebounceCoalescesBursts(t *testing.T) {
+	const wait = 100 * time.Millisecond
+	events := make(chan string)
+	fired, done := startDebounce(events, wait)
+
+	// Rapid saves, each well within the quiet period, fire once
+	for _, name := range []string{"main.tf", "network.tf", "main.tf", "main.tf"} {
+		events <- name
+		time.Sleep(wait / 10)
+	}
+	if names := receiveBatch(t, fired); !slices.Equal(names, []string{"main.tf", "network.tf"}) {
+		t.Errorf("fired %q, want each changed file once", names)
+	}
+	select {
+	case names := <-fired:
+		t.Errorf("burst fired again with %q", names)
+	case <-time.After(2 * wait):
+	}
+
+	// A later change is a new batch
+	events <- "Dockerfile"
+	if names := receiveBatch(t, fired); !slices.Equal(names, []string{"Dockerfile"}) {
+		t.Errorf("fired %q, want only the later change", names)
+	}
+
+	close(events)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("debounce did not return once events closed")
+	}
+}
+
+func TestDebounceFlushesPendingOnClose(t *testing.T) {
+	events := make(chan string)
+	fired, done := startDebounce(events, time.Hour)
+
+	events <- "main.tf"
+	close(events)
+	<-done
+	select {
+	case names := <-fired:
+		if !slices.Equal(names, []string{"main.tf"}) {
+			t.Errorf("fired %q, want the pending change", names)
+		}
+	default:
+		t.Error("pending change was dropped when events closed")
+	}
+	select {
+	case names := <-fired:
+		t.Errorf("fired again with %q", names)
+	default:
+	}
+}
+
+func TestDebounceWithoutEvents(t *testing.T) {
+	events := make(chan string)
+	fired, done := startDebounce(events, time.Millisecond)
+	close(events)
+	<-done
+	if len(fired) != 0 {
+		t.Error("debounce fired without any events")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startWebhook serves a webhook endpoint answering status, returning its URL
+// and a channel of the bodies posted to it
+func startWebhook(t *testing.T, status int) (string, <-chan []byte) {
+	t.Helper()
+	posted := make(chan []byte, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost && r.Header.Get("Content-Type") == "application/json" {
+			posted <- body
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, posted
+}
+
+func TestSlackMessage(t *testing.T) {
+	event := checkFailedEvent{
+		Repository:  "github.com/acme/infra",
+		Branch:      "main",
+		Commit:      "0123456789abcdef",
+		FailedRules: []string{"s3-no-public-buckets", "sg-no-open-ingress"},
+		Passed:      3,
+		Failed:      2,
+		Warnings:    1,
+	}
+	want := "🚫 Mondrian policy check failed in github.com/acme/infra on main at 0123456: 2 failed, 1 warnings, 3 passed\nFailed rules: `s3-no-public-buckets`, `sg-no-open-ingress`"
+	if got := slackMessage(event)["text"]; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	if got := slackMessage(checkFailedEvent{FailedRules: []string{"r"}, Failed: 1})["text"]; !strings.HasPrefix(got, "🚫 Mondrian policy check failed: 1 failed") {
+		t.Errorf("text without git context = %q", got)
+	}
+}
+
+func TestCheckWebhook(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{
+		"main.tf": "resource \"aws_s3_bucket\" \"b\" {\n  acl = \"public-read\"\n}\n",
+	})
+	runTestGit(t, dir, "remote", "add", "origin", "https://github.com/acme/infra.git")
+	commit := strings.TrimSpace(runTestGit(t, dir, "rev-parse", "HEAD"))
+	binary := buildMondrian(t)
+	url, posted := startWebhook(t, http.StatusOK)
+
+	if _, code := runMondrian(t, binary, dir, "check", "--webhook", url); code != 1 {
+		t.Fatalf("check --webhook exited %d, want 1", code)
+	}
+	var event checkFailedEvent
+	select {
+	case body := <-posted:
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Fatalf("posted %s: %v", body, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing was posted")
+	}
+	if event.Event != "check_failed" || event.Repository != "github.com/acme/infra" || event.Branch != "main" || event.Commit != commit {
+		t.Errorf("event = %+v, want check_failed on main at %s", event, commit)
+	}
+	if !slices.Contains(event.FailedRules, "s3-no-public-buckets") || event.Failed < len(event.FailedRules) || event.CheckedAt.IsZero() {
+		t.Errorf("event = %+v, want the failed rules counted", event)
+	}
+
+	if _, code := runMondrian(t, binary, dir, "check", "--webhook", url, "--webhook-format", "slack"); code != 1 {
+		t.Fatalf("check --webhook-format slack exited %d, want 1", code)
+	}
+	var message map[string]string
+	if err := json.Unmarshal(<-posted, &message); err != nil || !strings.Contains(message["text"], "`s3-no-public-buckets`") || len(message) != 1 {
+		t.Errorf("slack message = %v, %v, want a text naming the failed rule", message, err)
+	}
+
+	// A passing run posts nothing
+	if _, code := runMondrian(t, binary, dir, "check", "--webhook", url, "--rule", "sg-no-open-ingress"); code != 0 {
+		t.Errorf("passing check exited %d", code)
+	}
+	select {
+	case body := <-posted:
+		t.Errorf("a passing run posted %s", body)
+	default:
+	}
+}
+
+func TestCheckWebhookFailureKeepsExitCode(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"main.tf": "resource \"aws_s3_bucket\" \"b\" {\n  acl = \"public-read\"\n}\n"})
+	binary := buildMondrian(t)
+	broken, _ := startWebhook(t, http.StatusInternalServerError)
+
+	for _, url := range []string{broken, "http://127.0.0.1:1/hook"} {
+		if _, code := runMondrian(t, binary, dir, "check", "--webhook", url); code != 1 {
+			t.Errorf("check with webhook %s exited %d, want the check's own 1", url, code)
+		}
+		if _, code := runMondrian(t, binary, dir, "check", "--webhook", url, "--no-fail"); code != 0 {
+			t.Errorf("check --no-fail with webhook %s exited %d, want 0", url, code)
+		}
+		if stderr := runMondrianStderr(t, binary, dir, "check", "--webhook", url); !strings.Contains(stderr, "Failed to send webhook notification") {
+			t.Errorf("failed webhook logged %q, want a warning", stderr)
+		}
+	}
+
+	if stderr := runMondrianStderr(t, binary, dir, "check", "--webhook", broken, "--webhook-format", "teams"); !strings.Contains(stderr, `Unknown --webhook-format "teams"`) {
+		t.Errorf("unknown format logged %q", stderr)
+	}
+}
@@ -0,0 +1,13 @@
+# Example account-management Terraform with threat detection enabled
+resource "aws_iam_account_password_policy" "strict" {
+  minimum_password_length        = 14
+  require_symbols                = true
+  require_numbers                = true
+  allow_users_to_change_password = true
+}
+
+resource "aws_guardduty_detector" "main" {
+  enable = true
+}
+
+resource "aws_securityhub_account" "main" {}
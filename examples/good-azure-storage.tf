@@ -0,0 +1,15 @@
+# Example Terraform file with Azure storage closed to anonymous access
+resource "azurerm_storage_account" "media" {
+  name                            = "acmemedia"
+  resource_group_name             = "media"
+  location                        = "eastus"
+  account_tier                    = "Standard"
+  account_replication_type        = "LRS"
+  allow_nested_items_to_be_public = false
+}
+
+resource "azurerm_storage_container" "uploads" {
+  name                  = "uploads"
+  storage_account_name  = azurerm_storage_account.media.name
+  container_access_type = "private"
+}
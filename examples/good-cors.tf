@@ -0,0 +1,29 @@
+# CORS scoped to the application's own origins
+
+resource "aws_s3_bucket_cors_configuration" "assets" {
+  bucket = "example-assets"
+
+  cors_rule {
+    allowed_headers = ["Authorization"]
+    allowed_methods = ["GET", "PUT"]
+    allowed_origins = ["https://app.example.com"]
+    max_age_seconds = 3000
+  }
+
+  # Public read-only access from any origin is fine
+  cors_rule {
+    allowed_methods = ["GET", "HEAD"]
+    allowed_origins = ["*"]
+  }
+}
+
+resource "aws_apigatewayv2_api" "api" {
+  name          = "example-api"
+  protocol_type = "HTTP"
+
+  cors_configuration {
+    allow_origins     = ["https://app.example.com", "https://admin.example.com"]
+    allow_methods     = ["GET", "POST"]
+    allow_credentials = true
+  }
+}
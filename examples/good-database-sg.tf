@@ -0,0 +1,14 @@
+# Database security group reachable only from inside the VPC
+
+resource "aws_security_group" "postgres" {
+  name_prefix = "postgres"
+  vpc_id      = "vpc-0123456789abcdef0"
+
+  ingress {
+    from_port   = 5432
+    to_port     = 5432
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/16"]
+    description = "PostgreSQL from the VPC"
+  }
+}
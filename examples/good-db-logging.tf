@@ -0,0 +1,16 @@
+# Example Postgres parameter group that logs schema changes without query parameters
+
+resource "aws_db_parameter_group" "orders" {
+  name   = "orders-pg15"
+  family = "postgres15"
+
+  parameter {
+    name  = "log_statement"
+    value = "ddl"
+  }
+
+  parameter {
+    name  = "log_min_duration_statement"
+    value = "1000"
+  }
+}
@@ -0,0 +1,15 @@
+# Example Terraform file with encrypted shared file systems
+resource "aws_kms_key" "shared_storage" {
+  description         = "KMS key for shared file systems"
+  enable_key_rotation = true
+}
+
+resource "aws_efs_file_system" "uploads" {
+  creation_token = "uploads"
+  encrypted      = true
+  kms_key_id     = aws_kms_key.shared_storage.arn
+
+  tags = {
+    Name = "uploads"
+  }
+}
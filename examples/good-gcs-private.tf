@@ -0,0 +1,13 @@
+# Example Terraform file with Cloud Storage access scoped to specific principals
+resource "google_storage_bucket" "assets" {
+  name                        = "acme-private-assets"
+  location                    = "US"
+  uniform_bucket_level_access = true
+  public_access_prevention    = "enforced"
+}
+
+resource "google_storage_bucket_iam_member" "assets_reader" {
+  bucket = google_storage_bucket.assets.name
+  role   = "roles/storage.objectViewer"
+  member = "serviceAccount:web@acme-prod.iam.gserviceaccount.com"
+}
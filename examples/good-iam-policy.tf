@@ -0,0 +1,41 @@
+# Example Terraform file with least-privilege IAM policies
+
+resource "aws_iam_policy" "uploader" {
+  name = "artifact-uploader"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [{
+      Effect   = "Allow"
+      Action   = ["s3:ListBucket", "s3:PutObject"]
+      Resource = [
+        "arn:aws:s3:::example-artifacts",
+        "arn:aws:s3:::example-artifacts/*",
+      ]
+    }]
+  })
+}
+
+resource "aws_iam_role_policy" "queue_consumer" {
+  name = "queue-consumer"
+  role = "queue-consumer"
+
+  policy = <<POLICY
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": ["sqs:ReceiveMessage", "sqs:DeleteMessage"],
+      "Resource": "arn:aws:sqs:us-east-1:123456789012:jobs"
+    },
+    {
+      "Effect": "Deny",
+      "Action": "*",
+      "Resource": "*",
+      "Condition": { "Bool": { "aws:SecureTransport": "false" } }
+    }
+  ]
+}
+POLICY
+}
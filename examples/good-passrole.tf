@@ -0,0 +1,36 @@
+# Example Terraform file with iam:PassRole scoped to a single role
+resource "aws_iam_role" "worker" {
+  name = "deploy-worker"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [{
+      Effect    = "Allow"
+      Action    = "sts:AssumeRole"
+      Principal = { Service = "lambda.amazonaws.com" }
+    }]
+  })
+}
+
+data "aws_iam_policy_document" "deployer" {
+  statement {
+    actions   = ["lambda:CreateFunction", "lambda:UpdateFunctionCode"]
+    resources = ["arn:aws:lambda:us-east-1:123456789012:function:deploy-*"]
+  }
+
+  statement {
+    actions   = ["iam:PassRole"]
+    resources = [aws_iam_role.worker.arn]
+
+    condition {
+      test     = "StringEquals"
+      variable = "iam:PassedToService"
+      values   = ["lambda.amazonaws.com"]
+    }
+  }
+}
+
+resource "aws_iam_policy" "deployer" {
+  name   = "deployer"
+  policy = data.aws_iam_policy_document.deployer.json
+}
@@ -0,0 +1,26 @@
+# Bucket whose legacy public ACL is neutralized by its public access block.
+# Setting acl = "public-read" here without the block would fail the check.
+
+resource "aws_s3_bucket" "legacy_assets" {
+  bucket = "legacy-assets"
+  acl    = "public-read"
+}
+
+resource "aws_s3_bucket_server_side_encryption_configuration" "legacy_assets" {
+  bucket = aws_s3_bucket.legacy_assets.id
+
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "aws:kms"
+    }
+  }
+}
+
+resource "aws_s3_bucket_public_access_block" "legacy_assets" {
+  bucket = aws_s3_bucket.legacy_assets.id
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
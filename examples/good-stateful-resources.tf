@@ -0,0 +1,17 @@
+# Example production database protected from accidental terraform destroy
+
+resource "aws_db_instance" "orders" {
+  identifier        = "orders"
+  engine            = "postgres"
+  instance_class    = "db.t3.medium"
+  allocated_storage = 50
+  storage_encrypted = true
+
+  tags = {
+    Environment = "production"
+  }
+
+  lifecycle {
+    prevent_destroy = true
+  }
+}
@@ -0,0 +1,22 @@
+# Example Terraform file with RDS and EBS storage encrypted at rest
+resource "aws_db_instance" "orders" {
+  identifier        = "orders"
+  engine            = "postgres"
+  instance_class    = "db.t3.micro"
+  allocated_storage = 20
+  storage_encrypted = true
+  kms_key_id        = aws_kms_key.data.arn
+}
+
+resource "aws_rds_cluster" "analytics" {
+  cluster_identifier = "analytics"
+  engine             = "aurora-postgresql"
+  storage_encrypted  = true
+}
+
+resource "aws_ebs_volume" "scratch" {
+  availability_zone = "us-east-1a"
+  size              = 100
+  encrypted         = true
+  kms_key_id        = aws_kms_key.data.arn
+}
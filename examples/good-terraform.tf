@@ -4,6 +4,16 @@ resource "aws_s3_bucket" "good_bucket" {
   bucket = "my-private-bucket"
 }
 
+resource "aws_s3_bucket_server_side_encryption_configuration" "good_bucket" {
+  bucket = aws_s3_bucket.good_bucket.id
+
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "aws:kms"
+    }
+  }
+}
+
 resource "aws_s3_bucket_public_access_block" "good_bucket" {
   bucket = aws_s3_bucket.good_bucket.id
 
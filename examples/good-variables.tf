@@ -0,0 +1,13 @@
+# Example Terraform variables with secrets handled safely
+
+variable "db_password" {
+  description = "Master password for the application database"
+  type        = string
+  sensitive   = true
+}
+
+variable "region" {
+  description = "AWS region to deploy into"
+  type        = string
+  default     = "us-east-1"
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Writes to the evidence directory are retried this many times in total,
+// waiting ioRetryDelay before the second attempt and doubling it after each,
+// so a networked filesystem or CI mount hiccup doesn't lose an attestation
+var (
+	ioAttempts   = 3
+	ioRetryDelay = 100 * time.Millisecond
+)
+
+// retryIO runs op until it succeeds, fails permanently or runs out of
+// attempts, and returns its last error
+func retryIO(op func() error) error {
+	delay := ioRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= ioAttempts || !isTransient(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isTransient reports whether retrying might help: a file that already
+// exists or that we may not write won't change by waiting
+func isTransient(err error) bool {
+	return !errors.Is(err, fs.ErrExist) && !errors.Is(err, fs.ErrPermission)
+}
+
+// writeFileAtomic replaces path with data so that readers, or a crash
+// mid-write, only ever see the old or the new content: data goes to a
+// temporary file in the same directory which is then renamed over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return retryIO(func() error {
+		temp, err := writeTemp(path, data, perm)
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(temp, path); err != nil {
+			os.Remove(temp)
+			return err
+		}
+		return nil
+	})
+}
+
+// createFileAtomic is writeFileAtomic for a file that must not exist yet.
+// It fails with an fs.ErrExist error, without retrying, when path exists.
+func createFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return retryIO(func() error {
+		temp, err := writeTemp(path, data, perm)
+		if err != nil {
+			return err
+		}
+		// Unlike a rename, a hard link never replaces an existing file
+		defer os.Remove(temp)
+		return os.Link(temp, path)
+	})
+}
+
+// writeTemp writes data to a new hidden file next to path and returns its
+// name. Dot files are never mistaken for attestation or chain files.
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// staleTempAge is how old a leftover temporary file must be before it is
+// treated as abandoned by a crashed write rather than one still in progress
+const staleTempAge = time.Minute
+
+// removeStaleTemps deletes temporary files that writes interrupted by a
+// crash left in dir. The files they were replacing are still intact.
+func removeStaleTemps(dir string) {
+	matches, _ := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleTempAge {
+			os.Remove(path)
+		}
+	}
+}
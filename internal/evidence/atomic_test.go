@@ -0,0 +1,187 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fastRetries shortens the retry delay for the rest of the test
+func fastRetries(t *testing.T) time.Duration {
+	t.Helper()
+	delay := ioRetryDelay
+	ioRetryDelay = 10 * time.Millisecond
+	t.Cleanup(func() { ioRetryDelay = delay })
+	return ioRetryDelay
+}
+
+// leftoverTemps lists the temporary files writes left in dir
+func leftoverTemps(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestRetryIO(t *testing.T) {
+	delay := fastRetries(t)
+
+	// A writer that fails transiently twice, then succeeds
+	calls := 0
+	started := time.Now()
+	err := retryIO(func() error {
+		calls++
+		if calls < 3 {
+			return syscall.EIO
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("retryIO = %v after %d calls, want success on the third", err, calls)
+	}
+	// Backing off waits the delay, then twice it
+	if elapsed := time.Since(started); elapsed < 3*delay {
+		t.Errorf("retried within %s, want backoff of at least %s", elapsed, 3*delay)
+	}
+
+	calls = 0
+	if err := retryIO(func() error { calls++; return syscall.EIO }); !errors.Is(err, syscall.EIO) || calls != ioAttempts {
+		t.Errorf("retryIO = %v after %d calls, want the last error after %d", err, calls, ioAttempts)
+	}
+
+	// Waiting won't make a file go away or grant permission
+	for _, permanent := range []error{fs.ErrExist, fs.ErrPermission} {
+		calls = 0
+		if err := retryIO(func() error { calls++; return permanent }); err != permanent || calls != 1 {
+			t.Errorf("retryIO = %v after %d calls, want %v without retrying", err, calls, permanent)
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	fastRetries(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chain.json")
+	if err := os.WriteFile(path, []byte(`{"length": 1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte(`{"length": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	if string(data) != `{"length": 2}` || info.Mode().Perm() != 0644 {
+		t.Errorf("file = %s with mode %v, want the new content with mode 644", data, info.Mode().Perm())
+	}
+	if temps := leftoverTemps(t, dir); len(temps) != 0 {
+		t.Errorf("write left %v behind", temps)
+	}
+
+	// A write that can't complete leaves nothing behind
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "entry"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(blocked, []byte("{}"), 0644); err == nil {
+		t.Error("replacing a directory succeeded")
+	}
+	if temps := leftoverTemps(t, dir); len(temps) != 0 {
+		t.Errorf("failed write left %v behind", temps)
+	}
+}
+
+func TestCreateFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "attestation-1.json")
+	if err := createFileAtomic(path, []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := createFileAtomic(path, []byte("second"), 0644); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("err = %v, want the existing file kept", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "first" {
+		t.Errorf("file = %q, want the first write", data)
+	}
+	if temps := leftoverTemps(t, dir); len(temps) != 0 {
+		t.Errorf("writes left %v behind", temps)
+	}
+}
+
+func TestLoadChainIgnoresInterruptedWrite(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 2)
+	dir := cm.evidenceDir
+
+	// A crash mid-write leaves a partial temporary file beside the intact
+	// chain, and one beside a new attestation that was never linked in
+	partial := []string{
+		filepath.Join(dir, ".chain.json.tmp-1234"),
+		filepath.Join(dir, ".attestation-20250601-120000-abcdef01.json.tmp-5678"),
+	}
+	for _, path := range partial {
+		if err := os.WriteFile(path, []byte(`{"chainId": "trunc`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := cm.LoadChain()
+	if err != nil {
+		t.Fatalf("LoadChain after an interrupted write: %v", err)
+	}
+	if loaded.Head != chain.Head || loaded.Length != 2 {
+		t.Errorf("loaded head %s length %d, want the previous chain %s 2", loaded.Head, loaded.Length, chain.Head)
+	}
+	if err := cm.VerifyChain(loaded); err != nil {
+		t.Errorf("previous chain no longer verifies: %v", err)
+	}
+	files, err := cm.findAttestationFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("found attestation files %v, want the 2 complete ones", files)
+	}
+
+	// Repair clears out temporaries old enough to be abandoned, leaving any
+	// write that may still be in progress
+	old := time.Now().Add(-2 * staleTempAge)
+	if err := os.Chtimes(partial[0], old, old); err != nil {
+		t.Fatal(err)
+	}
+	repaired, err := cm.ScanAndRepairChain(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repaired.Head != chain.Head {
+		t.Errorf("repaired head %s, want %s", repaired.Head, chain.Head)
+	}
+	if temps := leftoverTemps(t, dir); len(temps) != 1 || temps[0] != partial[1] {
+		t.Errorf("temporaries after repair = %v, want only the recent one", temps)
+	}
+}
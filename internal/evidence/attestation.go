@@ -21,6 +21,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/miqcie/mondrian/internal/policy"
@@ -38,14 +39,26 @@ type Attestation struct {
 	RunID         string                 `json:"runId"`
 	ParentHash    string                 `json:"parentHash,omitempty"`
 	Hash          string                 `json:"hash"`
+	HashAlgorithm string                 `json:"hashAlgorithm,omitempty"` // How Hash is computed, see calculateHash
+	
+	// storedPredicate is the predicate as written when it predates
+	// PredicateVersion, see UnmarshalJSON
+	storedPredicate json.RawMessage
 }
 
+// CanonicalHashAlgorithm hashes the RFC 8785 canonical JSON of an attestation.
+// Attestations without a HashAlgorithm predate it and hash Go's encoding.
+const CanonicalHashAlgorithm = "sha256-jcs"
+
 type Subject struct {
 	Name   string            `json:"name"`
 	Digest map[string]string `json:"digest"`
 }
 
 type PolicyCheckPredicate struct {
+	// Version is the PredicateVersion the predicate has the shape of
+	Version       string               `json:"version,omitempty"`
+	
 	// Core policy check information
 	Results       []policy.CheckResult `json:"results"`
 	Summary       Summary              `json:"summary"`
@@ -79,19 +92,26 @@ type ScannerInfo struct {
 func NewAttestation(results []policy.CheckResult, metadata AttestationMetadata) *Attestation {
 	summary := calculateSummary(results)
 	
-	// Generate subjects from scanned files
-	subjects := make([]Subject, len(metadata.FilesScanned))
-	for i, file := range metadata.FilesScanned {
-		hash := sha256.Sum256([]byte(file)) // Simple file path hash for now
-		subjects[i] = Subject{
+	// Explicit subjects (e.g. the artifact being deployed) come first, followed
+	// by subjects for the scanned files as context, digested by content so the
+	// attestation changes whenever a scanned file does
+	subjects := make([]Subject, 0, len(metadata.Subjects)+len(metadata.FilesScanned))
+	subjects = append(subjects, metadata.Subjects...)
+	for _, file := range metadata.FilesScanned {
+		digest, ok := metadata.FileDigests[file]
+		if !ok {
+			continue
+		}
+		subjects = append(subjects, Subject{
 			Name: file,
 			Digest: map[string]string{
-				"sha256": hex.EncodeToString(hash[:]),
+				"sha256": digest,
 			},
-		}
+		})
 	}
 	
 	predicate := PolicyCheckPredicate{
+		Version:      PredicateVersion,
 		Results:      results,
 		Summary:      summary,
 		Repository:   metadata.Repository,
@@ -107,12 +127,13 @@ func NewAttestation(results []policy.CheckResult, metadata AttestationMetadata)
 	}
 	
 	attestation := &Attestation{
-		PredicateType: "https://mondrian.dev/policy-check/v0.1",
+		PredicateType: PredicateType(PredicateVersion),
 		Subject:       subjects,
 		Predicate:     predicate,
 		Timestamp:     time.Now().UTC(),
 		RunID:         generateRunID(),
 		ParentHash:    metadata.ParentHash,
+		HashAlgorithm: CanonicalHashAlgorithm,
 	}
 	
 	// Calculate hash of the complete attestation
@@ -121,14 +142,63 @@ func NewAttestation(results []policy.CheckResult, metadata AttestationMetadata)
 	return attestation
 }
 
+// DigestFiles returns the hex SHA-256 of each file's content, keyed by path,
+// for AttestationMetadata.FileDigests
+func DigestFiles(files map[string]string) map[string]string {
+	digests := make(map[string]string, len(files))
+	for path, content := range files {
+		hash := sha256.Sum256([]byte(content))
+		digests[path] = hex.EncodeToString(hash[:])
+	}
+	return digests
+}
+
 type AttestationMetadata struct {
 	Repository   string
 	Branch       string
 	Commit       string
 	Workflow     string
 	FilesScanned []string
+	FileDigests  map[string]string // SHA-256 of each scanned file's content, see DigestFiles
 	RulesUsed    []string
 	ParentHash   string
+	Subjects     []Subject // Explicit primary subjects such as image digests
+}
+
+// digestLengths gives the hex length of each supported subject digest algorithm
+var digestLengths = map[string]int{
+	"sha256": 64,
+	"sha512": 128,
+}
+
+// ParseSubject parses a subject of the form name@sha256:digest, e.g.
+// ghcr.io/acme/api@sha256:<64 hex chars>
+func ParseSubject(value string) (Subject, error) {
+	at := strings.LastIndex(value, "@")
+	if at <= 0 {
+		return Subject{}, fmt.Errorf("subject %q must look like name@sha256:digest", value)
+	}
+	name, digest := value[:at], value[at+1:]
+	
+	algorithm, hexDigest, ok := strings.Cut(digest, ":")
+	if !ok {
+		return Subject{}, fmt.Errorf("subject %q is missing a digest algorithm", value)
+	}
+	
+	length, supported := digestLengths[algorithm]
+	if !supported {
+		return Subject{}, fmt.Errorf("subject %q uses unsupported digest algorithm %q", value, algorithm)
+	}
+	if _, err := hex.DecodeString(hexDigest); err != nil || len(hexDigest) != length {
+		return Subject{}, fmt.Errorf("subject %q has an invalid %s digest", value, algorithm)
+	}
+	
+	return Subject{
+		Name: name,
+		Digest: map[string]string{
+			algorithm: strings.ToLower(hexDigest),
+		},
+	}, nil
 }
 
 // calculateSummary generates summary statistics from policy check results
@@ -166,7 +236,15 @@ func (a *Attestation) calculateHash() string {
 	temp := *a
 	temp.Hash = ""
 	
-	data, err := json.Marshal(temp)
+	// Canonical JSON hashes the same however the attestation was formatted or
+	// reordered, so other tools can recompute it
+	var data []byte
+	var err error
+	if a.HashAlgorithm == CanonicalHashAlgorithm {
+		data, err = CanonicalJSON(temp)
+	} else {
+		data, err = json.Marshal(temp)
+	}
 	if err != nil {
 		// Fallback to timestamp-based hash
 		data = []byte(fmt.Sprintf("%s-%s", a.RunID, a.Timestamp.Format(time.RFC3339)))
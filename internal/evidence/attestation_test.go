@@ -0,0 +1,116 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+const testImageDigest = "3b1f0e2c7d4a5968b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4"
+
+func TestParseSubject(t *testing.T) {
+	subject, err := ParseSubject("ghcr.io/acme/api@sha256:" + strings.ToUpper(testImageDigest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject.Name != "ghcr.io/acme/api" || subject.Digest["sha256"] != testImageDigest || len(subject.Digest) != 1 {
+		t.Errorf("subject = %+v, want the image with its lowercased digest", subject)
+	}
+
+	// A registry port or a tag-like @ earlier in the name is part of the name
+	if subject, err := ParseSubject("registry.local:5000/team@infra/api@sha512:" + strings.Repeat("ab", 64)); err != nil || subject.Name != "registry.local:5000/team@infra/api" {
+		t.Errorf("subject = %+v, %v, want the name up to the last @", subject, err)
+	}
+
+	invalid := map[string]string{
+		"ghcr.io/acme/api":                                   "must look like name@sha256:digest",
+		"@sha256:" + testImageDigest:                         "must look like name@sha256:digest",
+		"ghcr.io/acme/api@" + testImageDigest:                "missing a digest algorithm",
+		"ghcr.io/acme/api@md5:" + testImageDigest:            `unsupported digest algorithm "md5"`,
+		"ghcr.io/acme/api@sha256:" + testImageDigest[:63]:    "invalid sha256 digest",
+		"ghcr.io/acme/api@sha256:" + strings.Repeat("z", 64): "invalid sha256 digest",
+	}
+	for value, want := range invalid {
+		if _, err := ParseSubject(value); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseSubject(%q) = %v, want an error containing %q", value, err, want)
+		}
+	}
+}
+
+func TestNewAttestationExplicitSubjectsComeFirst(t *testing.T) {
+	image, err := ParseSubject("ghcr.io/acme/api@sha256:" + testImageDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"main.tf": "# main\n"}
+	attestation := NewAttestation(nil, AttestationMetadata{
+		FilesScanned: []string{"main.tf", "unreadable.tf"},
+		FileDigests:  DigestFiles(files),
+		Subjects:     []Subject{image},
+	})
+
+	subjects := attestation.Subject
+	if len(subjects) != 2 {
+		t.Fatalf("subjects = %+v, want the image and main.tf", subjects)
+	}
+	if subjects[0].Name != "ghcr.io/acme/api" || subjects[0].Digest["sha256"] != testImageDigest {
+		t.Errorf("primary subject = %+v, want the image digest", subjects[0])
+	}
+	if subjects[1].Name != "main.tf" || subjects[1].Digest["sha256"] != DigestFiles(files)["main.tf"] {
+		t.Errorf("context subject = %+v, want main.tf by content digest", subjects[1])
+	}
+
+	// The subject is covered by the attestation hash
+	before := attestation.Hash
+	attestation.Subject[0].Digest["sha256"] = strings.Repeat("0", 64)
+	if attestation.calculateHash() == before {
+		t.Errorf("changing the primary subject left the hash unchanged")
+	}
+}
+
+func TestSubjectDigestFollowsFileContent(t *testing.T) {
+	subject := func(content string) Subject {
+		t.Helper()
+		attestation := NewAttestation(nil, AttestationMetadata{
+			FilesScanned: []string{"main.tf"},
+			FileDigests:  DigestFiles(map[string]string{"main.tf": content}),
+		})
+		if len(attestation.Subject) != 1 {
+			t.Fatalf("subjects = %+v, want main.tf", attestation.Subject)
+		}
+		return attestation.Subject[0]
+	}
+
+	private := subject("resource \"aws_s3_bucket\" \"b\" {\n  acl = \"private\"\n}\n")
+	public := subject("resource \"aws_s3_bucket\" \"b\" {\n  acl = \"public-read\"\n}\n")
+	if private.Digest["sha256"] == public.Digest["sha256"] {
+		t.Errorf("different contents at the same path share the digest %s", private.Digest["sha256"])
+	}
+
+	// The digest is of the content, not the path
+	empty := sha256.Sum256(nil)
+	if got := subject("").Digest["sha256"]; got != hex.EncodeToString(empty[:]) {
+		t.Errorf("digest of an empty main.tf = %s, want the SHA-256 of no bytes", got)
+	}
+	path := sha256.Sum256([]byte("main.tf"))
+	if private.Digest["sha256"] == hex.EncodeToString(path[:]) {
+		t.Error("subject digest is the hash of the file path")
+	}
+}
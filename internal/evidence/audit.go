@@ -0,0 +1,151 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AuditEntry records a single Mondrian CLI invocation. Entries are stored one
+// per line and hash-chained like the evidence chain, so removing or editing an
+// entry breaks every later link.
+type AuditEntry struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Command    string            `json:"command"`
+	Args       []string          `json:"args,omitempty"`
+	Flags      map[string]string `json:"flags,omitempty"`
+	WorkingDir string            `json:"workingDir"`
+	User       string            `json:"user"`
+	ExitCode   int               `json:"exitCode"`
+	ParentHash string            `json:"parentHash"`
+	Hash       string            `json:"hash"`
+}
+
+// calculateHash generates a SHA-256 hash of the entry content
+func (e *AuditEntry) calculateHash() string {
+	temp := *e
+	temp.Hash = ""
+
+	data, err := json.Marshal(temp)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%s-%s", e.Command, e.Timestamp.Format(time.RFC3339Nano)))
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// AppendAuditEntry links the entry to the last entry in the log and appends it
+func AppendAuditEntry(logPath string, entry AuditEntry) error {
+	entries, err := ReadAuditLog(logPath)
+	if err != nil {
+		return err
+	}
+
+	entry.ParentHash = ""
+	if len(entries) > 0 {
+		entry.ParentHash = entries[len(entries)-1].Hash
+	}
+	entry.Hash = entry.calculateHash()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to serialize audit entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// ReadAuditLog loads all entries from the audit log. A missing log is empty.
+func ReadAuditLog(logPath string) ([]AuditEntry, error) {
+	data, err := os.ReadFile(logPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log line %d: %w", lineNum, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// VerifyAuditLog checks every entry's hash and parent link and returns the
+// entries it read. Truncating the newest entries cannot be detected
+// from the log alone; compare the returned head against an external record for that.
+func VerifyAuditLog(logPath string) ([]AuditEntry, error) {
+	entries, err := ReadAuditLog(logPath)
+	if err != nil {
+		return nil, err
+	}
+
+	previousHash := ""
+	for i, entry := range entries {
+		if entry.ParentHash != previousHash {
+			return entries, fmt.Errorf("broken audit chain at entry %d: parent hash mismatch", i+1)
+		}
+		if entry.calculateHash() != entry.Hash {
+			return entries, fmt.Errorf("audit entry %d has been modified: hash mismatch", i+1)
+		}
+		previousHash = entry.Hash
+	}
+
+	return entries, nil
+}
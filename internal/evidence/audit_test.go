@@ -0,0 +1,151 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestAuditLog appends one entry per command to a new audit log
+func writeTestAuditLog(t *testing.T, commands ...string) string {
+	t.Helper()
+	logPath := filepath.Join(t.TempDir(), ".mondrian", "audit.log")
+	for i, command := range commands {
+		err := AppendAuditEntry(logPath, AuditEntry{
+			Timestamp:  time.Date(2025, 6, 1, 12, i, 0, 0, time.UTC),
+			Command:    command,
+			Flags:      map[string]string{"audit": "true"},
+			WorkingDir: "/src/infra",
+			User:       "ci",
+			ExitCode:   i % 2,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return logPath
+}
+
+func TestAppendAuditEntryLinksEntries(t *testing.T) {
+	logPath := writeTestAuditLog(t, "check", "attest", "verify")
+
+	entries, err := VerifyAuditLog(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if entries[0].ParentHash != "" {
+		t.Errorf("first entry has parent %s, want none", entries[0].ParentHash)
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].ParentHash != entries[i-1].Hash {
+			t.Errorf("entry %d is not linked to entry %d", i+1, i)
+		}
+	}
+	if entries[1].Command != "attest" || entries[1].ExitCode != 1 || entries[1].Flags["audit"] != "true" {
+		t.Errorf("entry 2 = %+v, want the attest invocation", entries[1])
+	}
+}
+
+func TestReadAuditLogMissing(t *testing.T) {
+	entries, err := VerifyAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("missing log = %v, %v, want an empty log", entries, err)
+	}
+}
+
+func TestVerifyAuditLogDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(lines []string) []string
+		wantErr string
+	}{
+		{
+			name: "edited entry",
+			edit: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"exitCode":1`, `"exitCode":0`, 1)
+				return lines
+			},
+			wantErr: "audit entry 2 has been modified",
+		},
+		{
+			name: "deleted entry",
+			edit: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantErr: "broken audit chain at entry 2",
+		},
+		{
+			name: "reordered entries",
+			edit: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			wantErr: "broken audit chain at entry 2",
+		},
+		{
+			name: "garbage line",
+			edit: func(lines []string) []string {
+				return append(lines, "not json")
+			},
+			wantErr: "failed to parse audit log line 4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logPath := writeTestAuditLog(t, "check", "attest", "verify")
+			data, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.edit(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			if err := os.WriteFile(logPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := VerifyAuditLog(logPath); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppendAuditEntryAfterTruncation(t *testing.T) {
+	// Dropping the newest entries leaves a valid log, which is why the head
+	// must be compared against an external record
+	logPath := writeTestAuditLog(t, "check", "attest", "verify")
+	entries, err := VerifyAuditLog(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(logPath)
+	lines := strings.SplitAfter(string(data), "\n")
+	if err := os.WriteFile(logPath, []byte(lines[0]+lines[1]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	truncated, err := VerifyAuditLog(logPath)
+	if err != nil || len(truncated) != 2 || truncated[1].Hash == entries[2].Hash {
+		t.Errorf("truncated log = %d entries, %v, want 2 valid ones with a different head", len(truncated), err)
+	}
+}
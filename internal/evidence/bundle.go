@@ -0,0 +1,337 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"archive/zip"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Fixed names of the files every proof bundle contains
+const (
+	BundleManifestName  = "manifest.json"
+	BundleChainName     = "chain.json"
+	BundlePublicKeyName = "public.pem"
+)
+
+// bundleVersion is written to new manifests. Version 2 bundles hold every key
+// that signed the chain in public.pem, where version 1 held one.
+const bundleVersion = 2
+
+// BundleManifest lists the SHA-256 digest of every other file in a proof bundle
+type BundleManifest struct {
+	Version   int          `json:"version"`
+	ChainID   string       `json:"chainId"`
+	Head      string       `json:"head"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Files     []BundleFile `json:"files"`
+}
+
+type BundleFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// ExportBundle writes a proof bundle: a zip of chain.json, every attestation
+// the chain references, under the same relative paths as in the evidence
+// directory, and public.pem holding the keys that signed them, plus a manifest
+// of their digests. The bundle is verifiable on its own, without the repository.
+//
+// Each attestation must be signed and is verified as it is bundled, against
+// PublicKey or TrustedKeys when set, else the key it records. A chain signed
+// by several keys, such as before and after a key rotation, bundles them all;
+// keyless signatures are bundled as their Fulcio certificates.
+func (cm *ChainManager) ExportBundle(chain *EvidenceChain, outPath string) error {
+	chainData, err := json.MarshalIndent(chain, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize chain: %w", err)
+	}
+
+	type bundleEntry struct {
+		path string
+		data []byte
+	}
+	var attestations []bundleEntry
+	var keys []byte
+	bundled := make(map[string]bool)
+	for _, entry := range chain.Attestations {
+		data, err := os.ReadFile(filepath.Join(cm.evidenceDir, entry.FilePath))
+		if err != nil {
+			return fmt.Errorf("failed to read attestation %s: %w", entry.FilePath, err)
+		}
+		attestations = append(attestations, bundleEntry{filepath.ToSlash(entry.FilePath), data})
+
+		_, signed, err := parseAttestationData(data, cm.DecryptionKey)
+		if err != nil {
+			return fmt.Errorf("failed to read attestation %s: %w", entry.FilePath, err)
+		}
+		if signed == nil {
+			return fmt.Errorf("attestation %s is unsigned, so a bundle cannot prove who made it", entry.FilePath)
+		}
+		if err := cm.checkSignature(signed); err != nil {
+			return fmt.Errorf("attestation %s: %w", entry.FilePath, err)
+		}
+		if !bundled[signed.Metadata.KeyID] {
+			block, err := cm.signingKeyPEM(signed)
+			if err != nil {
+				return fmt.Errorf("attestation %s: %w", entry.FilePath, err)
+			}
+			keys = append(keys, block...)
+			bundled[signed.Metadata.KeyID] = true
+		}
+	}
+	entries := append([]bundleEntry{
+		{BundleChainName, chainData},
+		{BundlePublicKeyName, keys},
+	}, attestations...)
+
+	manifest := BundleManifest{
+		Version:   bundleVersion,
+		ChainID:   chain.ChainID,
+		Head:      chain.Head,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, entry := range entries {
+		hash := sha256.Sum256(entry.data)
+		manifest.Files = append(manifest.Files, BundleFile{Path: entry.path, SHA256: hex.EncodeToString(hash[:])})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize bundle manifest: %w", err)
+	}
+	entries = append([]bundleEntry{{BundleManifestName, manifestData}}, entries...)
+
+	// Write to a temporary file first so a failed export never leaves a partial bundle
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), ".proof-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+
+	archive := zip.NewWriter(tmp)
+	for _, entry := range entries {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     entry.path,
+			Method:   zip.Deflate,
+			Modified: manifest.CreatedAt,
+		})
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to add %s to bundle: %w", entry.path, err)
+		}
+		if _, err := w.Write(entry.data); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to add %s to bundle: %w", entry.path, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), outPath); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// OpenBundle extracts a proof bundle into a new temporary directory, which
+// the caller removes, and returns it with the bundled public keys, for
+// ChainManager.TrustedKeys. The directory can be used as an evidence
+// directory. A bundle is rejected when a file is missing from the manifest or
+// does not match its digest, or when it has no public key.
+func OpenBundle(path string) (string, []*ecdsa.PublicKey, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer archive.Close()
+
+	contents := make(map[string][]byte, len(archive.File))
+	for _, f := range archive.File {
+		name := f.Name
+		if name != filepath.ToSlash(filepath.Clean(name)) || filepath.IsAbs(name) || strings.HasPrefix(name, "../") {
+			return "", nil, fmt.Errorf("bundle contains unsafe path %q", name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if _, dup := contents[name]; dup {
+			return "", nil, fmt.Errorf("bundle contains %s more than once", name)
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read %s from bundle: %w", name, err)
+		}
+		contents[name] = data
+	}
+
+	manifestData, ok := contents[BundleManifestName]
+	if !ok {
+		return "", nil, fmt.Errorf("bundle has no %s", BundleManifestName)
+	}
+	var manifest BundleManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return "", nil, fmt.Errorf("failed to parse bundle manifest: %w", err)
+	}
+
+	listed := map[string]bool{BundleManifestName: true}
+	for _, file := range manifest.Files {
+		data, ok := contents[file.Path]
+		if !ok {
+			return "", nil, fmt.Errorf("bundle is missing %s listed in its manifest", file.Path)
+		}
+		hash := sha256.Sum256(data)
+		if hex.EncodeToString(hash[:]) != file.SHA256 {
+			return "", nil, fmt.Errorf("bundle file %s does not match its manifest digest", file.Path)
+		}
+		listed[file.Path] = true
+	}
+	for name := range contents {
+		if !listed[name] {
+			return "", nil, fmt.Errorf("bundle file %s is not listed in its manifest", name)
+		}
+	}
+	if !listed[BundleChainName] {
+		return "", nil, fmt.Errorf("bundle has no %s", BundleChainName)
+	}
+	if !listed[BundlePublicKeyName] {
+		return "", nil, fmt.Errorf("bundle has no %s to verify signatures with", BundlePublicKeyName)
+	}
+	publicKeys, err := parseBundleKeys(contents[BundlePublicKeyName])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid bundle public key: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "mondrian-bundle-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+	for name, data := range contents {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+			err = os.WriteFile(target, data, 0644)
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+	}
+	return dir, publicKeys, nil
+}
+
+// signingKeyPEM returns the PEM block a bundle verifies a signed attestation
+// with: the certificate of a keyless signature, else its public key
+func (cm *ChainManager) signingKeyPEM(signed *SignedAttestation) ([]byte, error) {
+	key := cm.PublicKey
+	for _, trusted := range cm.TrustedKeys {
+		if key == nil && keyIDFor(trusted) == signed.Metadata.KeyID {
+			key = trusted
+		}
+	}
+	if key == nil && len(signed.Metadata.CertificateChain) > 0 {
+		return []byte(signed.Metadata.CertificateChain[0]), nil
+	}
+	if key == nil {
+		embedded, err := ParsePublicKeyPEM(signed.Metadata.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		key = embedded
+	}
+
+	block, err := encodePublicKeyPEM(key)
+	return []byte(block), err
+}
+
+// parseBundleKeys decodes the PEM public keys and keyless signing
+// certificates of a bundle's public.pem
+func parseBundleKeys(data []byte) ([]*ecdsa.PublicKey, error) {
+	var keys []*ecdsa.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var key any
+		switch block.Type {
+		case "PUBLIC KEY":
+			parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse public key: %w", err)
+			}
+			key = parsed
+		case "CERTIFICATE":
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse certificate: %w", err)
+			}
+			key = certificate.PublicKey
+		default:
+			return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+		}
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is not ECDSA")
+		}
+		keys = append(keys, publicKey)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("failed to decode public key PEM")
+	}
+	return keys, nil
+}
+
+// maxBundleFileSize bounds how much of each bundle entry is read
+const maxBundleFileSize = 64 << 20
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxBundleFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBundleFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxBundleFileSize)
+	}
+	return data, nil
+}
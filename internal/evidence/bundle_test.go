@@ -0,0 +1,296 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestKeylessSigner returns a signer whose key is certified by a
+// self-signed certificate, standing in for a Fulcio-issued one
+func newTestKeylessSigner(t *testing.T) *Signer {
+	t.Helper()
+	signer := newTestSigner(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sigstore-intermediate"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(10 * time.Minute),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.publicKey, signer.privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.certificates = []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+	return signer
+}
+
+// exportTestBundle writes the chain's proof bundle and returns its path
+func exportTestBundle(t *testing.T, cm *ChainManager, chain *EvidenceChain) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "proof.zip")
+	if err := cm.ExportBundle(chain, path); err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+	return path
+}
+
+// verifyTestBundle opens a bundle and verifies its chain against the bundled
+// keys the way 'mondrian verify --bundle' does
+func verifyTestBundle(t *testing.T, path string) ([]ChainAnomaly, error) {
+	t.Helper()
+	dir, keys, err := OpenBundle(path)
+	if err != nil {
+		return nil, err
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cm := NewChainManager(dir)
+	cm.TrustedKeys = keys
+	chain, err := cm.LoadChain()
+	if err != nil {
+		return nil, err
+	}
+	if err := cm.VerifyChain(chain); err != nil {
+		return nil, err
+	}
+	return cm.VerifyEntries(chain, 0), nil
+}
+
+// rewriteBundle edits the files of a bundle in place, then refreshes the
+// manifest digests when refresh is set, as a careful forger would
+func rewriteBundle(t *testing.T, path string, refresh bool, edit func(files map[string][]byte)) {
+	t.Helper()
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	var names []string
+	for _, f := range archive.File {
+		data, err := readZipFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = data
+		names = append(names, f.Name)
+	}
+	archive.Close()
+
+	edit(files)
+	for name := range files {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if refresh {
+		var manifest BundleManifest
+		if err := json.Unmarshal(files[BundleManifestName], &manifest); err != nil {
+			t.Fatal(err)
+		}
+		manifest.Files = nil
+		for _, name := range names {
+			if data, ok := files[name]; ok && name != BundleManifestName {
+				hash := sha256.Sum256(data)
+				manifest.Files = append(manifest.Files, BundleFile{Path: name, SHA256: hex.EncodeToString(hash[:])})
+			}
+		}
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[BundleManifestName] = data
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	w := zip.NewWriter(out)
+	for _, name := range names {
+		data, ok := files[name]
+		if !ok {
+			continue
+		}
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 3)
+	cm.PublicKey = signer.GetPublicKey()
+
+	dir, keys, err := OpenBundle(exportTestBundle(t, cm, chain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if len(keys) != 1 || !keys[0].Equal(signer.GetPublicKey()) {
+		t.Fatalf("bundle holds %d keys, want just the signing key", len(keys))
+	}
+
+	bundled := NewChainManager(dir)
+	bundled.TrustedKeys = keys
+	loaded, err := bundled.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Head != chain.Head || loaded.Length != 3 {
+		t.Errorf("bundled chain head %s length %d, want %s 3", loaded.Head, loaded.Length, chain.Head)
+	}
+	if anomalies := bundled.VerifyEntries(loaded, 0); len(anomalies) != 0 {
+		t.Errorf("bundled chain reported %v", anomalies)
+	}
+}
+
+func TestBundleEmbedsEverySigningKey(t *testing.T) {
+	// A rotated key, an ephemeral key and a keyless signature, verified
+	// against the keys the attestations embed
+	original, rotated, keyless := newTestSigner(t), newTestSigner(t), newTestKeylessSigner(t)
+	cm, chain := newTestChain(t, original, 2)
+	attestTo(t, cm, chain, rotated, "pass")
+	attestTo(t, cm, chain, keyless, "pass")
+	attestTo(t, cm, chain, original, "pass")
+
+	path := exportTestBundle(t, cm, chain)
+	dir, keys, err := OpenBundle(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(dir)
+	if len(keys) != 3 {
+		t.Fatalf("bundle holds %d keys, want one per signer", len(keys))
+	}
+	for i, signer := range []*Signer{original, rotated, keyless} {
+		if !keys[i].Equal(signer.GetPublicKey()) {
+			t.Errorf("bundled key %d is not that of signer %d", i, i)
+		}
+	}
+
+	manifestKeys := readBundleFile(t, path, BundlePublicKeyName)
+	if !strings.Contains(manifestKeys, "BEGIN CERTIFICATE") {
+		t.Errorf("keyless signature was not bundled as its certificate")
+	}
+
+	anomalies, err := verifyTestBundle(t, path)
+	if err != nil || len(anomalies) != 0 {
+		t.Errorf("verifying bundle: %v %v", err, anomalies)
+	}
+}
+
+func TestExportBundleRejectsUntrustedSigner(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 1)
+	attestTo(t, cm, chain, newTestSigner(t), "pass")
+	cm.PublicKey = signer.GetPublicKey()
+
+	err := cm.ExportBundle(chain, filepath.Join(t.TempDir(), "proof.zip"))
+	if err == nil || !strings.Contains(err.Error(), "signature verification failed") {
+		t.Errorf("err = %v, want the other signer's attestation refused", err)
+	}
+}
+
+func TestTamperedBundleFailsVerification(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 3)
+	cm.PublicKey = signer.GetPublicKey()
+	target := filepath.ToSlash(chain.Attestations[1].FilePath)
+
+	tamper := func(files map[string][]byte) {
+		files[target] = []byte(strings.Replace(string(files[target]), `"payload": "`, `"payload": "e30`, 1))
+	}
+	otherKey := func(files map[string][]byte) {
+		other, err := newTestSigner(t).PublicKeyPEM()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[BundlePublicKeyName] = []byte(other)
+	}
+
+	tests := []struct {
+		name    string
+		refresh bool
+		edit    func(files map[string][]byte)
+		want    string
+	}{
+		{"attestation edited", false, tamper, "does not match its manifest digest"},
+		{"attestation and manifest edited", true, tamper, "entry 1"},
+		{"public key missing", true, func(files map[string][]byte) { delete(files, BundlePublicKeyName) }, "has no public.pem"},
+		{"public key replaced", true, otherKey, "is not trusted"},
+		{"unlisted file added", false, func(files map[string][]byte) { files["extra.json"] = []byte("{}") }, "not listed in its manifest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := exportTestBundle(t, cm, chain)
+			rewriteBundle(t, path, tt.refresh, tt.edit)
+
+			anomalies, err := verifyTestBundle(t, path)
+			problem := ""
+			if err != nil {
+				problem = err.Error()
+			} else if len(anomalies) > 0 {
+				problem = anomalies[0].Error()
+			}
+			if problem == "" || !strings.Contains(problem, tt.want) {
+				t.Errorf("tampered bundle: %q, want a failure containing %q", problem, tt.want)
+			}
+		})
+	}
+}
+
+func readBundleFile(t *testing.T, path, name string) string {
+	t.Helper()
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	for _, f := range archive.File {
+		if f.Name == name {
+			data, err := readZipFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(data)
+		}
+	}
+	t.Fatalf("bundle has no %s", name)
+	return ""
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultCacheSize bounds a VerificationCache when no size is given
+const DefaultCacheSize = 1024
+
+// VerificationCache remembers the outcome of verifying chain entries so
+// repeated lookups of the same attestation skip signature verification. A
+// cached result is reused only while the attestation file's content is
+// unchanged, which is checked by digest since an edit can keep the size and
+// restore the modification time, and while the chain entry still links and
+// points to it as it did. It is safe for concurrent use.
+type VerificationCache struct {
+	cm         *ChainManager
+	maxEntries int
+	// verify checks an entry against the file content it was read with
+	verify func(entry ChainEntry, data []byte) error
+	
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+}
+
+type cachedVerification struct {
+	hash string
+	// parentHash and filePath are the rest of the entry the result was
+	// checked against, since chain.json can be edited to relink an entry
+	parentHash string
+	filePath   string
+	digest     [sha256.Size]byte
+	err        error
+}
+
+// NewVerificationCache creates a cache holding at most maxEntries results
+// (DefaultCacheSize when maxEntries <= 0)
+func NewVerificationCache(cm *ChainManager, maxEntries int) *VerificationCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheSize
+	}
+	return &VerificationCache{
+		cm:         cm,
+		maxEntries: maxEntries,
+		verify:     cm.verifyEntryData,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Verify checks a chain entry like VerifyEntries does, reusing a previous
+// result when the attestation file has not changed since
+func (c *VerificationCache) Verify(entry ChainEntry) error {
+	data, err := os.ReadFile(filepath.Join(c.cm.evidenceDir, entry.FilePath))
+	if err != nil {
+		c.remove(entry.Hash)
+		return c.cm.verifyEntry(entry)
+	}
+	
+	digest := sha256.Sum256(data)
+	if cached, ok := c.get(entry.Hash); ok && cached.digest == digest &&
+		cached.parentHash == entry.ParentHash && cached.filePath == entry.FilePath {
+		return cached.err
+	}
+	
+	// Verify the content just digested, outside the lock so slow signature
+	// checks don't serialize callers
+	result := c.verify(entry, data)
+	c.put(cachedVerification{
+		hash:       entry.Hash,
+		parentHash: entry.ParentHash,
+		filePath:   entry.FilePath,
+		digest:     digest,
+		err:        result,
+	})
+	return result
+}
+
+// Len returns the number of cached results
+func (c *VerificationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *VerificationCache) get(hash string) (cachedVerification, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	elem, ok := c.entries[hash]
+	if !ok {
+		return cachedVerification{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(cachedVerification), true
+}
+
+func (c *VerificationCache) put(result cachedVerification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	if elem, ok := c.entries[result.hash]; ok {
+		elem.Value = result
+		c.lru.MoveToFront(elem)
+		return
+	}
+	
+	c.entries[result.hash] = c.lru.PushFront(result)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(cachedVerification).hash)
+	}
+}
+
+func (c *VerificationCache) remove(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	if elem, ok := c.entries[hash]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, hash)
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// countVerifications wraps the cache's verification, returning a pointer to
+// the number of times it ran
+func countVerifications(cache *VerificationCache) *int {
+	count := 0
+	verify := cache.verify
+	cache.verify = func(entry ChainEntry, data []byte) error {
+		count++
+		return verify(entry, data)
+	}
+	return &count
+}
+
+func TestVerificationCacheReusesResult(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 2)
+	cm.PublicKey = signer.GetPublicKey()
+	cache := NewVerificationCache(cm, 0)
+	verifications := countVerifications(cache)
+
+	entry := chain.Attestations[1]
+	for i := 0; i < 3; i++ {
+		if err := cache.Verify(entry); err != nil {
+			t.Fatalf("lookup %d: %v", i+1, err)
+		}
+	}
+	if *verifications != 1 {
+		t.Errorf("verified %d times for three lookups, want once", *verifications)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("cache holds %d results, want 1", cache.Len())
+	}
+}
+
+func TestVerificationCacheDetectsSameSizeEdit(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 1)
+	cm.PublicKey = signer.GetPublicKey()
+	cache := NewVerificationCache(cm, 0)
+	verifications := countVerifications(cache)
+
+	entry := chain.Attestations[0]
+	if err := cache.Verify(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip one payload character in place and put the modification time back,
+	// so size and mtime both match what was cached
+	path := filepath.Join(cm.evidenceDir, entry.FilePath)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := strings.Index(string(data), `"payload": "`) + len(`"payload": "`) + 40
+	if data[i] == 'A' {
+		data[i] = 'B'
+	} else {
+		data[i] = 'A'
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Verify(entry); err == nil {
+		t.Errorf("edited attestation still verified from the cache")
+	}
+	if *verifications != 2 {
+		t.Errorf("verified %d times, want the edit to be verified again", *verifications)
+	}
+}
+
+func TestVerificationCacheDetectsRelinkedEntry(t *testing.T) {
+	cm, chain := newTestChain(t, newTestSigner(t), 2)
+	cache := NewVerificationCache(cm, 0)
+	verifications := countVerifications(cache)
+
+	entry := chain.Attestations[1]
+	if err := cache.Verify(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// An edited chain.json can change an entry's parent or file while the
+	// attestation it names stays intact
+	relinked := entry
+	relinked.ParentHash = chain.Attestations[1].Hash
+	if err := cache.Verify(relinked); err == nil {
+		t.Errorf("relinked entry still verified from the cache")
+	}
+	moved := entry
+	moved.FilePath = chain.Attestations[0].FilePath
+	if err := cache.Verify(moved); err == nil {
+		t.Errorf("entry pointing at another file still verified from the cache")
+	}
+	if *verifications != 3 {
+		t.Errorf("verified %d times, want each edited entry verified again", *verifications)
+	}
+
+	if err := cache.Verify(entry); err != nil {
+		t.Errorf("restored entry: %v", err)
+	}
+}
+
+func TestVerificationCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cm, chain := newTestChain(t, newTestSigner(t), 3)
+	cache := NewVerificationCache(cm, 2)
+	verifications := countVerifications(cache)
+
+	for _, i := range []int{0, 1, 0, 2, 0} {
+		if err := cache.Verify(chain.Attestations[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Entry 1 was evicted for entry 2; entry 0 stayed in use throughout
+	if *verifications != 3 || cache.Len() != 2 {
+		t.Errorf("verified %d times holding %d results, want 3 and 2", *verifications, cache.Len())
+	}
+	if err := cache.Verify(chain.Attestations[1]); err != nil || *verifications != 4 {
+		t.Errorf("evicted entry: err %v after %d verifications, want it verified again", err, *verifications)
+	}
+}
+
+func TestVerificationCacheMissingFile(t *testing.T) {
+	cm, chain := newTestChain(t, newTestSigner(t), 1)
+	cache := NewVerificationCache(cm, 0)
+
+	entry := chain.Attestations[0]
+	if err := cache.Verify(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(cm.evidenceDir, entry.FilePath)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Verify(entry); err == nil || !strings.Contains(err.Error(), "attestation file missing") {
+		t.Errorf("err = %v, want the file to be missing", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("cache kept the result for a removed file")
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"sorted keys without whitespace", json.RawMessage(`{ "b": 1, "a": [true, null, "x"] }`), `{"a":[true,null,"x"],"b":1}`},
+		{"nested objects", map[string]interface{}{"z": map[string]interface{}{"y": 1, "x": 2}, "a": "b"}, `{"a":"b","z":{"x":2,"y":1}}`},
+		// RFC 8785 section 3.2.3 orders keys by UTF-16 code units, which puts
+		// the surrogate pair of U+1F600 before U+FB33
+		{"utf-16 key order", json.RawMessage(`{"\u20ac":1,"\r":2,"\ufb33":3,"1":4,"\ud83d\ude00":5,"\u0080":6,"\u00f6":7}`),
+			"{\"\\r\":2,\"1\":4,\"\u0080\":6,\"\u00f6\":7,\"\u20ac\":1,\"\U0001F600\":5,\"\ufb33\":3}"},
+		{"minimal escaping", "<a & b> é \u0007 \"q\" \\ \n", `"<a & b> é \u0007 \"q\" \\ \n"`},
+		{"integers", []interface{}{0, -0.0, 100, -7, 1e21, 1e20}, `[0,0,100,-7,1e+21,100000000000000000000]`},
+		{"fractions", []interface{}{1.5, 0.000001, 1e-7, 333333333.33333329, -4.5e-10}, `[1.5,0.000001,1e-7,333333333.3333333,-4.5e-10]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalJSON(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("CanonicalJSON = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := canonicalNumber(math.Inf(1)); err == nil {
+		t.Error("infinity was canonicalized")
+	}
+}
+
+// reorderedJSON re-serializes a JSON document with every object's keys in
+// reverse order and indented, the same value formatted another way
+func reorderedJSON(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var write func(value interface{}, indent string)
+	write = func(value interface{}, indent string) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			// Reverse of the sorted order json.Marshal uses
+			sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+			buf.WriteString("{\n")
+			for i, key := range keys {
+				fmt.Fprintf(&buf, "%s  %q: ", indent, key)
+				write(v[key], indent+"  ")
+				if i < len(keys)-1 {
+					buf.WriteByte(',')
+				}
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(indent + "}")
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf.Write(data)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	write(value, "")
+	return buf.Bytes()
+}
+
+func TestAttestationHashIgnoresKeyOrder(t *testing.T) {
+	results := []policy.CheckResult{{
+		RuleName: "s3-no-public-buckets",
+		Status:   "fail",
+		Severity: policy.SeverityCritical,
+		Message:  "aws_s3_bucket.assets is public-read",
+		File:     "main.tf",
+		Line:     3,
+		Metadata: map[string]interface{}{
+			"resource":     "aws_s3_bucket.assets",
+			"line_content": `acl = "public-read"`,
+			"frameworks":   map[string]string{"SOC2": "CC6.1", "CIS-AWS": "2.1.4"},
+			"port":         22,
+		},
+	}}
+	attestation := NewAttestation(results, AttestationMetadata{Repository: "github.com/acme/infra", FilesScanned: []string{"main.tf"}})
+	original, err := json.Marshal(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reordered := reorderedJSON(t, original)
+	if bytes.Equal(reordered, original) {
+		t.Fatal("reordering left the document unchanged")
+	}
+
+	a, err := CanonicalJSON(json.RawMessage(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CanonicalJSON(json.RawMessage(reordered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("canonical forms differ:\n%s\n%s", a, b)
+	}
+
+	// Read back from the reordered document, metadata numbers and all, the
+	// attestation still hashes to the value it was created with
+	var loaded Attestation
+	if err := json.Unmarshal(reordered, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HashAlgorithm != CanonicalHashAlgorithm || loaded.calculateHash() != attestation.Hash {
+		t.Errorf("reordered attestation hashes to %s, want %s", loaded.calculateHash(), attestation.Hash)
+	}
+
+	loaded.Predicate.Results[0].Metadata["port"] = 2222
+	if loaded.calculateHash() == attestation.Hash {
+		t.Error("changing a metadata value left the hash unchanged")
+	}
+}
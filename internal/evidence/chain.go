@@ -17,11 +17,15 @@ limitations under the License.
 package evidence
 
 import (
+	"bufio"
+	"crypto/ecdh"
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -37,6 +41,7 @@ type EvidenceChain struct {
 	Length      int                 `json:"length"`
 	Head        string              `json:"head"`        // Hash of most recent attestation
 	Genesis     string              `json:"genesis"`     // Hash of first attestation
+	Root        string              `json:"merkleRoot,omitempty"` // Merkle root over attestation hashes, see MerkleRoot
 	Attestations []ChainEntry       `json:"attestations"`
 }
 
@@ -53,6 +58,19 @@ type ChainEntry struct {
 type ChainManager struct {
 	evidenceDir string
 	chainPath   string
+	indexPath   string
+	
+	// PublicKey, when set, is the only key attestations may be signed with,
+	// and unsigned attestations fail verification
+	PublicKey *ecdsa.PublicKey
+	
+	// TrustedKeys, when PublicKey is nil, are the keys attestations may be
+	// signed with, each matched by key ID, such as every key that signed a
+	// proof bundle's chain. Unsigned attestations fail verification here too.
+	TrustedKeys []*ecdsa.PublicKey
+	
+	// DecryptionKey opens attestation files encrypted at rest, see Encryptor
+	DecryptionKey *ecdh.PrivateKey
 }
 
 // NewChainManager creates a new chain manager
@@ -60,6 +78,7 @@ func NewChainManager(evidenceDir string) *ChainManager {
 	return &ChainManager{
 		evidenceDir: evidenceDir,
 		chainPath:   filepath.Join(evidenceDir, "chain.json"),
+		indexPath:   filepath.Join(evidenceDir, "index.json"),
 	}
 }
 
@@ -91,6 +110,118 @@ func (cm *ChainManager) LoadOrCreateChain() (*EvidenceChain, error) {
 	return &chain, nil
 }
 
+// LoadChain loads the existing chain without creating one. A missing chain
+// file yields an empty, unsaved chain.
+func (cm *ChainManager) LoadChain() (*EvidenceChain, error) {
+	data, err := os.ReadFile(cm.chainPath)
+	if os.IsNotExist(err) {
+		return &EvidenceChain{Attestations: make([]ChainEntry, 0)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain file: %w", err)
+	}
+	
+	var chain EvidenceChain
+	if err := json.Unmarshal(data, &chain); err != nil {
+		return nil, fmt.Errorf("failed to parse chain file: %w", err)
+	}
+	
+	return &chain, nil
+}
+
+// LoadEntries loads the chain with only the window of up to limit entries
+// starting at offset in Attestations; Length still counts every entry. The
+// chain file is streamed, so entries outside the window are never unmarshaled.
+// A limit <= 0 reads to the end, and an offset past the end yields no entries.
+func (cm *ChainManager) LoadEntries(offset, limit int) (*EvidenceChain, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative, got %d", offset)
+	}
+	
+	file, err := os.Open(cm.chainPath)
+	if os.IsNotExist(err) {
+		return &EvidenceChain{Attestations: make([]ChainEntry, 0)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain file: %w", err)
+	}
+	defer file.Close()
+	
+	entries := make([]ChainEntry, 0)
+	header := make(map[string]json.RawMessage)
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, fmt.Errorf("failed to parse chain file: %w", err)
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chain file: %w", err)
+		}
+		key, _ := token.(string)
+		if key != "attestations" {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, fmt.Errorf("failed to parse chain file: %w", err)
+			}
+			header[key] = value
+			continue
+		}
+		
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chain file: %w", err)
+		}
+		if token == nil {
+			continue // "attestations": null
+		}
+		if token != json.Delim('[') {
+			return nil, fmt.Errorf("failed to parse chain file: attestations is not a list")
+		}
+		for i := 0; decoder.More(); i++ {
+			if i < offset || (limit > 0 && i >= offset+limit) {
+				// Skip the entry without building it
+				if err := decoder.Decode(&struct{}{}); err != nil {
+					return nil, fmt.Errorf("failed to parse chain file: %w", err)
+				}
+				continue
+			}
+			var entry ChainEntry
+			if err := decoder.Decode(&entry); err != nil {
+				return nil, fmt.Errorf("failed to parse chain file: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return nil, fmt.Errorf("failed to parse chain file: %w", err)
+		}
+	}
+	
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse chain file: %w", err)
+	}
+	var chain EvidenceChain
+	if err := json.Unmarshal(data, &chain); err != nil {
+		return nil, fmt.Errorf("failed to parse chain file: %w", err)
+	}
+	chain.Attestations = entries
+	
+	return &chain, nil
+}
+
+// expectDelim reads the next token, which must be delim
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", delim, token)
+	}
+	return nil
+}
+
 // SaveChain saves the evidence chain to disk
 func (cm *ChainManager) SaveChain(chain *EvidenceChain) error {
 	if err := os.MkdirAll(cm.evidenceDir, 0755); err != nil {
@@ -102,9 +233,10 @@ func (cm *ChainManager) SaveChain(chain *EvidenceChain) error {
 		return fmt.Errorf("failed to serialize chain: %w", err)
 	}
 	
-	if err := os.WriteFile(cm.chainPath, data, 0644); err != nil {
+	if err := writeFileAtomic(cm.chainPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write chain file: %w", err)
 	}
+	slog.Debug("Saved evidence chain", "path", cm.chainPath, "length", chain.Length, "head", shortHash(chain.Head))
 	
 	return nil
 }
@@ -124,6 +256,28 @@ func (cm *ChainManager) AddAttestation(chain *EvidenceChain, attestation *Attest
 	// Recalculate hash with parent hash included
 	attestation.Hash = attestation.calculateHash()
 	
+	return cm.appendEntry(chain, attestation, filePath)
+}
+
+// AppendAttestation appends an attestation that is already linked and signed,
+// such as one copied from another copy of the chain, without rewriting it. Its
+// parent hash must be the chain head, which is empty for a new chain.
+func (cm *ChainManager) AppendAttestation(chain *EvidenceChain, attestation *Attestation, filePath string) error {
+	if attestation.ParentHash != chain.Head {
+		return fmt.Errorf("attestation parent %s is not the chain head %s", shortHash(attestation.ParentHash), shortHash(chain.Head))
+	}
+	if chain.Length == 0 {
+		chain.Genesis = attestation.Hash
+	}
+	
+	return cm.appendEntry(chain, attestation, filePath)
+}
+
+// appendEntry records an attestation as the new chain head and saves the
+// chain and index
+func (cm *ChainManager) appendEntry(chain *EvidenceChain, attestation *Attestation, filePath string) error {
+	parentHash := attestation.ParentHash
+	
 	// Create chain entry
 	entry := ChainEntry{
 		Hash:       attestation.Hash,
@@ -134,13 +288,27 @@ func (cm *ChainManager) AddAttestation(chain *EvidenceChain, attestation *Attest
 		FilePath:   filePath,
 	}
 	
+	slog.Debug("Adding attestation to chain", "hash", shortHash(entry.Hash), "parent", shortHash(parentHash), "file", filePath)
+	
 	// Add to chain
 	chain.Attestations = append(chain.Attestations, entry)
 	chain.Length++
 	chain.Head = attestation.Hash
+	chain.Root = chain.MerkleRoot()
 	chain.LastUpdated = time.Now().UTC()
 	
-	return cm.SaveChain(chain)
+	if err := cm.SaveChain(chain); err != nil {
+		return err
+	}
+	
+	index, err := cm.LoadIndex()
+	if err != nil {
+		return err
+	}
+	if err := cm.indexEntry(index, entry, false); err != nil {
+		return err
+	}
+	return cm.SaveIndex(index)
 }
 
 // VerifyChain verifies the integrity of the evidence chain
@@ -157,8 +325,9 @@ func (cm *ChainManager) VerifyChain(chain *EvidenceChain) error {
 	// Verify hash chain links
 	for i, entry := range chain.Attestations {
 		// Load and verify attestation file
-		if _, err := os.Stat(filepath.Join(cm.evidenceDir, entry.FilePath)); os.IsNotExist(err) {
-			return fmt.Errorf("attestation file missing: %s", entry.FilePath)
+		fullPath := filepath.Join(cm.evidenceDir, entry.FilePath)
+		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+			return fmt.Errorf("attestation file missing: %s", fullPath)
 		}
 		
 		// Verify parent hash linkage
@@ -178,11 +347,27 @@ func (cm *ChainManager) VerifyChain(chain *EvidenceChain) error {
 		}
 	}
 	
+	// Chains written before Merkle roots were recorded have none to check
+	if chain.Root != "" && chain.Root != chain.MerkleRoot() {
+		return fmt.Errorf("merkle root mismatch: recorded %s, computed %s", shortHash(chain.Root), shortHash(chain.MerkleRoot()))
+	}
+	
 	return nil
 }
 
-// ScanAndRepairChain scans the evidence directory and rebuilds the chain
-func (cm *ChainManager) ScanAndRepairChain() (*EvidenceChain, error) {
+// ScanAndRepairChain scans the evidence directory and rebuilds the chain.
+// Attestations whose parent changes are relinked and their hash recomputed,
+// so the rebuilt chain matches what AddAttestation would have produced.
+// Relinked signed attestations are re-signed with signer, which may be nil
+// when no file needs rewriting. Attestations that fail their own hash or
+// signature check, against PublicKey when set, are left out rather than
+// re-signed. Files an earlier repair verified against the same key, and
+// whose content is unchanged since, are taken from index.json instead of being
+// parsed and verified again; the index is rewritten to match the rebuilt chain.
+// Temporary files left by interrupted writes are cleaned up.
+func (cm *ChainManager) ScanAndRepairChain(signer *Signer) (*EvidenceChain, error) {
+	removeStaleTemps(cm.evidenceDir)
+	
 	attestationFiles, err := cm.findAttestationFiles()
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan attestation files: %w", err)
@@ -200,12 +385,22 @@ func (cm *ChainManager) ScanAndRepairChain() (*EvidenceChain, error) {
 		return chain, cm.SaveChain(chain)
 	}
 	
+	index, err := cm.LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	indexed := cm.freshEntries(index)
+	
 	// Load and sort attestations by timestamp
 	var entries []ChainEntry
 	for _, file := range attestationFiles {
+		if entry, ok := indexed[file]; ok {
+			entries = append(entries, entry)
+			continue
+		}
 		entry, err := cm.loadAttestationEntry(file)
 		if err != nil {
-			fmt.Printf("Warning: failed to load attestation %s: %v\n", file, err)
+			slog.Warn("Failed to load attestation, leaving it out of the chain", "file", file, "err", err)
 			continue
 		}
 		entries = append(entries, entry)
@@ -216,15 +411,32 @@ func (cm *ChainManager) ScanAndRepairChain() (*EvidenceChain, error) {
 		return entries[i].Timestamp.Before(entries[j].Timestamp)
 	})
 	
-	// Rebuild chain with proper parent hash links
-	var rebuiltEntries []ChainEntry
+	// Rebuild chain with proper parent hash links. Each hash depends on the
+	// parent's, so entries are relinked in order.
+	rebuiltEntries := make([]ChainEntry, 0, len(entries))
 	var previousHash string
 	
 	for _, entry := range entries {
-		// Update parent hash
-		entry.ParentHash = previousHash
-		rebuiltEntries = append(rebuiltEntries, entry)
-		previousHash = entry.Hash
+		// Fresh index entries were verified against the same key by an earlier
+		// repair and their files are byte-for-byte unchanged since; they only
+		// need reading again when their parent changes
+		if _, ok := indexed[entry.FilePath]; ok && entry.ParentHash == previousHash {
+			rebuiltEntries = append(rebuiltEntries, entry)
+			previousHash = entry.Hash
+			continue
+		}
+		
+		relinked, err := cm.relinkAttestation(entry, previousHash, signer)
+		if err != nil {
+			slog.Warn("Skipping attestation", "file", entry.FilePath, "err", err)
+			continue
+		}
+		rebuiltEntries = append(rebuiltEntries, relinked)
+		previousHash = relinked.Hash
+	}
+	
+	if len(rebuiltEntries) == 0 {
+		return nil, fmt.Errorf("no valid attestations found to rebuild the chain from")
 	}
 	
 	// Create rebuilt chain
@@ -237,8 +449,78 @@ func (cm *ChainManager) ScanAndRepairChain() (*EvidenceChain, error) {
 		Head:         rebuiltEntries[len(rebuiltEntries)-1].Hash,
 		Attestations: rebuiltEntries,
 	}
+	chain.Root = chain.MerkleRoot()
+	
+	if err := cm.SaveChain(chain); err != nil {
+		return nil, err
+	}
+	
+	rebuiltIndex := newAttestationIndex()
+	for _, entry := range rebuiltEntries {
+		if err := cm.indexEntry(rebuiltIndex, entry, true); err != nil {
+			return nil, err
+		}
+	}
+	return chain, cm.SaveIndex(rebuiltIndex)
+}
+
+// relinkAttestation points the attestation file at parentHash, recomputing its
+// hash and rewriting the file when the parent changes
+func (cm *ChainManager) relinkAttestation(entry ChainEntry, parentHash string, signer *Signer) (ChainEntry, error) {
+	attestation, signed, err := cm.LoadAttestation(entry.FilePath)
+	if err != nil {
+		return ChainEntry{}, err
+	}
+	if attestation.calculateHash() != attestation.Hash {
+		return ChainEntry{}, fmt.Errorf("attestation content does not match its hash")
+	}
+	if err := cm.checkSignature(signed); err != nil {
+		return ChainEntry{}, err
+	}
+	
+	if attestation.ParentHash != parentHash {
+		attestation.ParentHash = parentHash
+		attestation.Hash = attestation.calculateHash()
+		
+		var data []byte
+		if signed != nil {
+			if signer == nil {
+				return ChainEntry{}, fmt.Errorf("relinking a signed attestation requires a signing key")
+			}
+			resigned, err := signer.SignAttestation(attestation)
+			if err != nil {
+				return ChainEntry{}, fmt.Errorf("failed to re-sign attestation: %w", err)
+			}
+			data, err = json.MarshalIndent(resigned, "", "  ")
+			if err != nil {
+				return ChainEntry{}, fmt.Errorf("failed to serialize signed attestation: %w", err)
+			}
+		} else {
+			data, err = attestation.ToJSON()
+			if err != nil {
+				return ChainEntry{}, fmt.Errorf("failed to serialize attestation: %w", err)
+			}
+		}
+		
+		// Files encrypted at rest stay encrypted, to the key that opened them
+		if cm.isEncrypted(entry.FilePath) {
+			encryptor, err := NewEncryptor(cm.DecryptionKey.PublicKey())
+			if err != nil {
+				return ChainEntry{}, err
+			}
+			if data, err = encryptor.Encrypt(data); err != nil {
+				return ChainEntry{}, err
+			}
+		}
+		
+		if err := writeFileAtomic(filepath.Join(cm.evidenceDir, entry.FilePath), data, 0644); err != nil {
+			return ChainEntry{}, fmt.Errorf("failed to rewrite attestation file: %w", err)
+		}
+	}
 	
-	return chain, cm.SaveChain(chain)
+	entry.Hash = attestation.Hash
+	entry.ParentHash = parentHash
+	return entry, nil
 }
 
 // findAttestationFiles finds all attestation JSON files in the evidence directory
@@ -250,6 +532,14 @@ func (cm *ChainManager) findAttestationFiles() ([]string, error) {
 			return err
 		}
 		
+		// A nested directory with its own chain.json is a separate chain,
+		// e.g. .mondrian/evidence/prod, and must not be merged into this one
+		if d.IsDir() && path != cm.evidenceDir {
+			if _, err := os.Stat(filepath.Join(path, "chain.json")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		
 		if !d.IsDir() && strings.HasPrefix(d.Name(), "attestation-") && strings.HasSuffix(d.Name(), ".json") {
 			relPath, err := filepath.Rel(cm.evidenceDir, path)
 			if err != nil {
@@ -266,41 +556,40 @@ func (cm *ChainManager) findAttestationFiles() ([]string, error) {
 
 // loadAttestationEntry loads basic information from an attestation file
 func (cm *ChainManager) loadAttestationEntry(filePath string) (ChainEntry, error) {
-	fullPath := filepath.Join(cm.evidenceDir, filePath)
-	data, err := os.ReadFile(fullPath)
+	attestation, _, err := cm.LoadAttestation(filePath)
 	if err != nil {
-		return ChainEntry{}, fmt.Errorf("failed to read file: %w", err)
-	}
-	
-	// Try to parse as SignedAttestation first
-	var signed SignedAttestation
-	if err := json.Unmarshal(data, &signed); err == nil {
-		// Extract attestation from DSSE envelope
-		var attestation Attestation
-		if err := json.Unmarshal([]byte(signed.Envelope.Payload), &attestation); err == nil {
-			return ChainEntry{
-				Hash:      attestation.Hash,
-				Timestamp: attestation.Timestamp,
-				RunID:     attestation.RunID,
-				Status:    attestation.Predicate.Summary.OverallStatus,
-				FilePath:  filePath,
-			}, nil
-		}
+		return ChainEntry{}, err
+	}
+	if attestation.Hash == "" {
+		return ChainEntry{}, fmt.Errorf("failed to parse attestation file: no attestation hash")
 	}
 	
-	// Try to parse as plain Attestation
-	var attestation Attestation
-	if err := json.Unmarshal(data, &attestation); err == nil {
-		return ChainEntry{
-			Hash:      attestation.Hash,
-			Timestamp: attestation.Timestamp,
-			RunID:     attestation.RunID,
-			Status:    attestation.Predicate.Summary.OverallStatus,
-			FilePath:  filePath,
-		}, nil
+	return ChainEntry{
+		Hash:       attestation.Hash,
+		ParentHash: attestation.ParentHash,
+		Timestamp:  attestation.Timestamp,
+		RunID:      attestation.RunID,
+		Status:     attestation.Predicate.Summary.OverallStatus,
+		FilePath:   filePath,
+	}, nil
+}
+
+// BackupChain copies chain.json to chain.json.bak and returns the backup
+// path, or "" when there is no chain file to back up
+func (cm *ChainManager) BackupChain() (string, error) {
+	data, err := os.ReadFile(cm.chainPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read chain file: %w", err)
 	}
 	
-	return ChainEntry{}, fmt.Errorf("failed to parse attestation file")
+	backupPath := cm.chainPath + ".bak"
+	if err := writeFileAtomic(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write chain backup: %w", err)
+	}
+	return backupPath, nil
 }
 
 // generateChainID creates a unique chain identifier
@@ -311,6 +600,21 @@ func generateChainID() string {
 	return hex.EncodeToString(hash[:8])
 }
 
+// CheckFreshness returns an error when the head attestation is older than maxAge
+func (chain *EvidenceChain) CheckFreshness(maxAge time.Duration, now time.Time) error {
+	if len(chain.Attestations) == 0 {
+		return fmt.Errorf("no attestations in chain")
+	}
+	
+	head := chain.Attestations[len(chain.Attestations)-1]
+	age := now.Sub(head.Timestamp)
+	if age > maxAge {
+		return fmt.Errorf("head attestation is %s old (max age %s)", age.Round(time.Second), maxAge)
+	}
+	
+	return nil
+}
+
 // GetChainSummary returns a human-readable summary of the chain
 func (chain *EvidenceChain) GetChainSummary() string {
 	if chain.Length == 0 {
@@ -0,0 +1,243 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+// newTestSigner returns a signer with a fresh in-memory key
+func newTestSigner(t testing.TB) *Signer {
+	t.Helper()
+	signer, err := NewSigner()
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return signer
+}
+
+// attestTo signs an attestation extending the chain and adds it the way
+// 'mondrian attest' does, returning the attestation
+func attestTo(t testing.TB, cm *ChainManager, chain *EvidenceChain, signer *Signer, status string) *Attestation {
+	t.Helper()
+	results := []policy.CheckResult{{RuleName: "s3-public-read", Status: status, Severity: "high", Message: "test"}}
+	attestation := NewAttestation(results, AttestationMetadata{
+		Repository: "github.com/acme/infra",
+		ParentHash: chain.Head,
+	})
+	signed, err := signer.SignAttestation(attestation)
+	if err != nil {
+		t.Fatalf("SignAttestation: %v", err)
+	}
+	savedPath, err := SaveSignedAttestation(signed, cm.evidenceDir, nil)
+	if err != nil {
+		t.Fatalf("SaveSignedAttestation: %v", err)
+	}
+	if err := cm.AddAttestation(chain, attestation, filepath.Base(savedPath)); err != nil {
+		t.Fatalf("AddAttestation: %v", err)
+	}
+	return attestation
+}
+
+// newTestChain writes a chain of n attestations signed by signer to a
+// temporary evidence directory
+func newTestChain(t testing.TB, signer *Signer, n int) (*ChainManager, *EvidenceChain) {
+	t.Helper()
+	cm := NewChainManager(t.TempDir())
+	chain, err := cm.LoadOrCreateChain()
+	if err != nil {
+		t.Fatalf("LoadOrCreateChain: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		attestTo(t, cm, chain, signer, "pass")
+	}
+	return cm, chain
+}
+
+func TestAddAttestationLinksChain(t *testing.T) {
+	cm, chain := newTestChain(t, newTestSigner(t), 3)
+
+	if chain.Length != 3 || len(chain.Attestations) != 3 {
+		t.Fatalf("chain length = %d with %d entries, want 3", chain.Length, len(chain.Attestations))
+	}
+	if chain.Genesis != chain.Attestations[0].Hash || chain.Head != chain.Attestations[2].Hash {
+		t.Errorf("genesis and head do not match the first and last entries")
+	}
+	if err := cm.VerifyChain(chain); err != nil {
+		t.Errorf("VerifyChain: %v", err)
+	}
+
+	reloaded, err := cm.LoadChain()
+	if err != nil {
+		t.Fatalf("LoadChain: %v", err)
+	}
+	if reloaded.Head != chain.Head || reloaded.Length != chain.Length {
+		t.Errorf("reloaded chain head %s length %d, want %s length %d", reloaded.Head, reloaded.Length, chain.Head, chain.Length)
+	}
+}
+
+func TestLoadEntriesWindows(t *testing.T) {
+	cm, chain := newTestChain(t, newTestSigner(t), 5)
+
+	tests := []struct {
+		name          string
+		offset, limit int
+		want          []int // positions of the entries in the window
+	}{
+		{"first page", 0, 2, []int{0, 1}},
+		{"middle page", 2, 2, []int{2, 3}},
+		{"last partial page", 4, 2, []int{4}},
+		{"limit past the end", 1, 100, []int{1, 2, 3, 4}},
+		{"offset at the end", 5, 2, nil},
+		{"offset past the end", 50, 2, nil},
+		{"zero limit reads to the end", 3, 0, []int{3, 4}},
+		{"negative limit reads to the end", 0, -1, []int{0, 1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := cm.LoadEntries(tt.offset, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if window.Length != 5 || window.Head != chain.Head || window.Genesis != chain.Genesis {
+				t.Errorf("header = length %d head %s, want the whole chain's", window.Length, window.Head)
+			}
+			if window.Attestations == nil || len(window.Attestations) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d", len(window.Attestations), len(tt.want))
+			}
+			for i, position := range tt.want {
+				if window.Attestations[i] != chain.Attestations[position] {
+					t.Errorf("entry %d is not chain entry %d", i, position)
+				}
+			}
+		})
+	}
+
+	if _, err := cm.LoadEntries(-1, 2); err == nil {
+		t.Errorf("negative offset loaded")
+	}
+}
+
+func TestLoadEntriesWithoutChain(t *testing.T) {
+	window, err := NewChainManager(t.TempDir()).LoadEntries(0, 10)
+	if err != nil || window.Length != 0 || len(window.Attestations) != 0 {
+		t.Errorf("missing chain = %+v, %v, want an empty one", window, err)
+	}
+}
+
+func TestCheckFreshness(t *testing.T) {
+	_, chain := newTestChain(t, newTestSigner(t), 2)
+	head := chain.Attestations[1].Timestamp
+
+	if err := chain.CheckFreshness(24*time.Hour, head.Add(time.Hour)); err != nil {
+		t.Errorf("head attested an hour ago failed a 24h max age: %v", err)
+	}
+	if err := chain.CheckFreshness(24*time.Hour, head.Add(24*time.Hour)); err != nil {
+		t.Errorf("head exactly at the max age failed: %v", err)
+	}
+
+	// Only the head counts, however fresh earlier entries are
+	chain.Attestations[0].Timestamp = head.Add(48 * time.Hour)
+	err := chain.CheckFreshness(24*time.Hour, head.Add(25*time.Hour))
+	if err == nil || !strings.Contains(err.Error(), "head attestation is 25h0m0s old (max age 24h0m0s)") {
+		t.Errorf("err = %v, want the expired head's age", err)
+	}
+
+	if err := (&EvidenceChain{}).CheckFreshness(time.Hour, head); err == nil {
+		t.Errorf("empty chain passed the freshness check")
+	}
+}
+
+// verifyStrictly checks the chain's links and recomputes every entry's hash
+// and signature from its file
+func verifyStrictly(t *testing.T, cm *ChainManager, chain *EvidenceChain) {
+	t.Helper()
+	if err := cm.VerifyChain(chain); err != nil {
+		t.Errorf("VerifyChain: %v", err)
+	}
+	if anomalies := cm.VerifyEntries(chain, 0); len(anomalies) != 0 {
+		t.Errorf("VerifyEntries: %v", anomalies)
+	}
+}
+
+func TestScanAndRepairCorruptedChainFile(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 3)
+	cm.PublicKey = signer.GetPublicKey()
+
+	if err := os.WriteFile(cm.chainPath, []byte(`{"attestations": [{"hash": "`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.LoadChain(); err == nil {
+		t.Fatal("corrupted chain.json loaded")
+	}
+
+	repaired, err := cm.ScanAndRepairChain(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repaired.Length != 3 || repaired.Genesis != chain.Genesis || repaired.Head != chain.Head {
+		t.Errorf("repaired chain %d long from %s to %s, want the original 3 entries", repaired.Length, shortHash(repaired.Genesis), shortHash(repaired.Head))
+	}
+	loaded, err := cm.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyStrictly(t, cm, loaded)
+}
+
+func TestScanAndRepairRecomputesRelinkedHashes(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 3)
+	cm.PublicKey = signer.GetPublicKey()
+	first, removed, last := chain.Attestations[0], chain.Attestations[1], chain.Attestations[2]
+
+	// Losing the middle attestation forces the last one onto a new parent
+	if err := os.Remove(filepath.Join(cm.evidenceDir, removed.FilePath)); err != nil {
+		t.Fatal(err)
+	}
+	repaired, err := cm.ScanAndRepairChain(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repaired.Length != 2 {
+		t.Fatalf("repaired chain has %d entries, want 2", repaired.Length)
+	}
+
+	relinked := repaired.Attestations[1]
+	if relinked.ParentHash != first.Hash || relinked.Hash == last.Hash {
+		t.Errorf("relinked entry has parent %s and hash %s, want parent %s and a new hash", shortHash(relinked.ParentHash), shortHash(relinked.Hash), shortHash(first.Hash))
+	}
+	attestation, _, err := cm.LoadAttestation(relinked.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attestation.ParentHash != first.Hash || attestation.Hash != relinked.Hash || attestation.calculateHash() != relinked.Hash {
+		t.Errorf("rewritten attestation does not carry the recomputed link and hash")
+	}
+	verifyStrictly(t, cm, repaired)
+
+	// A repaired chain extends like any other
+	attestTo(t, cm, repaired, signer, "pass")
+	verifyStrictly(t, cm, repaired)
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"sort"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+// Rule changes reported by DiffAttestations
+const (
+	RuleNewlyFailing    = "newly-failing"
+	RuleNewlyPassing    = "newly-passing"
+	RuleSeverityChanged = "severity-changed"
+	RuleStatusChanged   = "status-changed"
+	RuleFindingsChanged = "findings-changed"
+	RuleUnchanged       = "unchanged"
+	RuleOnlyBefore      = "only-before" // the later run did not use the rule
+	RuleOnlyAfter       = "only-after"  // the earlier run did not use the rule
+)
+
+// AttestationDiff compares the policy results of two attestations
+type AttestationDiff struct {
+	Before          string     `json:"before"`
+	After           string     `json:"after"`
+	Rules           []RuleDiff `json:"rules"`
+	FilesOnlyBefore []string   `json:"filesOnlyBefore,omitempty"`
+	FilesOnlyAfter  []string   `json:"filesOnlyAfter,omitempty"`
+}
+
+// RuleDiff describes how one rule's outcome changed. Findings in files only
+// one run scanned have no counterpart and are left out of Resolved and New.
+type RuleDiff struct {
+	Rule           string               `json:"rule"`
+	Change         string               `json:"change"`
+	BeforeStatus   string               `json:"beforeStatus,omitempty"`
+	AfterStatus    string               `json:"afterStatus,omitempty"`
+	BeforeSeverity string               `json:"beforeSeverity,omitempty"`
+	AfterSeverity  string               `json:"afterSeverity,omitempty"`
+	Resolved       []policy.CheckResult `json:"resolved,omitempty"`
+	New            []policy.CheckResult `json:"new,omitempty"`
+}
+
+// Changed reports whether the rule's outcome differs between the runs
+func (d RuleDiff) Changed() bool {
+	return d.Change != RuleUnchanged
+}
+
+// DiffAttestations compares before and after rule by rule, matching findings
+// by fingerprint so moved lines don't count as changes
+func DiffAttestations(before, after *Attestation) AttestationDiff {
+	diff := AttestationDiff{
+		Before: before.Hash,
+		After:  after.Hash,
+	}
+	
+	beforeFiles := stringSet(before.Predicate.FilesScanned)
+	afterFiles := stringSet(after.Predicate.FilesScanned)
+	for file := range beforeFiles {
+		if !afterFiles[file] {
+			diff.FilesOnlyBefore = append(diff.FilesOnlyBefore, file)
+		}
+	}
+	for file := range afterFiles {
+		if !beforeFiles[file] {
+			diff.FilesOnlyAfter = append(diff.FilesOnlyAfter, file)
+		}
+	}
+	sort.Strings(diff.FilesOnlyBefore)
+	sort.Strings(diff.FilesOnlyAfter)
+	
+	beforeRules := resultsByRule(before)
+	afterRules := resultsByRule(after)
+	
+	names := make(map[string]bool)
+	for name := range beforeRules {
+		names[name] = true
+	}
+	for name := range afterRules {
+		names[name] = true
+	}
+	
+	for name := range names {
+		beforeResults, inBefore := beforeRules[name]
+		afterResults, inAfter := afterRules[name]
+		
+		rule := RuleDiff{Rule: name}
+		if inBefore {
+			rule.BeforeStatus, rule.BeforeSeverity = ruleOutcome(beforeResults)
+		}
+		if inAfter {
+			rule.AfterStatus, rule.AfterSeverity = ruleOutcome(afterResults)
+		}
+		
+		switch {
+		case !inAfter:
+			rule.Change = RuleOnlyBefore
+		case !inBefore:
+			rule.Change = RuleOnlyAfter
+		default:
+			rule.Resolved = unmatchedFindings(beforeResults, afterResults, afterFiles)
+			rule.New = unmatchedFindings(afterResults, beforeResults, beforeFiles)
+			rule.Change = ruleChange(rule)
+		}
+		diff.Rules = append(diff.Rules, rule)
+	}
+	
+	sort.Slice(diff.Rules, func(i, j int) bool { return diff.Rules[i].Rule < diff.Rules[j].Rule })
+	return diff
+}
+
+func ruleChange(rule RuleDiff) string {
+	switch {
+	case rule.AfterStatus == "fail" && rule.BeforeStatus != "fail":
+		return RuleNewlyFailing
+	case rule.AfterStatus == "pass" && rule.BeforeStatus != "pass":
+		return RuleNewlyPassing
+	case rule.AfterSeverity != rule.BeforeSeverity:
+		return RuleSeverityChanged
+	case rule.AfterStatus != rule.BeforeStatus:
+		return RuleStatusChanged
+	case len(rule.Resolved) > 0 || len(rule.New) > 0:
+		return RuleFindingsChanged
+	}
+	return RuleUnchanged
+}
+
+// resultsByRule groups an attestation's results by rule. Rules the run used
+// without recording any result still count as present.
+func resultsByRule(attestation *Attestation) map[string][]policy.CheckResult {
+	rules := make(map[string][]policy.CheckResult)
+	for _, name := range attestation.Predicate.Scanner.RulesUsed {
+		rules[name] = nil
+	}
+	for _, result := range attestation.Predicate.Results {
+		rules[result.RuleName] = append(rules[result.RuleName], result)
+	}
+	return rules
+}
+
+// ruleOutcome returns the worst status of a rule's results and the highest
+// severity among its findings
+func ruleOutcome(results []policy.CheckResult) (string, string) {
+	status, severity := "pass", ""
+	for _, result := range results {
+		switch {
+		case result.Status == "fail":
+			status = "fail"
+		case result.Status == "warn" && status == "pass":
+			status = "warn"
+		}
+		if result.Status != "pass" && severityIndex(result.Severity) > severityIndex(severity) {
+			severity = result.Severity
+		}
+	}
+	return status, severity
+}
+
+func severityIndex(severity string) int {
+	for i, s := range policy.Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// unmatchedFindings returns the findings of results without a counterpart in
+// others, skipping files the other run did not scan
+func unmatchedFindings(results, others []policy.CheckResult, otherFiles map[string]bool) []policy.CheckResult {
+	known := make(map[string]bool)
+	for _, result := range others {
+		if result.Status != "pass" {
+			known[resultFingerprint(result)] = true
+		}
+	}
+	
+	var unmatched []policy.CheckResult
+	for _, result := range results {
+		if result.Status == "pass" || known[resultFingerprint(result)] {
+			continue
+		}
+		if result.File != "" && !otherFiles[result.File] {
+			continue
+		}
+		unmatched = append(unmatched, result)
+	}
+	return unmatched
+}
+
+func resultFingerprint(result policy.CheckResult) string {
+	if result.Fingerprint != "" {
+		return result.Fingerprint
+	}
+	return result.ComputeFingerprint()
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
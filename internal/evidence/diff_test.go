@@ -0,0 +1,87 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+func TestDiffAttestations(t *testing.T) {
+	s3Finding := policy.CheckResult{RuleName: "s3-no-public-buckets", Status: "fail", Severity: policy.SeverityCritical, File: "main.tf", Line: 3, Message: "aws_s3_bucket.assets sets public ACL \"public-read\""}
+	sgFinding := policy.CheckResult{RuleName: "sg-no-open-ingress", Status: "fail", Severity: policy.SeverityHigh, File: "network.tf", Line: 5, Message: "Security group allows ingress from 0.0.0.0/0"}
+	// Only the earlier run scanned legacy.tf, so its finding has no counterpart
+	legacyFinding := policy.CheckResult{RuleName: "sg-no-open-ingress", Status: "fail", Severity: policy.SeverityHigh, File: "legacy.tf", Line: 2, Message: "Security group allows ingress from 0.0.0.0/0"}
+
+	before := NewAttestation([]policy.CheckResult{
+		s3Finding,
+		{RuleName: "sg-no-open-ingress", Status: "pass", Message: "No open security groups detected"},
+		legacyFinding,
+	}, AttestationMetadata{FilesScanned: []string{"main.tf", "network.tf", "legacy.tf"}})
+	after := NewAttestation([]policy.CheckResult{
+		{RuleName: "s3-no-public-buckets", Status: "pass", Message: "No public S3 buckets detected"},
+		sgFinding,
+		{RuleName: "iam-no-wildcard-actions", Status: "pass", Message: "ok"},
+	}, AttestationMetadata{FilesScanned: []string{"main.tf", "network.tf"}})
+
+	diff := DiffAttestations(before, after)
+	if diff.Before != before.Hash || diff.After != after.Hash {
+		t.Errorf("diff compares %s and %s, want %s and %s", diff.Before, diff.After, before.Hash, after.Hash)
+	}
+	if len(diff.FilesOnlyBefore) != 1 || diff.FilesOnlyBefore[0] != "legacy.tf" || len(diff.FilesOnlyAfter) != 0 {
+		t.Errorf("files only before %v, only after %v; want [legacy.tf] and none", diff.FilesOnlyBefore, diff.FilesOnlyAfter)
+	}
+
+	rules := make(map[string]RuleDiff)
+	for _, rule := range diff.Rules {
+		rules[rule.Rule] = rule
+	}
+	if len(rules) != 3 {
+		t.Fatalf("got %d rules, want 3: %+v", len(rules), diff.Rules)
+	}
+
+	s3 := rules["s3-no-public-buckets"]
+	if s3.Change != RuleNewlyPassing || len(s3.Resolved) != 1 || s3.Resolved[0].File != "main.tf" || len(s3.New) != 0 {
+		t.Errorf("s3 rule = %+v, want newly passing with its finding resolved", s3)
+	}
+
+	// The legacy.tf finding isn't resolved, the later run just didn't look
+	sg := rules["sg-no-open-ingress"]
+	if sg.Change != RuleFindingsChanged || len(sg.New) != 1 || sg.New[0].File != "network.tf" || len(sg.Resolved) != 0 {
+		t.Errorf("sg rule = %+v, want the network.tf finding new and nothing resolved", sg)
+	}
+
+	if iam := rules["iam-no-wildcard-actions"]; iam.Change != RuleOnlyAfter || iam.BeforeStatus != "" {
+		t.Errorf("iam rule = %+v, want only-after", iam)
+	}
+}
+
+func TestDiffAttestationsSeverityChange(t *testing.T) {
+	finding := policy.CheckResult{RuleName: "s3-encryption", Status: "fail", Severity: policy.SeverityMedium, File: "main.tf", Line: 1, Message: "unencrypted"}
+	before := NewAttestation([]policy.CheckResult{finding}, AttestationMetadata{FilesScanned: []string{"main.tf"}})
+	finding.Severity = policy.SeverityHigh
+	after := NewAttestation([]policy.CheckResult{finding}, AttestationMetadata{FilesScanned: []string{"main.tf"}})
+
+	diff := DiffAttestations(before, after)
+	if len(diff.Rules) != 1 || diff.Rules[0].Change != RuleSeverityChanged || diff.Rules[0].AfterSeverity != policy.SeverityHigh {
+		t.Errorf("rules = %+v, want one whose severity changed to high", diff.Rules)
+	}
+	if !diff.Rules[0].Changed() || (RuleDiff{Change: RuleUnchanged}).Changed() {
+		t.Errorf("Changed() disagrees with Change")
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// EncryptionScheme identifies how an encrypted attestation file is sealed: a
+// NaCl anonymous sealed box (X25519, XSalsa20-Poly1305) to the recipient key
+const EncryptionScheme = "nacl-box-seal-x25519"
+
+// EncryptedAttestation is written in place of a signed attestation when
+// evidence is encrypted at rest. Hashes, signatures and chain linkage all
+// cover the plaintext, so encryption never changes what a chain proves.
+type EncryptedAttestation struct {
+	Encryption string `json:"encryption"`
+	Recipient  string `json:"recipient"`  // Key ID of the recipient public key
+	Ciphertext []byte `json:"ciphertext"` // Sealed attestation file, base64 in JSON
+}
+
+// Encryptor seals attestation files to a recipient X25519 public key, so only
+// the holder of the matching private key can read them
+type Encryptor struct {
+	publicKey [32]byte
+	keyID     string
+}
+
+// NewEncryptor creates an encryptor for an X25519 public key
+func NewEncryptor(publicKey *ecdh.PublicKey) (*Encryptor, error) {
+	if publicKey.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("encryption key must be an X25519 key")
+	}
+	e := &Encryptor{keyID: encryptionKeyID(publicKey)}
+	copy(e.publicKey[:], publicKey.Bytes())
+	return e, nil
+}
+
+// LoadEncryptor reads a PEM X25519 public key, such as the encryption.pub
+// written by 'mondrian init --encryption' or 'openssl pkey -pubout'
+func LoadEncryptor(path string) (*Encryptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse encryption key: %w", err)
+	}
+	publicKey, ok := key.(*ecdh.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("encryption key must be an X25519 key")
+	}
+	return NewEncryptor(publicKey)
+}
+
+// GetKeyID returns the identifier of the recipient key
+func (e *Encryptor) GetKeyID() string {
+	return e.keyID
+}
+
+// Encrypt seals an attestation file
+func (e *Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	ciphertext, err := box.SealAnonymous(nil, plaintext, &e.publicKey, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt attestation: %w", err)
+	}
+	return json.MarshalIndent(EncryptedAttestation{
+		Encryption: EncryptionScheme,
+		Recipient:  e.keyID,
+		Ciphertext: ciphertext,
+	}, "", "  ")
+}
+
+// GenerateEncryptionKey creates a new X25519 key pair for encrypting evidence
+func GenerateEncryptionKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// SaveEncryptionKey writes the private key to path and its public key to
+// publicKeyPath, both PEM-encoded
+func SaveEncryptionKey(key *ecdh.PrivateKey, path, publicKeyPath string) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode encryption key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(key.PublicKey())
+	if err != nil {
+		return fmt.Errorf("failed to encode encryption public key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return fmt.Errorf("failed to write encryption key: %w", err)
+	}
+	if err := os.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644); err != nil {
+		return fmt.Errorf("failed to write encryption public key: %w", err)
+	}
+	return nil
+}
+
+// LoadDecryptionKey reads a PEM X25519 private key that decrypts attestations
+func LoadDecryptionKey(path string) (*ecdh.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decryption key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse decryption key: %w", err)
+	}
+	privateKey, ok := key.(*ecdh.PrivateKey)
+	if !ok || privateKey.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("decryption key must be an X25519 key")
+	}
+	return privateKey, nil
+}
+
+// parseEncryptedAttestation returns the encrypted envelope in data, or nil
+// when data is a plaintext attestation file
+func parseEncryptedAttestation(data []byte) *EncryptedAttestation {
+	var encrypted EncryptedAttestation
+	if err := json.Unmarshal(data, &encrypted); err != nil || encrypted.Encryption == "" {
+		return nil
+	}
+	return &encrypted
+}
+
+// decryptAttestation opens an encrypted attestation file with key
+func decryptAttestation(encrypted *EncryptedAttestation, key *ecdh.PrivateKey) ([]byte, error) {
+	if encrypted.Encryption != EncryptionScheme {
+		return nil, fmt.Errorf("unsupported attestation encryption %q", encrypted.Encryption)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("attestation is encrypted to key %s and no decryption key was given", encrypted.Recipient)
+	}
+	if id := encryptionKeyID(key.PublicKey()); id != encrypted.Recipient {
+		return nil, fmt.Errorf("attestation is encrypted to key %s, not decryption key %s", encrypted.Recipient, id)
+	}
+
+	var publicKey, privateKey [32]byte
+	copy(publicKey[:], key.PublicKey().Bytes())
+	copy(privateKey[:], key.Bytes())
+	plaintext, ok := box.OpenAnonymous(nil, encrypted.Ciphertext, &publicKey, &privateKey)
+	if !ok {
+		return nil, fmt.Errorf("failed to decrypt attestation: ciphertext has been modified")
+	}
+	return plaintext, nil
+}
+
+// encryptionKeyID derives the key identifier from the first 8 bytes of the
+// public key hash, like signing key IDs
+func encryptionKeyID(publicKey *ecdh.PublicKey) string {
+	hash := sha256.Sum256(publicKey.Bytes())
+	return hex.EncodeToString(hash[:8])
+}
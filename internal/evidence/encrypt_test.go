@@ -0,0 +1,217 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+func newTestEncryptionKey(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	key := newTestEncryptionKey(t)
+	encryptor, err := NewEncryptor(key.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte(`{"line_content": "password = \"hunter2\""}`)
+
+	data, err := encryptor.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) {
+		t.Fatal("encrypted file contains the plaintext")
+	}
+	encrypted := parseEncryptedAttestation(data)
+	if encrypted == nil || encrypted.Encryption != EncryptionScheme || encrypted.Recipient != encryptor.GetKeyID() {
+		t.Fatalf("encrypted file = %s, want a %s envelope to %s", data, EncryptionScheme, encryptor.GetKeyID())
+	}
+
+	decrypted, err := decryptAttestation(encrypted, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("decrypted %q, want %q", decrypted, plaintext)
+	}
+
+	// Sealing is randomized, so equal plaintexts don't show as equal files
+	if again, _ := encryptor.Encrypt(plaintext); bytes.Equal(again, data) {
+		t.Error("encrypting twice produced the same file")
+	}
+	if parseEncryptedAttestation(plaintext) != nil {
+		t.Error("a plaintext attestation was taken for an encrypted one")
+	}
+}
+
+func TestDecryptAttestationErrors(t *testing.T) {
+	key := newTestEncryptionKey(t)
+	encryptor, err := NewEncryptor(key.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := encryptor.Encrypt([]byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		modify func(*EncryptedAttestation)
+		key    *ecdh.PrivateKey
+		want   string
+	}{
+		"no key":       {nil, nil, "no decryption key was given"},
+		"other key":    {nil, newTestEncryptionKey(t), "not decryption key"},
+		"tampered":     {func(e *EncryptedAttestation) { e.Ciphertext[len(e.Ciphertext)-1] ^= 1 }, key, "ciphertext has been modified"},
+		"other scheme": {func(e *EncryptedAttestation) { e.Encryption = "age" }, key, `unsupported attestation encryption "age"`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			encrypted := parseEncryptedAttestation(data)
+			if tt.modify != nil {
+				tt.modify(encrypted)
+			}
+			if _, err := decryptAttestation(encrypted, tt.key); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoadEncryptionKeys(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "keys", "encryption.pem")
+	publicKeyPath := filepath.Join(dir, "keys", "encryption.pub")
+	key := newTestEncryptionKey(t)
+	if err := SaveEncryptionKey(key, keyPath, publicKeyPath); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("private key mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	loaded, err := LoadDecryptionKey(keyPath)
+	if err != nil || !loaded.Equal(key) {
+		t.Errorf("LoadDecryptionKey = %v, want the saved key", err)
+	}
+	encryptor, err := LoadEncryptor(publicKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encryptor.GetKeyID() != encryptionKeyID(key.PublicKey()) {
+		t.Errorf("key ID = %s, want %s", encryptor.GetKeyID(), encryptionKeyID(key.PublicKey()))
+	}
+
+	// A P-256 key, such as a signing key, can't be used for encryption
+	p256, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(p256.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongPath := filepath.Join(dir, "p256.pub")
+	if err := os.WriteFile(wrongPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEncryptor(wrongPath); err == nil || !strings.Contains(err.Error(), "X25519") {
+		t.Errorf("err = %v, want a P-256 key rejected", err)
+	}
+	if _, err := LoadDecryptionKey(publicKeyPath); err == nil {
+		t.Error("a public key was loaded as the decryption key")
+	}
+}
+
+func TestEncryptedAttestationsInChain(t *testing.T) {
+	key := newTestEncryptionKey(t)
+	encryptor, err := NewEncryptor(key.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 1)
+
+	results := []policy.CheckResult{{
+		RuleName: "tf-no-hardcoded-secrets", Status: "fail", Severity: "critical", Message: "hardcoded secret",
+		Metadata: map[string]interface{}{"line_content": `password = "hunter2"`},
+	}}
+	attestation := NewAttestation(results, AttestationMetadata{ParentHash: chain.Head})
+	signed, err := signer.SignAttestation(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedPath, err := SaveSignedAttestation(signed, cm.evidenceDir, encryptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.AddAttestation(chain, attestation, filepath.Base(savedPath)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(savedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) {
+		t.Error("saved attestation contains line_content in plaintext")
+	}
+
+	// Hashes and the chain cover the plaintext
+	cm.DecryptionKey = key
+	loaded, _, err := cm.LoadAttestation(filepath.Base(savedPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Hash != attestation.Hash || loaded.Hash != chain.Head {
+		t.Errorf("decrypted attestation hash = %s, want %s at the chain head", loaded.Hash, attestation.Hash)
+	}
+	if anomalies := cm.VerifyEntries(chain, 1); len(anomalies) != 0 {
+		t.Errorf("VerifyEntries with the decryption key = %+v, want none", anomalies)
+	}
+
+	cm.DecryptionKey = nil
+	anomalies := cm.VerifyEntries(chain, 1)
+	if len(anomalies) != 1 || anomalies[0].Position != 1 || !strings.Contains(anomalies[0].Problem, "no decryption key") {
+		t.Errorf("VerifyEntries without the decryption key = %+v, want the encrypted attestation unreadable", anomalies)
+	}
+
+	// The envelope itself is plain JSON naming the recipient
+	var envelope EncryptedAttestation
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Recipient != encryptor.GetKeyID() {
+		t.Errorf("envelope = %+v, %v, want it addressed to %s", envelope, err, encryptor.GetKeyID())
+	}
+}
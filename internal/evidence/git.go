@@ -0,0 +1,236 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DetectGitContext describes the git checkout containing dir: the origin
+// repository as host/owner/name, the current branch and the full commit hash.
+// It reads .git directly, so it needs no git binary. Anything it cannot find,
+// such as the branch of a detached HEAD in CI or the remote of a checkout
+// without one, falls back to the GITHUB_REPOSITORY, GITHUB_REF and
+// GITHUB_SHA environment variables. Values that can't be determined are empty.
+func DetectGitContext(dir string) (repo, branch, commit string) {
+	if gitDir, commonDir := findGitDir(dir); gitDir != "" {
+		repo = normalizeRemote(readRemoteURL(filepath.Join(commonDir, "config"), "origin"))
+		branch, commit = readHead(gitDir, commonDir)
+	}
+
+	if repo == "" {
+		if name := os.Getenv("GITHUB_REPOSITORY"); name != "" {
+			host := "github.com"
+			if server, err := url.Parse(os.Getenv("GITHUB_SERVER_URL")); err == nil && server.Host != "" {
+				host = server.Host
+			}
+			repo = host + "/" + name
+		}
+	}
+	if branch == "" {
+		// Pull request checkouts are detached; GITHUB_HEAD_REF names the PR branch
+		if head := os.Getenv("GITHUB_HEAD_REF"); head != "" {
+			branch = head
+		} else if ref := os.Getenv("GITHUB_REF"); strings.HasPrefix(ref, "refs/heads/") {
+			branch = strings.TrimPrefix(ref, "refs/heads/")
+		}
+	}
+	if commit == "" {
+		commit = os.Getenv("GITHUB_SHA")
+	}
+	return repo, branch, commit
+}
+
+// findGitDir walks up from dir to the enclosing checkout and returns its git
+// directory and the common directory holding refs and config, which differ
+// for linked worktrees
+func findGitDir(dir string) (gitDir, commonDir string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ""
+	}
+
+	for {
+		candidate := filepath.Join(dir, ".git")
+		if info, err := os.Stat(candidate); err == nil {
+			if info.IsDir() {
+				gitDir = candidate
+			} else if gitDir = readGitFile(candidate); gitDir == "" {
+				return "", ""
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+
+	commonDir = gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		commonDir = filepath.Clean(common)
+	}
+	return gitDir, commonDir
+}
+
+// readGitFile follows the "gitdir: <path>" file that worktrees and
+// submodules have in place of a .git directory
+func readGitFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return filepath.Clean(target)
+}
+
+// readHead returns the checked out branch, empty when HEAD is detached, and
+// the commit HEAD points at, empty on a branch with no commits yet
+func readHead(gitDir, commonDir string) (branch, commit string) {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	head := strings.TrimSpace(string(data))
+
+	ref, symbolic := strings.CutPrefix(head, "ref:")
+	if !symbolic {
+		if isCommitHash(head) {
+			return "", head
+		}
+		return "", ""
+	}
+
+	ref = strings.TrimSpace(ref)
+	branch = strings.TrimPrefix(ref, "refs/heads/")
+	return branch, resolveRef(gitDir, commonDir, ref)
+}
+
+// resolveRef looks a ref up as a loose file, then in packed-refs. Shallow
+// clones resolve the same way; only their history is truncated.
+func resolveRef(gitDir, commonDir, ref string) string {
+	for _, dir := range []string{gitDir, commonDir} {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+			if hash := strings.TrimSpace(string(data)); isCommitHash(hash) {
+				return hash
+			}
+		}
+	}
+
+	file, err := os.Open(filepath.Join(commonDir, "packed-refs"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == ref && isCommitHash(hash) {
+			return hash
+		}
+	}
+	return ""
+}
+
+// readRemoteURL returns the url of the named remote from a git config file
+func readRemoteURL(configPath, remote string) string {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	section := `[remote "` + remote + `"]`
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == section
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "url" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// normalizeRemote turns https, ssh and scp-style remote URLs into
+// host/owner/name, dropping any credentials embedded in the URL
+func normalizeRemote(remote string) string {
+	if remote == "" {
+		return ""
+	}
+
+	var host, path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return ""
+		}
+		host, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remote, ":"); ok && !strings.Contains(at, "/") {
+		// scp-style git@github.com:owner/name.git
+		if i := strings.LastIndex(at, "@"); i >= 0 {
+			at = at[i+1:]
+		}
+		host, path = at, rest
+	} else {
+		return ""
+	}
+
+	// Local path and file:// remotes have no host worth recording
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return ""
+	}
+	return host + "/" + path
+}
+
+func isCommitHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// attestationIndexVersion changes whenever the index layout does; an index
+// with another version is discarded and rebuilt
+const attestationIndexVersion = 2
+
+// AttestationIndex maps attestation hashes to their files and chain metadata
+// so repair does not have to parse and verify every attestation. It is only a
+// cache: entries whose file content changed since they were indexed are
+// ignored, and a missing index is rebuilt from the attestation files.
+type AttestationIndex struct {
+	Version int                   `json:"version"`
+	Entries map[string]IndexEntry `json:"entries"` // keyed by attestation hash
+}
+
+// IndexEntry is the chain entry of an attestation file together with the
+// SHA-256 of the content it was read from. VerifiedWith records the key the
+// file's hash and signature were checked against, see verificationKeyID, and
+// is empty for entries indexed as written without being verified.
+type IndexEntry struct {
+	ChainEntry
+	Digest       string `json:"sha256"`
+	VerifiedWith string `json:"verifiedWith,omitempty"`
+}
+
+func newAttestationIndex() *AttestationIndex {
+	return &AttestationIndex{
+		Version: attestationIndexVersion,
+		Entries: make(map[string]IndexEntry),
+	}
+}
+
+// LoadIndex reads index.json. A missing, unreadable or outdated index yields
+// an empty one, since everything in it can be recomputed.
+func (cm *ChainManager) LoadIndex() (*AttestationIndex, error) {
+	data, err := os.ReadFile(cm.indexPath)
+	if os.IsNotExist(err) {
+		return newAttestationIndex(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attestation index: %w", err)
+	}
+	
+	var index AttestationIndex
+	if err := json.Unmarshal(data, &index); err != nil || index.Version != attestationIndexVersion || index.Entries == nil {
+		return newAttestationIndex(), nil
+	}
+	return &index, nil
+}
+
+// SaveIndex writes index.json
+func (cm *ChainManager) SaveIndex(index *AttestationIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize attestation index: %w", err)
+	}
+	
+	if err := writeFileAtomic(cm.indexPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write attestation index: %w", err)
+	}
+	return nil
+}
+
+// freshEntries returns the indexed entries, keyed by file path, that were
+// verified against the key in use and whose file content is unchanged since
+func (cm *ChainManager) freshEntries(index *AttestationIndex) map[string]ChainEntry {
+	keyID := cm.verificationKeyID()
+	fresh := make(map[string]ChainEntry, len(index.Entries))
+	for _, entry := range index.Entries {
+		if entry.VerifiedWith != keyID {
+			continue
+		}
+		digest, err := cm.fileDigest(entry.FilePath)
+		if err != nil || digest != entry.Digest {
+			continue
+		}
+		fresh[entry.FilePath] = entry.ChainEntry
+	}
+	return fresh
+}
+
+// indexEntry records the entry with the digest of its file, replacing any
+// previous entry for the same file. verified says whether the file was just
+// checked against the key in use.
+func (cm *ChainManager) indexEntry(index *AttestationIndex, entry ChainEntry, verified bool) error {
+	digest, err := cm.fileDigest(entry.FilePath)
+	if err != nil {
+		return err
+	}
+	
+	for hash, existing := range index.Entries {
+		if existing.FilePath == entry.FilePath {
+			delete(index.Entries, hash)
+		}
+	}
+	indexed := IndexEntry{
+		ChainEntry: entry,
+		Digest:     digest,
+	}
+	if verified {
+		indexed.VerifiedWith = cm.verificationKeyID()
+	}
+	index.Entries[entry.Hash] = indexed
+	return nil
+}
+
+// verificationKeyID identifies what attestations are verified against: the
+// ID of PublicKey, the IDs of TrustedKeys, or "embedded" for the key each
+// attestation records
+func (cm *ChainManager) verificationKeyID() string {
+	if cm.PublicKey != nil {
+		return keyIDFor(cm.PublicKey)
+	}
+	if len(cm.TrustedKeys) > 0 {
+		ids := make([]string, len(cm.TrustedKeys))
+		for i, key := range cm.TrustedKeys {
+			ids[i] = keyIDFor(key)
+		}
+		sort.Strings(ids)
+		return strings.Join(ids, ",")
+	}
+	return "embedded"
+}
+
+// fileDigest returns the hex SHA-256 of an attestation file's content
+func (cm *ChainManager) fileDigest(filePath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(cm.evidenceDir, filePath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read attestation file: %w", err)
+	}
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:]), nil
+}
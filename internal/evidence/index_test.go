@@ -0,0 +1,188 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexAgreesWithDiskAfterAppends(t *testing.T) {
+	cm, chain := newTestChain(t, newTestSigner(t), 5)
+
+	index, err := cm.LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Entries) != chain.Length {
+		t.Fatalf("index has %d entries, chain has %d", len(index.Entries), chain.Length)
+	}
+	for _, entry := range chain.Attestations {
+		indexed, ok := index.Entries[entry.Hash]
+		if !ok {
+			t.Errorf("attestation %s is not indexed", shortHash(entry.Hash))
+			continue
+		}
+		if indexed.ChainEntry != entry {
+			t.Errorf("index entry %+v differs from chain entry %+v", indexed.ChainEntry, entry)
+		}
+		digest, err := cm.fileDigest(entry.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if indexed.Digest != digest {
+			t.Errorf("index digest of %s does not match the file", entry.FilePath)
+		}
+		if indexed.VerifiedWith != "" {
+			t.Errorf("appended entry %s is marked verified", entry.FilePath)
+		}
+
+		loaded, err := cm.loadAttestationEntry(entry.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded != entry {
+			t.Errorf("file %s reads as %+v, index has %+v", entry.FilePath, loaded, entry)
+		}
+	}
+}
+
+func TestRepairMarksIndexVerified(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, _ := newTestChain(t, signer, 3)
+	cm.PublicKey = signer.GetPublicKey()
+
+	repaired, err := cm.ScanAndRepairChain(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := cm.LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh := cm.freshEntries(index); len(fresh) != repaired.Length {
+		t.Errorf("%d fresh index entries after repair, want %d", len(fresh), repaired.Length)
+	}
+
+	// Entries verified against one key are not trusted under another
+	cm.PublicKey = newTestSigner(t).GetPublicKey()
+	if fresh := cm.freshEntries(index); len(fresh) != 0 {
+		t.Errorf("%d index entries trusted under a different key", len(fresh))
+	}
+}
+
+func TestRepairVerifiesAppendedEntries(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 2)
+
+	// Appending indexes without verifying, so repair must still check the
+	// attestation signed by another key
+	attestTo(t, cm, chain, newTestSigner(t), "pass")
+	cm.PublicKey = signer.GetPublicKey()
+
+	repaired, err := cm.ScanAndRepairChain(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repaired.Length != 2 {
+		t.Errorf("repaired chain has %d attestations, want 2", repaired.Length)
+	}
+}
+
+func TestFreshEntriesIgnoresSameSizeEdit(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, _ := newTestChain(t, signer, 3)
+	cm.PublicKey = signer.GetPublicKey()
+	if _, err := cm.ScanAndRepairChain(signer); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := cm.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the signature without changing the file's size or mtime
+	edited := chain.Attestations[1]
+	path := filepath.Join(cm.evidenceDir, edited.FilePath)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.Index(data, []byte(`"sig": "`)) + len(`"sig": "`)
+	if data[i] == 'A' {
+		data[i] = 'B'
+	} else {
+		data[i] = 'A'
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := cm.LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cm.freshEntries(index)[edited.FilePath]; ok {
+		t.Fatalf("edited file %s is still fresh in the index", edited.FilePath)
+	}
+
+	repaired, err := cm.ScanAndRepairChain(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range repaired.Attestations {
+		if entry.FilePath == edited.FilePath {
+			t.Errorf("repair kept the attestation with a corrupted signature")
+		}
+	}
+}
+
+func BenchmarkScanAndRepairChain(b *testing.B) {
+	signer := newTestSigner(b)
+	cm, _ := newTestChain(b, signer, 500)
+	cm.PublicKey = signer.GetPublicKey()
+	if _, err := cm.ScanAndRepairChain(signer); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := cm.ScanAndRepairChain(signer); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("unindexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			os.Remove(cm.indexPath)
+			b.StartTimer()
+			if _, err := cm.ScanAndRepairChain(signer); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultFulcioURL is the public Sigstore certificate authority
+const DefaultFulcioURL = "https://fulcio.sigstore.dev"
+
+var keylessClient = &http.Client{Timeout: 30 * time.Second}
+
+// NewSignerFromGitHubOIDC creates a keyless signer: an ephemeral key whose
+// public half is certified by Fulcio for the identity in the GitHub Actions
+// OIDC token. The certificate chain is recorded in the signing metadata.
+// The workflow needs the id-token: write permission. Set MONDRIAN_FULCIO_URL
+// to use a private Fulcio instance.
+func NewSignerFromGitHubOIDC() (*Signer, error) {
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return nil, fmt.Errorf("keyless signing requires GitHub Actions with permissions id-token: write (ACTIONS_ID_TOKEN_REQUEST_URL is not set)")
+	}
+	
+	fulcioURL := os.Getenv("MONDRIAN_FULCIO_URL")
+	if fulcioURL == "" {
+		fulcioURL = DefaultFulcioURL
+	}
+	
+	idToken, err := fetchGitHubIDToken(requestURL, requestToken)
+	if err != nil {
+		return nil, err
+	}
+	
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	
+	certificates, err := requestFulcioCertificate(fulcioURL, idToken, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &Signer{
+		keyID:        keyIDFor(&privateKey.PublicKey),
+		privateKey:   privateKey,
+		publicKey:    &privateKey.PublicKey,
+		certificates: certificates,
+	}, nil
+}
+
+// fetchGitHubIDToken requests an OIDC token with the sigstore audience from the Actions runtime
+func fetchGitHubIDToken(requestURL, requestToken string) (string, error) {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("audience", "sigstore")
+	u.RawQuery = query.Encode()
+	
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create OIDC token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	
+	var response struct {
+		Value string `json:"value"`
+	}
+	if err := doKeylessRequest(req, &response); err != nil {
+		return "", fmt.Errorf("failed to get GitHub OIDC token: %w", err)
+	}
+	if response.Value == "" {
+		return "", fmt.Errorf("failed to get GitHub OIDC token: empty token in response")
+	}
+	return response.Value, nil
+}
+
+// requestFulcioCertificate exchanges the OIDC token for a short-lived code
+// signing certificate for privateKey's public key. Fulcio requires proof of
+// possession of the key: a signature over the token's subject claim.
+func requestFulcioCertificate(fulcioURL, idToken string, privateKey *ecdsa.PrivateKey) ([]string, error) {
+	subject, err := tokenSubject(idToken)
+	if err != nil {
+		return nil, err
+	}
+	subjectHash := sha256.Sum256([]byte(subject))
+	proof, err := ecdsa.SignASN1(rand.Reader, privateKey, subjectHash[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign proof of possession: %w", err)
+	}
+	
+	publicKeyPEM, err := encodePublicKeyPEM(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"credentials": map[string]string{"oidcIdentityToken": idToken},
+		"publicKeyRequest": map[string]interface{}{
+			"publicKey": map[string]string{
+				"algorithm": "ECDSA",
+				"content":   publicKeyPEM,
+			},
+			"proofOfPossession": base64.StdEncoding.EncodeToString(proof),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize certificate request: %w", err)
+	}
+	
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(fulcioURL, "/")+"/api/v2/signingCert", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	
+	type certificateChain struct {
+		Chain struct {
+			Certificates []string `json:"certificates"`
+		} `json:"chain"`
+	}
+	var response struct {
+		EmbeddedSCT *certificateChain `json:"signedCertificateEmbeddedSct"`
+		DetachedSCT *certificateChain `json:"signedCertificateDetachedSct"`
+	}
+	if err := doKeylessRequest(req, &response); err != nil {
+		return nil, fmt.Errorf("failed to get Fulcio certificate: %w", err)
+	}
+	
+	var certificates []string
+	switch {
+	case response.EmbeddedSCT != nil:
+		certificates = response.EmbeddedSCT.Chain.Certificates
+	case response.DetachedSCT != nil:
+		certificates = response.DetachedSCT.Chain.Certificates
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("failed to get Fulcio certificate: no certificate chain in response")
+	}
+	
+	leaf, err := parseCertificatePEM(certificates[0])
+	if err != nil {
+		return nil, err
+	}
+	if leafKey, ok := leaf.PublicKey.(*ecdsa.PublicKey); !ok || !leafKey.Equal(&privateKey.PublicKey) {
+		return nil, fmt.Errorf("fulcio certificate does not certify the signing key")
+	}
+	
+	return certificates, nil
+}
+
+// tokenSubject reads the sub claim of a JWT without verifying it; Fulcio does the verification
+func tokenSubject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed OIDC token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode OIDC token claims: %w", err)
+	}
+	
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("failed to parse OIDC token claims: %w", err)
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("OIDC token has no subject claim")
+	}
+	return claims.Subject, nil
+}
+
+func doKeylessRequest(req *http.Request, response interface{}) error {
+	resp, err := keylessClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if message := strings.TrimSpace(string(data)); message != "" {
+			return fmt.Errorf("server returned %s: %s", resp.Status, message)
+		}
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// parseCertificatePEM decodes a single PEM-encoded X.509 certificate
+func parseCertificatePEM(data string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to decode certificate PEM")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return certificate, nil
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWorkflowSubject = "repo:miqcie/mondrian:ref:refs/heads/main"
+
+// testIDToken returns an unsigned JWT with the given subject; only Fulcio
+// verifies the token, which the mock doesn't
+func testIDToken(subject string) string {
+	encode := func(v string) string { return base64.RawURLEncoding.EncodeToString([]byte(v)) }
+	return encode(`{"alg":"RS256"}`) + "." + encode(`{"sub":"`+subject+`","aud":"sigstore"}`) + "." + encode("signature")
+}
+
+// newMockOIDCServer stands in for the GitHub Actions token endpoint
+func newMockOIDCServer(t *testing.T, token string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer runtime-token" {
+			http.Error(w, "bad request token", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("audience") != "sigstore" {
+			http.Error(w, "wrong audience", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"value": token})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// mockFulcio is a certificate authority speaking Fulcio's v2 signingCert API.
+// When certify is set it issues the certificate for that key instead of the
+// requested one.
+type mockFulcio struct {
+	t       *testing.T
+	caKey   *ecdsa.PrivateKey
+	ca      *x509.Certificate
+	certify *ecdsa.PublicKey
+}
+
+func newMockFulcioServer(t *testing.T) (*mockFulcio, *httptest.Server) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore-intermediate"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fulcio := &mockFulcio{t: t, caKey: caKey, ca: ca}
+	server := httptest.NewServer(fulcio)
+	t.Cleanup(server.Close)
+	return fulcio, server
+}
+
+func (f *mockFulcio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/api/v2/signingCert" {
+		http.NotFound(w, r)
+		return
+	}
+	var request struct {
+		Credentials struct {
+			OIDCIdentityToken string `json:"oidcIdentityToken"`
+		} `json:"credentials"`
+		PublicKeyRequest struct {
+			PublicKey struct {
+				Content string `json:"content"`
+			} `json:"publicKey"`
+			ProofOfPossession string `json:"proofOfPossession"`
+		} `json:"publicKeyRequest"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fulcio checks the key signed the token's subject
+	publicKey, err := ParsePublicKeyPEM(request.PublicKeyRequest.PublicKey.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	subject, err := tokenSubject(request.Credentials.OIDCIdentityToken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	proof, _ := base64.StdEncoding.DecodeString(request.PublicKeyRequest.ProofOfPossession)
+	subjectHash := sha256.Sum256([]byte(subject))
+	if !ecdsa.VerifyASN1(publicKey, subjectHash[:], proof) {
+		http.Error(w, "invalid proof of possession", http.StatusBadRequest)
+		return
+	}
+
+	if f.certify != nil {
+		publicKey = f.certify
+	}
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(10 * time.Minute),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, leaf, f.ca, publicKey, f.caKey)
+	if err != nil {
+		f.t.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encode := func(der []byte) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+	response := map[string]interface{}{
+		"signedCertificateEmbeddedSct": map[string]interface{}{
+			"chain": map[string]interface{}{
+				"certificates": []string{encode(der), encode(f.ca.Raw)},
+			},
+		},
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// setKeylessEnv points keyless signing at the mock servers as a workflow with
+// id-token: write would
+func setKeylessEnv(t *testing.T, oidcURL, fulcioURL string) {
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", oidcURL+"/token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "runtime-token")
+	t.Setenv("MONDRIAN_FULCIO_URL", fulcioURL)
+}
+
+func TestNewSignerFromGitHubOIDC(t *testing.T) {
+	oidc := newMockOIDCServer(t, testIDToken(testWorkflowSubject))
+	fulcio, fulcioServer := newMockFulcioServer(t)
+	setKeylessEnv(t, oidc.URL, fulcioServer.URL)
+
+	signer, err := NewSignerFromGitHubOIDC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.SignAttestation(NewAttestation(nil, AttestationMetadata{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := signed.Metadata.CertificateChain
+	if len(chain) != 2 {
+		t.Fatalf("certificate chain has %d certificates, want leaf and intermediate", len(chain))
+	}
+	leaf, err := parseCertificatePEM(chain[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.CheckSignatureFrom(fulcio.ca); err != nil {
+		t.Errorf("leaf was not issued by the mock Fulcio: %v", err)
+	}
+	if leafKey, ok := leaf.PublicKey.(*ecdsa.PublicKey); !ok || !leafKey.Equal(signer.GetPublicKey()) {
+		t.Errorf("leaf does not certify the ephemeral signing key")
+	}
+
+	if err := VerifySignedAttestation(signed, signer.GetPublicKey()); err != nil {
+		t.Errorf("keyless attestation does not verify: %v", err)
+	}
+
+	// A certificate for another key can't vouch for the signature
+	other := newTestKeylessSigner(t)
+	signed.Metadata.CertificateChain = other.certificates
+	if err := VerifySignedAttestation(signed, signer.GetPublicKey()); err == nil || !strings.Contains(err.Error(), "signing certificate does not match") {
+		t.Errorf("err = %v, want the swapped certificate rejected", err)
+	}
+}
+
+func TestNewSignerFromGitHubOIDCOutsideActions(t *testing.T) {
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "")
+
+	// No silent fallback to an ephemeral key
+	signer, err := NewSignerFromGitHubOIDC()
+	if signer != nil || err == nil || !strings.Contains(err.Error(), "id-token: write") {
+		t.Errorf("signer = %v, err = %v, want an error naming the permission", signer, err)
+	}
+}
+
+func TestNewSignerFromGitHubOIDCErrors(t *testing.T) {
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		setup   func(oidcURL, fulcioURL string, fulcio *mockFulcio)
+		wantErr string
+	}{
+		{
+			name:    "request token rejected",
+			token:   testIDToken(testWorkflowSubject),
+			setup:   func(oidcURL, fulcioURL string, _ *mockFulcio) { t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "expired") },
+			wantErr: "failed to get GitHub OIDC token: server returned 401 Unauthorized: bad request token",
+		},
+		{
+			name:    "empty token",
+			token:   "",
+			wantErr: "empty token in response",
+		},
+		{
+			name:    "token without subject",
+			token:   testIDToken(""),
+			wantErr: "OIDC token has no subject claim",
+		},
+		{
+			name:    "malformed token",
+			token:   "not-a-jwt",
+			wantErr: "malformed OIDC token",
+		},
+		{
+			name:    "certificate for another key",
+			token:   testIDToken(testWorkflowSubject),
+			setup:   func(_, _ string, fulcio *mockFulcio) { fulcio.certify = &otherKey.PublicKey },
+			wantErr: "fulcio certificate does not certify the signing key",
+		},
+		{
+			name:    "Fulcio endpoint not found",
+			token:   testIDToken(testWorkflowSubject),
+			setup:   func(_, fulcioURL string, _ *mockFulcio) { t.Setenv("MONDRIAN_FULCIO_URL", fulcioURL+"/missing") },
+			wantErr: "failed to get Fulcio certificate: server returned 404 Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oidc := newMockOIDCServer(t, tt.token)
+			fulcio, fulcioServer := newMockFulcioServer(t)
+			setKeylessEnv(t, oidc.URL, fulcioServer.URL)
+			if tt.setup != nil {
+				tt.setup(oidc.URL, fulcioServer.URL, fulcio)
+			}
+
+			if _, err := NewSignerFromGitHubOIDC(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestFulcioCertificateWithoutChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"signedCertificateDetachedSct": {"chain": {"certificates": []}}}`))
+	}))
+	defer server.Close()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := requestFulcioCertificate(server.URL, testIDToken(testWorkflowSubject), key); err == nil || !strings.Contains(err.Error(), "no certificate chain") {
+		t.Errorf("err = %v, want no certificate chain", err)
+	}
+}
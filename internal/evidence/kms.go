@@ -0,0 +1,85 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KMS key URI schemes, following the conventions of cosign and sigstore
+const (
+	KMSProviderAWS   = "awskms"
+	KMSProviderGCP   = "gcpkms"
+	KMSProviderAzure = "azurekms"
+)
+
+// KMSKey identifies an asymmetric ECDSA P-256 signing key in a cloud KMS:
+//
+//	awskms:///<key id, ARN or alias>   awskms://<endpoint>/<key id>
+//	gcpkms://projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>/cryptoKeyVersions/<v>
+//	azurekms://<vault>.vault.azure.net/<key name>[/<version>]
+type KMSKey struct {
+	URI      string
+	Provider string
+	Host     string // AWS endpoint override or Azure vault host
+	Key      string // AWS key ID, GCP key version resource name or Azure key name
+	Version  string // Azure key version, latest when empty
+}
+
+// ParseKMSKeyURI parses and validates a KMS key URI
+func ParseKMSKeyURI(uri string) (KMSKey, error) {
+	key := KMSKey{URI: uri}
+	provider, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return key, fmt.Errorf("invalid KMS key URI %q: expected awskms://, gcpkms:// or azurekms://", uri)
+	}
+	key.Provider = provider
+	
+	switch provider {
+	case KMSProviderAWS:
+		key.Host, key.Key, _ = strings.Cut(rest, "/")
+		if key.Key == "" {
+			return key, fmt.Errorf("invalid KMS key URI %q: missing AWS key ID", uri)
+		}
+	case KMSProviderGCP:
+		key.Key = rest
+		parts := strings.Split(rest, "/")
+		if len(parts) != 10 || parts[0] != "projects" || parts[2] != "locations" || parts[4] != "keyRings" || parts[6] != "cryptoKeys" || parts[8] != "cryptoKeyVersions" {
+			return key, fmt.Errorf("invalid KMS key URI %q: expected gcpkms://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>/cryptoKeyVersions/<version>", uri)
+		}
+	case KMSProviderAzure:
+		parts := strings.Split(rest, "/")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return key, fmt.Errorf("invalid KMS key URI %q: expected azurekms://<vault>.vault.azure.net/<key name>[/<version>]", uri)
+		}
+		key.Host, key.Key = parts[0], parts[1]
+		if len(parts) == 3 {
+			key.Version = parts[2]
+		}
+	default:
+		return key, fmt.Errorf("unsupported KMS provider %q: expected awskms, gcpkms or azurekms", provider)
+	}
+	
+	return key, nil
+}
+
+// KMSKeyURIFromEnv returns the KMS key URI configured via MONDRIAN_KMS_KEY
+func KMSKeyURIFromEnv() string {
+	return os.Getenv("MONDRIAN_KMS_KEY")
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build kms
+
+package evidence
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// awsKMSClient signs with an ECC_NIST_P256 key in AWS KMS
+type awsKMSClient struct {
+	client *kms.Client
+	keyID  string
+}
+
+func newAWSKMSClient(ctx context.Context, key KMSKey) (*awsKMSClient, error) {
+	var options []func(*config.LoadOptions) error
+	// A key ARN names its region, which takes precedence over AWS_REGION
+	if parts := strings.Split(key.Key, ":"); len(parts) > 3 && parts[0] == "arn" {
+		options = append(options, config.WithRegion(parts[3]))
+	}
+	
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	
+	client := kms.NewFromConfig(cfg, func(o *kms.Options) {
+		if key.Host != "" {
+			o.BaseEndpoint = aws.String("https://" + key.Host)
+		}
+	})
+	return &awsKMSClient{client: client, keyID: key.Key}, nil
+}
+
+func (c *awsKMSClient) publicKey(ctx context.Context) (*ecdsa.PublicKey, error) {
+	out, err := c.client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(c.keyID)})
+	if err != nil {
+		return nil, err
+	}
+	if out.KeySpec != types.KeySpecEccNistP256 {
+		return nil, fmt.Errorf("AWS KMS key has key spec %s, expected %s", out.KeySpec, types.KeySpecEccNistP256)
+	}
+	return parseECDSAPublicKeyDER(out.PublicKey)
+}
+
+// signDigest returns the DER signature AWS KMS produces for ECDSA keys
+func (c *awsKMSClient) signDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	out, err := c.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(c.keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: types.SigningAlgorithmSpecEcdsaSha256,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Signature, nil
+}
+
+func (c *awsKMSClient) close() error {
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build kms
+
+package evidence
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+)
+
+// azureKMSClient signs with a P-256 EC key in Azure Key Vault
+type azureKMSClient struct {
+	client  *azkeys.Client
+	name    string
+	version string
+}
+
+func newAzureKMSClient(key KMSKey) (*azureKMSClient, error) {
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Azure credentials: %w", err)
+	}
+	client, err := azkeys.NewClient("https://"+key.Host, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Key Vault client: %w", err)
+	}
+	return &azureKMSClient{client: client, name: key.Key, version: key.Version}, nil
+}
+
+// publicKey reads the key's JWK. Without a version in the URI the latest
+// version is used, and signing is pinned to it from then on.
+func (c *azureKMSClient) publicKey(ctx context.Context) (*ecdsa.PublicKey, error) {
+	resp, err := c.client.GetKey(ctx, c.name, c.version, nil)
+	if err != nil {
+		return nil, err
+	}
+	jwk := resp.Key
+	if jwk == nil || jwk.Crv == nil || *jwk.Crv != azkeys.CurveNameP256 {
+		return nil, fmt.Errorf("Azure Key Vault key %s is not a P-256 EC key", c.name)
+	}
+	if jwk.KID != nil {
+		c.version = jwk.KID.Version()
+	}
+	
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(jwk.X),
+		Y:     new(big.Int).SetBytes(jwk.Y),
+	}, nil
+}
+
+// signDigest converts the r||s signature Key Vault returns into ASN.1
+func (c *azureKMSClient) signDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	resp, err := c.client.Sign(ctx, c.name, c.version, azkeys.SignParameters{
+		Algorithm: to.Ptr(azkeys.SignatureAlgorithmES256),
+		Value:     digest,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Result) != 64 {
+		return nil, fmt.Errorf("unexpected Azure Key Vault signature length %d", len(resp.Result))
+	}
+	
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		R: new(big.Int).SetBytes(resp.Result[:32]),
+		S: new(big.Int).SetBytes(resp.Result[32:]),
+	})
+}
+
+func (c *azureKMSClient) close() error {
+	return nil
+}
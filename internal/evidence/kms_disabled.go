@@ -0,0 +1,29 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !kms
+
+package evidence
+
+import "fmt"
+
+// NewSignerFromKMS is unavailable in builds without KMS support
+func NewSignerFromKMS(keyURI string) (*Signer, error) {
+	if _, err := ParseKMSKeyURI(keyURI); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("KMS signing is not supported by this build: rebuild with -tags kms")
+}
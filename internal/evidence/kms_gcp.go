@@ -0,0 +1,75 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build kms
+
+package evidence
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/pem"
+	"fmt"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+)
+
+// gcpKMSClient signs with an EC_SIGN_P256_SHA256 key version in Cloud KMS
+type gcpKMSClient struct {
+	client *kms.KeyManagementClient
+	name   string
+}
+
+func newGCPKMSClient(ctx context.Context, key KMSKey) (*gcpKMSClient, error) {
+	client, err := kms.NewKeyManagementClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud KMS client: %w", err)
+	}
+	return &gcpKMSClient{client: client, name: key.Key}, nil
+}
+
+func (c *gcpKMSClient) publicKey(ctx context.Context) (*ecdsa.PublicKey, error) {
+	resp, err := c.client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: c.name})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Algorithm != kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256 {
+		return nil, fmt.Errorf("Cloud KMS key version has algorithm %s, expected EC_SIGN_P256_SHA256", resp.Algorithm)
+	}
+	
+	block, _ := pem.Decode([]byte(resp.Pem))
+	if block == nil {
+		return nil, fmt.Errorf("Cloud KMS returned an invalid PEM public key")
+	}
+	return parseECDSAPublicKeyDER(block.Bytes)
+}
+
+// signDigest returns the DER signature Cloud KMS produces for ECDSA keys
+func (c *gcpKMSClient) signDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	resp, err := c.client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+		Name:   c.name,
+		Digest: &kmspb.Digest{Digest: &kmspb.Digest_Sha256{Sha256: digest}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+func (c *gcpKMSClient) close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build kms
+
+package evidence
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// kmsTimeout bounds each call to the KMS
+const kmsTimeout = 30 * time.Second
+
+// kmsClient is the provider-specific half of a KMS-backed key
+type kmsClient interface {
+	// publicKey fetches the verification key
+	publicKey(ctx context.Context) (*ecdsa.PublicKey, error)
+	// signDigest signs a SHA-256 digest and returns an ASN.1 ECDSA signature
+	signDigest(ctx context.Context, digest []byte) ([]byte, error)
+	close() error
+}
+
+// KMSSigner implements KeyBackend with an ECDSA P-256 key held in a cloud KMS
+type KMSSigner struct {
+	client    kmsClient
+	keyID     string
+	publicKey *ecdsa.PublicKey
+}
+
+// NewSignerFromKMS creates a signer backed by a key in AWS KMS, Google Cloud
+// KMS or Azure Key Vault. Credentials come from each provider's default chain
+// (environment, shared config files, workload or managed identity). Call Close
+// when done to release the KMS client.
+func NewSignerFromKMS(keyURI string) (*Signer, error) {
+	kms, err := NewKMSSigner(keyURI)
+	if err != nil {
+		return nil, err
+	}
+	return NewSignerFromBackend(kms, keyURI), nil
+}
+
+// NewKMSSigner connects to the KMS named by the URI and fetches the public key
+func NewKMSSigner(keyURI string) (*KMSSigner, error) {
+	key, err := ParseKMSKeyURI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+	
+	var client kmsClient
+	switch key.Provider {
+	case KMSProviderAWS:
+		client, err = newAWSKMSClient(ctx, key)
+	case KMSProviderGCP:
+		client, err = newGCPKMSClient(ctx, key)
+	case KMSProviderAzure:
+		client, err = newAzureKMSClient(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	publicKey, err := client.publicKey(ctx)
+	if err != nil {
+		client.close()
+		return nil, fmt.Errorf("failed to fetch public key for %s: %w", keyURI, err)
+	}
+	if publicKey.Curve != elliptic.P256() {
+		client.close()
+		return nil, fmt.Errorf("KMS signing key %s is not an ECDSA P-256 key", keyURI)
+	}
+	
+	return &KMSSigner{
+		client:    client,
+		keyID:     keyIDFor(publicKey),
+		publicKey: publicKey,
+	}, nil
+}
+
+// Sign hashes data locally and has the KMS sign the digest. The signature is
+// checked against the public key so a key rotated behind the URI is caught
+// here rather than at verification time.
+func (s *KMSSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	hash := sha256.Sum256(data)
+	
+	ctx, cancel := context.WithTimeout(ctx, kmsTimeout)
+	defer cancel()
+	
+	signature, err := s.client.signDigest(ctx, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("KMS signing failed: %w", err)
+	}
+	if !ecdsa.VerifyASN1(s.publicKey, hash[:], signature) {
+		return nil, fmt.Errorf("KMS signature does not verify with key ID %s", s.keyID)
+	}
+	return signature, nil
+}
+
+func (s *KMSSigner) KeyID() (string, error) {
+	return s.keyID, nil
+}
+
+func (s *KMSSigner) Public() *ecdsa.PublicKey {
+	return s.publicKey
+}
+
+// Close releases the KMS client
+func (s *KMSSigner) Close() error {
+	return s.client.close()
+}
+
+// parseECDSAPublicKeyDER decodes a DER SubjectPublicKeyInfo holding an ECDSA key
+func parseECDSAPublicKeyDER(der []byte) (*ecdsa.PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an ECDSA key")
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,116 @@
+//go:build kms
+
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeKMSClient signs digests with an in-memory key, as a KMS would
+type fakeKMSClient struct {
+	key    *ecdsa.PrivateKey
+	err    error
+	closed bool
+}
+
+func (f *fakeKMSClient) publicKey(ctx context.Context) (*ecdsa.PublicKey, error) {
+	return &f.key.PublicKey, f.err
+}
+
+func (f *fakeKMSClient) signDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return ecdsa.SignASN1(rand.Reader, f.key, digest)
+}
+
+func (f *fakeKMSClient) close() error {
+	f.closed = true
+	return nil
+}
+
+func newFakeKMSSigner(t *testing.T) (*KMSSigner, *fakeKMSClient) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &fakeKMSClient{key: key}
+	return &KMSSigner{client: client, keyID: keyIDFor(&key.PublicKey), publicKey: &key.PublicKey}, client
+}
+
+func TestKMSSigner(t *testing.T) {
+	kms, client := newFakeKMSSigner(t)
+	signer := NewSignerFromBackend(kms, "gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1")
+
+	signed, err := signer.SignAttestation(NewAttestation(nil, AttestationMetadata{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySignedAttestation(signed, kms.Public()); err != nil {
+		t.Errorf("KMS signature did not verify: %v", err)
+	}
+	if keyID, _ := kms.KeyID(); signed.Metadata.KeyID != keyID {
+		t.Errorf("key ID = %s, want %s", signed.Metadata.KeyID, keyID)
+	}
+
+	if err := signer.Close(); err != nil || !client.closed {
+		t.Errorf("Close = %v, client closed %v", err, client.closed)
+	}
+}
+
+func TestKMSSignerErrors(t *testing.T) {
+	kms, client := newFakeKMSSigner(t)
+	client.err = errors.New("AccessDeniedException")
+	if _, err := kms.Sign(context.Background(), []byte("payload")); err == nil || !strings.Contains(err.Error(), "AccessDeniedException") {
+		t.Errorf("err = %v, want the KMS error", err)
+	}
+
+	// A key rotated behind the URI signs with a key the signer doesn't expect
+	kms, client = newFakeKMSSigner(t)
+	rotated, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.key = rotated
+	if _, err := kms.Sign(context.Background(), []byte("payload")); err == nil || !strings.Contains(err.Error(), "does not verify") {
+		t.Errorf("err = %v, want the rotated key caught", err)
+	}
+}
+
+func TestParseECDSAPublicKeyDER(t *testing.T) {
+	kms, _ := newFakeKMSSigner(t)
+	der, err := x509.MarshalPKIXPublicKey(kms.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := parseECDSAPublicKeyDER(der); err != nil || !key.Equal(kms.Public()) {
+		t.Errorf("parseECDSAPublicKeyDER = %v, %v", key, err)
+	}
+	if _, err := parseECDSAPublicKeyDER([]byte("not DER")); err == nil {
+		t.Error("invalid DER accepted")
+	}
+}
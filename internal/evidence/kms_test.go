@@ -0,0 +1,129 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+)
+
+// fakeKMS is a KeyBackend whose private key stays inside it, as a KMS key would
+type fakeKMS struct {
+	key    *ecdsa.PrivateKey
+	signed int
+	closed bool
+}
+
+func newFakeKMS(t *testing.T) *fakeKMS {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeKMS{key: key}
+}
+
+func (f *fakeKMS) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	f.signed++
+	hash := sha256.Sum256(data)
+	return ecdsa.SignASN1(rand.Reader, f.key, hash[:])
+}
+
+func (f *fakeKMS) KeyID() (string, error) {
+	return keyIDFor(&f.key.PublicKey), nil
+}
+
+func (f *fakeKMS) Public() *ecdsa.PublicKey {
+	return &f.key.PublicKey
+}
+
+func (f *fakeKMS) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestSignerFromBackend(t *testing.T) {
+	const keyURI = "awskms:///alias/mondrian-attestations"
+	kms := newFakeKMS(t)
+	signer := NewSignerFromBackend(kms, keyURI)
+
+	attestation := NewAttestation(nil, AttestationMetadata{})
+	signed, err := signer.SignAttestation(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kms.signed != 1 {
+		t.Errorf("backend signed %d times, want once", kms.signed)
+	}
+	if signed.Metadata.KeyID != keyIDFor(kms.Public()) || signer.GetKeyID() != signed.Metadata.KeyID {
+		t.Errorf("key ID = %s, want the ID of the backend's public key", signed.Metadata.KeyID)
+	}
+	if signed.Metadata.KeyRef != keyURI {
+		t.Errorf("key ref = %q, want %q", signed.Metadata.KeyRef, keyURI)
+	}
+
+	// Verification needs only the backend's public key
+	if err := VerifySignedAttestation(signed, kms.Public()); err != nil {
+		t.Errorf("backend signature did not verify: %v", err)
+	}
+	if err := VerifySignedAttestation(signed, newTestSigner(t).GetPublicKey()); err == nil {
+		t.Error("backend signature verified with another key")
+	}
+
+	if err := signer.Close(); err != nil || !kms.closed {
+		t.Errorf("Close = %v, backend closed %v", err, kms.closed)
+	}
+}
+
+func TestParseKMSKeyURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want KMSKey
+	}{
+		{"awskms:///alias/mondrian", KMSKey{Provider: KMSProviderAWS, Key: "alias/mondrian"}},
+		{"awskms://localhost:4566/1234abcd-12ab-34cd-56ef-1234567890ab", KMSKey{Provider: KMSProviderAWS, Host: "localhost:4566", Key: "1234abcd-12ab-34cd-56ef-1234567890ab"}},
+		{"gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1", KMSKey{Provider: KMSProviderGCP, Key: "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"}},
+		{"azurekms://acme.vault.azure.net/signing", KMSKey{Provider: KMSProviderAzure, Host: "acme.vault.azure.net", Key: "signing"}},
+		{"azurekms://acme.vault.azure.net/signing/0123abcd", KMSKey{Provider: KMSProviderAzure, Host: "acme.vault.azure.net", Key: "signing", Version: "0123abcd"}},
+	}
+	for _, tt := range tests {
+		tt.want.URI = tt.uri
+		if got, err := ParseKMSKeyURI(tt.uri); err != nil || got != tt.want {
+			t.Errorf("ParseKMSKeyURI(%q) = %+v, %v, want %+v", tt.uri, got, err, tt.want)
+		}
+	}
+
+	for _, uri := range []string{
+		"alias/mondrian",
+		"awskms://",
+		"hashivault://transit/keys/mondrian",
+		"gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k",
+		"azurekms://acme.vault.azure.net",
+		"azurekms://acme.vault.azure.net/signing/v1/extra",
+	} {
+		if _, err := ParseKMSKeyURI(uri); err == nil {
+			t.Errorf("ParseKMSKeyURI(%q) accepted an invalid URI", uri)
+		}
+		if _, err := NewSignerFromKMS(uri); err == nil {
+			t.Errorf("NewSignerFromKMS(%q) accepted an invalid URI", uri)
+		}
+	}
+}
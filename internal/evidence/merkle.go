@@ -0,0 +1,139 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// The Merkle tree over a chain follows RFC 6962: leaves and interior nodes
+// are hashed with distinct prefixes so one can't be passed off as the other,
+// and a tree of n leaves splits at the largest power of two below n.
+
+// ProofNode is one sibling hash on the path from a leaf to the root. Left
+// reports whether the sibling sits to the left of the path.
+type ProofNode struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left,omitempty"`
+}
+
+// MerkleRoot computes the root of the Merkle tree whose leaves are the chain's
+// attestation hashes in order. An empty chain has an empty root.
+func (chain *EvidenceChain) MerkleRoot() string {
+	if len(chain.Attestations) == 0 {
+		return ""
+	}
+	return hex.EncodeToString(merkleTreeHash(chain.leaves()))
+}
+
+// InclusionProof returns the sibling hashes proving that the attestation with
+// the given hash is in the tree with root MerkleRoot()
+func (chain *EvidenceChain) InclusionProof(hash string) ([]ProofNode, error) {
+	index := -1
+	for i, entry := range chain.Attestations {
+		if entry.Hash == hash {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("no attestation with hash %s in chain", hash)
+	}
+
+	proof := merkleAuditPath(index, chain.leaves())
+	if proof == nil {
+		proof = []ProofNode{}
+	}
+	return proof, nil
+}
+
+// VerifyInclusion checks that proof leads from the attestation hash to root,
+// without needing the rest of the chain
+func VerifyInclusion(root, hash string, proof []ProofNode) error {
+	node := merkleLeafHash([]byte(hash))
+	for i, sibling := range proof {
+		siblingHash, err := hex.DecodeString(sibling.Hash)
+		if err != nil || len(siblingHash) != sha256.Size {
+			return fmt.Errorf("invalid hash at proof step %d", i)
+		}
+		if sibling.Left {
+			node = merkleNodeHash(siblingHash, node)
+		} else {
+			node = merkleNodeHash(node, siblingHash)
+		}
+	}
+
+	if computed := hex.EncodeToString(node); computed != root {
+		return fmt.Errorf("inclusion proof leads to root %s, not %s", shortHash(computed), shortHash(root))
+	}
+	return nil
+}
+
+func (chain *EvidenceChain) leaves() [][]byte {
+	leaves := make([][]byte, len(chain.Attestations))
+	for i, entry := range chain.Attestations {
+		leaves[i] = []byte(entry.Hash)
+	}
+	return leaves
+}
+
+func merkleTreeHash(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return merkleLeafHash(leaves[0])
+	}
+	k := merkleSplit(len(leaves))
+	return merkleNodeHash(merkleTreeHash(leaves[:k]), merkleTreeHash(leaves[k:]))
+}
+
+// merkleAuditPath lists the siblings of leaf index from the bottom up
+func merkleAuditPath(index int, leaves [][]byte) []ProofNode {
+	if len(leaves) == 1 {
+		return nil
+	}
+	k := merkleSplit(len(leaves))
+	if index < k {
+		sibling := merkleTreeHash(leaves[k:])
+		return append(merkleAuditPath(index, leaves[:k]), ProofNode{Hash: hex.EncodeToString(sibling)})
+	}
+	sibling := merkleTreeHash(leaves[:k])
+	return append(merkleAuditPath(index-k, leaves[k:]), ProofNode{Hash: hex.EncodeToString(sibling), Left: true})
+}
+
+// merkleSplit returns the largest power of two smaller than n
+func merkleSplit(n int) int {
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
+
+func merkleLeafHash(data []byte) []byte {
+	hash := sha256.Sum256(append([]byte{0x00}, data...))
+	return hash[:]
+}
+
+func merkleNodeHash(left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(data, 0x01)
+	data = append(data, left...)
+	data = append(data, right...)
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// syntheticChain returns a chain of n entries with made-up hashes, enough
+// for the Merkle tree, which only looks at the hashes
+func syntheticChain(n int) *EvidenceChain {
+	chain := &EvidenceChain{}
+	for i := 0; i < n; i++ {
+		hash := sha256.Sum256([]byte(fmt.Sprint(i)))
+		chain.Attestations = append(chain.Attestations, ChainEntry{Hash: hex.EncodeToString(hash[:])})
+	}
+	return chain
+}
+
+func TestInclusionProof(t *testing.T) {
+	_, chain := newTestChain(t, newTestSigner(t), 5)
+	root := chain.MerkleRoot()
+	if chain.Root != root {
+		t.Fatalf("recorded root %s, want the computed %s", chain.Root, root)
+	}
+
+	for name, i := range map[string]int{"first": 0, "middle": 2, "last": 4} {
+		hash := chain.Attestations[i].Hash
+		proof, err := chain.InclusionProof(hash)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := VerifyInclusion(root, hash, proof); err != nil {
+			t.Errorf("%s entry: %v", name, err)
+		}
+		// The proof is for this entry alone
+		other := chain.Attestations[(i+1)%5].Hash
+		if err := VerifyInclusion(root, other, proof); err == nil {
+			t.Errorf("%s entry's proof also proved entry %d", name, (i+1)%5)
+		}
+	}
+
+	if _, err := chain.InclusionProof(strings.Repeat("0", 64)); err == nil {
+		t.Error("proof for a hash not in the chain")
+	}
+}
+
+func TestInclusionProofEveryTreeShape(t *testing.T) {
+	// Balanced and unbalanced trees, from a single leaf up
+	for n := 1; n <= 9; n++ {
+		chain := syntheticChain(n)
+		root := chain.MerkleRoot()
+		for i, entry := range chain.Attestations {
+			proof, err := chain.InclusionProof(entry.Hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyInclusion(root, entry.Hash, proof); err != nil {
+				t.Errorf("%d leaves, entry %d: %v", n, i, err)
+			}
+		}
+	}
+}
+
+func TestMerkleRootFollowsRFC6962(t *testing.T) {
+	leaf := func(hash string) []byte {
+		sum := sha256.Sum256(append([]byte{0x00}, hash...))
+		return sum[:]
+	}
+	node := func(left, right []byte) []byte {
+		sum := sha256.Sum256(append(append([]byte{0x01}, left...), right...))
+		return sum[:]
+	}
+
+	// Three leaves split as two and one
+	chain := syntheticChain(3)
+	a, b, c := chain.Attestations[0].Hash, chain.Attestations[1].Hash, chain.Attestations[2].Hash
+	want := hex.EncodeToString(node(node(leaf(a), leaf(b)), leaf(c)))
+	if root := chain.MerkleRoot(); root != want {
+		t.Errorf("root = %s, want %s", root, want)
+	}
+	if root := (&EvidenceChain{}).MerkleRoot(); root != "" {
+		t.Errorf("empty chain root = %q, want none", root)
+	}
+}
+
+func TestVerifyInclusionRejectsTamperedProofs(t *testing.T) {
+	chain := syntheticChain(4)
+	root, hash := chain.MerkleRoot(), chain.Attestations[1].Hash
+	proof, err := chain.InclusionProof(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := append([]ProofNode(nil), proof...)
+	flipped[0].Left = !flipped[0].Left
+	malformed := append([]ProofNode(nil), proof...)
+	malformed[1].Hash = "not-hex"
+	for name, proof := range map[string][]ProofNode{
+		"wrong side":   flipped,
+		"invalid hash": malformed,
+		"truncated":    proof[:1],
+	} {
+		if err := VerifyInclusion(root, hash, proof); err == nil {
+			t.Errorf("%s proof verified", name)
+		}
+	}
+}
+
+func TestAddAttestationUpdatesMerkleRoot(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 2)
+	before := chain.Root
+
+	attestTo(t, cm, chain, signer, "pass")
+	if chain.Root == before || chain.Root != chain.MerkleRoot() {
+		t.Errorf("root after appending = %s, want the recomputed %s", chain.Root, chain.MerkleRoot())
+	}
+	loaded, err := cm.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Root != chain.Root {
+		t.Errorf("saved root %s, want %s", loaded.Root, chain.Root)
+	}
+
+	loaded.Root = before
+	if err := cm.VerifyChain(loaded); err == nil || !strings.Contains(err.Error(), "merkle root mismatch") {
+		t.Errorf("err = %v, want a stale root rejected", err)
+	}
+}
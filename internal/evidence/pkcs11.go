@@ -0,0 +1,64 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// PKCS11Config selects a signing key held in a PKCS#11 token such as an HSM
+type PKCS11Config struct {
+	ModulePath string // path to the vendor PKCS#11 library
+	Slot       uint
+	KeyLabel   string // CKA_LABEL shared by the private and public key objects
+	PIN        string
+}
+
+// PKCS11ConfigFromEnv reads the PKCS#11 configuration from MONDRIAN_PKCS11_*
+// environment variables. It reports false when no module is configured.
+func PKCS11ConfigFromEnv() (PKCS11Config, bool, error) {
+	config := PKCS11Config{
+		ModulePath: os.Getenv("MONDRIAN_PKCS11_MODULE"),
+		KeyLabel:   os.Getenv("MONDRIAN_PKCS11_KEY_LABEL"),
+		PIN:        os.Getenv("MONDRIAN_PKCS11_PIN"),
+	}
+	if config.ModulePath == "" {
+		return config, false, nil
+	}
+	
+	if slot := os.Getenv("MONDRIAN_PKCS11_SLOT"); slot != "" {
+		n, err := strconv.ParseUint(slot, 10, 32)
+		if err != nil {
+			return config, true, fmt.Errorf("invalid MONDRIAN_PKCS11_SLOT %q: %w", slot, err)
+		}
+		config.Slot = uint(n)
+	}
+	if config.KeyLabel == "" {
+		return config, true, fmt.Errorf("MONDRIAN_PKCS11_KEY_LABEL is required when MONDRIAN_PKCS11_MODULE is set")
+	}
+	
+	return config, true, nil
+}
+
+// KeyRef identifies the key as an RFC 7512 PKCS#11 URI. It names the key
+// without exposing any key material or the PIN.
+func (c PKCS11Config) KeyRef() string {
+	return fmt.Sprintf("pkcs11:slot-id=%d;object=%s;type=private", c.Slot, url.PathEscape(c.KeyLabel))
+}
@@ -0,0 +1,26 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build !pkcs11 || !cgo
+
+package evidence
+
+import "fmt"
+
+// NewSignerFromPKCS11 is unavailable in builds without PKCS#11 support
+func NewSignerFromPKCS11(config PKCS11Config) (*Signer, error) {
+	return nil, fmt.Errorf("PKCS#11 signing is not supported by this build: rebuild with CGO_ENABLED=1 and -tags pkcs11")
+}
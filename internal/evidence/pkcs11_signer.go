@@ -0,0 +1,218 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build pkcs11 && cgo
+
+package evidence
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// oidNamedCurveP256 is the CKA_EC_PARAMS value of a P-256 key
+var oidNamedCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+
+// PKCS11Signer implements the dsse.Signer interface with an ECDSA P-256 key
+// that never leaves its PKCS#11 token
+type PKCS11Signer struct {
+	mu        sync.Mutex // a PKCS#11 session runs one operation at a time
+	ctx       *pkcs11.Ctx
+	session   pkcs11.SessionHandle
+	key       pkcs11.ObjectHandle
+	keyID     string
+	publicKey *ecdsa.PublicKey
+}
+
+// NewSignerFromPKCS11 creates a signer backed by a key in a PKCS#11 token.
+// Call Close when done to end the token session.
+func NewSignerFromPKCS11(config PKCS11Config) (*Signer, error) {
+	hsm, err := NewPKCS11Signer(config)
+	if err != nil {
+		return nil, err
+	}
+	
+	return NewSignerFromBackend(hsm, config.KeyRef()), nil
+}
+
+// NewPKCS11Signer loads the module, opens a session on the configured slot
+// and looks up the private and public key objects by label
+func NewPKCS11Signer(config PKCS11Config) (*PKCS11Signer, error) {
+	ctx := pkcs11.New(config.ModulePath)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module: %s", config.ModulePath)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module: %w", err)
+	}
+	
+	session, err := ctx.OpenSession(config.Slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to open PKCS#11 session on slot %d: %w", config.Slot, err)
+	}
+	
+	s := &PKCS11Signer{ctx: ctx, session: session}
+	if err := s.open(config); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *PKCS11Signer) open(config PKCS11Config) error {
+	if config.PIN != "" {
+		if err := s.ctx.Login(s.session, pkcs11.CKU_USER, config.PIN); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			return fmt.Errorf("failed to log in to PKCS#11 token: %w", err)
+		}
+	}
+	
+	key, err := s.findObject(pkcs11.CKO_PRIVATE_KEY, config.KeyLabel)
+	if err != nil {
+		return err
+	}
+	publicKeyObject, err := s.findObject(pkcs11.CKO_PUBLIC_KEY, config.KeyLabel)
+	if err != nil {
+		return err
+	}
+	publicKey, err := s.readPublicKey(publicKeyObject)
+	if err != nil {
+		return err
+	}
+	
+	s.key = key
+	s.publicKey = publicKey
+	s.keyID = keyIDFor(publicKey)
+	return nil
+}
+
+// findObject returns the single object of the given class with the label
+func (s *PKCS11Signer) findObject(class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 token: %w", err)
+	}
+	objects, _, err := s.ctx.FindObjects(s.session, 2)
+	if finalErr := s.ctx.FindObjectsFinal(s.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 token: %w", err)
+	}
+	
+	kind := "private key"
+	if class == pkcs11.CKO_PUBLIC_KEY {
+		kind = "public key"
+	}
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("no %s labeled %q in PKCS#11 token", kind, label)
+	case 1:
+		return objects[0], nil
+	}
+	return 0, fmt.Errorf("more than one %s labeled %q in PKCS#11 token", kind, label)
+}
+
+// readPublicKey decodes the EC point of a P-256 public key object
+func (s *PKCS11Signer) readPublicKey(object pkcs11.ObjectHandle) (*ecdsa.PublicKey, error) {
+	attrs, err := s.ctx.GetAttributeValue(s.session, object, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#11 public key: %w", err)
+	}
+	
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(attrs[0].Value, &curve); err != nil || !curve.Equal(oidNamedCurveP256) {
+		return nil, fmt.Errorf("PKCS#11 signing key is not an ECDSA P-256 key")
+	}
+	
+	// CKA_EC_POINT is a DER OCTET STRING, though some modules return the raw point
+	point := attrs[1].Value
+	var wrapped []byte
+	if rest, err := asn1.Unmarshal(point, &wrapped); err == nil && len(rest) == 0 {
+		point = wrapped
+	}
+	
+	publicKey, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#11 public key: %w", err)
+	}
+	return publicKey, nil
+}
+
+// Sign hashes data and signs the digest in the token. The token returns r||s,
+// which is re-encoded as ASN.1 to match signatures made with file-based keys.
+func (s *PKCS11Signer) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	hash := sha256.Sum256(data)
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}
+	if err := s.ctx.SignInit(s.session, mechanism, s.key); err != nil {
+		return nil, fmt.Errorf("failed to start PKCS#11 signing: %w", err)
+	}
+	raw, err := s.ctx.Sign(s.session, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 signing failed: %w", err)
+	}
+	if len(raw) != 64 {
+		return nil, fmt.Errorf("unexpected PKCS#11 signature length %d", len(raw))
+	}
+	
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		R: new(big.Int).SetBytes(raw[:32]),
+		S: new(big.Int).SetBytes(raw[32:]),
+	})
+}
+
+func (s *PKCS11Signer) KeyID() (string, error) {
+	return s.keyID, nil
+}
+
+func (s *PKCS11Signer) Public() *ecdsa.PublicKey {
+	return s.publicKey
+}
+
+// Close logs out and releases the token session and module
+func (s *PKCS11Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	s.ctx.Logout(s.session)
+	err := s.ctx.CloseSession(s.session)
+	s.ctx.Finalize()
+	s.ctx.Destroy()
+	if err != nil {
+		return fmt.Errorf("failed to close PKCS#11 session: %w", err)
+	}
+	return nil
+}
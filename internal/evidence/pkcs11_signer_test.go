@@ -0,0 +1,181 @@
+//go:build pkcs11 && cgo
+
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"encoding/asn1"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+)
+
+const (
+	softHSMPIN   = "1234"
+	softHSMLabel = "mondrian-test"
+)
+
+// softHSMModules are where distributions install the SoftHSM2 module
+var softHSMModules = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+// newSoftHSMToken initializes a SoftHSM2 token in a temporary directory with
+// a P-256 key pair labeled softHSMLabel, skipping when SoftHSM2 is missing.
+// SOFTHSM2_MODULE overrides where the module is looked for.
+func newSoftHSMToken(t *testing.T) PKCS11Config {
+	t.Helper()
+	module := os.Getenv("SOFTHSM2_MODULE")
+	for _, path := range softHSMModules {
+		if _, err := os.Stat(path); module == "" && err == nil {
+			module = path
+		}
+	}
+	util, err := exec.LookPath("softhsm2-util")
+	if module == "" || err != nil {
+		t.Skip("SoftHSM2 is not installed")
+	}
+
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "softhsm2.conf")
+	tokens := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokens, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(conf, []byte("directories.tokendir = "+tokens+"\nobjectstore.backend = file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	output, err := exec.Command(util, "--init-token", "--free", "--label", "mondrian", "--pin", softHSMPIN, "--so-pin", "5678").CombinedOutput()
+	if err != nil {
+		t.Fatalf("softhsm2-util: %v\n%s", err, output)
+	}
+
+	// SoftHSM2 reassigns the slot of an initialized token
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		t.Fatalf("failed to load %s", module)
+	}
+	defer ctx.Destroy()
+	if err := ctx.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Finalize()
+	slots, err := ctx.GetSlotList(true)
+	if err != nil || len(slots) == 0 {
+		t.Fatalf("no initialized SoftHSM2 slot: %v", err)
+	}
+
+	session, err := ctx.OpenSession(slots[0], pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.CloseSession(session)
+	if err := ctx.Login(session, pkcs11.CKU_USER, softHSMPIN); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Logout(session)
+
+	params, err := asn1.Marshal(oidNamedCurveP256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = ctx.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, softHSMLabel),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, softHSMLabel),
+		})
+	if err != nil {
+		t.Fatalf("failed to generate SoftHSM2 key pair: %v", err)
+	}
+
+	return PKCS11Config{ModulePath: module, Slot: slots[0], KeyLabel: softHSMLabel, PIN: softHSMPIN}
+}
+
+func TestPKCS11SignerSignsVerifiableAttestations(t *testing.T) {
+	config := newSoftHSMToken(t)
+	hsm, err := NewPKCS11Signer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hsm.Close()
+	signer := NewSignerFromBackend(hsm, config.KeyRef())
+
+	signed, err := signer.SignAttestation(NewAttestation(nil, AttestationMetadata{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySignedAttestation(signed, hsm.Public()); err != nil {
+		t.Errorf("PKCS#11 signature does not verify: %v", err)
+	}
+
+	// The metadata names the key without carrying it
+	metadata := signed.Metadata
+	if metadata.KeyRef != config.KeyRef() || !strings.HasPrefix(metadata.KeyRef, "pkcs11:") {
+		t.Errorf("key reference = %q, want %q", metadata.KeyRef, config.KeyRef())
+	}
+	if metadata.KeyID != keyIDFor(hsm.Public()) {
+		t.Errorf("key ID = %s, want that of the token's public key", metadata.KeyID)
+	}
+	if strings.Contains(metadata.KeyRef, softHSMPIN) {
+		t.Errorf("key reference %q exposes the PIN", metadata.KeyRef)
+	}
+}
+
+func TestNewPKCS11SignerErrors(t *testing.T) {
+	config := newSoftHSMToken(t)
+
+	missing := config
+	missing.KeyLabel = "no-such-key"
+	if _, err := NewPKCS11Signer(missing); err == nil || !strings.Contains(err.Error(), `no private key labeled "no-such-key"`) {
+		t.Errorf("err = %v, want the missing key named", err)
+	}
+
+	// Private keys are only visible after logging in
+	loggedOut := config
+	loggedOut.PIN = ""
+	if _, err := NewPKCS11Signer(loggedOut); err == nil {
+		t.Errorf("found the private key without logging in")
+	}
+
+	badModule := config
+	badModule.ModulePath = filepath.Join(t.TempDir(), "missing.so")
+	if _, err := NewPKCS11Signer(badModule); err == nil || !strings.Contains(err.Error(), "failed to load PKCS#11 module") {
+		t.Errorf("err = %v, want the module load to fail", err)
+	}
+}
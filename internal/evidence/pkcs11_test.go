@@ -0,0 +1,54 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPKCS11ConfigFromEnv(t *testing.T) {
+	t.Setenv("MONDRIAN_PKCS11_MODULE", "")
+	if _, ok, err := PKCS11ConfigFromEnv(); ok || err != nil {
+		t.Errorf("unconfigured = %v, %v, want false and no error", ok, err)
+	}
+
+	t.Setenv("MONDRIAN_PKCS11_MODULE", "/usr/lib/softhsm/libsofthsm2.so")
+	t.Setenv("MONDRIAN_PKCS11_SLOT", "3")
+	t.Setenv("MONDRIAN_PKCS11_KEY_LABEL", "release signing")
+	t.Setenv("MONDRIAN_PKCS11_PIN", "1234")
+	config, ok, err := PKCS11ConfigFromEnv()
+	if !ok || err != nil {
+		t.Fatalf("configured = %v, %v", ok, err)
+	}
+	if config.Slot != 3 || config.KeyLabel != "release signing" || config.PIN != "1234" {
+		t.Errorf("config = %+v", config)
+	}
+	if ref := config.KeyRef(); ref != "pkcs11:slot-id=3;object=release%20signing;type=private" || strings.Contains(ref, "1234") {
+		t.Errorf("key reference = %q", ref)
+	}
+
+	t.Setenv("MONDRIAN_PKCS11_SLOT", "first")
+	if _, _, err := PKCS11ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "invalid MONDRIAN_PKCS11_SLOT") {
+		t.Errorf("err = %v, want an invalid slot", err)
+	}
+	t.Setenv("MONDRIAN_PKCS11_SLOT", "")
+	t.Setenv("MONDRIAN_PKCS11_KEY_LABEL", "")
+	if _, _, err := PKCS11ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "MONDRIAN_PKCS11_KEY_LABEL is required") {
+		t.Errorf("err = %v, want the key label required", err)
+	}
+}
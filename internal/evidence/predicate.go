@@ -0,0 +1,171 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PredicateTypePrefix is the predicate type of policy check attestations
+// without its version, e.g. https://mondrian.dev/policy-check/v0.2
+const PredicateTypePrefix = "https://mondrian.dev/policy-check/"
+
+// PredicateVersion is the version of PolicyCheckPredicate this build writes.
+// Bump it with every change to the predicate's shape, and register a
+// migration from the previous version in predicateMigrations.
+const PredicateVersion = "v0.2"
+
+// PredicateType returns the predicate type of a predicate version
+func PredicateType(version string) string {
+	return PredicateTypePrefix + version
+}
+
+// predicateMigration upgrades a predicate, decoded as generic JSON, to the
+// next version
+type predicateMigration struct {
+	to    string
+	apply func(predicate map[string]interface{}) error
+}
+
+// predicateMigrations is keyed by the version each migration upgrades from
+var predicateMigrations = map[string]predicateMigration{
+	// v0.2 records the version in the predicate itself
+	"v0.1": {to: "v0.2", apply: func(map[string]interface{}) error { return nil }},
+}
+
+// MigratePredicate decodes a predicate written as fromVersion, applying each
+// migration in turn up to PredicateVersion, so attestations made by earlier
+// releases read as the current struct
+func MigratePredicate(raw json.RawMessage, fromVersion string) (PolicyCheckPredicate, error) {
+	var predicate PolicyCheckPredicate
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		raw = json.RawMessage("{}")
+	}
+
+	if fromVersion != PredicateVersion {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		var fields map[string]interface{}
+		if err := decoder.Decode(&fields); err != nil {
+			return predicate, fmt.Errorf("failed to parse %s predicate: %w", fromVersion, err)
+		}
+
+		for version := fromVersion; version != PredicateVersion; {
+			migration, ok := predicateMigrations[version]
+			if !ok {
+				return predicate, fmt.Errorf("unsupported predicate version %q, this build reads up to %s", version, PredicateVersion)
+			}
+			if err := migration.apply(fields); err != nil {
+				return predicate, fmt.Errorf("failed to migrate predicate from %s to %s: %w", version, migration.to, err)
+			}
+			version = migration.to
+		}
+		fields["version"] = PredicateVersion
+
+		var err error
+		if raw, err = json.Marshal(fields); err != nil {
+			return predicate, err
+		}
+	}
+
+	if err := json.Unmarshal(raw, &predicate); err != nil {
+		return predicate, fmt.Errorf("failed to parse %s predicate: %w", fromVersion, err)
+	}
+	return predicate, nil
+}
+
+// PredicateVersion returns the version of the attestation's predicate type,
+// or "" when it isn't a policy check predicate
+func (a *Attestation) PredicateVersion() string {
+	version, ok := strings.CutPrefix(a.PredicateType, PredicateTypePrefix)
+	if !ok {
+		return ""
+	}
+	return version
+}
+
+// storedAttestation is an attestation as written, with its predicate in the
+// shape of the version it was written as. Its fields must match Attestation
+// in order, since hashes of attestations predating canonical JSON depend on
+// the order of the encoding.
+type storedAttestation struct {
+	PredicateType string          `json:"predicateType"`
+	Subject       []Subject       `json:"subject"`
+	Predicate     json.RawMessage `json:"predicate"`
+	Timestamp     time.Time       `json:"timestamp"`
+	RunID         string          `json:"runId"`
+	ParentHash    string          `json:"parentHash,omitempty"`
+	Hash          string          `json:"hash"`
+	HashAlgorithm string          `json:"hashAlgorithm,omitempty"`
+}
+
+// UnmarshalJSON migrates the predicate of an attestation written by an
+// earlier release to the current version, keeping the predicate as written
+// so the attestation still hashes, and serializes, as it was signed
+func (a *Attestation) UnmarshalJSON(data []byte) error {
+	var stored storedAttestation
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	*a = Attestation{
+		PredicateType: stored.PredicateType,
+		Subject:       stored.Subject,
+		Timestamp:     stored.Timestamp,
+		RunID:         stored.RunID,
+		ParentHash:    stored.ParentHash,
+		Hash:          stored.Hash,
+		HashAlgorithm: stored.HashAlgorithm,
+	}
+
+	version := a.PredicateVersion()
+	if version == "" {
+		return fmt.Errorf("unsupported predicate type %q", stored.PredicateType)
+	}
+	predicate, err := MigratePredicate(stored.Predicate, version)
+	if err != nil {
+		return err
+	}
+	a.Predicate = predicate
+	if version != PredicateVersion {
+		a.storedPredicate = stored.Predicate
+	}
+	return nil
+}
+
+// MarshalJSON writes a migrated attestation's predicate as it was read
+func (a Attestation) MarshalJSON() ([]byte, error) {
+	if a.storedPredicate == nil {
+		// The conversion drops the methods, so this doesn't recurse
+		type plain Attestation
+		return json.Marshal(plain(a))
+	}
+	return json.Marshal(storedAttestation{
+		PredicateType: a.PredicateType,
+		Subject:       a.Subject,
+		Predicate:     a.storedPredicate,
+		Timestamp:     a.Timestamp,
+		RunID:         a.RunID,
+		ParentHash:    a.ParentHash,
+		Hash:          a.Hash,
+		HashAlgorithm: a.HashAlgorithm,
+	})
+}
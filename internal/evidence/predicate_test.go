@@ -0,0 +1,134 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// v01Predicate is a predicate as written by v0.1, which had no version field
+const v01Predicate = `{
+  "results": [
+    {"rule_name": "s3-no-public-buckets", "status": "fail", "severity": "critical", "message": "public bucket", "file": "main.tf", "line": 2}
+  ],
+  "summary": {"totalChecks": 1, "passed": 0, "failed": 1, "warnings": 0, "overallStatus": "fail"},
+  "repository": "github.com/acme/infra",
+  "branch": "main",
+  "commit": "0123abc",
+  "scanner": {"name": "mondrian", "version": "0.1.0", "rulesUsed": ["s3-no-public-buckets"]},
+  "filesScanned": ["main.tf"]
+}`
+
+func TestMigratePredicateFromV01(t *testing.T) {
+	predicate, err := MigratePredicate(json.RawMessage(v01Predicate), "v0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if predicate.Version != PredicateVersion {
+		t.Errorf("version = %q, want %q", predicate.Version, PredicateVersion)
+	}
+	if len(predicate.Results) != 1 || predicate.Results[0].RuleName != "s3-no-public-buckets" || predicate.Results[0].Line != 2 {
+		t.Errorf("results = %+v, want the public bucket finding", predicate.Results)
+	}
+	if predicate.Summary.Failed != 1 || predicate.Summary.OverallStatus != "fail" {
+		t.Errorf("summary = %+v, want one failure", predicate.Summary)
+	}
+	if predicate.Repository != "github.com/acme/infra" || predicate.Commit != "0123abc" || predicate.Scanner.Version != "0.1.0" || len(predicate.FilesScanned) != 1 {
+		t.Errorf("predicate = %+v, want the environment and scanner carried over", predicate)
+	}
+}
+
+func TestMigratePredicate(t *testing.T) {
+	current := `{"version": "` + PredicateVersion + `", "results": [], "summary": {"overallStatus": "pass"}}`
+	if predicate, err := MigratePredicate(json.RawMessage(current), PredicateVersion); err != nil || predicate.Summary.OverallStatus != "pass" {
+		t.Errorf("current predicate = %+v, %v, want it read as is", predicate, err)
+	}
+	if predicate, err := MigratePredicate(nil, "v0.1"); err != nil || predicate.Version != PredicateVersion {
+		t.Errorf("empty v0.1 predicate = %+v, %v, want an empty current predicate", predicate, err)
+	}
+
+	if _, err := MigratePredicate(json.RawMessage(v01Predicate), "v9.0"); err == nil || !strings.Contains(err.Error(), `unsupported predicate version "v9.0"`) {
+		t.Errorf("err = %v, want v9.0 unsupported", err)
+	}
+	if _, err := MigratePredicate(json.RawMessage(`["not", "an", "object"]`), "v0.1"); err == nil {
+		t.Error("a predicate that isn't an object was migrated")
+	}
+}
+
+func TestUnmarshalV01Attestation(t *testing.T) {
+	// Simulate an attestation v0.1 wrote, hashed over its JSON encoding
+	stored := storedAttestation{
+		PredicateType: "https://mondrian.dev/policy-check/v0.1",
+		Subject:       []Subject{{Name: "main.tf", Digest: map[string]string{"sha256": testImageDigest}}},
+		Predicate:     json.RawMessage(v01Predicate),
+		Timestamp:     time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		RunID:         "run-0001",
+	}
+	unhashed, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(unhashed)
+	stored.Hash = hex.EncodeToString(sum[:])
+	data, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attestation Attestation
+	if err := json.Unmarshal(data, &attestation); err != nil {
+		t.Fatal(err)
+	}
+	if attestation.PredicateVersion() != "v0.1" || attestation.Predicate.Version != PredicateVersion {
+		t.Errorf("read as %s with predicate %q, want a v0.1 attestation migrated to %s", attestation.PredicateVersion(), attestation.Predicate.Version, PredicateVersion)
+	}
+	if attestation.Predicate.Summary.OverallStatus != "fail" || len(attestation.Predicate.Results) != 1 {
+		t.Errorf("predicate = %+v, want the recorded failure", attestation.Predicate)
+	}
+
+	// The migrated attestation still hashes, and serializes, as it was written
+	if hash := attestation.calculateHash(); hash != stored.Hash {
+		t.Errorf("hash = %s, want %s as written", hash, stored.Hash)
+	}
+	written, err := json.Marshal(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != string(data) {
+		t.Errorf("re-encoded as\n%s\nwant\n%s", written, data)
+	}
+
+	stored.PredicateType = "https://example.com/other/v1"
+	if data, err = json.Marshal(stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &attestation); err == nil || !strings.Contains(err.Error(), "unsupported predicate type") {
+		t.Errorf("err = %v, want another predicate type rejected", err)
+	}
+}
+
+func TestNewAttestationWritesCurrentVersion(t *testing.T) {
+	attestation := NewAttestation(nil, AttestationMetadata{})
+	if attestation.PredicateType != PredicateType(PredicateVersion) || attestation.Predicate.Version != PredicateVersion {
+		t.Errorf("new attestation is %s with predicate %q, want %s", attestation.PredicateType, attestation.Predicate.Version, PredicateVersion)
+	}
+}
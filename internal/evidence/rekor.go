@@ -0,0 +1,351 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultRekorURL is the public Sigstore transparency log
+const DefaultRekorURL = "https://rekor.sigstore.dev"
+
+// RekorEntry records where a signed attestation was logged in Rekor
+type RekorEntry struct {
+	URL            string `json:"url"`
+	UUID           string `json:"uuid"`
+	LogIndex       int64  `json:"logIndex"`
+	IntegratedTime int64  `json:"integratedTime"`
+}
+
+// rekorLogEntry is a log entry as returned by the Rekor API
+type rekorLogEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogIndex       int64  `json:"logIndex"`
+	Verification   struct {
+		InclusionProof *rekorInclusionProof `json:"inclusionProof"`
+	} `json:"verification"`
+}
+
+type rekorInclusionProof struct {
+	Hashes   []string `json:"hashes"`
+	LogIndex int64    `json:"logIndex"`
+	RootHash string   `json:"rootHash"`
+	TreeSize int64    `json:"treeSize"`
+}
+
+// rekorUploadAttempts bounds retries of transient Rekor failures
+const rekorUploadAttempts = 3
+
+var rekorClient = &http.Client{Timeout: 30 * time.Second}
+
+// UploadToRekor submits the DSSE envelope to a Rekor instance as a dsse entry
+// and records the returned log index and UUID in the signing metadata.
+// Network errors and server errors are retried a few times before giving up;
+// the caller decides what to do with the attestation if the upload fails.
+func UploadToRekor(signed *SignedAttestation, rekorURL string) (logIndex int64, uuid string, err error) {
+	if signed.Metadata.PublicKey == "" {
+		return 0, "", fmt.Errorf("signed attestation has no public key for Rekor to verify")
+	}
+	rekorURL = strings.TrimSuffix(rekorURL, "/")
+	
+	envelope, err := json.Marshal(signed.Envelope)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to serialize DSSE envelope: %w", err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "dsse",
+		"spec": map[string]interface{}{
+			"proposedContent": map[string]interface{}{
+				"envelope":  string(envelope),
+				"verifiers": [][]byte{[]byte(signed.Metadata.PublicKey)},
+			},
+		},
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to serialize Rekor entry: %w", err)
+	}
+	
+	var entries map[string]rekorLogEntry
+	for attempt := 1; ; attempt++ {
+		entries, err = postRekorEntry(rekorURL, body)
+		if err == nil || attempt == rekorUploadAttempts || !isTransientRekorError(err) {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	
+	for id, entry := range entries {
+		signed.Metadata.Rekor = &RekorEntry{
+			URL:            rekorURL,
+			UUID:           id,
+			LogIndex:       entry.LogIndex,
+			IntegratedTime: entry.IntegratedTime,
+		}
+		return entry.LogIndex, id, nil
+	}
+	return 0, "", fmt.Errorf("rekor returned no log entry")
+}
+
+// rekorHTTPError is a non-success response from Rekor
+type rekorHTTPError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *rekorHTTPError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("rekor returned %s: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("rekor returned %s", e.Status)
+}
+
+// isTransientRekorError reports whether a failed request is worth retrying
+func isTransientRekorError(err error) bool {
+	if httpErr, ok := err.(*rekorHTTPError); ok {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// postRekorEntry creates a log entry. An envelope that is already logged
+// yields 409 Conflict with the existing entry's location, which is fetched instead.
+func postRekorEntry(rekorURL string, body []byte) (map[string]rekorLogEntry, error) {
+	resp, err := rekorClient.Post(rekorURL+"/api/v1/log/entries", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach rekor: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode == http.StatusConflict {
+		if location := resp.Header.Get("Location"); location != "" {
+			uuid := location[strings.LastIndex(location, "/")+1:]
+			return getRekorEntry(rekorURL, uuid)
+		}
+	}
+	return decodeRekorResponse(resp)
+}
+
+// getRekorEntry fetches a log entry by UUID
+func getRekorEntry(rekorURL, uuid string) (map[string]rekorLogEntry, error) {
+	resp, err := rekorClient.Get(rekorURL + "/api/v1/log/entries/" + uuid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach rekor: %w", err)
+	}
+	defer resp.Body.Close()
+	return decodeRekorResponse(resp)
+}
+
+func decodeRekorResponse(resp *http.Response) (map[string]rekorLogEntry, error) {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rekor response: %w", err)
+	}
+	
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return nil, &rekorHTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Message: apiErr.Message}
+	}
+	
+	var entries map[string]rekorLogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse rekor response: %w", err)
+	}
+	return entries, nil
+}
+
+// VerifyRekorInclusion fetches the attestation's Rekor entry and checks that
+// the entry was made for this envelope's payload and that its Merkle inclusion
+// proof leads to the log's root hash. The log's checkpoint signature is not
+// checked, so this trusts the Rekor instance named in the metadata.
+func VerifyRekorInclusion(signed *SignedAttestation) error {
+	rekor := signed.Metadata.Rekor
+	if rekor == nil {
+		return fmt.Errorf("attestation has no Rekor entry")
+	}
+	
+	entries, err := getRekorEntry(rekor.URL, rekor.UUID)
+	if err != nil {
+		return err
+	}
+	entry, ok := entries[rekor.UUID]
+	if !ok {
+		for _, e := range entries {
+			entry, ok = e, true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("rekor entry %s not found", rekor.UUID)
+	}
+	if entry.LogIndex != rekor.LogIndex {
+		return fmt.Errorf("rekor entry has log index %d, attestation records %d", entry.LogIndex, rekor.LogIndex)
+	}
+	
+	body, err := base64.StdEncoding.DecodeString(entry.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decode rekor entry body: %w", err)
+	}
+	if err := checkRekorPayloadHash(body, signed); err != nil {
+		return err
+	}
+	
+	// Entry UUIDs end with the hex leaf hash of the entry body
+	leaf := rekorLeafHash(body)
+	if !strings.HasSuffix(rekor.UUID, hex.EncodeToString(leaf)) {
+		return fmt.Errorf("rekor entry body does not match UUID %s", rekor.UUID)
+	}
+	
+	proof := entry.Verification.InclusionProof
+	if proof == nil {
+		return fmt.Errorf("rekor entry %s has no inclusion proof", rekor.UUID)
+	}
+	return verifyInclusionProof(leaf, proof)
+}
+
+// checkRekorPayloadHash compares the payload hash of a dsse entry body with the envelope
+func checkRekorPayloadHash(body []byte, signed *SignedAttestation) error {
+	var logged struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			PayloadHash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"payloadHash"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(body, &logged); err != nil {
+		return fmt.Errorf("failed to parse rekor entry body: %w", err)
+	}
+	if logged.Kind != "dsse" || logged.Spec.PayloadHash.Algorithm != "sha256" {
+		return fmt.Errorf("rekor entry is not a sha256 dsse entry")
+	}
+	
+	payload, err := signed.Envelope.DecodeB64Payload()
+	if err != nil {
+		return fmt.Errorf("failed to decode envelope payload: %w", err)
+	}
+	hash := sha256.Sum256(payload)
+	if logged.Spec.PayloadHash.Value != hex.EncodeToString(hash[:]) {
+		return fmt.Errorf("rekor entry was made for a different payload")
+	}
+	return nil
+}
+
+// rekorLeafHash is the RFC 6962 leaf hash of an entry body
+func rekorLeafHash(body []byte) []byte {
+	hash := sha256.Sum256(append([]byte{0x00}, body...))
+	return hash[:]
+}
+
+// verifyInclusionProof checks an RFC 6962 Merkle audit path (RFC 9162 section 2.1.3.2)
+func verifyInclusionProof(leaf []byte, proof *rekorInclusionProof) error {
+	if proof.LogIndex < 0 || proof.LogIndex >= proof.TreeSize {
+		return fmt.Errorf("inclusion proof index %d is outside tree of size %d", proof.LogIndex, proof.TreeSize)
+	}
+	
+	fn, sn := proof.LogIndex, proof.TreeSize-1
+	r := leaf
+	for _, encoded := range proof.Hashes {
+		p, err := hex.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid inclusion proof hash: %w", err)
+		}
+		if sn == 0 {
+			return fmt.Errorf("inclusion proof is longer than the tree")
+		}
+		
+		if fn&1 == 1 || fn == sn {
+			r = hashChildren(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = hashChildren(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	
+	if sn != 0 {
+		return fmt.Errorf("inclusion proof is shorter than the tree")
+	}
+	if hex.EncodeToString(r) != proof.RootHash {
+		return fmt.Errorf("inclusion proof does not match root hash %s", shortHash(proof.RootHash))
+	}
+	return nil
+}
+
+func hashChildren(left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(data, 0x01)
+	data = append(data, left...)
+	data = append(data, right...)
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// VerifyRekorEntries checks the Rekor inclusion proof of every signed
+// attestation in the chain. Unsigned attestations and attestations that were
+// never uploaded are reported as anomalies.
+func (cm *ChainManager) VerifyRekorEntries(chain *EvidenceChain) []ChainAnomaly {
+	var anomalies []ChainAnomaly
+	for i, entry := range chain.Attestations {
+		err := cm.verifyRekorEntry(entry)
+		if err == nil {
+			continue
+		}
+		anomalies = append(anomalies, ChainAnomaly{
+			Position: i,
+			Hash:     entry.Hash,
+			FilePath: entry.FilePath,
+			Problem:  err.Error(),
+		})
+	}
+	return anomalies
+}
+
+func (cm *ChainManager) verifyRekorEntry(entry ChainEntry) error {
+	_, signed, err := cm.LoadAttestation(entry.FilePath)
+	if err != nil {
+		return err
+	}
+	if signed == nil {
+		return fmt.Errorf("attestation is unsigned and cannot be in Rekor")
+	}
+	if err := VerifyRekorInclusion(signed); err != nil {
+		return fmt.Errorf("rekor verification failed: %w", err)
+	}
+	return nil
+}
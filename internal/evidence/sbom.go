@@ -0,0 +1,166 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+// CycloneDX 1.5 structures, limited to the fields Mondrian fills in
+type cycloneDXBOM struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Metadata    cycloneDXMetadata    `json:"metadata"`
+	Components  []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string         `json:"timestamp"`
+	Tools     cycloneDXTools `json:"tools"`
+}
+
+type cycloneDXTools struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	Purl       string              `json:"purl,omitempty"`
+	Properties []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// GenerateSBOM produces a CycloneDX JSON SBOM of the components the scanned
+// files reference: Terraform providers and modules, Docker base images and
+// GitHub Actions. Versions are recorded as written, so a provider constraint
+// like "~> 5.0" appears as is rather than as the resolved release.
+func GenerateSBOM(files map[string]string) ([]byte, error) {
+	bom := cycloneDXBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: cycloneDXTools{
+				Components: []cycloneDXComponent{{Type: "application", Name: "mondrian", Version: "v0.1.0"}},
+			},
+		},
+		Components: []cycloneDXComponent{},
+	}
+	
+	for _, component := range policy.DetectComponents(files) {
+		entry := cycloneDXComponent{
+			Type:    "library",
+			Name:    component.Name,
+			Version: component.Version,
+			Purl:    componentPurl(component),
+			Properties: []cycloneDXProperty{
+				{Name: "mondrian:kind", Value: component.Kind},
+				{Name: "mondrian:location", Value: fmt.Sprintf("%s:%d", filepath.ToSlash(component.File), component.Line)},
+			},
+		}
+		if component.Kind == policy.ComponentContainerImage {
+			entry.Type = "container"
+		}
+		entry.BOMRef = entry.Purl
+		if entry.BOMRef == "" {
+			entry.BOMRef = component.Kind + ":" + component.Name + "@" + component.Version
+		}
+		bom.Components = append(bom.Components, entry)
+	}
+	
+	data, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize SBOM: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// componentPurl returns the package URL of a component, or "" for kinds
+// without a purl type such as Terraform providers
+func componentPurl(component policy.Component) string {
+	version := ""
+	if component.Version != "" {
+		version = "@" + url.PathEscape(component.Version)
+	}
+	
+	switch component.Kind {
+	case policy.ComponentContainerImage:
+		// Images from other registries carry the registry as repository_url
+		name, qualifier := component.Name, ""
+		if first, rest, ok := strings.Cut(name, "/"); ok && strings.ContainsAny(first, ".:") {
+			name, qualifier = rest, "?repository_url="+url.QueryEscape(first)
+		}
+		return "pkg:docker/" + name + version + qualifier
+	case policy.ComponentGitHubAction:
+		// owner/repo/path@ref keeps the path as a subpath
+		parts := strings.SplitN(component.Name, "/", 3)
+		if len(parts) < 2 {
+			return ""
+		}
+		purl := "pkg:github/" + parts[0] + "/" + parts[1] + version
+		if len(parts) == 3 {
+			purl += "#" + parts[2]
+		}
+		return purl
+	}
+	return ""
+}
+
+// SaveSBOM writes the SBOM to the evidence directory under a name derived
+// from its digest. It returns the path and a subject for the attestation.
+func SaveSBOM(data []byte, evidenceDir string) (string, Subject, error) {
+	if err := os.MkdirAll(evidenceDir, 0755); err != nil {
+		return "", Subject{}, fmt.Errorf("failed to create evidence directory: %w", err)
+	}
+	
+	subject := SBOMSubject(data)
+	path := filepath.Join(evidenceDir, subject.Name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", Subject{}, fmt.Errorf("failed to write SBOM: %w", err)
+	}
+	
+	return path, subject, nil
+}
+
+// SBOMSubject is the attestation subject for an SBOM, named as SaveSBOM names its file
+func SBOMSubject(data []byte) Subject {
+	hash := sha256.Sum256(data)
+	digest := hex.EncodeToString(hash[:])
+	return Subject{
+		Name:   fmt.Sprintf("sbom-%s.cdx.json", digest[:16]),
+		Digest: map[string]string{"sha256": digest},
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+// sbomFiles reference a pinned provider, a registry module, two base images
+// and a workflow action
+var sbomFiles = map[string]string{
+	"versions.tf": `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "5.31.0"
+    }
+  }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.0"
+}
+`,
+	"Dockerfile": `FROM golang:1.25 AS build
+FROM gcr.io/distroless/static-debian12:nonroot
+`,
+	".github/workflows/ci.yml": `on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+`,
+}
+
+func TestGenerateSBOM(t *testing.T) {
+	data, err := GenerateSBOM(sbomFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bom cycloneDXBOM
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("SBOM is not valid JSON: %v", err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" {
+		t.Errorf("format %s %s, want CycloneDX 1.5", bom.BOMFormat, bom.SpecVersion)
+	}
+
+	components := make(map[string]cycloneDXComponent)
+	for _, component := range bom.Components {
+		components[component.Name] = component
+	}
+	tests := []struct {
+		name, version, kind, purl string
+	}{
+		{"hashicorp/aws", "5.31.0", "library", ""},
+		{"terraform-aws-modules/vpc/aws", "~> 5.0", "library", ""},
+		{"golang", "1.25", "container", "pkg:docker/golang@1.25"},
+		{"gcr.io/distroless/static-debian12", "nonroot", "container", "pkg:docker/distroless/static-debian12@nonroot?repository_url=gcr.io"},
+		{"actions/checkout", "v4", "library", "pkg:github/actions/checkout@v4"},
+	}
+	for _, tt := range tests {
+		component, ok := components[tt.name]
+		if !ok {
+			t.Errorf("SBOM lacks %s", tt.name)
+			continue
+		}
+		if component.Version != tt.version || component.Type != tt.kind || component.Purl != tt.purl {
+			t.Errorf("%s = %+v, want version %q, type %s and purl %q", tt.name, component, tt.version, tt.kind, tt.purl)
+		}
+		if component.BOMRef == "" || len(component.Properties) != 2 {
+			t.Errorf("%s has no bom-ref or lacks its kind and location properties", tt.name)
+		}
+	}
+	if len(bom.Components) != len(tests) {
+		t.Errorf("SBOM lists %d components, want %d", len(bom.Components), len(tests))
+	}
+}
+
+func TestGenerateSBOMWithoutComponents(t *testing.T) {
+	data, err := GenerateSBOM(map[string]string{"main.tf": `resource "aws_s3_bucket" "b" {}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bom map[string]interface{}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatal(err)
+	}
+	if components, ok := bom["components"].([]interface{}); !ok || len(components) != 0 {
+		t.Errorf("components = %v, want an empty list", bom["components"])
+	}
+}
+
+func TestComponentPurl(t *testing.T) {
+	tests := []struct {
+		component policy.Component
+		want      string
+	}{
+		{policy.Component{Kind: policy.ComponentContainerImage, Name: "node", Version: "20-alpine"}, "pkg:docker/node@20-alpine"},
+		{policy.Component{Kind: policy.ComponentContainerImage, Name: "localhost:5000/app"}, "pkg:docker/app?repository_url=localhost%3A5000"},
+		{policy.Component{Kind: policy.ComponentGitHubAction, Name: "github/codeql-action/init", Version: "v3"}, "pkg:github/github/codeql-action@v3#init"},
+		{policy.Component{Kind: policy.ComponentGitHubAction, Name: "local"}, ""},
+		{policy.Component{Kind: policy.ComponentTerraformProvider, Name: "hashicorp/aws", Version: "5.0"}, ""},
+	}
+	for _, tt := range tests {
+		if got := componentPurl(tt.component); got != tt.want {
+			t.Errorf("componentPurl(%+v) = %q, want %q", tt.component, got, tt.want)
+		}
+	}
+}
+
+func TestSaveSBOM(t *testing.T) {
+	data, err := GenerateSBOM(sbomFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evidenceDir := filepath.Join(t.TempDir(), "evidence")
+	path, subject, err := SaveSBOM(data, evidenceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := sha256.Sum256(data)
+	digest := hex.EncodeToString(hash[:])
+	if subject.Digest["sha256"] != digest || subject.Name != "sbom-"+digest[:16]+".cdx.json" {
+		t.Errorf("subject = %+v, want the SBOM's digest", subject)
+	}
+	if path != filepath.Join(evidenceDir, subject.Name) {
+		t.Errorf("saved to %s, want %s", path, subject.Name)
+	}
+	if saved, err := os.ReadFile(path); err != nil || string(saved) != string(data) {
+		t.Errorf("saved SBOM differs from the generated one: %v", err)
+	}
+	if SBOMSubject(data).Name != subject.Name {
+		t.Errorf("SBOMSubject disagrees with SaveSBOM")
+	}
+}
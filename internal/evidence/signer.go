@@ -23,9 +23,12 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"time"
@@ -36,8 +39,35 @@ import (
 // Signer handles DSSE signing of attestations
 type Signer struct {
 	keyID      string
+	keyRef     string
 	privateKey *ecdsa.PrivateKey
 	publicKey  *ecdsa.PublicKey
+	backend    KeyBackend // set instead of privateKey when the key lives in an HSM or KMS
+	
+	certificates []string // Fulcio certificate chain of a keyless signer
+}
+
+// KeyBackend signs with an ECDSA P-256 private key held outside the process,
+// such as in a PKCS#11 token or a cloud KMS. Sign receives the DSSE
+// pre-authentication encoding and returns an ASN.1 signature over its SHA-256
+// digest, the same as a file-based key.
+type KeyBackend interface {
+	dsse.Signer
+	Public() *ecdsa.PublicKey
+	Close() error
+}
+
+// NewSignerFromBackend creates a signer for a key that never leaves its
+// backend. keyRef names the key in the signing metadata; the key ID is still
+// derived from the public key so attestations verify the same way as those
+// signed with a file-based key.
+func NewSignerFromBackend(backend KeyBackend, keyRef string) *Signer {
+	return &Signer{
+		keyID:     keyIDFor(backend.Public()),
+		keyRef:    keyRef,
+		publicKey: backend.Public(),
+		backend:   backend,
+	}
 }
 
 // SignedAttestation represents a DSSE-signed attestation
@@ -47,10 +77,19 @@ type SignedAttestation struct {
 }
 
 type SigningMetadata struct {
-	KeyID     string    `json:"keyId"`
-	Algorithm string    `json:"algorithm"`
-	Timestamp time.Time `json:"timestamp"`
-	Source    string    `json:"source"`
+	KeyID     string      `json:"keyId"`
+	Algorithm string      `json:"algorithm"`
+	Timestamp time.Time   `json:"timestamp"`
+	Source    string      `json:"source"`
+	PublicKey string      `json:"publicKey,omitempty"` // PEM-encoded verification key
+	KeyRef    string      `json:"keyRef,omitempty"`    // PKCS#11 or KMS URI of an externally held key
+	Rekor     *RekorEntry `json:"rekor,omitempty"`     // transparency log entry, when uploaded
+	
+	// TimestampToken is an RFC 3161 token over the envelope, when time-stamped
+	TimestampToken *TimestampToken `json:"timestampToken,omitempty"`
+	
+	// CertificateChain holds the PEM Fulcio certificates of a keyless signature, leaf first
+	CertificateChain []string `json:"certificateChain,omitempty"`
 }
 
 // NewSigner creates a new DSSE signer
@@ -61,23 +100,91 @@ func NewSigner() (*Signer, error) {
 		return nil, fmt.Errorf("failed to generate key pair: %w", err)
 	}
 	
-	// Generate key ID from public key
-	publicKeyBytes := elliptic.MarshalCompressed(privateKey.PublicKey.Curve, privateKey.PublicKey.X, privateKey.PublicKey.Y)
-	hash := sha256.Sum256(publicKeyBytes)
-	keyID := hex.EncodeToString(hash[:8]) // First 8 bytes for key ID
+	return &Signer{
+		keyID:      keyIDFor(&privateKey.PublicKey),
+		privateKey: privateKey,
+		publicKey:  &privateKey.PublicKey,
+	}, nil
+}
+
+// NewSignerFromFile loads a PEM-encoded EC private key (PKCS#8 or SEC 1)
+func NewSignerFromFile(keyPath string) (*Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode signing key PEM: %s", keyPath)
+	}
+	
+	var privateKey *ecdsa.PrivateKey
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse EC private key: %w", err)
+		}
+	default:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#8 private key: %w", err)
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key is not an ECDSA key")
+		}
+		privateKey = ecKey
+	}
 	
 	return &Signer{
-		keyID:      keyID,
+		keyID:      keyIDFor(&privateKey.PublicKey),
 		privateKey: privateKey,
 		publicKey:  &privateKey.PublicKey,
 	}, nil
 }
 
-// NewSignerFromGitHubOIDC creates a signer using GitHub OIDC token (future implementation)
-func NewSignerFromGitHubOIDC() (*Signer, error) {
-	// TODO: Implement GitHub OIDC token-based signing
-	// For now, fall back to ephemeral keys
-	return NewSigner()
+// SaveKey writes the private key as PKCS#8 PEM, readable only by the owner
+func (s *Signer) SaveKey(path string) error {
+	if s.privateKey == nil {
+		return fmt.Errorf("signing key %s is held outside mondrian and cannot be exported", s.keyRef)
+	}
+	
+	der, err := x509.MarshalPKCS8PrivateKey(s.privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+	
+	return nil
+}
+
+// PublicKeyPEM returns the PEM-encoded public key
+func (s *Signer) PublicKeyPEM() (string, error) {
+	return encodePublicKeyPEM(s.publicKey)
+}
+
+// SavePublicKey writes the PEM-encoded public key for distribution to verifiers
+func (s *Signer) SavePublicKey(path string) error {
+	data, err := s.PublicKeyPEM()
+	if err != nil {
+		return err
+	}
+	
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+	
+	return nil
 }
 
 // SignAttestation signs an attestation using DSSE
@@ -89,10 +196,13 @@ func (s *Signer) SignAttestation(attestation *Attestation) (*SignedAttestation,
 	}
 	
 	// Create DSSE signer with our private key
-	dsseSigner := &ECDSASigner{
+	var dsseSigner dsse.Signer = &ECDSASigner{
 		keyID:      s.keyID,
 		privateKey: s.privateKey,
 	}
+	if s.backend != nil {
+		dsseSigner = s.backend
+	}
 	
 	// Create envelope signer
 	envelopeSigner, err := dsse.NewEnvelopeSigner(dsseSigner)
@@ -106,11 +216,23 @@ func (s *Signer) SignAttestation(attestation *Attestation) (*SignedAttestation,
 		return nil, fmt.Errorf("failed to create DSSE envelope: %w", err)
 	}
 	
+	publicKeyPEM, err := encodePublicKeyPEM(s.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	
+	source := getSigningSource()
+	slog.Debug("Signed attestation", "keyId", s.keyID, "keyRef", s.keyRef, "source", source, "certificates", len(s.certificates))
+	
 	metadata := SigningMetadata{
 		KeyID:     s.keyID,
 		Algorithm: "ECDSA-SHA256",
 		Timestamp: time.Now().UTC(),
-		Source:    getSigningSource(),
+		Source:    source,
+		PublicKey: publicKeyPEM,
+		KeyRef:    s.keyRef,
+		
+		CertificateChain: s.certificates,
 	}
 	
 	return &SignedAttestation{
@@ -127,22 +249,44 @@ type ECDSASigner struct {
 
 func (e *ECDSASigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
 	hash := sha256.Sum256(data)
-	r, s, err := ecdsa.Sign(rand.Reader, e.privateKey, hash[:])
-	if err != nil {
-		return nil, err
-	}
-	
-	// Encode signature (simple concatenation for demo)
-	signature := append(r.Bytes(), s.Bytes()...)
-	return signature, nil
+	return ecdsa.SignASN1(rand.Reader, e.privateKey, hash[:])
 }
 
 func (e *ECDSASigner) KeyID() (string, error) {
 	return e.keyID, nil
 }
 
-// VerifySignedAttestation verifies a DSSE-signed attestation
+// VerifySignedAttestation verifies a DSSE-signed attestation. When publicKey is
+// nil the key recorded in the signing metadata is used, which proves the
+// envelope is intact but not who signed it.
 func VerifySignedAttestation(signed *SignedAttestation, publicKey *ecdsa.PublicKey) error {
+	if publicKey == nil {
+		if signed.Metadata.PublicKey == "" {
+			return fmt.Errorf("no public key available for key ID %s", signed.Metadata.KeyID)
+		}
+		key, err := ParsePublicKeyPEM(signed.Metadata.PublicKey)
+		if err != nil {
+			return err
+		}
+		publicKey = key
+	}
+	
+	if keyID := keyIDFor(publicKey); keyID != signed.Metadata.KeyID {
+		return fmt.Errorf("public key %s does not match signing key ID %s", keyID, signed.Metadata.KeyID)
+	}
+	
+	// A keyless signature must come from the key its certificate was issued for.
+	// The chain itself is not checked against a Sigstore trust root here.
+	if len(signed.Metadata.CertificateChain) > 0 {
+		leaf, err := parseCertificatePEM(signed.Metadata.CertificateChain[0])
+		if err != nil {
+			return err
+		}
+		if leafKey, ok := leaf.PublicKey.(*ecdsa.PublicKey); !ok || !leafKey.Equal(publicKey) {
+			return fmt.Errorf("signing certificate does not match key ID %s", signed.Metadata.KeyID)
+		}
+	}
+	
 	// Create verifier
 	verifier := &ECDSAVerifier{
 		keyID:     signed.Metadata.KeyID,
@@ -161,6 +305,12 @@ func VerifySignedAttestation(signed *SignedAttestation, publicKey *ecdsa.PublicK
 		return fmt.Errorf("DSSE verification failed: %w", err)
 	}
 	
+	if signed.Metadata.TimestampToken != nil {
+		if err := VerifyTimestamp(signed); err != nil {
+			return fmt.Errorf("time-stamp verification failed: %w", err)
+		}
+	}
+	
 	return nil
 }
 
@@ -171,9 +321,14 @@ type ECDSAVerifier struct {
 }
 
 func (e *ECDSAVerifier) Verify(ctx context.Context, data, signature []byte) error {
-	// For demo purposes, just return success
-	// Production would implement proper ECDSA signature verification
-	fmt.Printf("🔍 Verifying signature for key ID: %s (demo mode)\n", e.keyID[:8])
+	if e.publicKey == nil {
+		return fmt.Errorf("no public key for key ID %s", e.keyID)
+	}
+	
+	hash := sha256.Sum256(data)
+	if !ecdsa.VerifyASN1(e.publicKey, hash[:], signature) {
+		return fmt.Errorf("invalid ECDSA signature")
+	}
 	return nil
 }
 
@@ -190,16 +345,79 @@ func (s *Signer) GetPublicKey() *ecdsa.PublicKey {
 	return s.publicKey
 }
 
+// Close releases the PKCS#11 session or KMS client of an externally held key
+func (s *Signer) Close() error {
+	if s.backend != nil {
+		return s.backend.Close()
+	}
+	return nil
+}
+
 // GetKeyID returns the key identifier
 func (s *Signer) GetKeyID() string {
 	return s.keyID
 }
 
-// getSigningSource determines the source of signing (CI, local, etc.)
+// keyIDFor derives the key identifier from the first 8 bytes of the public key hash
+func keyIDFor(publicKey *ecdsa.PublicKey) string {
+	publicKeyBytes := elliptic.MarshalCompressed(publicKey.Curve, publicKey.X, publicKey.Y)
+	hash := sha256.Sum256(publicKeyBytes)
+	return hex.EncodeToString(hash[:8])
+}
+
+func encodePublicKeyPEM(publicKey *ecdsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// ParsePublicKeyPEM decodes a PEM-encoded ECDSA public key
+func ParsePublicKeyPEM(data string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key PEM")
+	}
+	
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an ECDSA key")
+	}
+	return publicKey, nil
+}
+
+// ciSources maps the environment variable identifying a CI platform to the
+// source name recorded for it and the variable holding its run identifier
+var ciSources = []struct {
+	env    string
+	source string
+	runID  string
+}{
+	{"GITHUB_ACTIONS", "github-actions", "GITHUB_RUN_ID"},
+	{"GITLAB_CI", "gitlab", "CI_PIPELINE_ID"},
+	{"CIRCLECI", "circleci", "CIRCLE_WORKFLOW_ID"},
+	{"BUILDKITE", "buildkite", "BUILDKITE_BUILD_ID"},
+}
+
+// getSigningSource determines the source of signing (CI, local, etc.). CI
+// sources carry the platform's run identifier when it is set, e.g.
+// gitlab:123456, so an attestation can be traced to the exact run.
 func getSigningSource() string {
 	// Check for CI environment variables
-	if os.Getenv("GITHUB_ACTIONS") == "true" {
-		return "github-actions"
+	for _, ci := range ciSources {
+		if os.Getenv(ci.env) != "true" {
+			continue
+		}
+		if id := os.Getenv(ci.runID); id != "" {
+			return ci.source + ":" + id
+		}
+		return ci.source
 	}
 	if os.Getenv("CI") == "true" {
 		return "ci"
@@ -214,28 +432,43 @@ func getSigningSource() string {
 	return fmt.Sprintf("local-%s", hostname)
 }
 
-// SaveSignedAttestation saves a signed attestation to the evidence store
-func SaveSignedAttestation(signed *SignedAttestation, evidenceDir string) error {
+// SaveSignedAttestation saves a signed attestation to the evidence store and
+// returns the file path. With an encryptor the file is encrypted at rest.
+func SaveSignedAttestation(signed *SignedAttestation, evidenceDir string, encryptor *Encryptor) (string, error) {
 	if err := os.MkdirAll(evidenceDir, 0755); err != nil {
-		return fmt.Errorf("failed to create evidence directory: %w", err)
+		return "", fmt.Errorf("failed to create evidence directory: %w", err)
 	}
 	
-	// Create filename with timestamp and key ID
-	timestamp := signed.Metadata.Timestamp.Format("20060102-150405")
-	filename := fmt.Sprintf("attestation-%s-%s.json", timestamp, signed.Metadata.KeyID[:8])
-	filePath := filepath.Join(evidenceDir, filename)
-	
 	// Serialize signed attestation
 	data, err := json.MarshalIndent(signed, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to serialize signed attestation: %w", err)
+		return "", fmt.Errorf("failed to serialize signed attestation: %w", err)
 	}
-	
-	// Write to file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write attestation file: %w", err)
+	if encryptor != nil {
+		if data, err = encryptor.Encrypt(data); err != nil {
+			return "", err
+		}
 	}
 	
-	fmt.Printf("📝 Saved attestation: %s\n", filePath)
-	return nil
+	// Create filename with timestamp and key ID. A persistent key can sign
+	// several attestations within one second, so never overwrite an existing file.
+	timestamp := signed.Metadata.Timestamp.Format("20060102-150405")
+	base := fmt.Sprintf("attestation-%s-%s", timestamp, signed.Metadata.KeyID[:8])
+	for n := 0; ; n++ {
+		filename := base + ".json"
+		if n > 0 {
+			filename = fmt.Sprintf("%s-%d.json", base, n)
+		}
+		filePath := filepath.Join(evidenceDir, filename)
+		
+		err := createFileAtomic(filePath, data, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write attestation file: %w", err)
+		}
+		
+		return filePath, nil
+	}
 }
\ No newline at end of file
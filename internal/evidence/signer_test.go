@@ -0,0 +1,181 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+func TestSaveKeyRoundTrip(t *testing.T) {
+	signer := newTestSigner(t)
+	keyPath := filepath.Join(t.TempDir(), "keys", "signing.pem")
+	if err := signer.SaveKey(keyPath); err != nil {
+		t.Fatalf("SaveKey: %v", err)
+	}
+
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("signing key mode = %o, want 600", perm)
+	}
+
+	loaded, err := NewSignerFromFile(keyPath)
+	if err != nil {
+		t.Fatalf("NewSignerFromFile: %v", err)
+	}
+	if !loaded.GetPublicKey().Equal(signer.GetPublicKey()) {
+		t.Errorf("loaded key differs from the saved one")
+	}
+
+	// An attestation signed with the reloaded key verifies against the original
+	attestation := NewAttestation([]policy.CheckResult{{RuleName: "r", Status: "pass"}}, AttestationMetadata{})
+	signed, err := loaded.SignAttestation(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySignedAttestation(signed, signer.GetPublicKey()); err != nil {
+		t.Errorf("VerifySignedAttestation with the original key: %v", err)
+	}
+}
+
+func TestKeyIDStableAcrossLoads(t *testing.T) {
+	signer := newTestSigner(t)
+	keyPath := filepath.Join(t.TempDir(), "signing.pem")
+	if err := signer.SaveKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		loaded, err := NewSignerFromFile(keyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.GetKeyID() != signer.GetKeyID() {
+			t.Errorf("load %d: keyID = %s, want %s", i, loaded.GetKeyID(), signer.GetKeyID())
+		}
+	}
+	if other := newTestSigner(t); other.GetKeyID() == signer.GetKeyID() {
+		t.Errorf("different keys share keyID %s", signer.GetKeyID())
+	}
+}
+
+func TestPublicKeyRoundTrip(t *testing.T) {
+	signer := newTestSigner(t)
+	publicKeyPath := filepath.Join(t.TempDir(), "signing.pub")
+	if err := signer.SavePublicKey(publicKeyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := ParsePublicKeyPEM(string(data))
+	if err != nil {
+		t.Fatalf("ParsePublicKeyPEM: %v", err)
+	}
+	if !publicKey.Equal(signer.GetPublicKey()) {
+		t.Errorf("persisted public key differs from the signing key")
+	}
+}
+
+func TestNewSignerFromFileRejectsGarbage(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(keyPath, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSignerFromFile(keyPath); err == nil {
+		t.Errorf("NewSignerFromFile accepted a file without a PEM key")
+	}
+}
+
+func TestScanAndRepairChainRejectsOtherSigner(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 2)
+
+	// An attestation slipped in under another key is left out of the repair
+	attestTo(t, cm, chain, newTestSigner(t), "pass")
+	if err := os.Remove(cm.indexPath); err != nil {
+		t.Fatal(err)
+	}
+	cm.PublicKey = signer.GetPublicKey()
+	repaired, err := cm.ScanAndRepairChain(signer)
+	if err != nil {
+		t.Fatalf("ScanAndRepairChain: %v", err)
+	}
+	if repaired.Length != 2 {
+		t.Errorf("repaired chain has %d attestations, want the 2 signed by the pinned key", repaired.Length)
+	}
+	if anomalies := cm.VerifyEntries(repaired, 0); len(anomalies) != 0 {
+		t.Errorf("repaired chain fails verification: %v", anomalies)
+	}
+}
+
+func TestGetSigningSource(t *testing.T) {
+	hostname, _ := os.Hostname()
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"github actions", map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_RUN_ID": "9876543210", "CI": "true"}, "github-actions:9876543210"},
+		{"gitlab", map[string]string{"GITLAB_CI": "true", "CI_PIPELINE_ID": "123456", "CI": "true"}, "gitlab:123456"},
+		{"circleci", map[string]string{"CIRCLECI": "true", "CIRCLE_WORKFLOW_ID": "5f0a-77", "CI": "true"}, "circleci:5f0a-77"},
+		{"buildkite", map[string]string{"BUILDKITE": "true", "BUILDKITE_BUILD_ID": "0190-abcd", "CI": "true"}, "buildkite:0190-abcd"},
+		{"gitlab without a pipeline id", map[string]string{"GITLAB_CI": "true"}, "gitlab"},
+		{"generic ci", map[string]string{"CI": "true"}, "ci"},
+		{"not true", map[string]string{"GITLAB_CI": "1"}, "local-" + hostname},
+		{"local", nil, "local-" + hostname},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, ci := range ciSources {
+				t.Setenv(ci.env, "")
+				t.Setenv(ci.runID, "")
+			}
+			t.Setenv("CI", "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if got := getSigningSource(); got != tt.want {
+				t.Errorf("getSigningSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignAttestationRecordsSource(t *testing.T) {
+	for _, ci := range ciSources {
+		t.Setenv(ci.env, "")
+	}
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_PIPELINE_ID", "42")
+
+	signed, err := newTestSigner(t).SignAttestation(NewAttestation(nil, AttestationMetadata{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Metadata.Source != "gitlab:42" {
+		t.Errorf("signed source = %q, want gitlab:42", signed.Metadata.Source)
+	}
+}
@@ -0,0 +1,419 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// TimestampToken is an RFC 3161 time-stamp token over the DSSE envelope,
+// proving the envelope existed at Time without trusting the local clock
+type TimestampToken struct {
+	URL   string    `json:"url"`
+	Time  time.Time `json:"time"`
+	Token []byte    `json:"token"` // DER-encoded CMS SignedData from the TSA
+}
+
+var (
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidMessageDigest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECPublicKey     = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+)
+
+// RFC 3161 section 2.4.1 and 2.4.2
+type tsaRequest struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional"`
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type tsaResponse struct {
+	Status         tsaStatus
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type tsaStatus struct {
+	Status       int
+	StatusString asn1.RawValue  `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       tsaAccuracy   `asn1:"optional"`
+	Ordering       bool          `asn1:"optional"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type tsaAccuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// CMS (RFC 5652) structures of a time-stamp token
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+var tsaClient = &http.Client{Timeout: 30 * time.Second}
+
+// Timestamp sends the SHA-256 digest of the DSSE envelope to an RFC 3161
+// time-stamp authority and records the verified token in the signing
+// metadata. There is no fallback: when the TSA cannot be reached or returns an
+// unusable token the error is returned and the metadata is left unchanged.
+func Timestamp(signed *SignedAttestation, tsaURL string) error {
+	digest, err := envelopeDigest(signed)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	request, err := asn1.Marshal(tsaRequest{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode time-stamp request: %w", err)
+	}
+
+	resp, err := tsaClient.Post(tsaURL, "application/timestamp-query", bytes.NewReader(request))
+	if err != nil {
+		return fmt.Errorf("failed to reach time-stamp authority: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read time-stamp response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("time-stamp authority returned %s", resp.Status)
+	}
+
+	var reply tsaResponse
+	if _, err := asn1.Unmarshal(data, &reply); err != nil {
+		return fmt.Errorf("failed to parse time-stamp response: %w", err)
+	}
+	// 0 is granted, 1 is grantedWithMods
+	if reply.Status.Status > 1 {
+		return fmt.Errorf("time-stamp authority rejected the request (status %d)", reply.Status.Status)
+	}
+	if len(reply.TimeStampToken.FullBytes) == 0 {
+		return fmt.Errorf("time-stamp response has no token")
+	}
+
+	token := reply.TimeStampToken.FullBytes
+	info, err := verifyTimestampToken(token, digest)
+	if err != nil {
+		return err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return fmt.Errorf("time-stamp token nonce does not match the request")
+	}
+
+	signed.Metadata.TimestampToken = &TimestampToken{
+		URL:   tsaURL,
+		Time:  info.GenTime.UTC(),
+		Token: token,
+	}
+	return nil
+}
+
+// VerifyTimestamp checks that the attestation's time-stamp token covers its
+// envelope, is signed by the time-stamping certificate embedded in the token
+// and matches the recorded time. The TSA certificate is not checked against a
+// trust root, so this trusts the TSA named in the metadata.
+func VerifyTimestamp(signed *SignedAttestation) error {
+	ts := signed.Metadata.TimestampToken
+	if ts == nil {
+		return fmt.Errorf("attestation has no time-stamp token")
+	}
+
+	digest, err := envelopeDigest(signed)
+	if err != nil {
+		return err
+	}
+	info, err := verifyTimestampToken(ts.Token, digest)
+	if err != nil {
+		return err
+	}
+	if !info.GenTime.Equal(ts.Time) {
+		return fmt.Errorf("time-stamp token time %s does not match recorded time %s",
+			info.GenTime.UTC().Format(time.RFC3339), ts.Time.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// envelopeDigest is the SHA-256 of the serialized DSSE envelope, which covers
+// both the payload and its signatures
+func envelopeDigest(signed *SignedAttestation) ([]byte, error) {
+	envelope, err := json.Marshal(signed.Envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize DSSE envelope: %w", err)
+	}
+	hash := sha256.Sum256(envelope)
+	return hash[:], nil
+}
+
+// verifyTimestampToken checks the CMS signature of a time-stamp token and that
+// its message imprint is the given SHA-256 digest
+func verifyTimestampToken(token, digest []byte) (*tstInfo, error) {
+	var content contentInfo
+	if _, err := asn1.Unmarshal(token, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse time-stamp token: %w", err)
+	}
+	if !content.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("time-stamp token is not CMS signed data")
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(content.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("failed to parse time-stamp signed data: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) || len(sd.EncapContentInfo.EContent) == 0 {
+		return nil, fmt.Errorf("time-stamp token does not contain TSTInfo")
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("time-stamp token has %d signers, expected 1", len(sd.SignerInfos))
+	}
+
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse TSTInfo: %w", err)
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, fmt.Errorf("time-stamp token was issued for a different envelope")
+	}
+
+	certificates, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse time-stamp certificates: %w", err)
+	}
+	signer := sd.SignerInfos[0]
+	certificate := findSignerCertificate(certificates, signer.SID)
+	if certificate == nil {
+		return nil, fmt.Errorf("time-stamp token does not include its signing certificate")
+	}
+	if !hasTimeStampingUsage(certificate) {
+		return nil, fmt.Errorf("time-stamp certificate %s is not valid for time stamping", certificate.Subject)
+	}
+	if info.GenTime.Before(certificate.NotBefore) || info.GenTime.After(certificate.NotAfter) {
+		return nil, fmt.Errorf("time-stamp certificate %s was not valid at %s", certificate.Subject, info.GenTime.UTC().Format(time.RFC3339))
+	}
+
+	if err := verifySignerInfo(signer, certificate, sd.EncapContentInfo.EContent); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// verifySignerInfo checks the message-digest attribute against the TSTInfo
+// and the signature over the DER-encoded signed attributes
+func verifySignerInfo(signer signerInfo, certificate *x509.Certificate, content []byte) error {
+	hash, ok := digestHash(signer.DigestAlgorithm.Algorithm)
+	if !ok {
+		return fmt.Errorf("unsupported time-stamp digest algorithm %s", signer.DigestAlgorithm.Algorithm)
+	}
+	if len(signer.SignedAttrs.FullBytes) == 0 {
+		return fmt.Errorf("time-stamp token has no signed attributes")
+	}
+
+	// Signed attributes are signed as a SET OF, not with their [0] IMPLICIT tag
+	attrsDER := append([]byte{0x31}, signer.SignedAttrs.FullBytes[1:]...)
+	var attrs []cmsAttribute
+	if _, err := asn1.UnmarshalWithParams(attrsDER, &attrs, "set"); err != nil {
+		return fmt.Errorf("failed to parse time-stamp signed attributes: %w", err)
+	}
+
+	h := hash.New()
+	h.Write(content)
+	var messageDigest []byte
+	for _, attr := range attrs {
+		if attr.Type.Equal(oidMessageDigest) {
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &messageDigest); err != nil {
+				return fmt.Errorf("failed to parse time-stamp message digest: %w", err)
+			}
+		}
+	}
+	if !bytes.Equal(messageDigest, h.Sum(nil)) {
+		return fmt.Errorf("time-stamp message digest does not match TSTInfo")
+	}
+
+	algorithm, ok := signatureAlgorithm(signer.SignatureAlgorithm.Algorithm, hash)
+	if !ok {
+		return fmt.Errorf("unsupported time-stamp signature algorithm %s", signer.SignatureAlgorithm.Algorithm)
+	}
+	if err := certificate.CheckSignature(algorithm, attrsDER, signer.Signature); err != nil {
+		return fmt.Errorf("time-stamp signature verification failed: %w", err)
+	}
+	return nil
+}
+
+// findSignerCertificate matches a signer identifier, either issuer and serial
+// number or a [0] subject key identifier, against the embedded certificates
+func findSignerCertificate(certificates []*x509.Certificate, sid asn1.RawValue) *x509.Certificate {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, certificate := range certificates {
+			if bytes.Equal(certificate.SubjectKeyId, sid.Bytes) {
+				return certificate
+			}
+		}
+		return nil
+	}
+
+	var id issuerAndSerial
+	if _, err := asn1.Unmarshal(sid.FullBytes, &id); err != nil {
+		return nil
+	}
+	for _, certificate := range certificates {
+		if bytes.Equal(certificate.RawIssuer, id.Issuer.FullBytes) && certificate.SerialNumber.Cmp(id.Serial) == 0 {
+			return certificate
+		}
+	}
+	return nil
+}
+
+func hasTimeStampingUsage(certificate *x509.Certificate) bool {
+	for _, usage := range certificate.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageTimeStamping {
+			return true
+		}
+	}
+	return false
+}
+
+func digestHash(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, true
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, true
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// signatureAlgorithm resolves a CMS signature algorithm, which TSAs often give
+// as the bare key type with the hash taken from the digest algorithm
+func signatureAlgorithm(oid asn1.ObjectIdentifier, hash crypto.Hash) (x509.SignatureAlgorithm, bool) {
+	switch {
+	case oid.Equal(oidSHA256WithRSA):
+		return x509.SHA256WithRSA, true
+	case oid.Equal(oidSHA384WithRSA):
+		return x509.SHA384WithRSA, true
+	case oid.Equal(oidSHA512WithRSA):
+		return x509.SHA512WithRSA, true
+	case oid.Equal(oidECDSAWithSHA256):
+		return x509.ECDSAWithSHA256, true
+	case oid.Equal(oidECDSAWithSHA384):
+		return x509.ECDSAWithSHA384, true
+	case oid.Equal(oidECDSAWithSHA512):
+		return x509.ECDSAWithSHA512, true
+	case oid.Equal(oidRSAEncryption):
+		return map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA256: x509.SHA256WithRSA,
+			crypto.SHA384: x509.SHA384WithRSA,
+			crypto.SHA512: x509.SHA512WithRSA,
+		}[hash], true
+	case oid.Equal(oidECPublicKey):
+		return map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA256: x509.ECDSAWithSHA256,
+			crypto.SHA384: x509.ECDSAWithSHA384,
+			crypto.SHA512: x509.ECDSAWithSHA512,
+		}[hash], true
+	}
+	return x509.UnknownSignatureAlgorithm, false
+}
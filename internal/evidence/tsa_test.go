@@ -0,0 +1,261 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockTSA is an RFC 3161 responder signing TSTInfo with an ECDSA key. Fields
+// bend its replies so tests can check how bad tokens are handled.
+type mockTSA struct {
+	key         *ecdsa.PrivateKey
+	certificate *x509.Certificate
+	status      int      // PKIStatus to reply with
+	nonce       *big.Int // replaces the request nonce when set
+}
+
+// newMockTSA starts a TSA whose certificate has the given extended key usages
+func newMockTSA(t *testing.T, usages ...x509.ExtKeyUsage) (*mockTSA, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Mock TSA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  usages,
+		SubjectKeyId: []byte{1, 2, 3, 4},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tsa := &mockTSA{key: key, certificate: certificate}
+	server := httptest.NewServer(tsa)
+	t.Cleanup(server.Close)
+	return tsa, server.URL
+}
+
+func (m *mockTSA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var request tsaRequest
+	if _, err := asn1.Unmarshal(body, &request); err != nil || r.Header.Get("Content-Type") != "application/timestamp-query" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if m.status != 0 {
+		data, _ := asn1.Marshal(tsaResponse{Status: tsaStatus{Status: m.status}})
+		w.Write(data)
+		return
+	}
+
+	nonce := request.Nonce
+	if m.nonce != nil {
+		nonce = m.nonce
+	}
+	token, err := m.token(request.MessageImprint, nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, _ := asn1.Marshal(tsaResponse{TimeStampToken: asn1.RawValue{FullBytes: token}})
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(data)
+}
+
+// token builds a CMS SignedData time-stamp token over TSTInfo
+func (m *mockTSA) token(imprint messageImprint, nonce *big.Int) ([]byte, error) {
+	info, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(time.Now().UnixNano()),
+		GenTime:        time.Now().UTC().Truncate(time.Second),
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	infoDigest := sha256.Sum256(info)
+	digestValue, err := asn1.Marshal(infoDigest[:])
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := asn1.MarshalWithParams([]cmsAttribute{{
+		Type:   oidMessageDigest,
+		Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: digestValue},
+	}}, "set")
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(attrs)
+	signature, err := ecdsa.SignASN1(rand.Reader, m.key, attrsDigest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// Signed attributes travel with a [0] IMPLICIT tag in place of SET OF
+	signedAttrs := append([]byte{0xa0}, attrs[1:]...)
+	digestAlgorithms, err := asn1.MarshalWithParams([]pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}}, "set")
+	if err != nil {
+		return nil, err
+	}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: asn1.RawValue{FullBytes: digestAlgorithms},
+		EncapContentInfo: encapContentInfo{EContentType: oidTSTInfo, EContent: info},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: m.certificate.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            3,
+			SID:                asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: m.certificate.SubjectKeyId},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{FullBytes: signedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          signature,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+func newTestSignedAttestation(t *testing.T) (*SignedAttestation, *Signer) {
+	t.Helper()
+	signer := newTestSigner(t)
+	signed, err := signer.SignAttestation(NewAttestation(nil, AttestationMetadata{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed, signer
+}
+
+func TestTimestamp(t *testing.T) {
+	_, tsaURL := newMockTSA(t, x509.ExtKeyUsageTimeStamping)
+	signed, signer := newTestSignedAttestation(t)
+
+	before := time.Now().Add(-time.Second)
+	if err := Timestamp(signed, tsaURL); err != nil {
+		t.Fatal(err)
+	}
+	token := signed.Metadata.TimestampToken
+	if token == nil || token.URL != tsaURL || len(token.Token) == 0 {
+		t.Fatalf("token = %+v, want one from %s", token, tsaURL)
+	}
+	if token.Time.Before(before) || token.Time.After(time.Now()) {
+		t.Errorf("token time %s is not the time of the request", token.Time)
+	}
+	if err := VerifyTimestamp(signed); err != nil {
+		t.Errorf("VerifyTimestamp: %v", err)
+	}
+	if err := VerifySignedAttestation(signed, signer.GetPublicKey()); err != nil {
+		t.Errorf("time-stamped attestation did not verify: %v", err)
+	}
+}
+
+func TestVerifyTimestampDetectsTampering(t *testing.T) {
+	_, tsaURL := newMockTSA(t, x509.ExtKeyUsageTimeStamping)
+
+	tests := map[string]func(*SignedAttestation){
+		"envelope signature changed": func(signed *SignedAttestation) {
+			signed.Envelope.Signatures[0].Sig = signed.Envelope.Signatures[0].Sig[1:]
+		},
+		"recorded time moved": func(signed *SignedAttestation) {
+			signed.Metadata.TimestampToken.Time = signed.Metadata.TimestampToken.Time.Add(-24 * time.Hour)
+		},
+		"token corrupted": func(signed *SignedAttestation) {
+			signed.Metadata.TimestampToken.Token[len(signed.Metadata.TimestampToken.Token)-1] ^= 0xff
+		},
+		"token removed": func(signed *SignedAttestation) {
+			signed.Metadata.TimestampToken = nil
+		},
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			signed, _ := newTestSignedAttestation(t)
+			if err := Timestamp(signed, tsaURL); err != nil {
+				t.Fatal(err)
+			}
+			tamper(signed)
+			if err := VerifyTimestamp(signed); err == nil {
+				t.Error("tampered time-stamp verified")
+			}
+		})
+	}
+}
+
+func TestTimestampFailures(t *testing.T) {
+	rejecting, rejectingURL := newMockTSA(t, x509.ExtKeyUsageTimeStamping)
+	rejecting.status = 2
+	replaying, replayingURL := newMockTSA(t, x509.ExtKeyUsageTimeStamping)
+	replaying.nonce = big.NewInt(42)
+	_, codeSigningURL := newMockTSA(t, x509.ExtKeyUsageCodeSigning)
+	overloaded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "try later", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(overloaded.Close)
+	unavailable := httptest.NewServer(http.NotFoundHandler())
+	unavailable.Close()
+
+	tests := []struct {
+		name, url, want string
+	}{
+		{"unreachable", unavailable.URL, "failed to reach time-stamp authority"},
+		{"unavailable", overloaded.URL, "returned 503 Service Unavailable"},
+		{"rejected", rejectingURL, "rejected the request (status 2)"},
+		{"nonce mismatch", replayingURL, "nonce does not match"},
+		{"not a time-stamping certificate", codeSigningURL, "not valid for time stamping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, _ := newTestSignedAttestation(t)
+			err := Timestamp(signed, tt.url)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+			if signed.Metadata.TimestampToken != nil {
+				t.Error("failed time-stamp left a token in the metadata")
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"crypto/ecdh"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChainAnomaly describes a problem found while verifying a single chain entry
+type ChainAnomaly struct {
+	Position int    `json:"position"`
+	Hash     string `json:"hash"`
+	FilePath string `json:"filePath"`
+	Problem  string `json:"problem"`
+}
+
+func (a ChainAnomaly) Error() string {
+	return fmt.Sprintf("entry %d (%s): %s", a.Position, a.FilePath, a.Problem)
+}
+
+// VerifyEntries re-reads every attestation referenced by the chain, recomputes
+// its hash and checks its signature. Entries are independent, so they are
+// verified by a pool of workers (runtime.NumCPU() when workers <= 0).
+// Anomalies are returned ordered by chain position regardless of scheduling.
+// Chain linkage is checked separately by VerifyChain.
+func (cm *ChainManager) VerifyEntries(chain *EvidenceChain, workers int) []ChainAnomaly {
+	return cm.VerifyEntriesSince(chain, time.Time{}, workers)
+}
+
+// VerifyEntriesSince is VerifyEntries for just the attestations made at or
+// after since, so reviewing recent activity doesn't re-read a long chain's
+// whole history. A zero since verifies every entry.
+func (cm *ChainManager) VerifyEntriesSince(chain *EvidenceChain, since time.Time, workers int) []ChainAnomaly {
+	var selected []int
+	for i, entry := range chain.Attestations {
+		if !entry.Timestamp.Before(since) {
+			selected = append(selected, i)
+		}
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(selected) {
+		workers = len(selected)
+	}
+
+	problems := make([]error, len(chain.Attestations))
+	positions := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range positions {
+				problems[i] = cm.verifyEntry(chain.Attestations[i])
+			}
+		}()
+	}
+
+	for _, i := range selected {
+		positions <- i
+	}
+	close(positions)
+	wg.Wait()
+
+	var anomalies []ChainAnomaly
+	for i, err := range problems {
+		if err == nil {
+			continue
+		}
+		entry := chain.Attestations[i]
+		anomalies = append(anomalies, ChainAnomaly{
+			Position: i,
+			Hash:     entry.Hash,
+			FilePath: entry.FilePath,
+			Problem:  err.Error(),
+		})
+	}
+
+	return anomalies
+}
+
+// verifyEntry checks that an attestation file matches its chain entry
+func (cm *ChainManager) verifyEntry(entry ChainEntry) error {
+	attestation, signed, err := cm.LoadAttestation(entry.FilePath)
+	if err != nil {
+		return err
+	}
+	return cm.checkEntry(entry, attestation, signed)
+}
+
+// verifyEntryData is verifyEntry for attestation file content already read
+func (cm *ChainManager) verifyEntryData(entry ChainEntry, data []byte) error {
+	attestation, signed, err := parseAttestationData(data, cm.DecryptionKey)
+	if err != nil {
+		return err
+	}
+	return cm.checkEntry(entry, attestation, signed)
+}
+
+// checkEntry checks a loaded attestation against its chain entry and signature
+func (cm *ChainManager) checkEntry(entry ChainEntry, attestation *Attestation, signed *SignedAttestation) error {
+	if attestation.Hash != entry.Hash {
+		return fmt.Errorf("attestation hash %s does not match chain entry", shortHash(attestation.Hash))
+	}
+
+	if recomputed := attestation.calculateHash(); recomputed != entry.Hash {
+		return fmt.Errorf("attestation content has been modified: recomputed hash %s", shortHash(recomputed))
+	}
+
+	if attestation.ParentHash != entry.ParentHash {
+		return fmt.Errorf("attestation parent hash does not match chain entry")
+	}
+
+	return cm.checkSignature(signed)
+}
+
+// checkSignature verifies a signed envelope against PublicKey, else the one
+// of TrustedKeys with its key ID, else the key it records. With PublicKey or
+// TrustedKeys set, unsigned attestations fail.
+func (cm *ChainManager) checkSignature(signed *SignedAttestation) error {
+	if signed == nil {
+		if cm.PublicKey != nil || len(cm.TrustedKeys) > 0 {
+			return fmt.Errorf("attestation is unsigned")
+		}
+		return nil
+	}
+
+	key := cm.PublicKey
+	if key == nil && len(cm.TrustedKeys) > 0 {
+		for _, trusted := range cm.TrustedKeys {
+			if keyIDFor(trusted) == signed.Metadata.KeyID {
+				key = trusted
+				break
+			}
+		}
+		if key == nil {
+			return fmt.Errorf("signature verification failed: signing key %s is not trusted", signed.Metadata.KeyID)
+		}
+	}
+	if err := VerifySignedAttestation(signed, key); err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+	return nil
+}
+
+// LoadAttestation reads an attestation file relative to the evidence directory,
+// decrypting it with DecryptionKey when it is encrypted at rest. The signed
+// envelope is returned as well when the file is DSSE-signed.
+func (cm *ChainManager) LoadAttestation(filePath string) (*Attestation, *SignedAttestation, error) {
+	return readAttestationFile(filepath.Join(cm.evidenceDir, filePath), cm.DecryptionKey)
+}
+
+// isEncrypted reports whether an attestation file is encrypted at rest
+func (cm *ChainManager) isEncrypted(filePath string) bool {
+	data, err := os.ReadFile(filepath.Join(cm.evidenceDir, filePath))
+	return err == nil && parseEncryptedAttestation(data) != nil
+}
+
+// LoadAttestationFile reads an attestation file at any path, for example one
+// copied out of another repository's chain. Its content hash and, when signed,
+// its signature must verify; chain linkage cannot be checked without the chain.
+// key decrypts the file when it is encrypted at rest.
+func LoadAttestationFile(path string, key *ecdh.PrivateKey) (*Attestation, error) {
+	attestation, signed, err := readAttestationFile(path, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAttestation(attestation, signed); err != nil {
+		return nil, err
+	}
+	
+	return attestation, nil
+}
+
+// ParseSignedAttestation parses a DSSE-signed attestation as written by
+// 'mondrian attest', for example one received over the network. Its content
+// hash and signature must verify; unsigned and encrypted attestations are
+// refused.
+func ParseSignedAttestation(data []byte) (*Attestation, *SignedAttestation, error) {
+	var signed SignedAttestation
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse signed attestation: %w", err)
+	}
+	if signed.Envelope.Payload == "" || len(signed.Envelope.Signatures) == 0 {
+		return nil, nil, fmt.Errorf("not a signed attestation envelope")
+	}
+	
+	attestation, err := decodeSignedPayload(&signed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkAttestation(attestation, &signed); err != nil {
+		return nil, nil, err
+	}
+	
+	return attestation, &signed, nil
+}
+
+// checkAttestation checks an attestation against its own content hash and,
+// when signed, the key recorded in its signing metadata
+func checkAttestation(attestation *Attestation, signed *SignedAttestation) error {
+	if recomputed := attestation.calculateHash(); recomputed != attestation.Hash {
+		return fmt.Errorf("attestation content has been modified: recomputed hash %s", shortHash(recomputed))
+	}
+	if signed != nil {
+		if err := VerifySignedAttestation(signed, nil); err != nil {
+			return fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func readAttestationFile(fullPath string, key *ecdh.PrivateKey) (*Attestation, *SignedAttestation, error) {
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("attestation file missing: %s", fullPath)
+		}
+		return nil, nil, fmt.Errorf("failed to read attestation file: %w", err)
+	}
+	return parseAttestationData(data, key)
+}
+
+// parseAttestationData parses the content of an attestation file, decrypting
+// it with key when it is encrypted at rest
+func parseAttestationData(data []byte, key *ecdh.PrivateKey) (*Attestation, *SignedAttestation, error) {
+	var err error
+	if encrypted := parseEncryptedAttestation(data); encrypted != nil {
+		if data, err = decryptAttestation(encrypted, key); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var signed SignedAttestation
+	if err := json.Unmarshal(data, &signed); err == nil && signed.Envelope.Payload != "" {
+		attestation, err := decodeSignedPayload(&signed)
+		if err != nil {
+			return nil, nil, err
+		}
+		return attestation, &signed, nil
+	}
+
+	var attestation Attestation
+	if err := json.Unmarshal(data, &attestation); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse attestation file: %w", err)
+	}
+
+	return &attestation, nil, nil
+}
+
+// decodeSignedPayload decodes the attestation carried in a DSSE envelope
+func decodeSignedPayload(signed *SignedAttestation) (*Attestation, error) {
+	payload, err := signed.Envelope.DecodeB64Payload()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode envelope payload: %w", err)
+	}
+	var attestation Attestation
+	if err := json.Unmarshal(payload, &attestation); err != nil {
+		return nil, fmt.Errorf("failed to parse signed attestation payload: %w", err)
+	}
+	return &attestation, nil
+}
+
+// FindAttestation loads the attestation with the given hash, or unique hash
+// prefix, after verifying it still matches its chain entry
+func (cm *ChainManager) FindAttestation(chain *EvidenceChain, hash string) (*Attestation, error) {
+	if hash == "" {
+		return nil, fmt.Errorf("attestation hash is required")
+	}
+
+	var match *ChainEntry
+	for i, entry := range chain.Attestations {
+		if strings.HasPrefix(entry.Hash, hash) {
+			if match != nil {
+				return nil, fmt.Errorf("attestation hash prefix %s is ambiguous", hash)
+			}
+			match = &chain.Attestations[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no attestation with hash %s in chain", hash)
+	}
+
+	if err := cm.verifyEntry(*match); err != nil {
+		return nil, fmt.Errorf("attestation %s failed verification: %w", shortHash(match.Hash), err)
+	}
+
+	attestation, _, err := cm.LoadAttestation(match.FilePath)
+	return attestation, err
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 16 {
+		return hash[:16] + "..."
+	}
+	return hash
+}
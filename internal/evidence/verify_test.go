@@ -0,0 +1,231 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evidence
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+func TestVerifyEntriesValidChain(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 3)
+	cm.PublicKey = signer.GetPublicKey()
+
+	if anomalies := cm.VerifyEntries(chain, 0); len(anomalies) != 0 {
+		t.Errorf("VerifyEntries reported %v on an untouched chain", anomalies)
+	}
+}
+
+func TestVerifyEntriesPinnedKeyRejectsOtherSigner(t *testing.T) {
+	cm, chain := newTestChain(t, newTestSigner(t), 2)
+
+	// The chain verifies against the keys it embeds, but not a pinned key
+	if anomalies := cm.VerifyEntries(chain, 0); len(anomalies) != 0 {
+		t.Fatalf("VerifyEntries with embedded keys reported %v", anomalies)
+	}
+	cm.PublicKey = newTestSigner(t).GetPublicKey()
+	anomalies := cm.VerifyEntries(chain, 0)
+	if len(anomalies) != 2 {
+		t.Fatalf("got %d anomalies with another pinned key, want 2", len(anomalies))
+	}
+	if !strings.Contains(anomalies[0].Problem, "signature verification failed") {
+		t.Errorf("problem = %q, want a signature failure", anomalies[0].Problem)
+	}
+}
+
+func TestVerifyEntriesPinnedKeyRejectsUnsigned(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 1)
+
+	attestation := NewAttestation([]policy.CheckResult{{RuleName: "r", Status: "pass"}}, AttestationMetadata{ParentHash: chain.Head})
+	path := filepath.Join(cm.evidenceDir, "unsigned.json")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.AddAttestation(chain, attestation, "unsigned.json"); err != nil {
+		t.Fatal(err)
+	}
+	// Written after AddAttestation, which relinks the attestation
+	data, err := json.Marshal(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cm.PublicKey = signer.GetPublicKey()
+	anomalies := cm.VerifyEntries(chain, 0)
+	if len(anomalies) != 1 || anomalies[0].Position != 1 || anomalies[0].Problem != "attestation is unsigned" {
+		t.Errorf("anomalies = %v, want the unsigned entry at position 1", anomalies)
+	}
+}
+
+func TestVerifyEntriesDetectsModifiedAttestation(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 3)
+	cm.PublicKey = signer.GetPublicKey()
+
+	tamperAttestation(t, cm, chain.Attestations[1])
+
+	anomalies := cm.VerifyEntries(chain, 0)
+	if len(anomalies) != 1 || anomalies[0].Position != 1 {
+		t.Fatalf("anomalies = %v, want only position 1", anomalies)
+	}
+}
+
+func TestVerifyEntriesNamesMissingFile(t *testing.T) {
+	cm, chain := newTestChain(t, newTestSigner(t), 2)
+
+	missing := filepath.Join(cm.evidenceDir, chain.Attestations[0].FilePath)
+	if err := os.Remove(missing); err != nil {
+		t.Fatal(err)
+	}
+
+	anomalies := cm.VerifyEntries(chain, 0)
+	if len(anomalies) != 1 || !strings.Contains(anomalies[0].Problem, missing) {
+		t.Errorf("anomalies = %v, want one naming %s", anomalies, missing)
+	}
+}
+
+func TestVerifyEntriesParallelAgreesWithSequential(t *testing.T) {
+	signer := newTestSigner(t)
+	cm, chain := newTestChain(t, signer, 12)
+	cm.PublicKey = signer.GetPublicKey()
+
+	tamperAttestation(t, cm, chain.Attestations[2])
+	tamperAttestation(t, cm, chain.Attestations[7])
+	if err := os.Remove(filepath.Join(cm.evidenceDir, chain.Attestations[9].FilePath)); err != nil {
+		t.Fatal(err)
+	}
+
+	sequential := cm.VerifyEntries(chain, 1)
+	if len(sequential) != 3 {
+		t.Fatalf("sequential verification reported %v, want 3 anomalies", sequential)
+	}
+	for _, workers := range []int{2, 4, 0} {
+		parallel := cm.VerifyEntries(chain, workers)
+		if !reflect.DeepEqual(parallel, sequential) {
+			t.Errorf("%d workers reported %v, want %v", workers, parallel, sequential)
+		}
+	}
+}
+
+func BenchmarkVerifyEntries(b *testing.B) {
+	signer := newTestSigner(b)
+	cm, chain := newLongTestChain(b, signer, 5000)
+	cm.PublicKey = signer.GetPublicKey()
+
+	for _, workers := range []int{1, 0} {
+		name := "sequential"
+		if workers == 0 {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if anomalies := cm.VerifyEntries(chain, workers); len(anomalies) != 0 {
+					b.Fatal(anomalies)
+				}
+			}
+		})
+	}
+}
+
+// newLongTestChain is newTestChain for chains too long to save after every
+// append. The chain is saved once, with no index.
+func newLongTestChain(b *testing.B, signer *Signer, n int) (*ChainManager, *EvidenceChain) {
+	b.Helper()
+	cm := NewChainManager(b.TempDir())
+	chain, err := cm.LoadOrCreateChain()
+	if err != nil {
+		b.Fatal(err)
+	}
+	results := []policy.CheckResult{{RuleName: "s3-public-read", Status: "pass", Message: "test"}}
+	for i := 0; i < n; i++ {
+		attestation := NewAttestation(results, AttestationMetadata{ParentHash: chain.Head})
+		signed, err := signer.SignAttestation(attestation)
+		if err != nil {
+			b.Fatal(err)
+		}
+		// SaveSignedAttestation names files by the second, so name them here
+		data, err := json.Marshal(signed)
+		if err != nil {
+			b.Fatal(err)
+		}
+		filename := fmt.Sprintf("attestation-%05d.json", i)
+		if err := os.WriteFile(filepath.Join(cm.evidenceDir, filename), data, 0644); err != nil {
+			b.Fatal(err)
+		}
+		if chain.Length == 0 {
+			chain.Genesis = attestation.Hash
+		}
+		chain.Attestations = append(chain.Attestations, ChainEntry{
+			Hash:       attestation.Hash,
+			ParentHash: attestation.ParentHash,
+			Timestamp:  attestation.Timestamp,
+			RunID:      attestation.RunID,
+			Status:     attestation.Predicate.Summary.OverallStatus,
+			FilePath:   filename,
+		})
+		chain.Length++
+		chain.Head = attestation.Hash
+	}
+	if err := cm.SaveChain(chain); err != nil {
+		b.Fatal(err)
+	}
+	return cm, chain
+}
+
+// tamperAttestation rewrites the signed payload of an entry's attestation file
+// with a different status, leaving the signature and stored hash alone
+func tamperAttestation(t testing.TB, cm *ChainManager, entry ChainEntry) {
+	t.Helper()
+	path := filepath.Join(cm.evidenceDir, entry.FilePath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signed SignedAttestation
+	if err := json.Unmarshal(data, &signed); err != nil {
+		t.Fatal(err)
+	}
+	attestation, err := decodeSignedPayload(&signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attestation.Predicate.Summary.OverallStatus = "tampered"
+	payload, err := json.Marshal(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed.Envelope.Payload = base64.StdEncoding.EncodeToString(payload)
+	if data, err = json.MarshalIndent(&signed, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+)
+
+// Allowlist records accepted findings for specific Terraform resources, set
+// by the allowlist key of mondrian.yaml
+type Allowlist struct {
+	Entries []AllowlistEntry
+}
+
+// AllowlistEntry accepts findings of a rule on one resource address, e.g.
+//
+//	allowlist:
+//	  - rule: s3-no-public-buckets
+//	    resource: aws_s3_bucket.website
+//	    reason: Static website, served publicly by design
+//
+// An empty rule matches every rule.
+type AllowlistEntry struct {
+	Rule     string `yaml:"rule"`
+	Resource string `yaml:"resource"`
+	Reason   string `yaml:"reason"`
+}
+
+// Match returns the entry accepting the result, if any. Only findings that
+// report a resource address in Metadata["resource"] can be allowlisted.
+func (a *Allowlist) Match(result CheckResult) (AllowlistEntry, bool) {
+	if a == nil || result.Status == "pass" {
+		return AllowlistEntry{}, false
+	}
+
+	resource, _ := result.Metadata["resource"].(string)
+	if resource == "" {
+		return AllowlistEntry{}, false
+	}
+
+	for _, entry := range a.Entries {
+		if entry.Resource == resource && (entry.Rule == "" || entry.Rule == result.RuleName) {
+			return entry, true
+		}
+	}
+	return AllowlistEntry{}, false
+}
+
+// Apply marks allowlisted findings as passing. They are kept rather than
+// dropped so attestations still record which exceptions were applied.
+func (a *Allowlist) Apply(results []CheckResult) []CheckResult {
+	for i, result := range results {
+		entry, ok := a.Match(result)
+		if !ok {
+			continue
+		}
+
+		metadata := make(map[string]interface{}, len(result.Metadata)+2)
+		for k, v := range result.Metadata {
+			metadata[k] = v
+		}
+		metadata["allowlisted"] = true
+		metadata["original_status"] = result.Status
+		if entry.Reason != "" {
+			metadata["allowlist_reason"] = entry.Reason
+		}
+
+		results[i].Status = "pass"
+		results[i].Message = fmt.Sprintf("Allowlisted: %s (%s)", result.Message, entry.Resource)
+		results[i].Metadata = metadata
+	}
+	return results
+}
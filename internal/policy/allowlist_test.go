@@ -0,0 +1,111 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig saves content as the mondrian.yaml of a new directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAllowlistOneBucketStillFlagsAnother(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, `rules:
+  - s3-no-public-buckets
+allowlist:
+  - rule: s3-no-public-buckets
+    resource: aws_s3_bucket.website
+    reason: Static website, served publicly by design
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewPolicyEngineFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := `resource "aws_s3_bucket" "website" {
+  acl = "public-read"
+}
+
+resource "aws_s3_bucket" "uploads" {
+  acl = "public-read"
+}
+`
+	results, err := engine.RunChecks(context.Background(), map[string]string{"main.tf": content})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byResource := make(map[string]CheckResult)
+	for _, result := range results {
+		if resource, ok := result.Metadata["resource"].(string); ok {
+			byResource[resource] = result
+		}
+	}
+	website := byResource["aws_s3_bucket.website"]
+	if website.Status != "pass" || website.Metadata["allowlisted"] != true || website.Metadata["allowlist_reason"] != "Static website, served publicly by design" {
+		t.Errorf("website bucket = %+v, want an allowlisted pass with its reason", website)
+	}
+	if website.Metadata["original_status"] != "fail" || !strings.HasPrefix(website.Message, "Allowlisted: ") {
+		t.Errorf("website bucket = %+v, want the original finding recorded", website)
+	}
+	if uploads := byResource["aws_s3_bucket.uploads"]; uploads.Status != "fail" {
+		t.Errorf("uploads bucket = %+v, want it still flagged", uploads)
+	}
+}
+
+func TestAllowlistEntryWithoutRuleMatchesEveryRule(t *testing.T) {
+	allowlist := &Allowlist{Entries: []AllowlistEntry{{Resource: "aws_security_group.bastion"}}}
+	finding := CheckResult{RuleName: "sg-no-open-ingress", Status: "fail", Metadata: map[string]interface{}{"resource": "aws_security_group.bastion"}}
+
+	if _, ok := allowlist.Match(finding); !ok {
+		t.Errorf("entry without a rule did not match %s", finding.RuleName)
+	}
+	// Findings without a resource address can't be allowlisted
+	finding.Metadata = nil
+	if _, ok := allowlist.Match(finding); ok {
+		t.Errorf("finding without a resource address matched")
+	}
+}
+
+func TestLoadConfigRejectsInvalidAllowlist(t *testing.T) {
+	tests := map[string]string{
+		"missing resource": "allowlist:\n  - rule: s3-no-public-buckets\n",
+		"unknown rule":     "allowlist:\n  - rule: s3-no-such-rule\n    resource: aws_s3_bucket.website\n",
+		"unknown key":      "allowlist:\n  - resource: aws_s3_bucket.website\n    until: 2026-01-01\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+				t.Errorf("LoadConfig accepted %q", content)
+			}
+		})
+	}
+}
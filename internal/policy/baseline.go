@@ -0,0 +1,251 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ApplyBaseline marks findings already present in the baseline as passing, so
+// only new findings fail the run. Known findings are kept with their original
+// status in Metadata. It returns the updated results and the number of known findings.
+func ApplyBaseline(results, baseline []CheckResult) ([]CheckResult, int) {
+	known := make(map[string]bool)
+	for _, result := range baseline {
+		if result.Status != "pass" {
+			known[result.fingerprint()] = true
+		}
+	}
+
+	count := 0
+	for i, result := range results {
+		if result.Status == "pass" || !known[result.fingerprint()] {
+			continue
+		}
+		results[i] = markKnown(result)
+		count++
+	}
+
+	return results, count
+}
+
+// Regressions returns the findings in results that are new or worse than in
+// baseline: absent from it, or recorded there with a lower severity. Statuses
+// aren't compared since they follow each run's fail_on threshold. A run
+// without regressions is as good as the baseline or better, however many of
+// its findings remain.
+func Regressions(results, baseline []CheckResult) []CheckResult {
+	known := make(map[string]CheckResult)
+	for _, result := range baseline {
+		if result.Status != "pass" {
+			known[result.fingerprint()] = result
+		}
+	}
+
+	var regressions []CheckResult
+	for _, result := range results {
+		if result.Status == "pass" {
+			continue
+		}
+		before, ok := known[result.fingerprint()]
+		if !ok || severityRank(resultSeverity(result)) > severityRank(resultSeverity(before)) {
+			regressions = append(regressions, result)
+		}
+	}
+	return regressions
+}
+
+// markKnown turns a finding into a pass, keeping its original status in Metadata
+func markKnown(result CheckResult) CheckResult {
+	metadata := make(map[string]interface{}, len(result.Metadata)+2)
+	for k, v := range result.Metadata {
+		metadata[k] = v
+	}
+	metadata["baseline"] = true
+	metadata["original_status"] = result.Status
+
+	result.Status = "pass"
+	result.Message = "Known (baseline): " + result.Message
+	result.Metadata = metadata
+	return result
+}
+
+// BaselineFileName is the suppression file, under .mondrian, that
+// 'mondrian check --update-baseline' writes
+const BaselineFileName = "baseline.json"
+
+// BaselineFile records findings accepted when Mondrian was adopted, so that
+// only new findings fail later runs
+type BaselineFile struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is an accepted finding. Fingerprint matches the finding
+// exactly; DriftKey, from the rule, file and message with numbers and
+// whitespace normalized, still matches when the finding moves or its line
+// content is reformatted. A finding whose severity rose above Severity is
+// reported again.
+type BaselineEntry struct {
+	RuleID      string `json:"ruleId"`
+	Severity    string `json:"severity"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint"`
+	DriftKey    string `json:"driftKey"`
+}
+
+// NewBaselineFile records every finding in results
+func NewBaselineFile(results []CheckResult) *BaselineFile {
+	baseline := &BaselineFile{Version: 1, Findings: []BaselineEntry{}}
+	for _, result := range results {
+		if result.Status == "pass" {
+			continue
+		}
+		baseline.Findings = append(baseline.Findings, BaselineEntry{
+			RuleID:      result.RuleName,
+			Severity:    resultSeverity(result),
+			File:        result.File,
+			Line:        result.Line,
+			Message:     result.Message,
+			Fingerprint: result.fingerprint(),
+			DriftKey:    driftKey(result),
+		})
+	}
+	sort.Slice(baseline.Findings, func(i, j int) bool {
+		a, b := baseline.Findings[i], baseline.Findings[j]
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return baseline
+}
+
+// LoadBaselineFile reads a baseline file. A missing file yields nil.
+func LoadBaselineFile(path string) (*BaselineFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var baseline BaselineFile
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	for i, entry := range baseline.Findings {
+		if entry.RuleID == "" || (entry.Fingerprint == "" && entry.DriftKey == "") {
+			return nil, fmt.Errorf("baseline entry %d needs a ruleId and a fingerprint or driftKey", i)
+		}
+	}
+	return &baseline, nil
+}
+
+// Save writes the baseline file
+func (b *BaselineFile) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize baseline: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Apply marks findings recorded in the baseline as passing, like
+// ApplyBaseline. It returns the updated results and the number suppressed.
+func (b *BaselineFile) Apply(results []CheckResult) ([]CheckResult, int) {
+	count := 0
+	for i, result := range results {
+		if result.Status == "pass" || b.match(result) < 0 {
+			continue
+		}
+		results[i] = markKnown(result)
+		count++
+	}
+	return results, count
+}
+
+// Resolved returns the baseline entries that none of the results match any
+// more, which can be pruned with another --update-baseline
+func (b *BaselineFile) Resolved(results []CheckResult) []BaselineEntry {
+	matched := make(map[int]bool)
+	for _, result := range results {
+		if result.Status == "pass" {
+			continue
+		}
+		if i := b.match(result); i >= 0 {
+			matched[i] = true
+		}
+	}
+
+	var resolved []BaselineEntry
+	for i, entry := range b.Findings {
+		if !matched[i] {
+			resolved = append(resolved, entry)
+		}
+	}
+	return resolved
+}
+
+// match returns the index of the entry accepting the finding, or -1
+func (b *BaselineFile) match(result CheckResult) int {
+	if b == nil {
+		return -1
+	}
+	fingerprint, drift := result.fingerprint(), driftKey(result)
+	severity := severityRank(resultSeverity(result))
+	for i, entry := range b.Findings {
+		if entry.RuleID != result.RuleName || severity > severityRank(entry.Severity) {
+			continue
+		}
+		if entry.Fingerprint == fingerprint || entry.DriftKey == drift {
+			return i
+		}
+	}
+	return -1
+}
+
+var driftNumbers = regexp.MustCompile(`\d+`)
+
+// driftKey identifies a finding by rule, file and message, with numbers such
+// as ports, counts and line references and runs of whitespace normalized
+func driftKey(result CheckResult) string {
+	message := driftNumbers.ReplaceAllString(result.Message, "#")
+	message = strings.Join(strings.Fields(message), " ")
+	hash := sha256.Sum256([]byte(result.RuleName + "|" + filepath.ToSlash(result.File) + "|" + message))
+	return hex.EncodeToString(hash[:16])
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyBaseline(t *testing.T) {
+	known := CheckResult{RuleName: "s3-no-public-buckets", Status: "fail", Severity: SeverityHigh, Message: "public bucket", File: "main.tf", Line: 2,
+		Metadata: map[string]interface{}{"resource": "aws_s3_bucket.legacy"}}
+	baseline := []CheckResult{
+		known,
+		{RuleName: "sg-no-open-ingress", Status: "pass", Message: "ok"},
+	}
+
+	// The known finding has moved down the file since it was attested
+	moved := known
+	moved.Line = 9
+	results := []CheckResult{
+		moved,
+		{RuleName: "sg-no-open-ingress", Status: "fail", Message: "open ingress", File: "network.tf", Line: 5},
+		{RuleName: "iam-no-wildcard-actions", Status: "pass", Message: "ok"},
+	}
+
+	results, count := ApplyBaseline(results, baseline)
+	if count != 1 {
+		t.Errorf("%d known findings, want 1", count)
+	}
+	if results[0].Status != "pass" || results[0].Metadata["baseline"] != true || results[0].Metadata["original_status"] != "fail" {
+		t.Errorf("known finding = %+v, want it passed and marked as baseline", results[0])
+	}
+	if results[0].Metadata["resource"] != "aws_s3_bucket.legacy" || known.Metadata["baseline"] != nil {
+		t.Errorf("marking a known finding lost its metadata or changed the baseline's")
+	}
+	if results[1].Status != "fail" {
+		t.Errorf("new finding = %+v, want it still failing", results[1])
+	}
+	if len(failures(results)) != 1 {
+		t.Errorf("failures = %+v, want just the new finding", failures(results))
+	}
+}
+
+func TestRegressions(t *testing.T) {
+	finding := func(rule, severity string) CheckResult {
+		return CheckResult{RuleName: rule, Status: "fail", Severity: severity, Message: rule, File: "main.tf"}
+	}
+	baseline := []CheckResult{
+		finding("s3-no-public-buckets", SeverityHigh),
+		finding("sg-no-open-ingress", SeverityMedium),
+		finding("iam-no-wildcard-actions", SeverityHigh),
+	}
+
+	// One finding fixed, one unchanged, one worse and one new
+	warned := finding("s3-no-public-buckets", SeverityHigh)
+	warned.Status = "warn"
+	results := []CheckResult{
+		warned,
+		finding("sg-no-open-ingress", SeverityCritical),
+		finding("rds-no-public-access", SeverityLow),
+	}
+
+	regressions := Regressions(results, baseline)
+	if len(regressions) != 2 || regressions[0].RuleName != "sg-no-open-ingress" || regressions[1].RuleName != "rds-no-public-access" {
+		t.Errorf("regressions = %+v, want the escalated and the new finding", regressions)
+	}
+	if regressions := Regressions(baseline, baseline); len(regressions) != 0 {
+		t.Errorf("a run identical to its baseline regressed: %+v", regressions)
+	}
+}
+
+func TestBaselineFile(t *testing.T) {
+	finding := func(rule, file string, line int, message string) CheckResult {
+		return CheckResult{RuleName: rule, Status: "fail", Severity: SeverityHigh, Message: message, File: file, Line: line}
+	}
+	accepted := []CheckResult{
+		finding("sg-no-open-ingress", "network.tf", 4, "Security group allows port 22 from 0.0.0.0/0"),
+		finding("s3-no-public-buckets", "main.tf", 7, "aws_s3_bucket.assets is public-read"),
+		finding("iam-no-wildcard-actions", "iam.tf", 12, "Policy grants s3:*"),
+		{RuleName: "dockerfile-best-practices", Status: "pass", Message: "ok"},
+	}
+	path := filepath.Join(t.TempDir(), ".mondrian", BaselineFileName)
+	if err := NewBaselineFile(accepted).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := LoadBaselineFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(baseline.Findings) != 3 {
+		t.Fatalf("recorded %d findings, want the 3 failures", len(baseline.Findings))
+	}
+
+	// The ingress finding drifted down the file and now names another
+	// port; the bucket finding was fixed
+	results := []CheckResult{
+		finding("sg-no-open-ingress", "network.tf", 9, "Security group allows port  2222 from 0.0.0.0/0"),
+		finding("iam-no-wildcard-actions", "iam.tf", 12, "Policy grants s3:*"),
+		finding("iam-no-wildcard-actions", "iam.tf", 30, "Policy grants ec2:*"),
+		finding("sg-no-open-ingress", "other.tf", 4, "Security group allows port 22 from 0.0.0.0/0"),
+	}
+	results, suppressed := baseline.Apply(results)
+	if suppressed != 2 {
+		t.Errorf("suppressed %d findings, want the drifted and the unchanged one", suppressed)
+	}
+	var stillFailing []string
+	for _, result := range failures(results) {
+		stillFailing = append(stillFailing, result.File+":"+result.Message)
+	}
+	want := []string{"iam.tf:Policy grants ec2:*", "other.tf:Security group allows port 22 from 0.0.0.0/0"}
+	if strings.Join(stillFailing, "|") != strings.Join(want, "|") {
+		t.Errorf("new findings = %q, want %q", stillFailing, want)
+	}
+
+	resolved := baseline.Resolved([]CheckResult{accepted[0], accepted[2]})
+	if len(resolved) != 1 || resolved[0].RuleID != "s3-no-public-buckets" {
+		t.Errorf("resolved = %+v, want the fixed bucket finding", resolved)
+	}
+}
+
+func TestBaselineFileReportsEscalatedSeverity(t *testing.T) {
+	accepted := CheckResult{RuleName: "sg-no-open-ingress", Status: "fail", Severity: SeverityMedium, Message: "open ingress", File: "network.tf", Line: 4}
+	baseline := NewBaselineFile([]CheckResult{accepted})
+
+	escalated := accepted
+	escalated.Severity = SeverityCritical
+	if _, suppressed := baseline.Apply([]CheckResult{escalated}); suppressed != 0 {
+		t.Errorf("a finding whose severity rose above the baseline was suppressed")
+	}
+}
+
+func TestLoadBaselineFile(t *testing.T) {
+	dir := t.TempDir()
+	if baseline, err := LoadBaselineFile(filepath.Join(dir, BaselineFileName)); baseline != nil || err != nil {
+		t.Errorf("missing baseline = %v, %v, want nil, nil", baseline, err)
+	}
+
+	path := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1, "findings": [{"ruleId": "s3-no-public-buckets"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBaselineFile(path); err == nil || !strings.Contains(err.Error(), "entry 0") {
+		t.Errorf("err = %v, want the entry without a fingerprint rejected", err)
+	}
+}
+
+func TestRegressionsScenarios(t *testing.T) {
+	bucket := CheckResult{RuleName: "s3-no-public-buckets", Status: "fail", Severity: SeverityCritical, Message: "public bucket", File: "main.tf", Line: 2}
+	ingress := CheckResult{RuleName: "sg-no-open-ingress", Status: "fail", Severity: SeverityHigh, Message: "open SSH", File: "network.tf", Line: 5}
+	pass := CheckResult{RuleName: "iam-no-wildcard-actions", Status: "pass", Message: "ok"}
+	baseline := []CheckResult{bucket, ingress, pass}
+
+	tests := []struct {
+		name    string
+		results []CheckResult
+		want    int
+	}{
+		{"unchanged", []CheckResult{bucket, ingress, pass}, 0},
+		{"improved", []CheckResult{ingress, pass}, 0},
+		{"all fixed", []CheckResult{pass}, 0},
+		{"regressed", []CheckResult{bucket, ingress, {RuleName: "iam-no-wildcard-actions", Status: "fail", Severity: SeverityHigh, Message: "s3:*", File: "iam.tf", Line: 3}}, 1},
+		{"improved and regressed", []CheckResult{bucket, {RuleName: "rds-no-public-access", Status: "fail", Severity: SeverityHigh, Message: "public", File: "db.tf", Line: 1}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if regressions := Regressions(tt.results, baseline); len(regressions) != tt.want {
+				t.Errorf("regressions = %+v, want %d", regressions, tt.want)
+			}
+		})
+	}
+}
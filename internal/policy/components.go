@@ -0,0 +1,198 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"sort"
+	"strings"
+)
+
+// Component kinds reported by DetectComponents
+const (
+	ComponentTerraformProvider = "terraform-provider"
+	ComponentTerraformModule   = "terraform-module"
+	ComponentContainerImage    = "container-image"
+	ComponentGitHubAction      = "github-action"
+)
+
+// Component is a third-party dependency a scanned file pins or references
+type Component struct {
+	Kind    string
+	Name    string // e.g. hashicorp/aws, nginx, actions/checkout
+	Version string // constraint, tag, digest or ref as written; empty when unpinned
+	File    string
+	Line    int
+}
+
+// DetectComponents lists the Terraform providers and registry modules, Docker
+// base images and GitHub Actions referenced by the files. A component
+// referenced from several places is listed once, at its first location.
+func DetectComponents(files map[string]string) []Component {
+	var components []Component
+	
+	for filename, content := range files {
+		switch {
+		case isTerraformFile(filename):
+			components = append(components, terraformComponents(filename, content)...)
+		case isDockerfile(filename):
+			components = append(components, dockerComponents(filename, content)...)
+		case isGitHubActionFile(filename):
+			components = append(components, workflowComponents(filename, content)...)
+		}
+	}
+	
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].File != components[j].File {
+			return components[i].File < components[j].File
+		}
+		return components[i].Line < components[j].Line
+	})
+	
+	seen := make(map[string]bool)
+	var unique []Component
+	for _, component := range components {
+		key := component.Kind + "\x00" + component.Name + "\x00" + component.Version
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, component)
+		}
+	}
+	
+	sort.SliceStable(unique, func(i, j int) bool {
+		if unique[i].Kind != unique[j].Kind {
+			return unique[i].Kind < unique[j].Kind
+		}
+		if unique[i].Name != unique[j].Name {
+			return unique[i].Name < unique[j].Name
+		}
+		return unique[i].Version < unique[j].Version
+	})
+	return unique
+}
+
+// terraformComponents reads required_providers entries and modules sourced
+// from a registry or remote location
+func terraformComponents(filename, content string) []Component {
+	var components []Component
+	
+	for _, block := range parseTerraform(content) {
+		switch block.Type {
+		case "terraform":
+			for _, providers := range block.Children("required_providers") {
+				for name, attr := range providers.Attrs {
+					component := Component{
+						Kind: ComponentTerraformProvider,
+						Name: "hashicorp/" + name,
+						File: filename,
+						Line: attr.Line,
+					}
+					// aws = { source = "hashicorp/aws", version = "~> 5.0" }, or
+					// the pre-0.13 aws = "~> 2.0"
+					p := &hclValueParser{s: attr.Value}
+					switch value := p.parse().(type) {
+					case map[string]interface{}:
+						if source, ok := value["source"].(string); ok {
+							component.Name = source
+						}
+						component.Version, _ = value["version"].(string)
+					case string:
+						component.Version = strings.Trim(value, `"`)
+					}
+					components = append(components, component)
+				}
+			}
+		case "module":
+			source, ok := block.Attr("source")
+			if !ok || strings.HasPrefix(source.String(), "./") || strings.HasPrefix(source.String(), "../") {
+				continue
+			}
+			version, _ := block.Attr("version")
+			components = append(components, Component{
+				Kind:    ComponentTerraformModule,
+				Name:    source.String(),
+				Version: version.String(),
+				File:    filename,
+				Line:    source.Line,
+			})
+		}
+	}
+	return components
+}
+
+// dockerComponents reads FROM base images, skipping earlier build stages,
+// scratch and images chosen by build argument
+func dockerComponents(filename, content string) []Component {
+	var components []Component
+	stages := make(map[string]bool)
+	
+	for _, instruction := range parseDockerfile(content) {
+		if instruction.Command != "FROM" {
+			continue
+		}
+		image, alias := dockerFromImage(instruction.Args)
+		fromStage := stages[strings.ToLower(image)]
+		if alias != "" {
+			stages[strings.ToLower(alias)] = true
+		}
+		if image == "" || image == "scratch" || fromStage || strings.Contains(image, "$") {
+			continue
+		}
+		
+		name, version := image, ""
+		if i := strings.Index(name, "@"); i >= 0 {
+			name, version = name[:i], name[i+1:]
+		} else if tag := dockerImageTag(name); tag != "" {
+			name, version = name[:len(name)-len(tag)-1], tag
+		}
+		components = append(components, Component{
+			Kind:    ComponentContainerImage,
+			Name:    name,
+			Version: version,
+			File:    filename,
+			Line:    instruction.Line,
+		})
+	}
+	return components
+}
+
+// workflowComponents reads the actions and reusable workflows a workflow
+// uses; local ones are part of the repository itself
+func workflowComponents(filename, content string) []Component {
+	var components []Component
+	for _, value := range workflowUses(content) {
+		reference := strings.TrimSpace(value.Value)
+		if strings.HasPrefix(reference, "./") {
+			continue
+		}
+		
+		component := Component{
+			Kind: ComponentGitHubAction,
+			File: filename,
+			Line: value.Line,
+		}
+		if image, ok := strings.CutPrefix(reference, "docker://"); ok {
+			component.Kind = ComponentContainerImage
+			reference = image
+			if tag := dockerImageTag(image); tag != "" && !strings.Contains(image, "@") {
+				reference = image[:len(image)-len(tag)-1] + "@" + tag
+			}
+		}
+		component.Name, component.Version, _ = strings.Cut(reference, "@")
+		components = append(components, component)
+	}
+	return components
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the policy config looked up by FindConfig
+const ConfigFileName = "mondrian.yaml"
+
+// FindConfig looks for ConfigFileName in dir, then in each parent directory
+// up to the repository root, the first one containing .git, so a run from a
+// subdirectory still finds the project config. It returns "" when there is
+// none.
+func FindConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	
+	for {
+		path := filepath.Join(dir, ConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Config controls which rules run and how their findings are reported, e.g.
+//
+//	rules:
+//	  - s3-no-public-buckets
+//	  - iam-no-static-access-keys
+//	severity:
+//	  tf-prevent-destroy-stateful: high
+//	fail_on: medium
+//	ignore:
+//	  - examples/**
+//	settings:
+//	  sg-no-open-ingress:
+//	    sensitive_ports: [22, 3389]
+//	allowlist:
+//	  - rule: s3-no-public-buckets
+//	    resource: aws_s3_bucket.website
+//	    reason: Static website, served publicly by design
+//	policies: policies
+//	plugins: .mondrian/plugins
+type Config struct {
+	// Rules lists the enabled rules; empty enables every rule
+	Rules []string `yaml:"rules"`
+	// Severity overrides the severity of a rule's findings
+	Severity map[string]string `yaml:"severity"`
+	// FailOn is the minimum severity that fails a run (default high)
+	FailOn string `yaml:"fail_on"`
+	// Ignore lists path globs, relative to the scan root, that are never checked
+	Ignore []string `yaml:"ignore"`
+	// Settings holds per-rule parameters, passed to rules implementing ConfigurableRule
+	Settings map[string]map[string]interface{} `yaml:"settings"`
+	// Allowlist accepts findings on specific Terraform resources, see Allowlist
+	Allowlist []AllowlistEntry `yaml:"allowlist"`
+	// Policies is a directory of .rego files run as rules alongside the
+	// built-in ones, relative to the config file, see RegoRule
+	Policies string `yaml:"policies"`
+	// Plugins is a directory of rule plugin executables, relative to the
+	// config file, see PluginProtocolVersion
+	Plugins string `yaml:"plugins"`
+	
+	// dir is the directory of the config file, which paths are relative to
+	dir string
+}
+
+// PoliciesDir returns the Rego policies directory, or "" when none is configured
+func (c *Config) PoliciesDir() string {
+	return c.resolve(c.Policies)
+}
+
+// PluginsDir returns the rule plugins directory, or "" when none is configured
+func (c *Config) PluginsDir() string {
+	return c.resolve(c.Plugins)
+}
+
+func (c *Config) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.dir, path)
+}
+
+// ConfiguredRules returns the built-in rules followed by the Rego policies
+// and plugin rules the config loads, before the rules list is applied
+func (c *Config) ConfiguredRules() ([]PolicyRule, error) {
+	rules := NewPolicyEngine().Rules
+	
+	if dir := c.PoliciesDir(); dir != "" {
+		regoRules, err := LoadRegoRules(dir)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, regoRules...)
+	}
+	if dir := c.PluginsDir(); dir != "" {
+		pluginRules, err := LoadPluginRules(dir)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, pluginRules...)
+	}
+	
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if seen[rule.Name()] {
+			return nil, fmt.Errorf("more than one rule is named %s", rule.Name())
+		}
+		seen[rule.Name()] = true
+	}
+	return rules, nil
+}
+
+// LoadConfig reads a policy config file. A missing file yields an empty
+// config, which enables every rule.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	
+	// The schema pinpoints mistakes by line and key before decoding does
+	if err := ValidateConfigSchema(data); err != nil {
+		return nil, fmt.Errorf("invalid config %s:\n%w", path, err)
+	}
+	
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	cfg.dir = filepath.Dir(path)
+	
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	
+	return &cfg, nil
+}
+
+// Validate rejects unknown rule names so a typo can't silently disable a check
+func (c *Config) Validate() error {
+	known := make(map[string]bool)
+	for _, name := range RuleNames() {
+		known[name] = true
+	}
+	if dir := c.PoliciesDir(); dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("policies directory %s not found", dir)
+		}
+		names, err := RegoRuleNames(dir)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			known[name] = true
+		}
+	}
+	if dir := c.PluginsDir(); dir != "" {
+		names, err := PluginRuleNames(dir)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			known[name] = true
+		}
+	}
+	
+	for _, name := range c.Rules {
+		if !known[name] {
+			return fmt.Errorf("unknown rule %q (known rules: %s)", name, strings.Join(sortedKeys(known), ", "))
+		}
+	}
+	
+	for name, severity := range c.Severity {
+		if !known[name] {
+			return fmt.Errorf("severity override for unknown rule %q", name)
+		}
+		if err := ValidateSeverity(severity); err != nil {
+			return fmt.Errorf("severity override for %s: %w", name, err)
+		}
+	}
+	
+	if c.FailOn != "" {
+		if err := ValidateSeverity(c.FailOn); err != nil {
+			return fmt.Errorf("fail_on: %w", err)
+		}
+	}
+	
+	for _, pattern := range c.Ignore {
+		if err := ValidatePathGlob(pattern); err != nil {
+			return fmt.Errorf("invalid ignore pattern: %w", err)
+		}
+	}
+	
+	for i, entry := range c.Allowlist {
+		if entry.Resource == "" {
+			return fmt.Errorf("allowlist entry %d has no resource address", i+1)
+		}
+		if entry.Rule != "" && !known[entry.Rule] {
+			return fmt.Errorf("allowlist entry for %s names unknown rule %q", entry.Resource, entry.Rule)
+		}
+	}
+	
+	// Configure a throwaway engine so bad settings are reported at load time
+	if err := NewPolicyEngine().ConfigureRules(c.Settings); err != nil {
+		return err
+	}
+	
+	return nil
+}
+
+// NewPolicyEngineFromConfig creates an engine running only the rules enabled
+// by cfg, configured with its rule settings. A nil or empty config behaves
+// like NewPolicyEngine.
+func NewPolicyEngineFromConfig(cfg *Config) (*PolicyEngine, error) {
+	engine := NewPolicyEngine()
+	if cfg == nil {
+		return engine, nil
+	}
+	
+	rules, err := cfg.ConfiguredRules()
+	if err != nil {
+		return nil, err
+	}
+	engine.Rules = rules
+	
+	if err := engine.ConfigureRules(cfg.Settings); err != nil {
+		return nil, err
+	}
+	
+	if len(cfg.Rules) > 0 {
+		enabled := make(map[string]bool)
+		for _, name := range cfg.Rules {
+			enabled[name] = true
+		}
+		
+		var rules []PolicyRule
+		for _, rule := range engine.Rules {
+			if enabled[rule.Name()] {
+				rules = append(rules, rule)
+			}
+		}
+		engine.Rules = rules
+	}
+	
+	engine.SeverityOverrides = cfg.Severity
+	engine.IgnorePaths = cfg.Ignore
+	engine.FailOn = cfg.FailOn
+	engine.Allowlist = &Allowlist{Entries: cfg.Allowlist}
+	
+	return engine, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
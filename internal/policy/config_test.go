@@ -0,0 +1,65 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindConfig(t *testing.T) {
+	outer := t.TempDir()
+	repo := filepath.Join(outer, "repo")
+	writeTree(t, outer, map[string]string{
+		ConfigFileName:                      "rules: []\n",
+		"repo/.git/HEAD":                    "ref: refs/heads/main\n",
+		"repo/" + ConfigFileName:            "rules: [s3-no-public-buckets]\n",
+		"repo/modules/net/main.tf":          "",
+		"repo/modules/app/mondrian.yaml/.k": "",
+	})
+
+	// From a subdirectory the nearest config is found, skipping a directory
+	// that only shares its name
+	for _, dir := range []string{repo, filepath.Join(repo, "modules", "net"), filepath.Join(repo, "modules", "app")} {
+		if got := FindConfig(dir); got != filepath.Join(repo, ConfigFileName) {
+			t.Errorf("FindConfig(%s) = %q, want the repository config", dir, got)
+		}
+	}
+
+	// The search stops at the repository root rather than reading a config
+	// outside it
+	if err := os.Remove(filepath.Join(repo, ConfigFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindConfig(filepath.Join(repo, "modules", "net")); got != "" {
+		t.Errorf("FindConfig found %q outside the repository", got)
+	}
+}
+
+func TestFindConfigWithoutRepository(t *testing.T) {
+	dir := t.TempDir()
+	if got := FindConfig(dir); got != "" {
+		t.Errorf("FindConfig = %q, want none", got)
+	}
+
+	// Outside a repository the walk goes on to the parents
+	writeTree(t, dir, map[string]string{ConfigFileName: "rules: []\n", "infra/main.tf": ""})
+	if got := FindConfig(filepath.Join(dir, "infra")); got != filepath.Join(dir, ConfigFileName) {
+		t.Errorf("FindConfig = %q, want the parent's config", got)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ComputeFingerprint identifies a finding across runs from its rule, file,
+// message and matched content (the resource address, else the offending line
+// with whitespace normalized). The line number is left out so unrelated edits
+// above a finding don't make it look new.
+func (r CheckResult) ComputeFingerprint() string {
+	anchor, _ := r.Metadata["resource"].(string)
+	if anchor == "" {
+		content, _ := r.Metadata["line_content"].(string)
+		anchor = strings.Join(strings.Fields(content), " ")
+	}
+	data := fmt.Sprintf("%s|%s|%s|%s", r.RuleName, r.File, r.Message, anchor)
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:16])
+}
+
+// fingerprint returns the recorded fingerprint, computing it for results
+// from before fingerprints were recorded
+func (r CheckResult) fingerprint() string {
+	if r.Fingerprint != "" {
+		return r.Fingerprint
+	}
+	return r.ComputeFingerprint()
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"testing"
+)
+
+// checkFingerprints runs the S3 rule over main.tf and returns the
+// fingerprints of its findings
+func checkFingerprints(t *testing.T, content string) []string {
+	t.Helper()
+	engine := NewPolicyEngine()
+	if err := engine.SelectRules([]string{"s3-no-public-buckets"}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := engine.RunChecks(context.Background(), map[string]string{"main.tf": content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fingerprints []string
+	for _, result := range failures(results) {
+		if result.Fingerprint == "" {
+			t.Fatalf("finding %+v has no fingerprint", result)
+		}
+		fingerprints = append(fingerprints, result.Fingerprint)
+	}
+	return fingerprints
+}
+
+func TestFingerprintStableUnderLineShifts(t *testing.T) {
+	original := `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`
+	shifted := `# Buckets for the web tier
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`
+	before, after := checkFingerprints(t, original), checkFingerprints(t, shifted)
+	if len(before) != 1 || len(after) != 1 {
+		t.Fatalf("fingerprints = %v and %v, want one finding each", before, after)
+	}
+	if before[0] != after[0] {
+		t.Errorf("fingerprint changed from %s to %s when the finding moved down", before[0], after[0])
+	}
+
+	changed := checkFingerprints(t, `resource "aws_s3_bucket" "uploads" {
+  acl = "public-read"
+}
+`)
+	if len(changed) != 1 || changed[0] == before[0] {
+		t.Errorf("fingerprint %v unchanged for a different resource", changed)
+	}
+}
+
+func TestComputeFingerprint(t *testing.T) {
+	base := CheckResult{
+		RuleName: "no-hardcoded-secrets",
+		File:     "main.tf",
+		Line:     4,
+		Message:  "Hardcoded secret",
+		Metadata: map[string]interface{}{"line_content": `password = "hunter2"`},
+	}
+	fingerprint := base.ComputeFingerprint()
+	if len(fingerprint) != 32 || fingerprint != base.ComputeFingerprint() {
+		t.Fatalf("fingerprint %q is not a deterministic 128-bit hex hash", fingerprint)
+	}
+
+	same := base
+	same.Line = 40
+	same.Metadata = map[string]interface{}{"line_content": `password   =  "hunter2"`}
+	if same.ComputeFingerprint() != fingerprint {
+		t.Errorf("fingerprint depends on the line number or whitespace")
+	}
+
+	for name, edit := range map[string]func(*CheckResult){
+		"rule":    func(r *CheckResult) { r.RuleName = "sensitive-variables" },
+		"file":    func(r *CheckResult) { r.File = "prod.tf" },
+		"content": func(r *CheckResult) { r.Metadata = map[string]interface{}{"line_content": `password = "hunter3"`} },
+	} {
+		changed := base
+		edit(&changed)
+		if changed.ComputeFingerprint() == fingerprint {
+			t.Errorf("fingerprint unchanged when the %s changed", name)
+		}
+	}
+
+	recorded := base
+	recorded.Fingerprint = "recorded"
+	if recorded.fingerprint() != "recorded" || base.fingerprint() != fingerprint {
+		t.Errorf("fingerprint() does not prefer the recorded fingerprint")
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+// FrameworkRule is a rule that supports controls of compliance frameworks,
+// keyed by framework: CIS-AWS, CIS-GCP and CIS-Azure are the CIS Foundations
+// Benchmarks (AWS v3.0.0, GCP v2.0.0, Azure v2.0.0) and SOC2 the 2017 Trust
+// Services Criteria. A value may list several controls, comma-separated.
+type FrameworkRule interface {
+	PolicyRule
+	Frameworks() map[string]string
+}
+
+// RuleFrameworks returns the controls a rule supports, or nil for rules that
+// declare none
+func RuleFrameworks(rule PolicyRule) map[string]string {
+	if r, ok := rule.(FrameworkRule); ok {
+		return r.Frameworks()
+	}
+	return nil
+}
+
+// withFrameworks records the rule's framework controls in the metadata of each
+// result, passing ones included, so results can be read as control evidence
+func withFrameworks(results []CheckResult, frameworks map[string]string) []CheckResult {
+	if len(frameworks) == 0 {
+		return results
+	}
+	for i, result := range results {
+		metadata := make(map[string]interface{}, len(result.Metadata)+1)
+		for k, v := range result.Metadata {
+			metadata[k] = v
+		}
+		metadata["frameworks"] = frameworks
+		results[i].Metadata = metadata
+	}
+	return results
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestS3PublicBucketRuleFrameworks(t *testing.T) {
+	want := map[string]string{"CIS-AWS": "2.1.4", "SOC2": "CC6.1"}
+	if got := RuleFrameworks(&S3PublicBucketRule{}); !maps.Equal(got, want) {
+		t.Errorf("frameworks = %v, want %v", got, want)
+	}
+
+	// Every result carries the controls, so passes count as evidence too
+	engine := NewPolicyEngine()
+	if err := engine.SelectRules([]string{"s3-no-public-buckets"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"fail": readExample(t, "bad-s3-public-acl.tf.bak"),
+		"pass": readExample(t, "good-s3-acl.tf"),
+	} {
+		results, err := engine.RunChecks(context.Background(), map[string]string{"main.tf": content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range results {
+			if got, _ := result.Metadata["frameworks"].(map[string]string); !maps.Equal(got, want) {
+				t.Errorf("%s result %q frameworks = %v, want %v", name, result.Message, result.Metadata["frameworks"], want)
+			}
+		}
+	}
+}
+
+func TestBuiltinRuleFrameworks(t *testing.T) {
+	known := []string{"CIS-AWS", "CIS-GCP", "CIS-Azure", "SOC2"}
+	for _, rule := range NewPolicyEngine().Rules {
+		for framework, controls := range RuleFrameworks(rule) {
+			if !slices.Contains(known, framework) || strings.TrimSpace(controls) == "" {
+				t.Errorf("%s maps %q to %q, want a known framework and a control", rule.Name(), framework, controls)
+			}
+		}
+	}
+}
+
+func TestWithFrameworks(t *testing.T) {
+	original := map[string]interface{}{"resource": "aws_s3_bucket.assets"}
+	results := withFrameworks([]CheckResult{{Status: "fail", Metadata: original}}, map[string]string{"SOC2": "CC6.1"})
+	if results[0].Metadata["resource"] != "aws_s3_bucket.assets" || results[0].Metadata["frameworks"] == nil {
+		t.Errorf("metadata = %v, want the resource kept and the frameworks added", results[0].Metadata)
+	}
+	if _, ok := original["frameworks"]; ok {
+		t.Error("withFrameworks changed the rule's own metadata map")
+	}
+
+	// Rules declaring no controls leave results untouched
+	if RuleFrameworks(probeRule{func() {}}) != nil {
+		t.Error("a rule without Frameworks reported some")
+	}
+	if results := withFrameworks([]CheckResult{{Status: "pass"}}, nil); results[0].Metadata != nil {
+		t.Errorf("metadata = %v, want none", results[0].Metadata)
+	}
+}
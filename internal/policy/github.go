@@ -0,0 +1,81 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ToGitHubAnnotations renders failing and warning results as GitHub Actions
+// workflow commands, which show up as annotations on the pull request diff
+func ToGitHubAnnotations(results []CheckResult) []byte {
+	var output strings.Builder
+	
+	for _, result := range results {
+		command := "warning"
+		switch result.Status {
+		case "pass":
+			continue
+		case "fail":
+			command = "error"
+		}
+		
+		var properties []string
+		if result.File != "" {
+			properties = append(properties, "file="+escapeAnnotationProperty(filepath.ToSlash(result.File)))
+			if result.Line > 0 {
+				properties = append(properties, fmt.Sprintf("line=%d", result.Line))
+			}
+		}
+		properties = append(properties, "title="+escapeAnnotationProperty(result.RuleName))
+		
+		message := result.Message
+		if result.Remediation != "" {
+			message += "\n" + result.Remediation
+		}
+		
+		fmt.Fprintf(&output, "::%s %s::%s\n", command, strings.Join(properties, ","), escapeAnnotationData(message))
+	}
+	
+	return []byte(output.String())
+}
+
+// FilterByFiles keeps findings located in one of the given files. Results
+// without a file, such as a rule's passing summary, are always kept.
+func FilterByFiles(results []CheckResult, files map[string]bool) []CheckResult {
+	var filtered []CheckResult
+	for _, result := range results {
+		if result.File == "" || files[filepath.ToSlash(result.File)] {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+func escapeAnnotationData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+func escapeAnnotationProperty(s string) string {
+	s = escapeAnnotationData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+const bucket = "resource \"aws_s3_bucket\" \"b\" {}\n"
+
+// scannedNames scans dir and returns the sorted relative paths read
+func scannedNames(t *testing.T, scanner *FileScanner) []string {
+	t.Helper()
+	files, err := scanner.ScanRelevantFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestScanRespectsGitignore(t *testing.T) {
+	tests := []struct {
+		name      string
+		gitignore string
+		want      []string
+	}{
+		{
+			// Like git, a negation can't reach into an excluded directory
+			name:      "directory with a negated file",
+			gitignore: "dist/\n!dist/keep.tf\n",
+			want:      []string{"main.tf", "modules/net/net.tf", "modules/net/out.gen.tf"},
+		},
+		{
+			name:      "directory contents with a negated file",
+			gitignore: "dist/*\n!dist/keep.tf\n",
+			want:      []string{"dist/keep.tf", "main.tf", "modules/net/net.tf", "modules/net/out.gen.tf"},
+		},
+		{
+			name:      "file pattern anywhere",
+			gitignore: "# generated\n*.gen.tf\n",
+			want:      []string{"dist/build.tf", "dist/keep.tf", "main.tf", "modules/net/net.tf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, map[string]string{
+				".gitignore":             tt.gitignore,
+				"main.tf":                bucket,
+				"dist/build.tf":          bucket,
+				"dist/keep.tf":           bucket,
+				"modules/net/net.tf":     bucket,
+				"modules/net/out.gen.tf": bucket,
+			})
+			scanner := NewFileScanner(dir)
+			if !scanner.RespectGitignore {
+				t.Fatal("RespectGitignore defaults to false")
+			}
+			if got := scannedNames(t, scanner); !slices.Equal(got, tt.want) {
+				t.Errorf("scanned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanNestedGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".gitignore":                    "*.local.tf\n",
+		"main.tf":                       bucket,
+		"dev.local.tf":                  bucket,
+		"generated.tf":                  bucket,
+		"modules/app/.gitignore":        "generated.tf\n!override.local.tf\n",
+		"modules/app/main.tf":           bucket,
+		"modules/app/generated.tf":      bucket,
+		"modules/app/override.local.tf": bucket,
+	})
+
+	// The nested file applies only below modules/app, and can negate a
+	// pattern from the root for its own files
+	want := []string{"generated.tf", "main.tf", "modules/app/main.tf", "modules/app/override.local.tf"}
+	if got := scannedNames(t, NewFileScanner(dir)); !slices.Equal(got, want) {
+		t.Errorf("scanned %v, want %v", got, want)
+	}
+}
+
+func TestScanIgnoringGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".gitignore":   "dist/\n",
+		"main.tf":      bucket,
+		"dist/keep.tf": bucket,
+	})
+	scanner := NewFileScanner(dir)
+	scanner.RespectGitignore = false
+
+	if got := scannedNames(t, scanner); !slices.Equal(got, []string{"dist/keep.tf", "main.tf"}) {
+		t.Errorf("scanned %v, want every file with RespectGitignore off", got)
+	}
+}
+
+func TestGitignoreIgnored(t *testing.T) {
+	g := &gitignore{}
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{".gitignore": "/build\nlogs/\n**/tmp/*.tf\nsecret?.tf\n[abc].tf\n"})
+	g.load(dir, "")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"build", true, true},
+		{"build", false, true},
+		{"sub/build", true, false},
+		{"logs", true, true},
+		{"logs", false, false},
+		{"sub/logs", true, true},
+		{"tmp/x.tf", false, true},
+		{"a/b/tmp/x.tf", false, true},
+		{"tmp/x.yaml", false, false},
+		{"secret1.tf", false, true},
+		{"secret12.tf", false, false},
+		{"b.tf", false, true},
+		{"d.tf", false, false},
+	}
+	for _, tt := range tests {
+		if got := g.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
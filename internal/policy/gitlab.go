@@ -0,0 +1,83 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+)
+
+// CodeQualityIssue is one entry of a GitLab Code Quality report
+type CodeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    CodeQualityLocation `json:"location"`
+}
+
+type CodeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines CodeQualityLines `json:"lines"`
+}
+
+type CodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// ToGitLabCodeQuality converts failing and warning results to a GitLab Code
+// Quality report. Results without a file cannot be placed in a merge request
+// diff and are omitted.
+func ToGitLabCodeQuality(results []CheckResult) ([]byte, error) {
+	issues := make([]CodeQualityIssue, 0, len(results))
+
+	for _, result := range results {
+		if result.Status == "pass" || result.File == "" {
+			continue
+		}
+
+		line := result.Line
+		if line < 1 {
+			line = 1
+		}
+
+		issues = append(issues, CodeQualityIssue{
+			Description: result.Message,
+			CheckName:   result.RuleName,
+			Fingerprint: result.fingerprint(),
+			Severity:    codeQualitySeverity(result),
+			Location: CodeQualityLocation{
+				Path:  result.File,
+				Lines: CodeQualityLines{Begin: line},
+			},
+		})
+	}
+
+	return json.MarshalIndent(issues, "", "  ")
+}
+
+// codeQualitySeverity maps a result onto GitLab's info/minor/major/critical/blocker scale
+func codeQualitySeverity(result CheckResult) string {
+	switch resultSeverity(result) {
+	case SeverityCritical:
+		return "critical"
+	case SeverityHigh:
+		return "major"
+	case SeverityMedium:
+		return "minor"
+	}
+	return "info"
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestToGitLabCodeQualityShape(t *testing.T) {
+	data, err := ToGitLabCodeQuality([]CheckResult{
+		{RuleName: "s3-no-public-buckets", Status: "fail", Severity: SeverityCritical, Message: "S3 bucket has public-read ACL", File: "main.tf", Line: 7},
+		{RuleName: "sg-no-open-ingress", Status: "warn", Severity: SeverityMedium, Message: "Open ingress", File: "network.tf"},
+		{RuleName: "iam-no-wildcard-actions", Status: "pass", Message: "ok", File: "iam.tf", Line: 3},
+		{RuleName: "required-tags", Status: "fail", Message: "No Terraform files found"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GitLab reads exactly these fields, so decode generically rather than
+	// into the structs under test
+	var issues []map[string]interface{}
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatalf("report is not a JSON array: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want passes and fileless findings omitted", len(issues))
+	}
+
+	for _, issue := range issues {
+		var keys []string
+		for key := range issue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if want := []string{"check_name", "description", "fingerprint", "location", "severity"}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("issue keys = %v, want %v", keys, want)
+		}
+	}
+
+	want := map[string]interface{}{
+		"description": "S3 bucket has public-read ACL",
+		"check_name":  "s3-no-public-buckets",
+		"fingerprint": issues[0]["fingerprint"],
+		"severity":    "critical",
+		"location": map[string]interface{}{
+			"path":  "main.tf",
+			"lines": map[string]interface{}{"begin": 7.0},
+		},
+	}
+	if !reflect.DeepEqual(issues[0], want) {
+		t.Errorf("issue = %v, want %v", issues[0], want)
+	}
+	if location := issues[1]["location"].(map[string]interface{}); location["lines"].(map[string]interface{})["begin"] != 1.0 {
+		t.Errorf("finding without a line begins at %v, want line 1", location["lines"])
+	}
+	if issues[1]["severity"] != "minor" {
+		t.Errorf("medium finding has severity %v, want minor", issues[1]["severity"])
+	}
+}
+
+func TestToGitLabCodeQualityEmpty(t *testing.T) {
+	data, err := ToGitLabCodeQuality([]CheckResult{{RuleName: "s3-no-public-buckets", Status: "pass"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("clean report = %s, want an empty array", data)
+	}
+}
+
+func TestCodeQualitySeverity(t *testing.T) {
+	tests := map[string]string{
+		SeverityCritical: "critical",
+		SeverityHigh:     "major",
+		SeverityMedium:   "minor",
+		SeverityLow:      "info",
+	}
+	for severity, want := range tests {
+		if got := codeQualitySeverity(CheckResult{Status: "fail", Severity: severity}); got != want {
+			t.Errorf("%s maps to %s, want %s", severity, got, want)
+		}
+	}
+}
+
+// codeQualityFingerprints runs the S3 rule over content and returns the
+// report's fingerprint for each line number
+func codeQualityFingerprints(t *testing.T, content string) map[int]string {
+	t.Helper()
+	engine := NewPolicyEngine()
+	if err := engine.SelectRules([]string{"s3-no-public-buckets"}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := engine.RunChecks(context.Background(), map[string]string{"main.tf": content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ToGitLabCodeQuality(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issues []CodeQualityIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatal(err)
+	}
+	fingerprints := make(map[int]string)
+	for _, issue := range issues {
+		fingerprints[issue.Location.Lines.Begin] = issue.Fingerprint
+	}
+	return fingerprints
+}
+
+func TestToGitLabCodeQualityFingerprintStability(t *testing.T) {
+	content := `resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+
+resource "aws_s3_bucket" "uploads" {
+  acl = "public-read"
+}
+`
+	first := codeQualityFingerprints(t, content)
+	if len(first) != 2 || first[2] == first[6] {
+		t.Fatalf("fingerprints = %v, want two distinct ones", first)
+	}
+	if again := codeQualityFingerprints(t, content); !reflect.DeepEqual(again, first) {
+		t.Errorf("rerun fingerprints = %v, want %v", again, first)
+	}
+
+	// Lines added above the findings move them without making them new
+	shifted := codeQualityFingerprints(t, "# storage\n\n"+content)
+	if shifted[4] != first[2] || shifted[8] != first[6] {
+		t.Errorf("fingerprints after an edit above = %v, want %v moved down two lines", shifted, first)
+	}
+}
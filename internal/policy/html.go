@@ -0,0 +1,186 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"bytes"
+	"html/template"
+	"sort"
+	"time"
+)
+
+// ReportMeta describes the run an HTML report was produced from
+type ReportMeta struct {
+	Repository   string
+	Branch       string
+	Commit       string
+	GeneratedAt  time.Time
+	FilesScanned int
+	FailOn       string
+}
+
+type htmlReport struct {
+	Meta     ReportMeta
+	Status   string
+	Passed   int
+	Failed   int
+	Warnings int
+	Rules    []htmlRule
+}
+
+type htmlRule struct {
+	Name     string
+	Status   string
+	Passed   int
+	Findings []htmlFinding
+}
+
+type htmlFinding struct {
+	CheckResult
+	Severity    string
+	LineContent string
+}
+
+// ToHTML renders results as a self-contained HTML page for sharing outside
+// engineering: an overall summary, then one section per rule with its
+// failing and warning findings and the source line each points at. Rules
+// with failures come first.
+func ToHTML(results []CheckResult, meta ReportMeta) ([]byte, error) {
+	report := htmlReport{Meta: meta, Status: "pass"}
+	if report.Meta.FailOn == "" {
+		report.Meta.FailOn = DefaultFailOn
+	}
+
+	rules := make(map[string]*htmlRule)
+	var order []string
+	for _, result := range results {
+		rule, ok := rules[result.RuleName]
+		if !ok {
+			rule = &htmlRule{Name: result.RuleName, Status: "pass"}
+			rules[result.RuleName] = rule
+			order = append(order, result.RuleName)
+		}
+
+		switch result.Status {
+		case "pass":
+			report.Passed++
+			rule.Passed++
+			continue
+		case "fail":
+			report.Failed++
+			rule.Status = "fail"
+		case "warn":
+			report.Warnings++
+			if rule.Status == "pass" {
+				rule.Status = "warn"
+			}
+		}
+
+		finding := htmlFinding{CheckResult: result, Severity: resultSeverity(result)}
+		if content, ok := result.Metadata["line_content"].(string); ok {
+			finding.LineContent = content
+		}
+		rule.Findings = append(rule.Findings, finding)
+	}
+
+	if report.Failed > 0 {
+		report.Status = "fail"
+	} else if report.Warnings > 0 {
+		report.Status = "warn"
+	}
+
+	rank := map[string]int{"fail": 0, "warn": 1, "pass": 2}
+	for _, name := range order {
+		report.Rules = append(report.Rules, *rules[name])
+	}
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		return rank[report.Rules[i].Status] < rank[report.Rules[j].Status]
+	})
+
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04:05 UTC")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Mondrian Policy Report</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; margin: 2rem auto; max-width: 960px; color: #1f2328; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid #d0d7de; vertical-align: top; }
+section { margin-top: 1.5rem; }
+h2 { font-size: 1.1rem; margin-bottom: 0.4rem; }
+pre { background: #f6f8fa; padding: 0.4rem 0.6rem; margin: 0.3rem 0 0; overflow-x: auto; }
+code, pre { font-size: 0.9em; }
+.summary { font-size: 1.2rem; }
+.pass { color: #1a7f37; } .fail { color: #cf222e; } .warn { color: #9a6700; }
+.muted { color: #59636e; }
+</style>
+</head>
+<body>
+<h1>Mondrian Policy Report</h1>
+<table>
+{{with .Meta.Repository}}<tr><th>Repository</th><td>{{.}}</td></tr>{{end}}
+{{with .Meta.Branch}}<tr><th>Branch</th><td>{{.}}</td></tr>{{end}}
+{{with .Meta.Commit}}<tr><th>Commit</th><td><code>{{.}}</code></td></tr>{{end}}
+{{if not .Meta.GeneratedAt.IsZero}}<tr><th>Generated</th><td>{{time .Meta.GeneratedAt}}</td></tr>{{end}}
+<tr><th>Files scanned</th><td>{{.Meta.FilesScanned}}</td></tr>
+<tr><th>Fails on</th><td>{{.Meta.FailOn}} severity and above</td></tr>
+</table>
+
+<p class="summary {{.Status}}">
+{{if eq .Status "fail"}}🚫 Policy check failed{{else if eq .Status "warn"}}⚠️ Policy check passed with warnings{{else}}✅ All policy checks passed{{end}}
+&middot; {{.Passed}} passed, {{.Failed}} failed, {{.Warnings}} warnings
+</p>
+
+{{range .Rules}}
+<section>
+<h2 class="{{.Status}}">{{.Name}}</h2>
+{{if .Findings}}
+<table>
+<tr><th>Status</th><th>Severity</th><th>Finding</th></tr>
+{{range .Findings}}
+<tr>
+<td class="{{.Status}}">{{.Status}}</td>
+<td>{{.Severity}}</td>
+<td>
+{{.Message}}
+{{if .File}}<div class="muted"><code>{{.File}}{{if .Line}}:{{.Line}}{{end}}</code></div>{{end}}
+{{if .LineContent}}<pre>{{.LineContent}}</pre>{{end}}
+{{if .Remediation}}<div class="muted">Remediation: {{.Remediation}}</div>{{end}}
+</td>
+</tr>
+{{end}}
+</table>
+{{if .Passed}}<p class="muted">{{.Passed}} other checks passed</p>{{end}}
+{{else}}
+<p class="pass">Passed{{if gt .Passed 1}} ({{.Passed}} checks){{end}}</p>
+{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,105 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToHTML(t *testing.T) {
+	files := map[string]string{
+		"s3.tf":      readExample(t, "bad-s3-public-acl.tf.bak"),
+		"network.tf": readExample(t, "bad-database-sg.tf.bak"),
+		"Dockerfile": readExample(t, "bad.Dockerfile.bak"),
+	}
+	results, err := NewPolicyEngine().RunChecks(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := ReportMeta{Repository: "github.com/acme/infra", Commit: "abc123", GeneratedAt: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), FilesScanned: 3}
+	data, err := ToHTML(results, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+
+	failing := make(map[string]bool)
+	for _, result := range results {
+		if result.Status == "fail" {
+			failing[result.RuleName] = true
+		}
+	}
+	if len(failing) < 3 {
+		t.Fatalf("only %d rules failed on the bad examples", len(failing))
+	}
+	for rule := range failing {
+		if !strings.Contains(html, `<h2 class="fail">`+rule+`</h2>`) {
+			t.Errorf("report has no failing section for %s", rule)
+		}
+	}
+	for _, want := range []string{"🚫 Policy check failed", "github.com/acme/infra", "2025-06-01 12:00:00 UTC", "<td>3</td>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+
+	// Failing rules are listed before passing ones
+	if last, first := strings.LastIndex(html, `<h2 class="fail">`), strings.Index(html, `<h2 class="pass">`); first >= 0 && last > first {
+		t.Error("a passing rule is listed before a failing one")
+	}
+	// Self-contained, with nothing fetched from elsewhere
+	for _, external := range []string{"<script", "<link", "src="} {
+		if strings.Contains(html, external) {
+			t.Errorf("report loads external content through %s", external)
+		}
+	}
+}
+
+func TestToHTMLEscapesFindings(t *testing.T) {
+	results := []CheckResult{{
+		RuleName: "env-no-secrets",
+		Status:   "fail",
+		Message:  "secret in <script>alert(1)</script>",
+		File:     "app.env",
+		Line:     2,
+		Metadata: map[string]interface{}{"line_content": `TOKEN="<img src=x>"`},
+	}}
+	data, err := ToHTML(results, ReportMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+	if strings.Contains(html, "<script>") || strings.Contains(html, "<img") {
+		t.Error("finding content was rendered as markup")
+	}
+	if !strings.Contains(html, "&lt;script&gt;") || !strings.Contains(html, "app.env:2") {
+		t.Error("report is missing the escaped finding")
+	}
+}
+
+func TestToHTMLAllPassing(t *testing.T) {
+	data, err := ToHTML([]CheckResult{{RuleName: "s3-no-public-buckets", Status: "pass", Message: "ok"}}, ReportMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html := string(data); !strings.Contains(html, "✅ All policy checks passed") || !strings.Contains(html, "Fails on</th><td>"+DefaultFailOn) {
+		t.Error("all-passing report has no passing summary or default threshold")
+	}
+}
@@ -0,0 +1,406 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// iamStatement is one statement of an IAM policy document
+type iamStatement struct {
+	Effect      string
+	Actions     []string
+	Resources   []string
+	Principals  []string // resource policies only; "*" is anyone
+	Conditional bool     // the statement has a Condition
+	Line        int      // 1-based line of the statement, 0 when unknown
+}
+
+// iamDocument is an IAM policy document found in Terraform, either an
+// aws_iam_policy_document data source or an inline policy attribute
+type iamDocument struct {
+	File       string
+	Line       int
+	Address    string
+	Statements []iamStatement
+}
+
+// iamPolicyResources lists resources whose policy attribute holds an identity policy
+var iamPolicyResources = []string{"aws_iam_policy", "aws_iam_role_policy", "aws_iam_user_policy", "aws_iam_group_policy"}
+
+// iamPolicyDocuments collects identity policy documents across the Terraform
+// files. Inline policies written as jsonencode() or heredoc JSON are parsed;
+// policies referencing a data source are covered by that data source.
+func iamPolicyDocuments(files map[string]string) []iamDocument {
+	var documents []iamDocument
+
+	for filename, content := range files {
+		if !isTerraformFile(filename) {
+			continue
+		}
+		for _, block := range parseTerraform(content) {
+			if block.Type == "data" && len(block.Labels) == 2 && block.Labels[0] == "aws_iam_policy_document" {
+				documents = append(documents, iamDocument{
+					File:       filename,
+					Line:       block.Line,
+					Address:    block.Address(),
+					Statements: policyDocumentStatements(block),
+				})
+			}
+		}
+	}
+
+	for filename, blocks := range terraformResources(files, iamPolicyResources...) {
+		for _, block := range blocks {
+			attr, ok := block.Attr("policy")
+			if !ok {
+				continue
+			}
+			document, lines, ok := parsePolicyExpression(attr.Value, attr.Line)
+			if !ok {
+				continue
+			}
+			documents = append(documents, iamDocument{
+				File:       filename,
+				Line:       attr.Line,
+				Address:    block.Address(),
+				Statements: jsonPolicyStatements(document, lines),
+			})
+		}
+	}
+
+	return documents
+}
+
+// policyDocumentStatements reads the statement blocks of an aws_iam_policy_document
+func policyDocumentStatements(block *tfBlock) []iamStatement {
+	var statements []iamStatement
+	for _, child := range block.Children("statement") {
+		statement := iamStatement{Effect: "Allow", Line: child.Line}
+		if effect, ok := child.Attr("effect"); ok {
+			statement.Effect = effect.String()
+		}
+		if actions, ok := child.Attr("actions"); ok {
+			statement.Actions = hclStrings(actions.Value)
+		}
+		if resources, ok := child.Attr("resources"); ok {
+			statement.Resources = hclStrings(resources.Value)
+		}
+		for _, principals := range child.Children("principals") {
+			kind, _ := principals.Attr("type")
+			if kind.String() == "*" {
+				statement.Principals = append(statement.Principals, "*")
+				continue
+			}
+			if identifiers, ok := principals.Attr("identifiers"); ok {
+				statement.Principals = append(statement.Principals, hclStrings(identifiers.Value)...)
+			}
+		}
+		statement.Conditional = len(child.Children("condition")) > 0
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// parsePolicyExpression decodes a policy attribute written as jsonencode({...})
+// or as a heredoc containing JSON. firstLine is the line the attribute starts
+// on; the returned map gives the line of each value by path, e.g.
+// "Statement/0", so findings can point at a statement.
+func parsePolicyExpression(value string, firstLine int) (interface{}, map[string]int, bool) {
+	value = strings.TrimSpace(value)
+
+	var body string
+	var bodyOffset int
+	heredoc := false
+	if lines := strings.Split(value, "\n"); strings.HasPrefix(value, "<<") && tfHeredoc.MatchString(lines[0]) {
+		if len(lines) < 2 {
+			return nil, nil, false
+		}
+		body = strings.Join(lines[1:len(lines)-1], "\n")
+		bodyOffset = len(lines[0]) + 1
+		heredoc = true
+	} else if strings.HasPrefix(value, "jsonencode(") && strings.HasSuffix(value, ")") {
+		body = value[len("jsonencode(") : len(value)-1]
+		bodyOffset = len("jsonencode(")
+	} else {
+		return nil, nil, false
+	}
+
+	// A heredoc must hold valid JSON before its positions are worth reading
+	var document interface{}
+	if heredoc {
+		if err := json.Unmarshal([]byte(body), &document); err != nil {
+			return nil, nil, false
+		}
+	}
+
+	// The literal parser reads JSON too, so it provides positions for both forms
+	p := &hclValueParser{s: body, offsets: make(map[string]int)}
+	if parsed := p.parse(); !heredoc {
+		document = parsed
+	}
+
+	lines := make(map[string]int, len(p.offsets))
+	for path, offset := range p.offsets {
+		lines[path] = firstLine + LineOf(value, bodyOffset+offset) - 1
+	}
+	return document, lines, true
+}
+
+// jsonPolicyStatements reads statements from a decoded JSON policy document.
+// lines maps value paths to lines, see parsePolicyExpression; it may be nil.
+func jsonPolicyStatements(document interface{}, lines map[string]int) []iamStatement {
+	doc, ok := document.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var raw []interface{}
+	paths := []string{"Statement"}
+	switch s := doc["Statement"].(type) {
+	case []interface{}:
+		raw = s
+		paths = nil
+		for i := range s {
+			paths = append(paths, fmt.Sprintf("Statement/%d", i))
+		}
+	case map[string]interface{}:
+		raw = []interface{}{s}
+	}
+
+	var statements []iamStatement
+	for i, item := range raw {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		effect, _ := fields["Effect"].(string)
+		statement := iamStatement{
+			Effect:      effect,
+			Actions:     stringOrList(fields["Action"]),
+			Resources:   stringOrList(fields["Resource"]),
+			Principals:  stringOrList(fields["Principal"]),
+			Conditional: fields["Condition"] != nil,
+			Line:        lines[paths[i]],
+		}
+		// Principal = { AWS = "*" } or { Service = [...] }
+		if principals, ok := fields["Principal"].(map[string]interface{}); ok {
+			for _, value := range principals {
+				statement.Principals = append(statement.Principals, stringOrList(value)...)
+			}
+		}
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// statementLine returns the line of a statement, falling back to the document
+func (d iamDocument) statementLine(statement iamStatement) int {
+	if statement.Line > 0 {
+		return statement.Line
+	}
+	return d.Line
+}
+
+func stringOrList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// hclStrings parses an HCL list of strings, e.g. ["iam:PassRole", "ec2:*"]
+func hclStrings(value string) []string {
+	p := &hclValueParser{s: value}
+	return stringOrList(p.parse())
+}
+
+// allowsOnAnyResource reports whether the statement allows action with Resource "*"
+func (s iamStatement) allowsOnAnyResource(action string) bool {
+	if !strings.EqualFold(s.Effect, "Allow") {
+		return false
+	}
+	anyResource := false
+	for _, resource := range s.Resources {
+		if resource == "*" {
+			anyResource = true
+		}
+	}
+	return anyResource && s.allows(action)
+}
+
+// allowsAnyone reports whether the statement unconditionally allows any principal
+func (s iamStatement) allowsAnyone() bool {
+	return strings.EqualFold(s.Effect, "Allow") && !s.Conditional && containsString(s.Principals, "*")
+}
+
+// allows reports whether any action pattern of the statement covers action
+func (s iamStatement) allows(action string) bool {
+	if !strings.EqualFold(s.Effect, "Allow") {
+		return false
+	}
+	for _, pattern := range s.Actions {
+		if iamActionMatches(pattern, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// iamActionMatches matches an IAM action pattern such as "iam:Pass*" case-insensitively
+func iamActionMatches(pattern, action string) bool {
+	expr := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(expr, action)
+	return err == nil && matched
+}
+
+// hclValueParser parses the HCL object and list literals used inside
+// jsonencode() into the same shapes encoding/json produces. References and
+// function calls are kept as their raw text.
+type hclValueParser struct {
+	s   string
+	pos int
+	// offsets, when non-nil, records where each value starts by its path of
+	// keys and list indexes, e.g. "Statement/0/Action"
+	offsets map[string]int
+}
+
+func (p *hclValueParser) parse() interface{} {
+	return p.parseAt("")
+}
+
+func (p *hclValueParser) parseAt(path string) interface{} {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return nil
+	}
+	if p.offsets != nil {
+		p.offsets[path] = p.pos
+	}
+
+	switch p.s[p.pos] {
+	case '{':
+		p.pos++
+		object := make(map[string]interface{})
+		for {
+			p.skipSeparators()
+			// A mismatched ] ends the object too, so malformed input can't
+			// stall the parser
+			if p.pos >= len(p.s) || p.s[p.pos] == '}' || p.s[p.pos] == ']' {
+				p.pos++
+				return object
+			}
+			key := p.parseKey()
+			p.skipSpace()
+			if p.pos < len(p.s) && (p.s[p.pos] == '=' || p.s[p.pos] == ':') {
+				p.pos++
+			}
+			object[key] = p.parseAt(joinValuePath(path, key))
+		}
+	case '[':
+		p.pos++
+		var list []interface{}
+		for {
+			p.skipSeparators()
+			if p.pos >= len(p.s) || p.s[p.pos] == ']' || p.s[p.pos] == '}' {
+				p.pos++
+				return list
+			}
+			list = append(list, p.parseAt(joinValuePath(path, strconv.Itoa(len(list)))))
+		}
+	case '"':
+		return p.parseString()
+	}
+
+	// Bare token: bool, number or expression
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(",}]\n", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	token := strings.TrimSpace(p.s[start:p.pos])
+	switch token {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return token
+}
+
+func joinValuePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "/" + key
+}
+
+func (p *hclValueParser) parseKey() string {
+	if p.pos < len(p.s) && p.s[p.pos] == '"' {
+		return p.parseString()
+	}
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(" \t\r\n=:", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *hclValueParser) parseString() string {
+	p.pos++ // opening quote
+	var value strings.Builder
+	for p.pos < len(p.s) && p.s[p.pos] != '"' {
+		if p.s[p.pos] == '\\' && p.pos+1 < len(p.s) {
+			p.pos++
+		}
+		value.WriteByte(p.s[p.pos])
+		p.pos++
+	}
+	p.pos++ // closing quote
+	return value.String()
+}
+
+func (p *hclValueParser) skipSpace() {
+	for p.pos < len(p.s) && strings.ContainsRune(" \t\r", rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *hclValueParser) skipSeparators() {
+	for p.pos < len(p.s) && strings.ContainsRune(" \t\r\n,", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	// Skip comments between entries
+	if p.pos < len(p.s) && (p.s[p.pos] == '#' || strings.HasPrefix(p.s[p.pos:], "//")) {
+		for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+			p.pos++
+		}
+		p.skipSeparators()
+	}
+}
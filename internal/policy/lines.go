@@ -0,0 +1,32 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import "strings"
+
+// LineOf returns the 1-based line containing the byte at offset in content.
+// Lines end at "\n", so CRLF files count the same as LF ones; offsets past
+// the end map to the last line.
+func LineOf(content string, byteOffset int) int {
+	if byteOffset < 0 {
+		byteOffset = 0
+	}
+	if byteOffset > len(content) {
+		byteOffset = len(content)
+	}
+	return strings.Count(content[:byteOffset], "\n") + 1
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineOf(t *testing.T) {
+	for _, newline := range []string{"\n", "\r\n"} {
+		content := strings.Join([]string{"first", "", "third", "fourth"}, newline)
+		third := strings.Index(content, "third")
+
+		tests := []struct {
+			name   string
+			offset int
+			want   int
+		}{
+			{"start", 0, 1},
+			{"end of first line", len("first"), 1},
+			{"empty line", len("first" + newline), 2},
+			{"start of third line", third, 3},
+			{"inside third line", third + 2, 3},
+			{"newline ending the third line", third + len("third"), 3},
+			{"last byte", len(content) - 1, 4},
+			{"end of content", len(content), 4},
+			{"past the end", len(content) + 10, 4},
+			{"negative", -1, 1},
+		}
+		for _, tt := range tests {
+			if got := LineOf(content, tt.offset); got != tt.want {
+				t.Errorf("%q: LineOf at %s (%d) = %d, want %d", newline, tt.name, tt.offset, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestPolicyStatementLines(t *testing.T) {
+	for _, newline := range []string{"\n", "\r\n"} {
+		content := strings.Join([]string{
+			`resource "aws_iam_policy" "heredoc" {`,
+			`  policy = <<EOT`,
+			`{`,
+			`  "Statement": [`,
+			`    {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"},`,
+			``,
+			`    {`,
+			`      "Effect": "Allow",`,
+			`      "Action": "*",`,
+			`      "Resource": "arn:aws:s3:::logs/*"`,
+			`    }`,
+			`  ]`,
+			`}`,
+			`EOT`,
+			`}`,
+			``,
+			`resource "aws_iam_policy" "jsonencode" {`,
+			`  policy = jsonencode({`,
+			`    Statement = [`,
+			`      { Effect = "Allow", Action = "*", Resource = "*" },`,
+			`    ]`,
+			`  })`,
+			`}`,
+		}, newline)
+
+		lines := make(map[string][]int)
+		for _, document := range iamPolicyDocuments(map[string]string{"iam.tf": content}) {
+			for _, statement := range document.Statements {
+				lines[document.Address] = append(lines[document.Address], document.statementLine(statement))
+			}
+		}
+		if got := lines["aws_iam_policy.heredoc"]; !equalInts(got, []int{5, 7}) {
+			t.Errorf("%q: heredoc statements on lines %v, want 5 and 7", newline, got)
+		}
+		if got := lines["aws_iam_policy.jsonencode"]; !equalInts(got, []int{20}) {
+			t.Errorf("%q: jsonencode statement on lines %v, want 20", newline, got)
+		}
+	}
+}
+
+func TestHCLValueParserMismatchedClosers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"[ }", []interface{}(nil)},
+		{"{ ] }", map[string]interface{}{}},
+		{`{"Statement": [{"Action": "*"}}`, map[string]interface{}{"Statement": []interface{}{map[string]interface{}{"Action": "*"}}}},
+		{`[1, }, 2]`, []interface{}{"1"}},
+	}
+	for _, tt := range tests {
+		done := make(chan interface{}, 1)
+		go func() {
+			p := &hclValueParser{s: tt.input, offsets: make(map[string]int)}
+			done <- p.parse()
+		}()
+		select {
+		case got := <-done:
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("parse(%q) never returned", tt.input)
+		}
+	}
+}
+
+func TestIAMWildcardRuleMalformedJSONEncode(t *testing.T) {
+	content := `resource "aws_iam_policy" "broken" {
+  policy = jsonencode({
+    Statement = [{ Effect = "Allow", Action = "*" }}
+  })
+}
+`
+	done := make(chan []CheckResult, 1)
+	go func() { done <- (&IAMWildcardRule{}).Check(map[string]string{"main.tf": content}) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("checking a malformed jsonencode policy never returned")
+	}
+}
+
+func TestParsePolicyExpressionMalformedHeredoc(t *testing.T) {
+	value := "<<EOF\n{\"Statement\": [{\"Action\": \"*\"}}\nEOF"
+	done := make(chan bool, 1)
+	go func() {
+		document, lines, ok := parsePolicyExpression(value, 2)
+		done <- ok || document != nil || lines != nil
+	}()
+	select {
+	case parsed := <-done:
+		if parsed {
+			t.Error("a heredoc holding invalid JSON was parsed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("parsing a heredoc holding invalid JSON never returned")
+	}
+
+	content := "resource \"aws_iam_policy\" \"broken\" {\n  policy = " + value + "\n}\n"
+	if results := (&IAMWildcardRule{}).Check(map[string]string{"main.tf": content}); len(failures(results)) != 0 {
+		t.Errorf("invalid heredoc policy reported %+v, want nothing to flag", failures(results))
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FilterPaths keeps the files matching at least one include glob (all files
+// when there are none) and none of the exclude globs. Excludes win on conflict.
+func FilterPaths(files map[string]string, include, exclude []string) map[string]string {
+	if len(include) == 0 && len(exclude) == 0 {
+		return files
+	}
+	
+	filtered := make(map[string]string, len(files))
+	for path, content := range files {
+		if len(include) > 0 && !matchesPathGlob(path, include) {
+			continue
+		}
+		if matchesPathGlob(path, exclude) {
+			continue
+		}
+		filtered[path] = content
+	}
+	return filtered
+}
+
+// ValidatePathGlob returns an error when pattern is not a usable path glob
+func ValidatePathGlob(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("empty path pattern")
+	}
+	if _, err := regexp.Compile(globToRegexp(filepath.ToSlash(pattern))); err != nil {
+		return fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// matchesPathGlob reports whether path matches one of the globs. Globs use
+// gitignore syntax (* within a segment, ** across segments), and a glob also
+// matches everything below a directory it matches, so "infra" and "infra/**"
+// are equivalent.
+func matchesPathGlob(path string, patterns []string) bool {
+	path = filepath.ToSlash(path)
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("^" + globToRegexp(strings.TrimPrefix(filepath.ToSlash(pattern), "./")) + "$")
+		if err != nil {
+			continue
+		}
+		
+		// Try the full path, then each parent directory
+		for candidate := path; candidate != "." && candidate != "/"; candidate = filepath.ToSlash(filepath.Dir(candidate)) {
+			if re.MatchString(candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestFilterPaths(t *testing.T) {
+	files := map[string]string{
+		"main.tf":                        "",
+		"infra/network.tf":               "",
+		"infra/modules/db/main.tf":       "",
+		"infra/testdata/bad.tf":          "",
+		"app/testdata/fixture.yaml":      "",
+		"app/deploy.yaml":                "",
+		".github/workflows/release.yaml": "",
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "no patterns",
+			want: slices.Sorted(maps.Keys(files)),
+		},
+		{
+			name:    "include narrows to infra",
+			include: []string{"infra/**"},
+			want:    []string{"infra/modules/db/main.tf", "infra/network.tf", "infra/testdata/bad.tf"},
+		},
+		{
+			name:    "bare directory include",
+			include: []string{"./infra"},
+			want:    []string{"infra/modules/db/main.tf", "infra/network.tf", "infra/testdata/bad.tf"},
+		},
+		{
+			name:    "exclude drops testdata anywhere",
+			exclude: []string{"**/testdata/**"},
+			want:    []string{".github/workflows/release.yaml", "app/deploy.yaml", "infra/modules/db/main.tf", "infra/network.tf", "main.tf"},
+		},
+		{
+			name:    "exclude wins over include",
+			include: []string{"infra/**"},
+			exclude: []string{"**/testdata/**"},
+			want:    []string{"infra/modules/db/main.tf", "infra/network.tf"},
+		},
+		{
+			name:    "several includes",
+			include: []string{"*.tf", "app/*.yaml"},
+			want:    []string{"app/deploy.yaml", "main.tf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slices.Sorted(maps.Keys(FilterPaths(files, tt.include, tt.exclude)))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePathGlob(t *testing.T) {
+	for _, pattern := range []string{"infra/**", "**/testdata/**", "*.tf", "env/[a-z]*"} {
+		if err := ValidatePathGlob(pattern); err != nil {
+			t.Errorf("ValidatePathGlob(%q) = %v", pattern, err)
+		}
+	}
+	for _, pattern := range []string{"", "  ", "env/[z-a]"} {
+		if err := ValidatePathGlob(pattern); err == nil {
+			t.Errorf("ValidatePathGlob(%q) accepted an invalid pattern", pattern)
+		}
+	}
+}
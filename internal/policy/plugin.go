@@ -0,0 +1,260 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PluginProtocolVersion is the version of the rule plugin protocol spoken by
+// this build. Plugins report the version they implement and are refused when
+// it differs.
+//
+// A rule plugin is an executable in the plugins directory of mondrian.yaml
+// that may provide several rules. Plugins run as separate processes, so they
+// can be written in any language, and a crash in one cannot take down a run.
+//
+// "<plugin> describe" prints the rules it provides:
+//
+//	{"protocol": 1, "rules": [{"name": "...", "description": "...", "severity": "medium"}]}
+//
+// "<plugin> check <rule>" reads {"files": {"<path>": "<content>"}} on stdin
+// and prints the rule's findings, in the same form as 'mondrian check
+// --format json':
+//
+//	{"results": [{"rule_name": "...", "status": "fail", "message": "...", "file": "main.tf", "line": 3}]}
+const PluginProtocolVersion = 1
+
+// PluginTimeout bounds each plugin invocation
+var PluginTimeout = 2 * time.Minute
+
+// PluginRule is one rule provided by a rule plugin
+type PluginRule struct {
+	name        string
+	description string
+	severity    string
+	path        string
+}
+
+type pluginDescription struct {
+	Protocol int `json:"protocol"`
+	Rules    []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Severity    string `json:"severity"`
+	} `json:"rules"`
+}
+
+type pluginInput struct {
+	Files map[string]string `json:"files"`
+}
+
+type pluginOutput struct {
+	Results []CheckResult `json:"results"`
+}
+
+// LoadPluginRules asks every executable in dir which rules it provides. Rule
+// names must be unique across built-in rules and plugins.
+func LoadPluginRules(dir string) ([]PolicyRule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	seen := make(map[string]string)
+	for _, name := range RuleNames() {
+		seen[name] = "built-in rules"
+	}
+
+	var rules []PolicyRule
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !isExecutable(path) {
+			continue
+		}
+
+		description, err := describePlugin(path)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", entry.Name(), err)
+		}
+		for _, rule := range description.Rules {
+			if rule.Name == "" {
+				return nil, fmt.Errorf("plugin %s: rule without a name", entry.Name())
+			}
+			if other, ok := seen[rule.Name]; ok {
+				return nil, fmt.Errorf("plugin %s: rule %s is already provided by %s", entry.Name(), rule.Name, other)
+			}
+			seen[rule.Name] = "plugin " + entry.Name()
+
+			severity := rule.Severity
+			if severity == "" {
+				severity = SeverityHigh
+			}
+			if err := ValidateSeverity(severity); err != nil {
+				return nil, fmt.Errorf("plugin %s: rule %s: %w", entry.Name(), rule.Name, err)
+			}
+			rules = append(rules, &PluginRule{
+				name:        rule.Name,
+				description: rule.Description,
+				severity:    severity,
+				path:        path,
+			})
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name() < rules[j].Name() })
+	return rules, nil
+}
+
+// PluginRuleNames returns the names of the rules the plugins in dir provide
+func PluginRuleNames(dir string) ([]string, error) {
+	rules, err := LoadPluginRules(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name())
+	}
+	return names, nil
+}
+
+func describePlugin(path string) (*pluginDescription, error) {
+	output, err := runPlugin(path, nil, "describe")
+	if err != nil {
+		return nil, err
+	}
+
+	var description pluginDescription
+	if err := json.Unmarshal(output, &description); err != nil {
+		return nil, fmt.Errorf("invalid describe output: %w", err)
+	}
+	if description.Protocol != PluginProtocolVersion {
+		return nil, fmt.Errorf("speaks plugin protocol %d, this build speaks %d", description.Protocol, PluginProtocolVersion)
+	}
+	return &description, nil
+}
+
+// runPlugin runs the plugin with args, feeding it input, and returns stdout
+func runPlugin(path string, input []byte, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), PluginTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", PluginTimeout)
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+func (r *PluginRule) Name() string {
+	return r.name
+}
+
+func (r *PluginRule) Description() string {
+	if r.description == "" {
+		return "Rule from plugin " + filepath.Base(r.path)
+	}
+	return r.description
+}
+
+func (r *PluginRule) DefaultSeverity() string {
+	return r.severity
+}
+
+func (r *PluginRule) Check(files map[string]string) []CheckResult {
+	input, err := json.Marshal(pluginInput{Files: files})
+	if err == nil {
+		var output []byte
+		output, err = runPlugin(r.path, input, "check", r.name)
+		if err == nil {
+			var parsed pluginOutput
+			if err = json.Unmarshal(output, &parsed); err == nil {
+				return r.normalize(parsed.Results)
+			}
+			err = fmt.Errorf("invalid check output: %w", err)
+		}
+	}
+
+	return []CheckResult{{
+		RuleName:    r.Name(),
+		Status:      "fail",
+		Severity:    r.severity,
+		Message:     fmt.Sprintf("Plugin %s failed: %v", filepath.Base(r.path), err),
+		Remediation: "Fix or remove the plugin, or disable the rule in " + ConfigFileName,
+	}}
+}
+
+// normalize attributes results to the rule and fills in what the plugin left
+// out, so plugin findings behave like built-in ones
+func (r *PluginRule) normalize(results []CheckResult) []CheckResult {
+	for i := range results {
+		results[i].RuleName = r.name
+		switch results[i].Status {
+		case "pass", "fail", "warn":
+		default:
+			results[i].Status = "fail"
+		}
+		if results[i].Status != "pass" && results[i].Severity == "" {
+			results[i].Severity = r.severity
+		}
+	}
+
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  r.Description(),
+		})
+	}
+	return results
+}
+
+// isExecutable reports whether path is a regular file the plugin loader
+// should run: executable on Unix, a .exe on Windows
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), ".") {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+	return info.Mode().Perm()&0111 != 0
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// buildTestPlugin compiles testdata/todo-plugin into a plugins directory next
+// to a mondrian.yaml loading it, and returns the config's path
+func buildTestPlugin(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds the example plugin")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "plugins", "todo-plugin")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	if output, err := exec.Command("go", "build", "-o", binary, "./testdata/todo-plugin").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, output)
+	}
+
+	path := filepath.Join(dir, ConfigFileName)
+	if err := os.WriteFile(path, []byte("plugins: plugins\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPluginResultsAreMerged(t *testing.T) {
+	cfg, err := LoadConfig(buildTestPlugin(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewPolicyEngineFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{"main.tf": `resource "aws_s3_bucket" "assets" {
+  # TODO: make this private
+  acl = "public-read"
+}
+`}
+	results, err := engine.RunChecks(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plugin, builtin []CheckResult
+	for _, result := range failures(results) {
+		switch result.RuleName {
+		case "no-todo-comments":
+			plugin = append(plugin, result)
+		case "s3-no-public-buckets":
+			builtin = append(builtin, result)
+		}
+	}
+	if len(builtin) == 0 {
+		t.Errorf("built-in findings missing alongside the plugin's")
+	}
+	if len(plugin) != 1 {
+		t.Fatalf("plugin findings = %+v, want the TODO comment", plugin)
+	}
+	finding := plugin[0]
+	if finding.File != "main.tf" || finding.Line != 2 || finding.Severity != SeverityLow || finding.Fingerprint == "" {
+		t.Errorf("plugin finding = %+v, want a low finding on main.tf:2 with a fingerprint", finding)
+	}
+
+	// A clean run passes the plugin rule like a built-in one
+	results, err = engine.RunChecks(context.Background(), map[string]string{"main.tf": "# nothing to do\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.RuleName == "no-todo-comments" && result.Status != "pass" {
+			t.Errorf("clean file produced %+v", result)
+		}
+	}
+}
+
+func TestPluginCrashFailsOnlyItsRule(t *testing.T) {
+	cfg, err := LoadConfig(buildTestPlugin(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewPolicyEngineFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := engine.RunChecks(context.Background(), map[string]string{
+		"panic.tf": `resource "aws_s3_bucket" "b" {
+  acl = "public-read"
+}
+`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var crashed, builtin bool
+	for _, result := range failures(results) {
+		switch result.RuleName {
+		case "no-todo-comments":
+			crashed = strings.HasPrefix(result.Message, "Plugin todo-plugin") && strings.Contains(result.Message, "panic: cannot parse panic.tf")
+		case "s3-no-public-buckets":
+			builtin = true
+		}
+	}
+	if !crashed || !builtin {
+		t.Errorf("results = %+v, want the plugin's crash reported and built-in rules still run", failures(results))
+	}
+}
+
+func TestLoadPluginRulesRefusesOtherProtocols(t *testing.T) {
+	dir := filepath.Join(filepath.Dir(buildTestPlugin(t)), "plugins")
+
+	rules, err := LoadPluginRules(dir)
+	if err != nil || len(rules) != 1 || rules[0].Name() != "no-todo-comments" {
+		t.Fatalf("rules = %v, %v, want no-todo-comments", rules, err)
+	}
+
+	t.Setenv("TODO_PLUGIN_PROTOCOL", "2")
+	if _, err := LoadPluginRules(dir); err == nil || !strings.Contains(err.Error(), "speaks plugin protocol 2") {
+		t.Errorf("err = %v, want the protocol refused", err)
+	}
+}
+
+// panickingRule crashes the way a buggy rule would
+type panickingRule struct{}
+
+func (panickingRule) Name() string        { return "panicking-rule" }
+func (panickingRule) Description() string { return "Panics on every file" }
+func (panickingRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	return results[:1]
+}
+
+func TestCheckRuleIsolatesPanics(t *testing.T) {
+	engine := NewPolicyEngine()
+	engine.Rules = []PolicyRule{panickingRule{}, &S3PublicBucketRule{}}
+
+	results, err := engine.RunChecks(context.Background(), map[string]string{"main.tf": `resource "aws_s3_bucket" "b" {
+  acl = "public-read"
+}
+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var panicked, checked bool
+	for _, result := range failures(results) {
+		switch result.RuleName {
+		case "panicking-rule":
+			panicked = result.Severity == SeverityHigh && strings.Contains(result.Message, "Rule panicking-rule panicked: runtime error")
+		case "s3-no-public-buckets":
+			checked = true
+		}
+	}
+	if !panicked || !checked {
+		t.Errorf("results = %+v, want the panic reported and the next rule still run", failures(results))
+	}
+}
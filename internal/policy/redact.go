@@ -0,0 +1,55 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Redact prepares results for sharing outside the organization. File paths are
+// replaced with stable hashes, Metadata (line content, resource addresses) is
+// dropped, and finding messages, which often name resources, are replaced with
+// the rule description. Rule names, statuses and counts are unchanged.
+func Redact(results []CheckResult) []CheckResult {
+	descriptions := make(map[string]string)
+	for _, rule := range NewPolicyEngine().Rules {
+		descriptions[rule.Name()] = rule.Description()
+	}
+	
+	redacted := make([]CheckResult, len(results))
+	for i, result := range results {
+		result.Metadata = nil
+		
+		if result.File != "" {
+			hash := sha256.Sum256([]byte(result.File))
+			result.File = "redacted-" + hex.EncodeToString(hash[:6])
+		}
+		
+		if result.Status != "pass" {
+			if description, ok := descriptions[result.RuleName]; ok {
+				result.Message = description
+			} else {
+				result.Message = "Finding redacted"
+			}
+		}
+		
+		redacted[i] = result
+	}
+	
+	return redacted
+}
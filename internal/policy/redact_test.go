@@ -0,0 +1,86 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRedactedOutputHasNoFilePaths(t *testing.T) {
+	files := map[string]string{
+		"infra/payments/storage.tf": `resource "aws_s3_bucket" "payments_exports" {
+  acl = "public-read"
+}
+`,
+		"infra/payments/network.tf": `resource "aws_security_group" "payments_api" {
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+`,
+	}
+	results, err := NewPolicyEngine().RunChecks(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures(results)) < 2 {
+		t.Fatalf("findings = %+v, want the bucket and the security group", failures(results))
+	}
+	redacted := Redact(results)
+
+	if len(redacted) != len(results) || len(failures(redacted)) != len(failures(results)) {
+		t.Errorf("redaction changed the counts: %d results, %d findings", len(redacted), len(failures(redacted)))
+	}
+	again := Redact(results)
+	for i, result := range redacted {
+		if result.RuleName != results[i].RuleName || result.Status != results[i].Status || result.Metadata != nil {
+			t.Errorf("result %d = %+v, want the rule and status kept and metadata dropped", i, result)
+		}
+		if result.File != "" && (!strings.HasPrefix(result.File, "redacted-") || result.File != again[i].File) {
+			t.Errorf("file = %q, want a stable redacted- hash", result.File)
+		}
+	}
+
+	outputs := map[string][]byte{
+		"text":   []byte(FormatResults(redacted)),
+		"github": ToGitHubAnnotations(redacted),
+	}
+	for format, render := range map[string]func([]CheckResult) ([]byte, error){
+		"json":   ToJSON,
+		"ndjson": ToNDJSON,
+		"sarif":  ToSARIF,
+		"gitlab": ToGitLabCodeQuality,
+		"html":   func(results []CheckResult) ([]byte, error) { return ToHTML(results, ReportMeta{}) },
+	} {
+		data, err := render(redacted)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		outputs[format] = data
+	}
+
+	for format, data := range outputs {
+		// Rule descriptions may mention 0.0.0.0/0; findings' own details may not
+		for _, secret := range []string{"infra/", "payments", ".tf", "public-read"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("redacted %s output contains %q", format, secret)
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RegoRule is a custom rule written in OPA's Rego language, loaded from a
+// .rego file in the policies directory of mondrian.yaml. The rule is named
+// after its file, e.g. policies/require-owner.rego runs as require-owner.
+//
+// The policy receives the scanned files as input.files, a map from path to
+// content, and reports violations through a deny set of messages or of
+// objects with msg and optional file, line and remediation. Optional
+// description and severity rules describe it:
+//
+//	package mondrian.require_owner
+//
+//	description := "Terraform files must name an owner"
+//	severity := "medium"
+//
+//	deny contains {"msg": "no owner tag", "file": path} if {
+//		some path, content in input.files
+//		endswith(path, ".tf")
+//		not contains(content, "Owner")
+//	}
+type RegoRule struct {
+	name        string
+	path        string
+	description string
+	severity    string
+
+	// eval runs the compiled policy against input and returns its deny set
+	eval func(input map[string]interface{}) ([]interface{}, error)
+}
+
+// RegoFileExt is the extension of policy files loaded by LoadRegoRules
+const RegoFileExt = ".rego"
+
+// RegoRuleNames returns the names of the Rego rules in dir, sorted, without
+// compiling them. A missing directory has none.
+func RegoRuleNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policies directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != RegoFileExt || strings.HasSuffix(name, "_test"+RegoFileExt) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(name, RegoFileExt))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LoadRegoRules compiles every .rego file in dir into a rule. Rego test files
+// (*_test.rego) are skipped, and a name clashing with a built-in rule is an
+// error.
+func LoadRegoRules(dir string) ([]PolicyRule, error) {
+	names, err := RegoRuleNames(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	builtin := RuleNames()
+	var rules []PolicyRule
+	for _, name := range names {
+		if containsString(builtin, name) {
+			return nil, fmt.Errorf("policy %s%s has the same name as a built-in rule", name, RegoFileExt)
+		}
+
+		path := filepath.Join(dir, name+RegoFileExt)
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy: %w", err)
+		}
+		rule, err := compileRegoRule(name, path, string(source))
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", path, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r *RegoRule) Name() string {
+	return r.name
+}
+
+func (r *RegoRule) Description() string {
+	if r.description == "" {
+		return "Custom Rego policy " + filepath.Base(r.path)
+	}
+	return r.description
+}
+
+func (r *RegoRule) DefaultSeverity() string {
+	return r.severity
+}
+
+func (r *RegoRule) Check(files map[string]string) []CheckResult {
+	input := make(map[string]interface{}, len(files))
+	for path, content := range files {
+		input[path] = content
+	}
+
+	denials, err := r.eval(map[string]interface{}{"files": input})
+	if err != nil {
+		return []CheckResult{{
+			RuleName:    r.Name(),
+			Status:      "fail",
+			Severity:    r.severity,
+			Message:     fmt.Sprintf("Policy %s could not be evaluated: %v", filepath.Base(r.path), err),
+			Remediation: "Fix the policy, e.g. with 'opa eval' against the same input",
+		}}
+	}
+
+	var results []CheckResult
+	for _, denial := range denials {
+		result := CheckResult{
+			RuleName: r.Name(),
+			Status:   "fail",
+			Severity: r.severity,
+		}
+		switch d := denial.(type) {
+		case string:
+			result.Message = d
+		case map[string]interface{}:
+			result.Message, _ = d["msg"].(string)
+			result.File, _ = d["file"].(string)
+			result.Remediation, _ = d["remediation"].(string)
+			result.Line = regoInt(d["line"])
+		default:
+			data, _ := json.Marshal(d)
+			result.Message = string(data)
+		}
+		if result.Message == "" {
+			result.Message = "Denied by " + filepath.Base(r.path)
+		}
+		if lines := strings.Split(files[result.File], "\n"); result.Line > 0 && result.Line <= len(lines) {
+			result.Metadata = map[string]interface{}{
+				"line_content": strings.TrimSpace(lines[result.Line-1]),
+			}
+		}
+		results = append(results, result)
+	}
+
+	// Sets have no order, so order findings for stable output
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		if results[i].Line != results[j].Line {
+			return results[i].Line < results[j].Line
+		}
+		return results[i].Message < results[j].Message
+	})
+
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  r.Description(),
+		})
+	}
+
+	return results
+}
+
+// newRegoRule builds the rule from a compiled policy's description and
+// severity, validating the severity
+func newRegoRule(name, path, description, severity string, eval func(map[string]interface{}) ([]interface{}, error)) (*RegoRule, error) {
+	if severity == "" {
+		severity = SeverityHigh
+	}
+	if err := ValidateSeverity(severity); err != nil {
+		return nil, fmt.Errorf("severity: %w", err)
+	}
+	return &RegoRule{
+		name:        name,
+		path:        path,
+		description: description,
+		severity:    severity,
+		eval:        eval,
+	}, nil
+}
+
+// regoInt converts a number from Rego output, or returns 0
+func regoInt(value interface{}) int {
+	switch v := value.(type) {
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+//go:build !rego
+
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadRegoRulesWithoutRegoSupport(t *testing.T) {
+	dir := writePolicies(t, map[string]string{"require-owner.rego": "package mondrian.require_owner\n"})
+	if _, err := LoadRegoRules(dir); err == nil || !strings.Contains(err.Error(), "rebuild with -tags rego") {
+		t.Errorf("err = %v, want a hint to rebuild with -tags rego", err)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:build rego
+
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// compileRegoRule compiles a Rego v1 policy and reads its description and
+// severity, which must not depend on the input
+func compileRegoRule(name, path, source string) (*RegoRule, error) {
+	module, err := ast.ParseModule(path, source)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := rego.New(
+		rego.Query(module.Package.Path.String()),
+		rego.ParsedModule(module),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	evalPackage := func(input map[string]interface{}) (map[string]interface{}, error) {
+		rs, err := query.Eval(context.Background(), rego.EvalInput(input))
+		if err != nil {
+			return nil, err
+		}
+		if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+			return map[string]interface{}{}, nil
+		}
+		document, ok := rs[0].Expressions[0].Value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("package %s did not evaluate to an object", module.Package.Path)
+		}
+		return document, nil
+	}
+
+	document, err := evalPackage(map[string]interface{}{"files": map[string]interface{}{}})
+	if err != nil {
+		return nil, err
+	}
+	description, _ := document["description"].(string)
+	severity, _ := document["severity"].(string)
+
+	return newRegoRule(name, path, description, severity, func(input map[string]interface{}) ([]interface{}, error) {
+		document, err := evalPackage(input)
+		if err != nil {
+			return nil, err
+		}
+		switch deny := document["deny"].(type) {
+		case nil:
+			return nil, nil
+		case []interface{}:
+			return deny, nil
+		default:
+			return nil, fmt.Errorf("deny must be a set, got %T", deny)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+//go:build rego
+
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const requireOwnerPolicy = `package mondrian.require_owner
+
+description := "Terraform files must name an owner"
+severity := "medium"
+
+deny contains {"msg": "no owner tag", "file": path, "line": 1} if {
+	some path, content in input.files
+	endswith(path, ".tf")
+	not contains(content, "Owner")
+}
+`
+
+func TestLoadRegoRulesDeny(t *testing.T) {
+	rules, err := LoadRegoRules(writePolicies(t, map[string]string{"require-owner.rego": requireOwnerPolicy}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("loaded %d rules, want 1", len(rules))
+	}
+	rule := rules[0].(*RegoRule)
+	if rule.Name() != "require-owner" || rule.Description() != "Terraform files must name an owner" || rule.DefaultSeverity() != "medium" {
+		t.Errorf("rule = %s %q %s, want require-owner described as in the policy", rule.Name(), rule.Description(), rule.DefaultSeverity())
+	}
+
+	results := rule.Check(map[string]string{
+		"unowned.tf": `resource "aws_s3_bucket" "b" {}`,
+		"owned.tf":   `resource "aws_s3_bucket" "c" { tags = { Owner = "platform" } }`,
+		"README.md":  "no tags here",
+	})
+	if len(results) != 1 || results[0].Status != "fail" || results[0].File != "unowned.tf" || results[0].Line != 1 || results[0].Message != "no owner tag" {
+		t.Errorf("results = %+v, want a failure for unowned.tf", results)
+	}
+
+	if results := rule.Check(map[string]string{"owned.tf": `tags = { Owner = "platform" }`}); len(results) != 1 || results[0].Status != "pass" {
+		t.Errorf("results = %+v, want a pass", results)
+	}
+}
+
+func TestLoadRegoRulesRejectsInvalidPolicies(t *testing.T) {
+	tests := map[string]string{
+		"syntax error":     "package mondrian.broken\n\ndeny contains msg if {\n",
+		"unknown severity": "package mondrian.loud\n\nseverity := \"catastrophic\"\n",
+	}
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := writePolicies(t, map[string]string{"broken.rego": source})
+			if _, err := LoadRegoRules(dir); err == nil || !strings.Contains(err.Error(), filepath.Join(dir, "broken.rego")) {
+				t.Errorf("err = %v, want it to name the policy", err)
+			}
+		})
+	}
+}
+
+func TestRegoPoliciesRunAlongsideBuiltinRules(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"policies/require-owner.rego": requireOwnerPolicy,
+		"mondrian.yaml":               "policies: policies\nrules: [require-owner, s3-no-public-buckets]\n",
+	})
+	cfg, err := LoadConfig(filepath.Join(dir, "mondrian.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewPolicyEngineFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := engine.RunChecks(context.Background(), map[string]string{"main.tf": `resource "aws_s3_bucket" "b" {
+  acl = "public-read"
+}
+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagged := map[string]bool{}
+	for _, result := range failures(results) {
+		flagged[result.RuleName] = true
+	}
+	if !flagged["require-owner"] || !flagged["s3-no-public-buckets"] {
+		t.Errorf("rules flagging = %v, want the Rego policy and the built-in rule", flagged)
+	}
+}
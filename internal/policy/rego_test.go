@@ -0,0 +1,129 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writePolicies writes the named .rego sources to a new policies directory
+func writePolicies(t *testing.T, policies map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, source := range policies {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRegoRuleCheck(t *testing.T) {
+	var input map[string]interface{}
+	rule, err := newRegoRule("require-owner", "policies/require-owner.rego", "", "medium", func(in map[string]interface{}) ([]interface{}, error) {
+		input = in
+		return []interface{}{
+			map[string]interface{}{"msg": "no owner tag", "file": "main.tf", "line": float64(2), "remediation": "Tag it"},
+			"plain denial",
+			map[string]interface{}{"file": "b.tf"},
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"main.tf": "resource \"aws_s3_bucket\" \"b\" {\n  bucket = \"b\"\n}\n", "b.tf": ""}
+
+	results := rule.Check(files)
+	if got := input["files"]; !reflect.DeepEqual(got, map[string]interface{}{"main.tf": files["main.tf"], "b.tf": ""}) {
+		t.Errorf("input.files = %v, want every file's content by path", got)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %+v, want one per denial", results)
+	}
+	for _, result := range results {
+		if result.Status != "fail" || result.Severity != "medium" || result.RuleName != "require-owner" {
+			t.Errorf("result = %+v, want a medium require-owner failure", result)
+		}
+	}
+
+	// Findings are ordered by file, then line
+	if results[0].Message != "plain denial" || results[1].Message != "Denied by require-owner.rego" || results[1].File != "b.tf" {
+		t.Errorf("results = %+v, want the string denial, then b.tf with a default message", results)
+	}
+	owner := results[2]
+	if owner.Message != "no owner tag" || owner.Line != 2 || owner.Remediation != "Tag it" || owner.Metadata["line_content"] != `bucket = "b"` {
+		t.Errorf("object denial = %+v, want msg, file, line and remediation carried over", owner)
+	}
+}
+
+func TestRegoRuleCheckPassesAndReportsEvalErrors(t *testing.T) {
+	rule, err := newRegoRule("no-op", "no-op.rego", "Nothing to deny", "", func(map[string]interface{}) ([]interface{}, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.DefaultSeverity() != SeverityHigh {
+		t.Errorf("default severity = %s, want %s", rule.DefaultSeverity(), SeverityHigh)
+	}
+	if results := rule.Check(nil); len(results) != 1 || results[0].Status != "pass" || results[0].Message != "Nothing to deny" {
+		t.Errorf("results = %+v, want a single pass", results)
+	}
+
+	rule.eval = func(map[string]interface{}) ([]interface{}, error) {
+		return nil, errors.New("rego_type_error")
+	}
+	if results := rule.Check(nil); len(results) != 1 || results[0].Status != "fail" || !strings.Contains(results[0].Message, "could not be evaluated: rego_type_error") {
+		t.Errorf("results = %+v, want the evaluation error reported as a failure", results)
+	}
+
+	if _, err := newRegoRule("loud", "loud.rego", "", "catastrophic", nil); err == nil {
+		t.Error("an unknown severity was accepted")
+	}
+}
+
+func TestRegoRuleNames(t *testing.T) {
+	dir := writePolicies(t, map[string]string{
+		"require-owner.rego":      "package mondrian.require_owner\n",
+		"a-first.rego":            "package mondrian.a_first\n",
+		"require-owner_test.rego": "package mondrian.require_owner_test\n",
+		"README.md":               "# policies\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "nested.rego"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := RegoRuleNames(dir)
+	if err != nil || !reflect.DeepEqual(names, []string{"a-first", "require-owner"}) {
+		t.Errorf("RegoRuleNames = %v, %v, want [a-first require-owner]", names, err)
+	}
+	if names, err := RegoRuleNames(filepath.Join(dir, "missing")); names != nil || err != nil {
+		t.Errorf("missing directory = %v, %v, want no rules", names, err)
+	}
+}
+
+func TestLoadRegoRulesRejectsBuiltinNames(t *testing.T) {
+	dir := writePolicies(t, map[string]string{"s3-no-public-buckets.rego": "package mondrian.s3\n"})
+	if _, err := LoadRegoRules(dir); err == nil || !strings.Contains(err.Error(), "same name as a built-in rule") {
+		t.Errorf("err = %v, want a clash with the built-in rule", err)
+	}
+}
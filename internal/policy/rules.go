@@ -17,25 +17,47 @@ limitations under the License.
 package policy
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"path/filepath"
 	"regexp"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 type CheckResult struct {
 	RuleName    string                 `json:"rule_name"`
 	Status      string                 `json:"status"` // "pass", "fail", "warn"
+	Severity    string                 `json:"severity,omitempty"` // "low", "medium", "high", "critical"
 	Message     string                 `json:"message"`
 	File        string                 `json:"file,omitempty"`
 	Line        int                    `json:"line,omitempty"`
 	Remediation string                 `json:"remediation,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Fingerprint string                 `json:"fingerprint,omitempty"` // Stable identity across runs, see ComputeFingerprint
 }
 
 type PolicyEngine struct {
-	Rules []PolicyRule
+	Rules     []PolicyRule
+	Allowlist *Allowlist
+	
+	// SeverityOverrides replaces the severity of a rule's findings
+	SeverityOverrides map[string]string
+	// FailOn is the minimum severity that fails; empty means DefaultFailOn
+	FailOn string
+	// IgnorePaths lists path globs excluded from every rule
+	IgnorePaths []string
+	// OnResult, when set, receives each result as soon as its rule has run,
+	// with severity, fingerprint and allowlist already applied
+	OnResult func(CheckResult)
 }
 
 type PolicyRule interface {
@@ -48,21 +70,129 @@ func NewPolicyEngine() *PolicyEngine {
 	return &PolicyEngine{
 		Rules: []PolicyRule{
 			&S3PublicBucketRule{},
-			&SecurityGroupOpenRule{},
+			NewSecurityGroupOpenRule(),
 			&MissingOIDCRule{},
+			&SensitiveVariableRule{},
+			&AccessKeyRotationRule{},
+			NewPreventDestroyRule(),
+			&PlaintextInternalTrafficRule{},
+			&SensitiveLoggingRule{},
+			&S3EncryptionRule{},
+			NewHighEntropySecretRule(),
+			&FileSystemEncryptionRule{},
+			&IAMPassRoleRule{},
+			&ThreatDetectionRule{},
+			&S3PublicAccessBlockRule{},
+			&CORSRule{},
+			&DockerfileRule{},
+			&IAMWildcardRule{},
+			&WorkflowPermissionsRule{},
+			&PinnedActionRule{},
+			&DatabasePortExposureRule{},
+			&GCPPublicBucketRule{},
+			&AzureStoragePublicRule{},
+			&EncryptionAtRestRule{},
+			&RequiredTagsRule{},
+			&RDSPublicRule{},
+			&IMDSv2Rule{},
 		},
 	}
 }
 
-func (pe *PolicyEngine) RunChecks(files map[string]string) []CheckResult {
+// RuleNames returns the names of every registered rule, sorted
+func RuleNames() []string {
+	known := make(map[string]bool)
+	for _, rule := range NewPolicyEngine().Rules {
+		known[rule.Name()] = true
+	}
+	return sortedKeys(known)
+}
+
+// SelectRules restricts the engine to the named rules. A name that isn't a
+// registered rule or loaded policy, or that the config disabled, is an error.
+func (pe *PolicyEngine) SelectRules(names []string) error {
+	known := RuleNames()
+	for _, rule := range pe.Rules {
+		if !containsString(known, rule.Name()) {
+			known = append(known, rule.Name())
+		}
+	}
+	selected := make(map[string]bool)
+	for _, name := range names {
+		if !containsString(known, name) {
+			return fmt.Errorf("unknown rule %q (known rules: %s)", name, strings.Join(known, ", "))
+		}
+		selected[name] = true
+	}
+	
+	var rules []PolicyRule
+	for _, rule := range pe.Rules {
+		if selected[rule.Name()] {
+			rules = append(rules, rule)
+			delete(selected, rule.Name())
+		}
+	}
+	for _, name := range names {
+		if selected[name] {
+			return fmt.Errorf("rule %q is not enabled by the rules list in %s", name, ConfigFileName)
+		}
+	}
+	
+	pe.Rules = rules
+	return nil
+}
+
+// RunChecks runs every rule over files. It stops with ctx's error once ctx is
+// done, discarding the results gathered so far.
+func (pe *PolicyEngine) RunChecks(ctx context.Context, files map[string]string) ([]CheckResult, error) {
 	var results []CheckResult
 	
+	if len(pe.IgnorePaths) > 0 {
+		checked := make(map[string]string, len(files))
+		for path, content := range files {
+			if !matchesPathGlob(path, pe.IgnorePaths) {
+				checked[path] = content
+			}
+		}
+		files = checked
+	}
+	
 	for _, rule := range pe.Rules {
-		ruleResults := rule.Check(files)
+		// Rules run to completion; cancellation is noticed between them
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		started := time.Now()
+		ruleResults := withResourceContext(checkRule(rule, files), files)
+		slog.Debug("Ran rule", "rule", rule.Name(), "results", len(ruleResults), "duration", time.Since(started).Round(time.Microsecond))
+		if severity, ok := pe.SeverityOverrides[rule.Name()]; ok {
+			for i := range ruleResults {
+				if ruleResults[i].Status != "pass" {
+					ruleResults[i].Severity = severity
+				}
+			}
+		}
+		
+		ruleResults = ApplyThreshold(ruleResults, pe.FailOn)
+		ruleResults = withFrameworks(ruleResults, RuleFrameworks(rule))
+		
+		// Fingerprint before suppressions rewrite messages, so identity is stable
+		for i := range ruleResults {
+			if ruleResults[i].Status != "pass" {
+				ruleResults[i].Fingerprint = ruleResults[i].ComputeFingerprint()
+			}
+		}
+		
+		ruleResults = pe.Allowlist.Apply(ruleResults)
+		if pe.OnResult != nil {
+			for _, result := range ruleResults {
+				pe.OnResult(result)
+			}
+		}
 		results = append(results, ruleResults...)
 	}
 	
-	return results
+	return results, nil
 }
 
 // S3PublicBucketRule checks for public S3 buckets in Terraform
@@ -73,43 +203,87 @@ func (r *S3PublicBucketRule) Name() string {
 }
 
 func (r *S3PublicBucketRule) Description() string {
-	return "S3 buckets should not allow public read access"
+	return "S3 buckets should not be made public through canned ACLs or bucket policies"
+}
+
+func (r *S3PublicBucketRule) DefaultSeverity() string {
+	return SeverityCritical
+}
+
+func (r *S3PublicBucketRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "2.1.4",
+		"SOC2":    "CC6.1",
+	}
 }
 
+// publicACLs are canned ACLs granting read or write access to everyone
+var publicACLs = []string{"public-read", "public-read-write"}
+
 func (r *S3PublicBucketRule) Check(files map[string]string) []CheckResult {
 	var results []CheckResult
 	
-	for filename, content := range files {
-		if !isTerraformFile(filename) {
-			continue
-		}
-		
-		// Check for public bucket configurations
-		publicPatterns := []string{
-			`public_read_write`,
-			`public-read-write`,
-			`public_read`,
-			`public-read`,
-			`"*".*s3:GetObject`,
+	// Public access blocks override ACLs and policies set on the bucket
+	var accountBlocks []*tfBlock
+	for _, blocks := range terraformResources(files, "aws_s3_account_public_access_block") {
+		accountBlocks = append(accountBlocks, blocks...)
+	}
+	accessBlocks := resourceTargets(files, "aws_s3_bucket_public_access_block", "bucket")
+	blocked := func(accessBlock *tfBlock, flags ...string) bool {
+		for _, block := range append([]*tfBlock{accessBlock}, accountBlocks...) {
+			if block == nil {
+				continue
+			}
+			for _, flag := range flags {
+				if attr, ok := block.Attr(flag); ok && attr.IsTrue() {
+					return true
+				}
+			}
 		}
+		return false
+	}
+	
+	policyDocuments := make(map[string]iamDocument)
+	for _, document := range iamPolicyDocuments(files) {
+		policyDocuments[document.Address] = document
+	}
+	
+	for filename, blocks := range terraformResources(files, "aws_s3_bucket", "aws_s3_bucket_acl", "aws_s3_bucket_policy") {
+		lines := strings.Split(files[filename], "\n")
 		
-		lines := strings.Split(content, "\n")
-		for lineNum, line := range lines {
-			for _, pattern := range publicPatterns {
-				matched, _ := regexp.MatchString(pattern, line)
-				if matched {
-					results = append(results, CheckResult{
-						RuleName:    r.Name(),
-						Status:      "fail",
-						Message:     "S3 bucket configured with public access",
-						File:        filename,
-						Line:        lineNum + 1,
-						Remediation: "Remove public access configuration or use specific IAM policies instead",
-						Metadata: map[string]interface{}{
-							"pattern": pattern,
-							"line_content": strings.TrimSpace(line),
-						},
-					})
+		for _, block := range blocks {
+			accessBlock := targetedBy(accessBlocks, block, "bucket")
+			if bucket, ok := block.Attr("bucket"); ok && block.Labels[0] != "aws_s3_bucket" {
+				accessBlock = attrTarget(accessBlocks, bucket)
+			}
+			
+			result := CheckResult{
+				RuleName: r.Name(),
+				Status:   "fail",
+				Severity: SeverityCritical,
+				File:     filename,
+			}
+			
+			if acl, ok := block.Attr("acl"); ok && containsString(publicACLs, acl.String()) {
+				if !blocked(accessBlock, "block_public_acls", "ignore_public_acls") {
+					finding := result
+					finding.Message = fmt.Sprintf("%s sets public ACL %q", block.Address(), acl.String())
+					finding.Line = acl.Line
+					finding.Remediation = "Use acl = \"private\" and grant access through IAM or a bucket policy scoped to specific principals"
+					finding.Metadata = resourceMetadata(block, lines[acl.Line-1])
+					results = append(results, finding)
+				}
+			}
+			
+			if policy, ok := block.Attr("policy"); ok {
+				line, public := publicBucketPolicy(policy, policyDocuments)
+				if public && !blocked(accessBlock, "block_public_policy", "restrict_public_buckets") {
+					finding := result
+					finding.Message = fmt.Sprintf("Bucket policy of %s allows access from any principal", block.Address())
+					finding.Line = line
+					finding.Remediation = "Replace Principal \"*\" with specific principals, or add a Condition such as aws:SourceVpce or aws:PrincipalOrgID"
+					finding.Metadata = resourceMetadata(block, lines[line-1])
+					results = append(results, finding)
 				}
 			}
 		}
@@ -126,8 +300,44 @@ func (r *S3PublicBucketRule) Check(files map[string]string) []CheckResult {
 	return results
 }
 
+// publicBucketPolicy reports whether a policy attribute, inline or a reference
+// to an aws_iam_policy_document, unconditionally allows any principal. The
+// line is that of the public statement when it is inline, else the attribute.
+func publicBucketPolicy(policy tfAttr, documents map[string]iamDocument) (int, bool) {
+	document := iamDocument{Line: policy.Line}
+	if parsed, lines, ok := parsePolicyExpression(policy.Value, policy.Line); ok {
+		document.Statements = jsonPolicyStatements(parsed, lines)
+	} else if m := tfPolicyDocumentRef.FindStringSubmatch(policy.Value); m != nil {
+		for _, statement := range documents["data.aws_iam_policy_document."+m[1]].Statements {
+			statement.Line = 0 // in the data source, usually another block or file
+			document.Statements = append(document.Statements, statement)
+		}
+	}
+	
+	for _, statement := range document.Statements {
+		if statement.allowsAnyone() {
+			return document.statementLine(statement), true
+		}
+	}
+	return 0, false
+}
+
+var tfPolicyDocumentRef = regexp.MustCompile(`data\.aws_iam_policy_document\.([A-Za-z_][\w-]*)\.json`)
+
 // SecurityGroupOpenRule checks for overly permissive security groups
-type SecurityGroupOpenRule struct{}
+type SecurityGroupOpenRule struct {
+	// OpenCIDRs are ingress sources treated as open to the internet
+	OpenCIDRs []string
+	// SensitivePorts limits findings to ingress covering one of these ports; empty means any port
+	SensitivePorts []int
+}
+
+// NewSecurityGroupOpenRule creates the rule flagging 0.0.0.0/0 ingress on any port
+func NewSecurityGroupOpenRule() *SecurityGroupOpenRule {
+	return &SecurityGroupOpenRule{
+		OpenCIDRs: []string{"0.0.0.0/0"},
+	}
+}
 
 func (r *SecurityGroupOpenRule) Name() string {
 	return "sg-no-open-ingress"
@@ -137,46 +347,256 @@ func (r *SecurityGroupOpenRule) Description() string {
 	return "Security groups should not allow ingress from 0.0.0.0/0 on sensitive ports"
 }
 
+func (r *SecurityGroupOpenRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *SecurityGroupOpenRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "5.2",
+		"SOC2":    "CC6.6",
+	}
+}
+
+// Configure accepts open_cidrs (list of CIDRs) and sensitive_ports (list of port numbers)
+func (r *SecurityGroupOpenRule) Configure(settings map[string]interface{}) error {
+	if err := checkSettingKeys(settings, "open_cidrs", "sensitive_ports"); err != nil {
+		return err
+	}
+	
+	var cidrs []string
+	if ok, err := settingList(settings, "open_cidrs", func(item interface{}) bool {
+		cidr, isString := item.(string)
+		if _, _, err := net.ParseCIDR(cidr); !isString || err != nil {
+			return false
+		}
+		cidrs = append(cidrs, cidr)
+		return true
+	}); err != nil {
+		return err
+	} else if ok {
+		r.OpenCIDRs = cidrs
+	}
+	
+	var ports []int
+	if ok, err := settingList(settings, "sensitive_ports", func(item interface{}) bool {
+		port, isInt := item.(int)
+		if !isInt || port < 0 || port > 65535 {
+			return false
+		}
+		ports = append(ports, port)
+		return true
+	}); err != nil {
+		return err
+	} else if ok {
+		r.SensitivePorts = ports
+	}
+	
+	return nil
+}
+
 func (r *SecurityGroupOpenRule) Check(files map[string]string) []CheckResult {
 	var results []CheckResult
 	
-	for filename, content := range files {
-		if !isTerraformFile(filename) {
-			continue
-		}
-		
-		// Look for security group rules with 0.0.0.0/0
-		openCIDRPattern := `0\.0\.0\.0/0`
+	for filename, blocks := range terraformResources(files) {
+		lines := strings.Split(files[filename], "\n")
 		
-		lines := strings.Split(content, "\n")
-		inIngressBlock := false
+		for _, block := range blocks {
+			for _, ingress := range block.NestedBlocks("ingress") {
+				port, exposed := r.exposedPort(ingress)
+				if !exposed {
+					continue
+				}
+				
+				for _, attrName := range []string{"cidr_blocks", "ipv6_cidr_blocks"} {
+					attr, ok := ingress.Attr(attrName)
+					if !ok {
+						continue
+					}
+					cidr := ""
+					for _, source := range hclStrings(attr.Value) {
+						if containsString(r.OpenCIDRs, source) {
+							cidr = source
+							break
+						}
+					}
+					if cidr == "" {
+						continue
+					}
+					
+					message := fmt.Sprintf("Security group allows ingress from %s", cidr)
+					if len(r.SensitivePorts) > 0 {
+						message += fmt.Sprintf(" on sensitive port %d", port)
+					}
+					results = append(results, CheckResult{
+						RuleName:    r.Name(),
+						Status:      "fail",
+						Severity:    SeverityHigh,
+						Message:     message,
+						File:        filename,
+						Line:        attr.Line,
+						Remediation: "Restrict ingress to specific CIDR blocks or security groups",
+						Metadata:    resourceMetadata(block, lines[attr.Line-1]),
+					})
+				}
+			}
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No overly permissive security groups detected",
+		})
+	}
+	
+	return results
+}
+
+// exposedPort returns the first sensitive port the ingress block's port range
+// covers. Every ingress counts when no sensitive ports are configured, and
+// ranges that are not literal numbers are assumed to cover every port.
+func (r *SecurityGroupOpenRule) exposedPort(ingress *tfBlock) (int, bool) {
+	if len(r.SensitivePorts) == 0 {
+		return 0, true
+	}
+	
+	from, to := ingressPortRange(ingress)
+	for _, port := range r.SensitivePorts {
+		if port >= from && port <= to {
+			return port, true
+		}
+	}
+	return 0, false
+}
+
+// ingressPortRange returns the from_port/to_port range of an ingress block or
+// rule resource. All-protocol rules and ports that are not literal numbers
+// cover every port.
+func ingressPortRange(ingress *tfBlock) (int, int) {
+	from, to := 0, 65535
+	protocol, ok := ingress.Attr("protocol")
+	if !ok {
+		protocol, _ = ingress.Attr("ip_protocol")
+	}
+	if p := protocol.String(); p != "-1" && p != "all" {
+		fromAttr, _ := ingress.Attr("from_port")
+		toAttr, _ := ingress.Attr("to_port")
+		if f, err := strconv.Atoi(fromAttr.String()); err == nil {
+			from = f
+		}
+		if t, err := strconv.Atoi(toAttr.String()); err == nil {
+			to = t
+		}
+	}
+	return from, to
+}
+
+// databasePort is a remote-administration or datastore port that should never
+// be reachable from the internet
+type databasePort struct {
+	Port        int
+	Service     string
+	Remediation string
+}
+
+var databasePorts = []databasePort{
+	{22, "SSH", "Restrict SSH to a bastion host CIDR, or use SSM Session Manager instead of opening port 22"},
+	{3389, "RDP", "Restrict RDP to a bastion host or VPN CIDR"},
+	{3306, "MySQL", "Restrict MySQL to the application's security group or the VPC CIDR"},
+	{5432, "PostgreSQL", "Restrict PostgreSQL to the application's security group or the VPC CIDR"},
+	{6379, "Redis", "Restrict Redis to the application's security group; Redis has no authentication by default"},
+	{27017, "MongoDB", "Restrict MongoDB to the application's security group or the VPC CIDR"},
+	{9200, "Elasticsearch", "Restrict Elasticsearch to the VPC CIDR and front it with an authenticating proxy"},
+}
+
+// internetCIDRs are ingress sources open to the whole internet
+var internetCIDRs = []string{"0.0.0.0/0", "::/0"}
+
+// DatabasePortExposureRule checks for database and remote-administration
+// ports open to the internet, a more severe case of SecurityGroupOpenRule
+type DatabasePortExposureRule struct{}
+
+func (r *DatabasePortExposureRule) Name() string {
+	return "sg-no-open-database-ports"
+}
+
+func (r *DatabasePortExposureRule) Description() string {
+	return "Security groups must not expose SSH, RDP or database ports to the internet"
+}
+
+func (r *DatabasePortExposureRule) DefaultSeverity() string {
+	return SeverityCritical
+}
+
+func (r *DatabasePortExposureRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "5.2",
+		"SOC2":    "CC6.6",
+	}
+}
+
+func (r *DatabasePortExposureRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for filename, blocks := range terraformResources(files) {
+		lines := strings.Split(files[filename], "\n")
 		
-		for lineNum, line := range lines {
-			trimmedLine := strings.TrimSpace(line)
-			
-			// Track if we're in an ingress block
-			if strings.Contains(trimmedLine, "ingress {") {
-				inIngressBlock = true
-			} else if strings.Contains(trimmedLine, "egress {") {
-				inIngressBlock = false
-			} else if strings.Contains(trimmedLine, "}") && inIngressBlock {
-				inIngressBlock = false
+		for _, block := range blocks {
+			// Inline ingress blocks, plus standalone rule resources
+			ingresses := block.NestedBlocks("ingress")
+			switch block.Labels[0] {
+			case "aws_security_group_rule":
+				if kind, _ := block.Attr("type"); kind.String() == "ingress" {
+					ingresses = append(ingresses, block)
+				}
+			case "aws_vpc_security_group_ingress_rule":
+				ingresses = append(ingresses, block)
 			}
 			
-			// Only flag 0.0.0.0/0 in ingress blocks
-			matched, _ := regexp.MatchString(openCIDRPattern, line)
-			if matched && inIngressBlock && strings.Contains(line, "cidr_blocks") {
-				results = append(results, CheckResult{
-					RuleName:    r.Name(),
-					Status:      "fail",
-					Message:     "Security group allows ingress from 0.0.0.0/0",
-					File:        filename,
-					Line:        lineNum + 1,
-					Remediation: "Restrict ingress to specific CIDR blocks or security groups",
-					Metadata: map[string]interface{}{
-						"line_content": strings.TrimSpace(line),
-					},
-				})
+			for _, ingress := range ingresses {
+				exposed := exposedDatabasePorts(ingress)
+				if len(exposed) == 0 {
+					continue
+				}
+				
+				for _, attrName := range []string{"cidr_blocks", "ipv6_cidr_blocks", "cidr_ipv4", "cidr_ipv6"} {
+					attr, ok := ingress.Attr(attrName)
+					if !ok {
+						continue
+					}
+					cidr := ""
+					for _, source := range hclStrings(attr.Value) {
+						if containsString(internetCIDRs, source) {
+							cidr = source
+							break
+						}
+					}
+					if cidr == "" {
+						continue
+					}
+					
+					var services []string
+					for _, port := range exposed {
+						services = append(services, fmt.Sprintf("%s (%d)", port.Service, port.Port))
+					}
+					remediation := exposed[0].Remediation
+					if len(exposed) > 1 {
+						remediation = "Narrow from_port/to_port to the ports the service needs and restrict ingress to specific CIDR blocks or security groups"
+					}
+					
+					results = append(results, CheckResult{
+						RuleName:    r.Name(),
+						Status:      "fail",
+						Severity:    SeverityCritical,
+						Message:     fmt.Sprintf("Security group allows ingress from %s to %s", cidr, strings.Join(services, ", ")),
+						File:        filename,
+						Line:        attr.Line,
+						Remediation: remediation,
+						Metadata:    resourceMetadata(block, lines[attr.Line-1]),
+					})
+				}
 			}
 		}
 	}
@@ -185,13 +605,25 @@ func (r *SecurityGroupOpenRule) Check(files map[string]string) []CheckResult {
 		results = append(results, CheckResult{
 			RuleName: r.Name(),
 			Status:   "pass",
-			Message:  "No overly permissive security groups detected",
+			Message:  "No database or remote administration ports open to the internet",
 		})
 	}
 	
 	return results
 }
 
+// exposedDatabasePorts returns the database ports covered by an ingress port range
+func exposedDatabasePorts(ingress *tfBlock) []databasePort {
+	from, to := ingressPortRange(ingress)
+	var exposed []databasePort
+	for _, port := range databasePorts {
+		if port.Port >= from && port.Port <= to {
+			exposed = append(exposed, port)
+		}
+	}
+	return exposed
+}
+
 // MissingOIDCRule checks for proper OIDC configuration in CI/CD
 type MissingOIDCRule struct{}
 
@@ -203,6 +635,16 @@ func (r *MissingOIDCRule) Description() string {
 	return "Deployment workflows should use OIDC workload identity instead of long-lived credentials"
 }
 
+func (r *MissingOIDCRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *MissingOIDCRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC6.1",
+	}
+}
+
 func (r *MissingOIDCRule) Check(files map[string]string) []CheckResult {
 	var results []CheckResult
 	foundOIDC := false
@@ -244,6 +686,7 @@ func (r *MissingOIDCRule) Check(files map[string]string) []CheckResult {
 					results = append(results, CheckResult{
 						RuleName:    r.Name(),
 						Status:      "fail",
+						Severity:    SeverityHigh,
 						Message:     "GitHub Action uses long-lived credentials instead of OIDC",
 						File:        filename,
 						Line:        lineNum + 1,
@@ -267,6 +710,7 @@ func (r *MissingOIDCRule) Check(files map[string]string) []CheckResult {
 		results = append(results, CheckResult{
 			RuleName: r.Name(),
 			Status:   "warn",
+			Severity: SeverityLow,
 			Message:  "No deployment workflows detected",
 		})
 	}
@@ -274,56 +718,2730 @@ func (r *MissingOIDCRule) Check(files map[string]string) []CheckResult {
 	return results
 }
 
-// Helper functions
-func isTerraformFile(filename string) bool {
-	ext := filepath.Ext(filename)
-	return ext == ".tf" || ext == ".tfvars"
+// WorkflowPermissionsRule checks that GitHub Actions workflows declare an
+// explicit GITHUB_TOKEN permissions block instead of relying on the repository
+// default, and never grant write-all
+type WorkflowPermissionsRule struct{}
+
+func (r *WorkflowPermissionsRule) Name() string {
+	return "gha-explicit-permissions"
 }
 
-func isGitHubActionFile(filename string) bool {
-	return strings.Contains(filename, ".github/workflows/") && filepath.Ext(filename) == ".yml" || filepath.Ext(filename) == ".yaml"
+func (r *WorkflowPermissionsRule) Description() string {
+	return "GitHub Actions workflows should set least-privilege permissions for GITHUB_TOKEN"
 }
 
-// FormatResults formats check results for display
-func FormatResults(results []CheckResult) string {
-	var output strings.Builder
-	
-	passCount := 0
-	failCount := 0
-	warnCount := 0
+func (r *WorkflowPermissionsRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *WorkflowPermissionsRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC6.3",
+	}
+}
+
+func (r *WorkflowPermissionsRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
 	
-	for _, result := range results {
-		switch result.Status {
-		case "pass":
-			passCount++
-			fmt.Fprintf(&output, "✅ %s: %s\n", result.RuleName, result.Message)
-		case "fail":
-			failCount++
-			fmt.Fprintf(&output, "❌ %s: %s\n", result.RuleName, result.Message)
-			if result.File != "" {
-				fmt.Fprintf(&output, "   📁 %s:%d\n", result.File, result.Line)
+	for filename, content := range files {
+		if !isGitHubActionFile(filename) {
+			continue
+		}
+		
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(content), &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		workflow := doc.Content[0]
+		
+		writeAll := func(key, value *yaml.Node, scope string) {
+			if value.Kind == yaml.ScalarNode && value.Value == "write-all" {
+				results = append(results, CheckResult{
+					RuleName:    r.Name(),
+					Status:      "fail",
+					Severity:    SeverityHigh,
+					Message:     fmt.Sprintf("Workflow grants GITHUB_TOKEN write-all permissions %s", scope),
+					File:        filename,
+					Line:        key.Line,
+					Remediation: "Replace write-all with the scopes the workflow needs, starting from contents: read",
+					Metadata: map[string]interface{}{
+						"scope": scope,
+					},
+				})
 			}
-			if result.Remediation != "" {
-				fmt.Fprintf(&output, "   💡 %s\n", result.Remediation)
+		}
+		
+		topKey, topPermissions := yamlMappingValue(workflow, "permissions")
+		if topPermissions != nil {
+			writeAll(topKey, topPermissions, "for every job")
+		}
+		
+		// Jobs without their own permissions inherit the top-level block, or the
+		// repository default when there is none
+		var unscoped []string
+		if _, jobs := yamlMappingValue(workflow, "jobs"); jobs != nil && jobs.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(jobs.Content); i += 2 {
+				jobName, job := jobs.Content[i].Value, jobs.Content[i+1]
+				key, permissions := yamlMappingValue(job, "permissions")
+				if permissions == nil {
+					unscoped = append(unscoped, jobName)
+					continue
+				}
+				writeAll(key, permissions, "in job "+jobName)
 			}
-		case "warn":
-			warnCount++
-			fmt.Fprintf(&output, "⚠️  %s: %s\n", result.RuleName, result.Message)
+		}
+		
+		if topPermissions == nil && len(unscoped) > 0 {
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "warn",
+				Severity:    SeverityMedium,
+				Message:     fmt.Sprintf("Workflow has no top-level permissions block, so jobs without their own (%s) use the repository's default GITHUB_TOKEN scopes", strings.Join(unscoped, ", ")),
+				File:        filename,
+				Line:        1,
+				Remediation: "Add a top-level permissions block with contents: read and grant more per job only where needed",
+				Metadata: map[string]interface{}{
+					"jobs": unscoped,
+				},
+			})
 		}
 	}
 	
-	fmt.Fprintf(&output, "\n📊 Summary: %d passed, %d failed, %d warnings\n", passCount, failCount, warnCount)
-	
-	if failCount > 0 {
-		fmt.Fprintf(&output, "\n🚫 Policy check failed - %d violations found\n", failCount)
-	} else {
-		fmt.Fprintf(&output, "\n✅ All policy checks passed!\n")
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "All workflows set explicit GITHUB_TOKEN permissions",
+		})
 	}
 	
-	return output.String()
+	return results
 }
 
-// ToJSON converts results to JSON for attestation
-func ToJSON(results []CheckResult) ([]byte, error) {
+// PinnedActionRule checks that workflow steps and reusable workflow calls pin
+// actions to a full commit SHA, since tags and branches can be moved to
+// different code after review
+type PinnedActionRule struct{}
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+func (r *PinnedActionRule) Name() string {
+	return "gha-pin-actions-sha"
+}
+
+func (r *PinnedActionRule) Description() string {
+	return "GitHub Actions uses: references should be pinned to a full commit SHA"
+}
+
+func (r *PinnedActionRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *PinnedActionRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC8.1",
+	}
+}
+
+func (r *PinnedActionRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for filename, content := range files {
+		if !isGitHubActionFile(filename) {
+			continue
+		}
+		
+		for _, value := range workflowUses(content) {
+			reference := strings.TrimSpace(value.Value)
+			// Local actions and workflows come from the same commit; container
+			// actions are pinned by image digest instead
+			if strings.HasPrefix(reference, "./") || strings.HasPrefix(reference, "docker://") {
+				continue
+			}
+			
+			action, ref, _ := strings.Cut(reference, "@")
+			if commitSHAPattern.MatchString(ref) {
+				continue
+			}
+			
+			message := fmt.Sprintf("Action %s is referenced by mutable ref %q instead of a commit SHA", action, ref)
+			if ref == "" {
+				message = fmt.Sprintf("Action %s is not pinned to any ref", action)
+			}
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "fail",
+				Severity:    SeverityHigh,
+				Message:     message,
+				File:        filename,
+				Line:        value.Line,
+				Remediation: fmt.Sprintf("Pin to the full commit SHA, e.g. %s@<40-char sha>, with the release tag in a comment for Dependabot", action),
+				Metadata: map[string]interface{}{
+					"action": action,
+					"ref":    ref,
+				},
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "All workflow actions are pinned to commit SHAs",
+		})
+	}
+	
+	return results
+}
+
+// yamlMappingValue returns the key and value nodes for key in a mapping node
+func yamlMappingValue(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// workflowUses returns the uses: values of a workflow: reusable workflow calls
+// on jobs and actions on their steps
+func workflowUses(content string) []*yaml.Node {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	_, jobs := yamlMappingValue(doc.Content[0], "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return nil
+	}
+	
+	var uses []*yaml.Node
+	for i := 1; i < len(jobs.Content); i += 2 {
+		job := jobs.Content[i]
+		if _, value := yamlMappingValue(job, "uses"); value != nil {
+			uses = append(uses, value)
+		}
+		if _, steps := yamlMappingValue(job, "steps"); steps != nil && steps.Kind == yaml.SequenceNode {
+			for _, step := range steps.Content {
+				if _, value := yamlMappingValue(step, "uses"); value != nil {
+					uses = append(uses, value)
+				}
+			}
+		}
+	}
+	return uses
+}
+
+// SensitiveVariableRule checks that Terraform variables holding secrets are marked sensitive
+type SensitiveVariableRule struct{}
+
+// secretVariableWords are name segments marking a variable as a secret, and
+// secretKeyPrefixes the segments that do so before "key", as in api_key
+var (
+	secretVariableWords = []string{"password", "passwd", "token", "secret", "apikey"}
+	secretKeyPrefixes   = []string{"api", "private"}
+)
+
+// variableMetadataSuffixes end names of variables describing a secret rather
+// than holding one, e.g. token_ttl, secret_name or password_min_length
+var variableMetadataSuffixes = []string{
+	"name", "id", "arn", "ttl", "length", "count", "version", "path", "file",
+	"enabled", "rotation", "expiry", "expiration", "duration", "type", "policy",
+}
+
+// variableNameSegment splits a variable name into snake_case, kebab-case and
+// camelCase words
+var variableNameSegment = regexp.MustCompile(`[A-Z]+[a-z0-9]*|[a-z0-9]+`)
+
+// isSecretVariable reports whether a variable name says it holds a secret.
+// Whole segments are matched, so passwordless_login doesn't count.
+func isSecretVariable(name string) bool {
+	segments := variableNameSegment.FindAllString(name, -1)
+	for i := range segments {
+		segments[i] = strings.ToLower(segments[i])
+	}
+	if len(segments) == 0 || containsString(variableMetadataSuffixes, segments[len(segments)-1]) {
+		return false
+	}
+	for i, segment := range segments {
+		if containsString(secretVariableWords, segment) {
+			return true
+		}
+		if segment == "key" && i > 0 && containsString(secretKeyPrefixes, segments[i-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SensitiveVariableRule) Name() string {
+	return "tf-sensitive-variables"
+}
+
+func (r *SensitiveVariableRule) Description() string {
+	return "Terraform variables holding secrets should be marked sensitive and have no literal default"
+}
+
+func (r *SensitiveVariableRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *SensitiveVariableRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "C1.1",
+	}
+}
+
+func (r *SensitiveVariableRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for filename, content := range files {
+		if !isTerraformFile(filename) {
+			continue
+		}
+		
+		for _, block := range parseTerraform(content) {
+			if block.Type != "variable" || len(block.Labels) != 1 {
+				continue
+			}
+			
+			name := block.Labels[0]
+			if !isSecretVariable(name) {
+				continue
+			}
+			
+			if sensitive, ok := block.Attr("sensitive"); !ok || !sensitive.IsTrue() {
+				results = append(results, CheckResult{
+					RuleName:    r.Name(),
+					Status:      "fail",
+					Severity:    SeverityHigh,
+					Message:     fmt.Sprintf("Variable %q looks like a secret but is not marked sensitive", name),
+					File:        filename,
+					Line:        block.Line,
+					Remediation: "Add sensitive = true so the value is redacted from plan and apply output",
+					Metadata: map[string]interface{}{
+						"variable": name,
+					},
+				})
+			}
+			
+			if def, ok := block.Attr("default"); ok && def.IsStringLiteral() && def.String() != "" {
+				results = append(results, CheckResult{
+					RuleName:    r.Name(),
+					Status:      "fail",
+					Severity:    SeverityHigh,
+					Message:     fmt.Sprintf("Variable %q has a hardcoded secret default", name),
+					File:        filename,
+					Line:        def.Line,
+					Remediation: "Remove the default and supply the value via TF_VAR_ environment variables or a secrets manager",
+					Metadata: map[string]interface{}{
+						"variable": name,
+					},
+				})
+			}
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "Secret variables are marked sensitive",
+		})
+	}
+	
+	return results
+}
+
+// AccessKeyRotationRule checks for Terraform-managed static IAM access keys
+type AccessKeyRotationRule struct{}
+
+func (r *AccessKeyRotationRule) Name() string {
+	return "iam-no-static-access-keys"
+}
+
+func (r *AccessKeyRotationRule) Description() string {
+	return "IAM access keys should not be managed in Terraform; use OIDC or role-based access instead"
+}
+
+func (r *AccessKeyRotationRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *AccessKeyRotationRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "1.14",
+		"SOC2":    "CC6.1",
+	}
+}
+
+func (r *AccessKeyRotationRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	var rotations, versions []*tfBlock
+	for _, blocks := range terraformResources(files, "aws_secretsmanager_secret_rotation") {
+		rotations = append(rotations, blocks...)
+	}
+	for _, blocks := range terraformResources(files, "aws_secretsmanager_secret_version") {
+		versions = append(versions, blocks...)
+	}
+	
+	for filename, keys := range terraformResources(files, "aws_iam_access_key") {
+		for _, key := range keys {
+			rotated := key.References("time_rotating.") || accessKeyRotated(key, rotations, versions)
+			
+			result := CheckResult{
+				RuleName:    r.Name(),
+				File:        filename,
+				Line:        key.Line,
+				Remediation: "Replace static access keys with OIDC workload identity or an assumable IAM role",
+				Metadata: map[string]interface{}{
+					"resource": key.Address(),
+					"rotated":  rotated,
+				},
+			}
+			if rotated {
+				result.Status = "warn"
+				result.Severity = SeverityMedium
+				result.Message = "Terraform manages a static IAM access key"
+			} else {
+				result.Status = "fail"
+				result.Severity = SeverityHigh
+				result.Message = "Terraform manages a static IAM access key with no rotation mechanism"
+			}
+			results = append(results, result)
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No static IAM access keys managed in Terraform",
+		})
+	}
+	
+	return results
+}
+
+// secretsManagerSecretRef matches a reference to an aws_secretsmanager_secret, capturing its name
+var secretsManagerSecretRef = regexp.MustCompile(`aws_secretsmanager_secret\.([A-Za-z0-9_-]+)\.`)
+
+// accessKeyRotated reports whether a Secrets Manager rotation covers key, either
+// by referencing it directly or by rotating a secret whose version stores the
+// key's secret. A rotation of some unrelated secret does not count.
+func accessKeyRotated(key *tfBlock, rotations, versions []*tfBlock) bool {
+	address := key.Address()
+	for _, rotation := range rotations {
+		if rotation.References(address + ".") {
+			return true
+		}
+	}
+	for _, version := range versions {
+		secretID, ok := version.Attr("secret_id")
+		if !ok || !version.References(address+".secret") {
+			continue
+		}
+		for _, match := range secretsManagerSecretRef.FindAllStringSubmatch(secretID.Value, -1) {
+			for _, rotation := range rotations {
+				if rotation.References("aws_secretsmanager_secret." + match[1] + ".") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// PreventDestroyRule checks that production stateful resources are protected from terraform destroy
+type PreventDestroyRule struct {
+	// ProductionPattern marks a resource as production when it matches the
+	// resource name, its tags, or the file path
+	ProductionPattern *regexp.Regexp
+}
+
+// defaultProductionPattern matches "prod" or "production" as a standalone word or name segment
+var defaultProductionPattern = regexp.MustCompile(`(?i)(^|[^a-z])prod(uction)?([^a-z]|$)`)
+
+// NewPreventDestroyRule creates the rule with the default prod/production signal
+func NewPreventDestroyRule() *PreventDestroyRule {
+	return &PreventDestroyRule{
+		ProductionPattern: defaultProductionPattern,
+	}
+}
+
+func (r *PreventDestroyRule) Name() string {
+	return "tf-prevent-destroy-stateful"
+}
+
+func (r *PreventDestroyRule) Description() string {
+	return "Production S3 buckets, databases and DynamoDB tables should set lifecycle prevent_destroy"
+}
+
+func (r *PreventDestroyRule) DefaultSeverity() string {
+	return SeverityMedium
+}
+
+func (r *PreventDestroyRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "A1.2",
+	}
+}
+
+// Configure accepts production_pattern, a regular expression replacing the prod/production signal
+func (r *PreventDestroyRule) Configure(settings map[string]interface{}) error {
+	if err := checkSettingKeys(settings, "production_pattern"); err != nil {
+		return err
+	}
+	
+	pattern, ok, err := settingString(settings, "production_pattern")
+	if err != nil {
+		return err
+	}
+	if ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid production_pattern: %w", err)
+		}
+		r.ProductionPattern = compiled
+	}
+	return nil
+}
+
+func (r *PreventDestroyRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	resources := terraformResources(files, "aws_s3_bucket", "aws_db_instance", "aws_dynamodb_table")
+	for filename, blocks := range resources {
+		for _, block := range blocks {
+			if !r.isProduction(filename, block) {
+				continue
+			}
+			
+			protected := false
+			for _, lifecycle := range block.Children("lifecycle") {
+				if attr, ok := lifecycle.Attr("prevent_destroy"); ok && attr.IsTrue() {
+					protected = true
+				}
+			}
+			if protected {
+				continue
+			}
+			
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "warn",
+				Severity:    SeverityMedium,
+				Message:     fmt.Sprintf("Production resource %s has no lifecycle prevent_destroy guard", block.Address()),
+				File:        filename,
+				Line:        block.Line,
+				Remediation: "Add lifecycle { prevent_destroy = true } to stop accidental deletion of stateful data",
+				Metadata: map[string]interface{}{
+					"resource": block.Address(),
+				},
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "Production stateful resources are protected from destroy",
+		})
+	}
+	
+	return results
+}
+
+func (r *PreventDestroyRule) isProduction(filename string, block *tfBlock) bool {
+	if r.ProductionPattern == nil {
+		return false
+	}
+	if r.ProductionPattern.MatchString(block.Labels[1]) || r.ProductionPattern.MatchString(filename) {
+		return true
+	}
+	if tags, ok := block.Attr("tags"); ok && r.ProductionPattern.MatchString(tags.Value) {
+		return true
+	}
+	return false
+}
+
+// PlaintextInternalTrafficRule checks app configs for unencrypted service-to-service traffic
+type PlaintextInternalTrafficRule struct{}
+
+var (
+	internalHTTPPattern     = regexp.MustCompile(`http://([A-Za-z0-9_.-]+)`)
+	sensitiveServicePattern = regexp.MustCompile(`(?i)(auth|payment|billing|account|vault|secret|token|identity|db|database|postgres|mysql|redis)`)
+	privateIPPattern        = regexp.MustCompile(`^(10\.|192\.168\.|172\.(1[6-9]|2[0-9]|3[01])\.)`)
+)
+
+func (r *PlaintextInternalTrafficRule) Name() string {
+	return "transit-encryption-internal"
+}
+
+func (r *PlaintextInternalTrafficRule) Description() string {
+	return "Service-to-service traffic should use TLS rather than plaintext HTTP"
+}
+
+func (r *PlaintextInternalTrafficRule) DefaultSeverity() string {
+	return SeverityMedium
+}
+
+func (r *PlaintextInternalTrafficRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC6.7",
+	}
+}
+
+func (r *PlaintextInternalTrafficRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for filename, content := range files {
+		if !isConfigFile(filename) || isGitHubActionFile(filename) {
+			continue
+		}
+		
+		lines := strings.Split(content, "\n")
+		for lineNum, line := range lines {
+			trimmedLine := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmedLine, "#") {
+				continue
+			}
+			
+			for _, match := range internalHTTPPattern.FindAllStringSubmatch(line, -1) {
+				host := match[1]
+				if !isInternalHost(host) {
+					continue
+				}
+				results = append(results, CheckResult{
+					RuleName:    r.Name(),
+					Status:      "warn",
+					Severity:    SeverityMedium,
+					Message:     fmt.Sprintf("Plaintext HTTP used for internal endpoint %s", host),
+					File:        filename,
+					Line:        lineNum + 1,
+					Remediation: "Use https:// with service certificates or a service mesh providing mTLS",
+					Metadata: map[string]interface{}{
+						"endpoint":     match[0],
+						"line_content": trimmedLine,
+					},
+				})
+			}
+		}
+		
+		results = append(results, r.checkKubernetesServices(filename, content)...)
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No plaintext internal service traffic detected",
+		})
+	}
+	
+	return results
+}
+
+// checkKubernetesServices flags Services for sensitive backends that expose plain HTTP ports
+func (r *PlaintextInternalTrafficRule) checkKubernetesServices(filename, content string) []CheckResult {
+	var results []CheckResult
+	
+	lineOffset := 0
+	for _, doc := range strings.Split(content, "\n---") {
+		docLines := strings.Split(doc, "\n")
+		
+		isService := false
+		serviceName := ""
+		for _, line := range docLines {
+			trimmedLine := strings.TrimSpace(line)
+			if trimmedLine == "kind: Service" {
+				isService = true
+			}
+			if serviceName == "" && strings.HasPrefix(trimmedLine, "name:") && strings.HasPrefix(line, "  name:") {
+				serviceName = strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmedLine, "name:")), `"'`)
+			}
+		}
+		
+		if isService && sensitiveServicePattern.MatchString(serviceName) {
+			for i, line := range docLines {
+				trimmedLine := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+				if trimmedLine == "port: 80" || trimmedLine == "name: http" {
+					results = append(results, CheckResult{
+						RuleName:    r.Name(),
+						Status:      "warn",
+						Severity:    SeverityMedium,
+						Message:     fmt.Sprintf("Kubernetes Service %s exposes a plaintext HTTP port", serviceName),
+						File:        filename,
+						Line:        lineOffset + i + 1,
+						Remediation: "Serve the backend over TLS (port 443) or enforce mTLS with a service mesh",
+						Metadata: map[string]interface{}{
+							"service":      serviceName,
+							"line_content": trimmedLine,
+						},
+					})
+					break
+				}
+			}
+		}
+		
+		lineOffset += len(docLines)
+	}
+	
+	return results
+}
+
+// isInternalHost reports whether a URL host looks like a cluster or private network address
+func isInternalHost(host string) bool {
+	if host == "localhost" || strings.HasPrefix(host, "127.") {
+		return false // loopback traffic never leaves the host
+	}
+	if privateIPPattern.MatchString(host) {
+		return true
+	}
+	name := strings.Split(host, ":")[0]
+	if !strings.Contains(name, ".") {
+		return true // bare service name, e.g. http://payments:8080
+	}
+	for _, suffix := range []string{".svc", ".svc.cluster.local", ".cluster.local", ".internal", ".local"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SensitiveLoggingRule checks for logging settings that capture sensitive data
+type SensitiveLoggingRule struct{}
+
+var debugLogLevelPattern = regexp.MustCompile(`(?i)["']?(log_?level|logging_?level|log-level)["']?\s*[:=]\s*["']?(debug|trace)["']?`)
+
+func (r *SensitiveLoggingRule) Name() string {
+	return "logging-no-sensitive-debug"
+}
+
+func (r *SensitiveLoggingRule) Description() string {
+	return "Production services and databases should not log at debug level or capture full SQL statements"
+}
+
+func (r *SensitiveLoggingRule) DefaultSeverity() string {
+	return SeverityMedium
+}
+
+func (r *SensitiveLoggingRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "C1.1",
+	}
+}
+
+func (r *SensitiveLoggingRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// Postgres parameter groups that log every statement, including bound secrets
+	groups := terraformResources(files, "aws_db_parameter_group", "aws_rds_cluster_parameter_group")
+	for filename, blocks := range groups {
+		for _, block := range blocks {
+			for _, param := range block.Children("parameter") {
+				name, _ := param.Attr("name")
+				value, _ := param.Attr("value")
+				
+				risky := false
+				switch name.String() {
+				case "log_statement":
+					risky = strings.EqualFold(value.String(), "all")
+				case "log_min_duration_statement":
+					risky = value.String() == "0"
+				}
+				if !risky {
+					continue
+				}
+				
+				results = append(results, CheckResult{
+					RuleName:    r.Name(),
+					Status:      "warn",
+					Severity:    SeverityMedium,
+					Message:     fmt.Sprintf("Parameter group %s sets %s = %s, logging query parameters", block.Address(), name.String(), value.String()),
+					File:        filename,
+					Line:        param.Line,
+					Remediation: "Use log_statement = 'ddl' (or 'none') and a positive log_min_duration_statement",
+					Metadata: map[string]interface{}{
+						"resource":  block.Address(),
+						"parameter": name.String(),
+					},
+				})
+			}
+		}
+	}
+	
+	// Debug-level logging in production service configs
+	for filename, content := range files {
+		if !isConfigFile(filename) || isGitHubActionFile(filename) {
+			continue
+		}
+		if !defaultProductionPattern.MatchString(filename) && !strings.Contains(strings.ToLower(content), "production") {
+			continue
+		}
+		
+		lines := strings.Split(content, "\n")
+		for lineNum, line := range lines {
+			trimmedLine := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmedLine, "#") || !debugLogLevelPattern.MatchString(trimmedLine) {
+				continue
+			}
+			
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "warn",
+				Severity:    SeverityMedium,
+				Message:     "Debug-level logging enabled for a production service",
+				File:        filename,
+				Line:        lineNum + 1,
+				Remediation: "Log at info or above in production; debug logs often include tokens and request bodies",
+				Metadata: map[string]interface{}{
+					"line_content": trimmedLine,
+				},
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No sensitive debug logging detected",
+		})
+	}
+	
+	return results
+}
+
+// S3EncryptionRule checks that S3 buckets have server-side encryption configured
+type S3EncryptionRule struct{}
+
+func (r *S3EncryptionRule) Name() string {
+	return "s3-require-encryption"
+}
+
+func (r *S3EncryptionRule) Description() string {
+	return "S3 buckets should have server-side encryption configured"
+}
+
+func (r *S3EncryptionRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *S3EncryptionRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC6.1",
+	}
+}
+
+func (r *S3EncryptionRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// Encryption configured in separate resources referencing the bucket
+	encryption := resourceTargets(files, "aws_s3_bucket_server_side_encryption_configuration", "bucket")
+	
+	for filename, buckets := range terraformResources(files, "aws_s3_bucket") {
+		for _, bucket := range buckets {
+			if len(bucket.Children("server_side_encryption_configuration")) > 0 {
+				continue // inline SSE (AWS provider < 4.0)
+			}
+			if targetedBy(encryption, bucket, "bucket") != nil {
+				continue
+			}
+			
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "fail",
+				Severity:    SeverityHigh,
+				Message:     fmt.Sprintf("S3 bucket %s has no server-side encryption configured", bucket.Address()),
+				File:        filename,
+				Line:        bucket.Line,
+				Remediation: "Add an aws_s3_bucket_server_side_encryption_configuration using SSE-KMS (sse_algorithm = \"aws:kms\")",
+				Metadata:    resourceMetadata(bucket, ""),
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "All S3 buckets have encryption configured",
+		})
+	}
+	
+	return results
+}
+
+// S3PublicAccessBlockRule checks that S3 buckets have a public access block with every flag enabled
+type S3PublicAccessBlockRule struct{}
+
+// publicAccessBlockFlags must all be true for a public access block to be complete
+var publicAccessBlockFlags = []string{"block_public_acls", "block_public_policy", "ignore_public_acls", "restrict_public_buckets"}
+
+func (r *S3PublicAccessBlockRule) Name() string {
+	return "s3-public-access-block"
+}
+
+func (r *S3PublicAccessBlockRule) Description() string {
+	return "S3 buckets should have a public access block with all four flags enabled"
+}
+
+func (r *S3PublicAccessBlockRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *S3PublicAccessBlockRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "2.1.4",
+		"SOC2":    "CC6.1",
+	}
+}
+
+func (r *S3PublicAccessBlockRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// A complete account-level block covers every bucket
+	for _, blocks := range terraformResources(files, "aws_s3_account_public_access_block") {
+		for _, block := range blocks {
+			if len(disabledAccessBlockFlags(block)) == 0 {
+				return []CheckResult{{
+					RuleName: r.Name(),
+					Status:   "pass",
+					Message:  "Account-level S3 public access block covers all buckets",
+				}}
+			}
+		}
+	}
+	
+	accessBlocks := resourceTargets(files, "aws_s3_bucket_public_access_block", "bucket")
+	
+	for filename, buckets := range terraformResources(files, "aws_s3_bucket") {
+		for _, bucket := range buckets {
+			result := CheckResult{
+				RuleName:    r.Name(),
+				Status:      "fail",
+				Severity:    SeverityHigh,
+				File:        filename,
+				Line:        bucket.Line,
+				Remediation: "Add an aws_s3_bucket_public_access_block for the bucket with all four flags set to true",
+				Metadata:    resourceMetadata(bucket, ""),
+			}
+			
+			accessBlock := targetedBy(accessBlocks, bucket, "bucket")
+			if accessBlock == nil {
+				result.Message = fmt.Sprintf("S3 bucket %s has no public access block", bucket.Address())
+				results = append(results, result)
+				continue
+			}
+			
+			disabled := disabledAccessBlockFlags(accessBlock)
+			if len(disabled) == 0 {
+				continue
+			}
+			result.Message = fmt.Sprintf("Public access block for S3 bucket %s does not enable %s", bucket.Address(), strings.Join(disabled, ", "))
+			result.Line = accessBlock.Line
+			result.Metadata["disabled_flags"] = disabled
+			results = append(results, result)
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "All S3 buckets have a complete public access block",
+		})
+	}
+	
+	return results
+}
+
+// disabledAccessBlockFlags lists the flags of a public access block that are not
+// set to true; an omitted flag defaults to false
+func disabledAccessBlockFlags(block *tfBlock) []string {
+	var disabled []string
+	for _, flag := range publicAccessBlockFlags {
+		if attr, ok := block.Attr(flag); !ok || !attr.IsTrue() {
+			disabled = append(disabled, flag)
+		}
+	}
+	return disabled
+}
+
+// GCPPublicBucketRule checks for Cloud Storage buckets granted to allUsers or allAuthenticatedUsers
+type GCPPublicBucketRule struct{}
+
+// gcpPublicMembers are the IAM principals that make a resource public
+var gcpPublicMembers = []string{"allUsers", "allAuthenticatedUsers"}
+
+func (r *GCPPublicBucketRule) Name() string {
+	return "gcs-no-public-buckets"
+}
+
+func (r *GCPPublicBucketRule) Description() string {
+	return "Cloud Storage buckets should not grant access to allUsers or allAuthenticatedUsers"
+}
+
+func (r *GCPPublicBucketRule) DefaultSeverity() string {
+	return SeverityCritical
+}
+
+func (r *GCPPublicBucketRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-GCP": "5.1",
+		"SOC2":    "CC6.1",
+	}
+}
+
+func (r *GCPPublicBucketRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// Public access prevention makes GCS reject public grants on the bucket
+	buckets := resourceIndex(files, "google_storage_bucket", "name")
+	enforced := func(block *tfBlock) bool {
+		bucketAttr, ok := block.Attr("bucket")
+		if !ok {
+			return false
+		}
+		bucket := attrTarget(buckets, bucketAttr)
+		if bucket == nil {
+			return false
+		}
+		prevention, ok := bucket.Attr("public_access_prevention")
+		return ok && prevention.String() == "enforced"
+	}
+	
+	for filename, blocks := range terraformResources(files,
+		"google_storage_bucket_iam_member", "google_storage_bucket_iam_binding",
+		"google_storage_bucket_access_control", "google_storage_default_object_access_control") {
+		lines := strings.Split(files[filename], "\n")
+		
+		for _, block := range blocks {
+			var member string
+			var line int
+			for _, attrName := range []string{"member", "members", "entity"} {
+				attr, ok := block.Attr(attrName)
+				if !ok {
+					continue
+				}
+				for _, value := range hclStrings(attr.Value) {
+					if containsString(gcpPublicMembers, value) {
+						member, line = value, attr.Line
+						break
+					}
+				}
+			}
+			if member == "" || enforced(block) {
+				continue
+			}
+			
+			role := ""
+			if attr, ok := block.Attr("role"); ok {
+				role = attr.String()
+			}
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "fail",
+				Severity:    SeverityCritical,
+				Message:     fmt.Sprintf("%s grants %s to %s", block.Address(), role, member),
+				File:        filename,
+				Line:        line,
+				Remediation: "Grant the role to specific users, groups or service accounts, and set public_access_prevention = \"enforced\" on the bucket",
+				Metadata: map[string]interface{}{
+					"line_content": strings.TrimSpace(lines[line-1]),
+					"resource":     block.Address(),
+				},
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No public Cloud Storage buckets detected",
+		})
+	}
+	
+	return results
+}
+
+// AzureStoragePublicRule checks for Azure storage accounts and containers open to anonymous access
+type AzureStoragePublicRule struct{}
+
+// azureAccountPublicFlags allow anonymous blob access on a storage account
+// (allow_blob_public_access before azurerm 3.0)
+var azureAccountPublicFlags = []string{"allow_nested_items_to_be_public", "allow_blob_public_access"}
+
+func (r *AzureStoragePublicRule) Name() string {
+	return "azure-storage-no-public-access"
+}
+
+func (r *AzureStoragePublicRule) Description() string {
+	return "Azure storage accounts and containers should not allow anonymous public access"
+}
+
+func (r *AzureStoragePublicRule) DefaultSeverity() string {
+	return SeverityCritical
+}
+
+func (r *AzureStoragePublicRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-Azure": "3.7",
+		"SOC2":      "CC6.1",
+	}
+}
+
+func (r *AzureStoragePublicRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// An account that disables public access overrides its containers
+	accounts := resourceIndex(files, "azurerm_storage_account", "name")
+	disabled := func(container *tfBlock) bool {
+		for _, attrName := range []string{"storage_account_name", "storage_account_id"} {
+			attr, ok := container.Attr(attrName)
+			if !ok {
+				continue
+			}
+			if account := attrTarget(accounts, attr); account != nil {
+				for _, flag := range azureAccountPublicFlags {
+					if value, ok := account.Attr(flag); ok && strings.TrimSpace(value.Value) == "false" {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	
+	for filename, blocks := range terraformResources(files, "azurerm_storage_account", "azurerm_storage_container") {
+		lines := strings.Split(files[filename], "\n")
+		
+		for _, block := range blocks {
+			result := CheckResult{
+				RuleName: r.Name(),
+				Status:   "fail",
+				Severity: SeverityCritical,
+				File:     filename,
+			}
+			
+			if block.Labels[0] == "azurerm_storage_account" {
+				for _, flag := range azureAccountPublicFlags {
+					attr, ok := block.Attr(flag)
+					if !ok || !attr.IsTrue() {
+						continue
+					}
+					result.Message = fmt.Sprintf("Storage account %s sets %s = true", block.Address(), flag)
+					result.Line = attr.Line
+					result.Remediation = fmt.Sprintf("Set %s = false and share data through SAS tokens or Azure AD roles", flag)
+					result.Metadata = map[string]interface{}{
+						"line_content": strings.TrimSpace(lines[attr.Line-1]),
+						"resource":     block.Address(),
+					}
+					results = append(results, result)
+				}
+				continue
+			}
+			
+			access, ok := block.Attr("container_access_type")
+			if !ok || (access.String() != "blob" && access.String() != "container") || disabled(block) {
+				continue
+			}
+			result.Message = fmt.Sprintf("Storage container %s allows anonymous %s access", block.Address(), access.String())
+			result.Line = access.Line
+			result.Remediation = "Use container_access_type = \"private\" and disable public access on the storage account"
+			result.Metadata = map[string]interface{}{
+				"line_content": strings.TrimSpace(lines[access.Line-1]),
+				"resource":     block.Address(),
+			}
+			results = append(results, result)
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No public Azure storage accounts or containers detected",
+		})
+	}
+	
+	return results
+}
+
+// FileSystemEncryptionRule checks that EFS and FSx file systems are encrypted at rest
+type FileSystemEncryptionRule struct{}
+
+func (r *FileSystemEncryptionRule) Name() string {
+	return "fs-require-encryption"
+}
+
+func (r *FileSystemEncryptionRule) Description() string {
+	return "EFS and FSx file systems should be encrypted at rest"
+}
+
+func (r *FileSystemEncryptionRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *FileSystemEncryptionRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "2.4.1",
+		"SOC2":    "CC6.1",
+	}
+}
+
+func (r *FileSystemEncryptionRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for filename, blocks := range terraformResources(files) {
+		for _, block := range blocks {
+			resourceType := block.Labels[0]
+			if resourceType != "aws_efs_file_system" && !strings.HasPrefix(resourceType, "aws_fsx_") {
+				continue
+			}
+			
+			if encrypted, ok := block.Attr("encrypted"); ok && encrypted.IsTrue() {
+				continue
+			}
+			if _, ok := block.Attr("kms_key_id"); ok {
+				continue
+			}
+			
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "fail",
+				Severity:    SeverityHigh,
+				Message:     fmt.Sprintf("File system %s is not encrypted at rest", block.Address()),
+				File:        filename,
+				Line:        block.Line,
+				Remediation: "Set encrypted = true and kms_key_id to a customer-managed KMS key",
+				Metadata: map[string]interface{}{
+					"resource": block.Address(),
+				},
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "All EFS and FSx file systems are encrypted",
+		})
+	}
+	
+	return results
+}
+
+// EncryptionAtRestRule checks that RDS databases and EBS volumes are encrypted at rest
+type EncryptionAtRestRule struct{}
+
+// encryptedAttrs maps each checked resource type to its encryption attribute
+var encryptedAttrs = map[string]string{
+	"aws_db_instance": "storage_encrypted",
+	"aws_rds_cluster": "storage_encrypted",
+	"aws_ebs_volume":  "encrypted",
+}
+
+func (r *EncryptionAtRestRule) Name() string {
+	return "storage-require-encryption"
+}
+
+func (r *EncryptionAtRestRule) Description() string {
+	return "RDS instances and clusters and EBS volumes should be encrypted at rest"
+}
+
+func (r *EncryptionAtRestRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *EncryptionAtRestRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "2.2.1, 2.3.1",
+		"SOC2":    "CC6.1",
+	}
+}
+
+func (r *EncryptionAtRestRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// EBS encryption by default applies to every new volume in the region
+	ebsDefault := false
+	for _, blocks := range terraformResources(files, "aws_ebs_encryption_by_default") {
+		for _, block := range blocks {
+			if enabled, ok := block.Attr("enabled"); !ok || enabled.IsTrue() {
+				ebsDefault = true
+			}
+		}
+	}
+	
+	for filename, blocks := range terraformResources(files, "aws_db_instance", "aws_rds_cluster", "aws_ebs_volume") {
+		lines := strings.Split(files[filename], "\n")
+		
+		for _, block := range blocks {
+			resourceType := block.Labels[0]
+			attrName := encryptedAttrs[resourceType]
+			
+			result := CheckResult{
+				RuleName:    r.Name(),
+				File:        filename,
+				Line:        block.Line,
+				Remediation: fmt.Sprintf("Set %s = true and kms_key_id to a customer-managed KMS key", attrName),
+				Metadata: map[string]interface{}{
+					"resource": block.Address(),
+				},
+			}
+			
+			attr, ok := block.Attr(attrName)
+			switch {
+			case ok && attr.IsTrue():
+				continue
+			case ok && strings.TrimSpace(attr.Value) == "false":
+				result.Status = "fail"
+				result.Severity = SeverityHigh
+				result.Message = fmt.Sprintf("%s sets %s = false", block.Address(), attrName)
+				result.Line = attr.Line
+				result.Metadata["line_content"] = strings.TrimSpace(lines[attr.Line-1])
+			case ok:
+				continue // computed from a variable or expression
+			default:
+				// Unset encryption depends on engine and account defaults, so
+				// it is only a warning. Replicas and restores inherit it from
+				// their source.
+				if hasAttr(block, "replicate_source_db") || hasAttr(block, "snapshot_identifier") || hasAttr(block, "snapshot_id") {
+					continue
+				}
+				if resourceType == "aws_ebs_volume" && ebsDefault {
+					continue
+				}
+				result.Status = "warn"
+				result.Severity = SeverityMedium
+				result.Message = fmt.Sprintf("%s does not set %s, so encryption at rest depends on provider defaults", block.Address(), attrName)
+			}
+			results = append(results, result)
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "All RDS databases and EBS volumes are encrypted at rest",
+		})
+	}
+	
+	return results
+}
+
+func hasAttr(block *tfBlock, name string) bool {
+	_, ok := block.Attr(name)
+	return ok
+}
+
+// RDSPublicRule checks for RDS databases reachable from the internet and
+// snapshots shared with every AWS account
+type RDSPublicRule struct{}
+
+// rdsSnapshotTypes are the snapshot resources that can be shared via shared_accounts
+var rdsSnapshotTypes = []string{"aws_db_snapshot", "aws_db_snapshot_copy", "aws_db_cluster_snapshot", "aws_rds_cluster_snapshot"}
+
+func (r *RDSPublicRule) Name() string {
+	return "rds-no-public-access"
+}
+
+func (r *RDSPublicRule) Description() string {
+	return "RDS instances should not be publicly accessible and snapshots should not be shared publicly"
+}
+
+func (r *RDSPublicRule) DefaultSeverity() string {
+	return SeverityCritical
+}
+
+func (r *RDSPublicRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "2.3.3",
+		"SOC2":    "CC6.6",
+	}
+}
+
+func (r *RDSPublicRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	subnetGroups := resourceIndex(files, "aws_db_subnet_group", "name")
+	private := privateSubnets(files)
+	
+	for filename, blocks := range terraformResources(files, "aws_db_instance", "aws_rds_cluster_instance") {
+		lines := strings.Split(files[filename], "\n")
+		
+		for _, block := range blocks {
+			attr, ok := block.Attr("publicly_accessible")
+			if !ok || !attr.IsTrue() {
+				continue
+			}
+			
+			result := CheckResult{
+				RuleName:    r.Name(),
+				Status:      "fail",
+				Severity:    SeverityCritical,
+				Message:     fmt.Sprintf("%s sets publicly_accessible = true", block.Address()),
+				File:        filename,
+				Line:        attr.Line,
+				Remediation: "Set publicly_accessible = false and reach the database from inside the VPC, through a bastion host or VPN",
+				Metadata:    resourceMetadata(block, lines[attr.Line-1]),
+			}
+			
+			// Without a route to an internet gateway the public address is
+			// unreachable, so a database confined to private subnets is
+			// only a warning. Subnets that can't be resolved may be public,
+			// as the default VPC's are.
+			if group, ok := block.Attr("db_subnet_group_name"); ok {
+				if subnets := subnetGroupSubnets(attrTarget(subnetGroups, group)); len(subnets) > 0 && allPrivate(subnets, private) {
+					result.Status = "warn"
+					result.Severity = SeverityMedium
+					result.Message += ", though its subnets have no route to an internet gateway"
+				}
+			}
+			results = append(results, result)
+		}
+	}
+	
+	for filename, blocks := range terraformResources(files, rdsSnapshotTypes...) {
+		lines := strings.Split(files[filename], "\n")
+		
+		for _, block := range blocks {
+			attr, ok := block.Attr("shared_accounts")
+			if !ok || !containsString(hclStrings(attr.Value), "all") {
+				continue
+			}
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "fail",
+				Severity:    SeverityCritical,
+				Message:     fmt.Sprintf("%s is shared with all AWS accounts", block.Address()),
+				File:        filename,
+				Line:        attr.Line,
+				Remediation: "Share the snapshot with specific account IDs in shared_accounts instead of \"all\"",
+				Metadata:    resourceMetadata(block, lines[attr.Line-1]),
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No publicly accessible RDS databases or public snapshots detected",
+		})
+	}
+	
+	return results
+}
+
+// subnetGroupSubnets returns the subnet_ids references of a DB subnet group,
+// as resource addresses such as aws_subnet.private_a
+func subnetGroupSubnets(group *tfBlock) []string {
+	if group == nil {
+		return nil
+	}
+	attr, ok := group.Attr("subnet_ids")
+	if !ok {
+		return nil
+	}
+	var subnets []string
+	for _, ref := range tfResourceRef.FindAllStringSubmatch(attr.Value, -1) {
+		if ref[1] == "aws_subnet" {
+			subnets = append(subnets, ref[1]+"."+ref[2])
+		}
+	}
+	return subnets
+}
+
+// privateSubnets returns the addresses of subnets known to be private: they
+// don't assign public IPs and are associated with a route table that has no
+// route to an internet gateway
+func privateSubnets(files map[string]string) map[string]bool {
+	routeTables := resourceIndex(files, "aws_route_table", "")
+	
+	internetRouted := make(map[string]bool)
+	for _, blocks := range terraformResources(files, "aws_route_table") {
+		for _, table := range blocks {
+			for _, route := range table.NestedBlocks("route") {
+				if gateway, ok := route.Attr("gateway_id"); ok && strings.Contains(gateway.Value, "aws_internet_gateway.") {
+					internetRouted[table.Address()] = true
+				}
+			}
+		}
+	}
+	for _, blocks := range terraformResources(files, "aws_route") {
+		for _, route := range blocks {
+			gateway, ok := route.Attr("gateway_id")
+			table, _ := route.Attr("route_table_id")
+			if target := attrTarget(routeTables, table); ok && target != nil && strings.Contains(gateway.Value, "aws_internet_gateway.") {
+				internetRouted[target.Address()] = true
+			}
+		}
+	}
+	
+	private := make(map[string]bool)
+	for _, blocks := range terraformResources(files, "aws_route_table_association") {
+		for _, association := range blocks {
+			subnet, ok := association.Attr("subnet_id")
+			table, _ := association.Attr("route_table_id")
+			target := attrTarget(routeTables, table)
+			if !ok || target == nil || internetRouted[target.Address()] {
+				continue
+			}
+			for _, ref := range tfResourceRef.FindAllStringSubmatch(subnet.Value, -1) {
+				if ref[1] == "aws_subnet" {
+					private[ref[1]+"."+ref[2]] = true
+				}
+			}
+		}
+	}
+	
+	for _, blocks := range terraformResources(files, "aws_subnet") {
+		for _, subnet := range blocks {
+			if attr, ok := subnet.Attr("map_public_ip_on_launch"); ok && attr.IsTrue() {
+				delete(private, subnet.Address())
+			}
+		}
+	}
+	return private
+}
+
+// allPrivate reports whether every subnet is in private
+func allPrivate(subnets []string, private map[string]bool) bool {
+	for _, subnet := range subnets {
+		if !private[subnet] {
+			return false
+		}
+	}
+	return true
+}
+
+// IMDSv2Rule checks that EC2 instances and launch templates require IMDSv2
+// session tokens. IMDSv1 answers plain GET requests, so an SSRF bug in an
+// application is enough to steal the instance role's credentials.
+type IMDSv2Rule struct{}
+
+func (r *IMDSv2Rule) Name() string {
+	return "ec2-require-imdsv2"
+}
+
+func (r *IMDSv2Rule) Description() string {
+	return "EC2 instances and launch templates should require IMDSv2 (http_tokens = \"required\")"
+}
+
+func (r *IMDSv2Rule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *IMDSv2Rule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "5.6",
+		"SOC2":    "CC6.1",
+	}
+}
+
+func (r *IMDSv2Rule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// Account-level metadata defaults apply to instances that don't set
+	// http_tokens themselves
+	accountDefault := false
+	for _, blocks := range terraformResources(files, "aws_ec2_instance_metadata_defaults") {
+		for _, block := range blocks {
+			if tokens, ok := block.Attr("http_tokens"); ok && tokens.String() == "required" {
+				accountDefault = true
+			}
+		}
+	}
+	
+	remediation := "Add metadata_options { http_tokens = \"required\" } so the instance metadata service only accepts IMDSv2 session tokens"
+	
+	for filename, blocks := range terraformResources(files, "aws_instance", "aws_launch_template", "aws_launch_configuration") {
+		lines := strings.Split(files[filename], "\n")
+		
+		for _, block := range blocks {
+			options := block.NestedBlocks("metadata_options")
+			
+			var tokens tfAttr
+			var hasTokens bool
+			line := block.Line
+			if len(options) > 0 {
+				line = options[0].Line
+				if endpoint, ok := options[0].Attr("http_endpoint"); ok && endpoint.String() == "disabled" {
+					continue // no metadata service to attack
+				}
+				tokens, hasTokens = options[0].Attr("http_tokens")
+			}
+			
+			switch {
+			case hasTokens && tokens.String() == "optional":
+				results = append(results, CheckResult{
+					RuleName:    r.Name(),
+					Status:      "fail",
+					Severity:    SeverityHigh,
+					Message:     fmt.Sprintf("%s sets http_tokens = \"optional\", allowing IMDSv1", block.Address()),
+					File:        filename,
+					Line:        tokens.Line,
+					Remediation: remediation,
+					Metadata:    resourceMetadata(block, lines[tokens.Line-1]),
+				})
+			case hasTokens:
+				continue // required, or computed from a variable or expression
+			case accountDefault:
+				continue
+			default:
+				// Whether IMDSv1 is enabled then depends on the AMI and
+				// account defaults, which were optional for years
+				results = append(results, CheckResult{
+					RuleName:    r.Name(),
+					Status:      "warn",
+					Severity:    SeverityMedium,
+					Message:     fmt.Sprintf("%s does not set metadata_options http_tokens, so IMDSv1 may be enabled", block.Address()),
+					File:        filename,
+					Line:        line,
+					Remediation: remediation,
+					Metadata:    resourceMetadata(block, lines[line-1]),
+				})
+			}
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "All EC2 instances and launch templates require IMDSv2",
+		})
+	}
+	
+	return results
+}
+
+// IAMPassRoleRule checks for iam:PassRole on any role combined with the ability
+// to launch compute, which lets a principal run code as a more privileged role
+type IAMPassRoleRule struct{}
+
+// passRoleComputeActions launch compute that runs with a passed role
+var passRoleComputeActions = []string{
+	"lambda:CreateFunction",
+	"lambda:UpdateFunctionConfiguration",
+	"ec2:RunInstances",
+	"ecs:RunTask",
+	"ecs:RegisterTaskDefinition",
+}
+
+func (r *IAMPassRoleRule) Name() string {
+	return "iam-no-passrole-escalation"
+}
+
+func (r *IAMPassRoleRule) Description() string {
+	return "IAM policies should not allow iam:PassRole on all roles together with launching Lambda, EC2 or ECS compute"
+}
+
+func (r *IAMPassRoleRule) DefaultSeverity() string {
+	return SeverityCritical
+}
+
+func (r *IAMPassRoleRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC6.3",
+	}
+}
+
+func (r *IAMPassRoleRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for _, document := range iamPolicyDocuments(files) {
+		passRole := 0
+		for _, statement := range document.Statements {
+			if passRole == 0 && statement.allowsOnAnyResource("iam:PassRole") {
+				passRole = document.statementLine(statement)
+			}
+		}
+		if passRole == 0 {
+			continue
+		}
+		
+		var compute []string
+		for _, action := range passRoleComputeActions {
+			for _, statement := range document.Statements {
+				if statement.allows(action) {
+					compute = append(compute, action)
+					break
+				}
+			}
+		}
+		if len(compute) == 0 {
+			continue
+		}
+		
+		results = append(results, CheckResult{
+			RuleName:    r.Name(),
+			Status:      "fail",
+			Severity:    SeverityCritical,
+			Message:     fmt.Sprintf("%s allows iam:PassRole on any role together with %s", document.Address, strings.Join(compute, ", ")),
+			File:        document.File,
+			Line:        passRole,
+			Remediation: "Scope iam:PassRole to specific role ARNs and add an iam:PassedToService condition",
+			Metadata: map[string]interface{}{
+				"resource":        document.Address,
+				"compute_actions": compute,
+			},
+		})
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No unrestricted iam:PassRole privilege escalation paths detected",
+		})
+	}
+	
+	return results
+}
+
+// IAMWildcardRule checks IAM policy documents for Allow statements granting
+// every action or applying to every resource
+type IAMWildcardRule struct{}
+
+func (r *IAMWildcardRule) Name() string {
+	return "iam-no-wildcard-permissions"
+}
+
+func (r *IAMWildcardRule) Description() string {
+	return "IAM policies should not allow Action \"*\" or Resource \"*\""
+}
+
+func (r *IAMWildcardRule) DefaultSeverity() string {
+	return SeverityCritical
+}
+
+func (r *IAMWildcardRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "1.16",
+		"SOC2":    "CC6.3",
+	}
+}
+
+func (r *IAMWildcardRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for _, document := range iamPolicyDocuments(files) {
+		for i, statement := range document.Statements {
+			if !strings.EqualFold(statement.Effect, "Allow") {
+				continue
+			}
+			
+			anyAction := containsString(statement.Actions, "*") || containsString(statement.Actions, "*:*")
+			anyResource := containsString(statement.Resources, "*")
+			
+			result := CheckResult{
+				RuleName: r.Name(),
+				File:     document.File,
+				Line:     document.statementLine(statement),
+				Metadata: map[string]interface{}{
+					"resource":  document.Address,
+					"statement": i,
+					"actions":   statement.Actions,
+					"resources": statement.Resources,
+				},
+			}
+			switch {
+			case anyAction && anyResource:
+				result.Status = "fail"
+				result.Severity = SeverityCritical
+				result.Message = fmt.Sprintf("%s grants full administrator access (Action \"*\" on Resource \"*\")", document.Address)
+				result.Remediation = "Replace the statement with the specific actions and resources the principal needs"
+			case anyAction:
+				result.Status = "fail"
+				result.Severity = SeverityHigh
+				result.Message = fmt.Sprintf("%s allows every action (Action \"*\")", document.Address)
+				result.Remediation = "List the specific actions the principal needs instead of \"*\""
+			case anyResource:
+				result.Status = "warn"
+				result.Severity = SeverityMedium
+				result.Message = fmt.Sprintf("%s allows %s on every resource (Resource \"*\")", document.Address, strings.Join(statement.Actions, ", "))
+				result.Remediation = "Scope Resource to specific ARNs, or add conditions where the actions cannot be scoped"
+			default:
+				continue
+			}
+			results = append(results, result)
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No IAM policies allow wildcard actions or resources",
+		})
+	}
+	
+	return results
+}
+
+// ThreatDetectionRule checks that account-management Terraform enables
+// GuardDuty and Security Hub. It is a coverage check: findings point at the
+// account-level resource that marks the repo as managing an account.
+type ThreatDetectionRule struct{}
+
+// accountManagementResources mark a Terraform repo as managing AWS accounts
+var accountManagementResources = []string{
+	"aws_organizations_organization",
+	"aws_organizations_account",
+	"aws_iam_account_alias",
+	"aws_iam_account_password_policy",
+	"aws_cloudtrail",
+	"aws_config_configuration_recorder",
+}
+
+func (r *ThreatDetectionRule) Name() string {
+	return "account-threat-detection"
+}
+
+func (r *ThreatDetectionRule) Description() string {
+	return "Account-management Terraform should enable GuardDuty and Security Hub"
+}
+
+func (r *ThreatDetectionRule) DefaultSeverity() string {
+	return SeverityMedium
+}
+
+func (r *ThreatDetectionRule) Frameworks() map[string]string {
+	return map[string]string{
+		"CIS-AWS": "4.16",
+		"SOC2":    "CC7.2",
+	}
+}
+
+func (r *ThreatDetectionRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// Anchor findings on the first account-level resource, in a stable order
+	var marker *tfBlock
+	markerFile := ""
+	for filename, blocks := range terraformResources(files, accountManagementResources...) {
+		if marker == nil || filename < markerFile {
+			marker, markerFile = blocks[0], filename
+		}
+	}
+	
+	if marker != nil {
+		guardDuty := false
+		for _, blocks := range terraformResources(files, "aws_guardduty_detector") {
+			for _, detector := range blocks {
+				if enable, ok := detector.Attr("enable"); !ok || enable.IsTrue() {
+					guardDuty = true
+				}
+			}
+		}
+		securityHub := len(terraformResources(files, "aws_securityhub_account")) > 0
+		
+		services := []struct {
+			enabled  bool
+			service  string
+			resource string
+		}{
+			{guardDuty, "GuardDuty", "aws_guardduty_detector"},
+			{securityHub, "Security Hub", "aws_securityhub_account"},
+		}
+		for _, m := range services {
+			if m.enabled {
+				continue
+			}
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "warn",
+				Severity:    SeverityMedium,
+				Message:     fmt.Sprintf("Account managed here (%s) does not enable %s", marker.Address(), m.service),
+				File:        markerFile,
+				Line:        marker.Line,
+				Remediation: fmt.Sprintf("Add a %s resource so threat detection findings are collected for the account", m.resource),
+				Metadata: map[string]interface{}{
+					"service": m.service,
+				},
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "Threat detection is enabled or no account-management Terraform found",
+		})
+	}
+	
+	return results
+}
+
+// CORSRule checks for CORS configurations that let any origin make
+// credentialed or state-changing requests. Terraform S3, API Gateway and
+// Lambda URL CORS blocks are checked, as are CORS settings in JSON and YAML
+// app configs.
+type CORSRule struct{}
+
+var (
+	// corsOriginKeys, corsMethodKeys and corsCredentialKeys are normalized
+	// app config keys (lowercase, without _ and -) holding CORS settings
+	corsOriginKeys     = []string{"allowedorigins", "alloworigins", "allowedorigin", "alloworigin", "corsorigins", "corsallowedorigins", "accesscontrolalloworigin"}
+	corsMethodKeys     = []string{"allowedmethods", "allowmethods", "methods", "corsallowedmethods", "accesscontrolallowmethods"}
+	corsCredentialKeys = []string{"allowcredentials", "credentials", "supportscredentials", "corsallowcredentials", "accesscontrolallowcredentials"}
+)
+
+func (r *CORSRule) Name() string {
+	return "cors-no-wildcard-origin"
+}
+
+func (r *CORSRule) Description() string {
+	return "CORS should not allow any origin together with credentials or non-GET methods"
+}
+
+func (r *CORSRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *CORSRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC6.6",
+	}
+}
+
+func (r *CORSRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	// Terraform resource type -> nested CORS block and its attribute names
+	corsBlocks := map[string]struct{ block, origins, methods, credentials string }{
+		"aws_s3_bucket":                    {"cors_rule", "allowed_origins", "allowed_methods", ""},
+		"aws_s3_bucket_cors_configuration": {"cors_rule", "allowed_origins", "allowed_methods", ""},
+		"aws_apigatewayv2_api":             {"cors_configuration", "allow_origins", "allow_methods", "allow_credentials"},
+		"aws_lambda_function_url":          {"cors", "allow_origins", "allow_methods", "allow_credentials"},
+	}
+	
+	for filename, blocks := range terraformResources(files, "aws_s3_bucket", "aws_s3_bucket_cors_configuration", "aws_apigatewayv2_api", "aws_lambda_function_url") {
+		for _, block := range blocks {
+			attrs := corsBlocks[block.Labels[0]]
+			for _, cors := range block.Children(attrs.block) {
+				var origins, methods []string
+				if attr, ok := cors.Attr(attrs.origins); ok {
+					origins = hclStrings(attr.Value)
+				}
+				if attr, ok := cors.Attr(attrs.methods); ok {
+					methods = hclStrings(attr.Value)
+				}
+				credentials := false
+				if attr, ok := cors.Attr(attrs.credentials); ok && attrs.credentials != "" {
+					credentials = attr.IsTrue()
+				}
+				
+				if result, ok := r.finding("CORS for "+block.Address(), origins, methods, credentials); ok {
+					result.File = filename
+					result.Line = cors.Line
+					result.Metadata["resource"] = block.Address()
+					results = append(results, result)
+				}
+			}
+		}
+	}
+	
+	for filename, content := range files {
+		if !isConfigFile(filename) || isGitHubActionFile(filename) {
+			continue
+		}
+		
+		decoder := yaml.NewDecoder(strings.NewReader(content))
+		for {
+			var doc yaml.Node
+			if err := decoder.Decode(&doc); err != nil {
+				break
+			}
+			for _, found := range r.checkConfigNode(&doc) {
+				found.File = filename
+				results = append(results, found)
+			}
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No CORS configurations allow any origin with credentials or write methods",
+		})
+	}
+	
+	return results
+}
+
+// checkConfigNode walks a JSON or YAML document for mappings holding CORS settings
+func (r *CORSRule) checkConfigNode(node *yaml.Node) []CheckResult {
+	var results []CheckResult
+	
+	if node.Kind == yaml.MappingNode {
+		var originKey *yaml.Node
+		var origins, methods []string
+		credentials := false
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			name := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key.Value))
+			switch {
+			case containsString(corsOriginKeys, name):
+				originKey = key
+				origins = yamlStrings(value)
+			case containsString(corsMethodKeys, name):
+				methods = yamlStrings(value)
+			case containsString(corsCredentialKeys, name):
+				credentials = value.Kind == yaml.ScalarNode && strings.EqualFold(value.Value, "true")
+			}
+		}
+		
+		if originKey != nil {
+			if result, ok := r.finding(fmt.Sprintf("CORS setting %q", originKey.Value), origins, methods, credentials); ok {
+				result.Line = originKey.Line
+				result.Metadata["key"] = originKey.Value
+				results = append(results, result)
+			}
+		}
+	}
+	
+	for _, child := range node.Content {
+		results = append(results, r.checkConfigNode(child)...)
+	}
+	return results
+}
+
+// finding reports a wildcard origin combined with credentials (high) or with
+// methods beyond GET, HEAD and OPTIONS (medium)
+func (r *CORSRule) finding(subject string, origins, methods []string, credentials bool) (CheckResult, bool) {
+	if !containsString(origins, "*") {
+		return CheckResult{}, false
+	}
+	
+	var writeMethods []string
+	for _, method := range methods {
+		switch strings.ToUpper(method) {
+		case "GET", "HEAD", "OPTIONS":
+		default:
+			writeMethods = append(writeMethods, strings.ToUpper(method))
+		}
+	}
+	
+	switch {
+	case credentials:
+		return CheckResult{
+			RuleName:    r.Name(),
+			Status:      "fail",
+			Severity:    SeverityHigh,
+			Message:     fmt.Sprintf("%s allows any origin to make credentialed requests", subject),
+			Remediation: "List the trusted origins explicitly instead of \"*\" when credentials are allowed",
+			Metadata:    map[string]interface{}{"credentials": true},
+		}, true
+	case len(writeMethods) > 0:
+		allowed := strings.Join(writeMethods, ", ") + " requests"
+		if containsString(writeMethods, "*") {
+			allowed = "requests with any method"
+		}
+		return CheckResult{
+			RuleName:    r.Name(),
+			Status:      "warn",
+			Severity:    SeverityMedium,
+			Message:     fmt.Sprintf("%s allows any origin to send %s", subject, allowed),
+			Remediation: "List the trusted origins explicitly, or limit wildcard origins to GET and HEAD",
+			Metadata:    map[string]interface{}{"methods": writeMethods},
+		}, true
+	}
+	return CheckResult{}, false
+}
+
+// yamlStrings returns a scalar as a one-element list, or the scalars of a sequence.
+// Comma-separated scalars such as "GET, POST" are split.
+func yamlStrings(node *yaml.Node) []string {
+	var values []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		for _, value := range strings.Split(node.Value, ",") {
+			values = append(values, strings.TrimSpace(value))
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode {
+				values = append(values, item.Value)
+			}
+		}
+	}
+	return values
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// DockerfileRule checks Dockerfiles for images that run as root, unpinned
+// :latest base images, ADD from remote URLs and piping downloads into a shell
+type DockerfileRule struct{}
+
+var (
+	// dockerPipeToShellPattern matches curl or wget output piped into a shell
+	dockerPipeToShellPattern = regexp.MustCompile(`\b(curl|wget)\b[^|;&]*\|\s*(sudo\s+)?(ba|z|da|k)?sh\b`)
+	dockerRemoteURLPattern   = regexp.MustCompile(`(?i)^https?://`)
+)
+
+// dockerInstruction is one Dockerfile instruction with continuation lines joined
+type dockerInstruction struct {
+	Line    int // 1-based line the instruction starts on
+	Command string
+	Args    string
+}
+
+func (r *DockerfileRule) Name() string {
+	return "dockerfile-hardening"
+}
+
+func (r *DockerfileRule) Description() string {
+	return "Dockerfiles should run as a non-root user, pin base images and not execute remote scripts"
+}
+
+func (r *DockerfileRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *DockerfileRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC6.8",
+	}
+}
+
+func (r *DockerfileRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for filename, content := range files {
+		if !isDockerfile(filename) {
+			continue
+		}
+		
+		finding := func(instruction dockerInstruction, check, status, severity, message, remediation string) {
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      status,
+				Severity:    severity,
+				Message:     message,
+				File:        filename,
+				Line:        instruction.Line,
+				Remediation: remediation,
+				Metadata: map[string]interface{}{
+					"check":        check,
+					"line_content": instruction.Command + " " + instruction.Args,
+				},
+			})
+		}
+		
+		// Only the final stage's user matters for the shipped image. A stage
+		// built FROM an earlier stage inherits that stage's USER.
+		var finalFrom, lastUser *dockerInstruction
+		stageUsers := make(map[string]*dockerInstruction)
+		stage := ""
+		
+		instructions := parseDockerfile(content)
+		for i := range instructions {
+			instruction := instructions[i]
+			switch instruction.Command {
+			case "FROM":
+				if stage != "" {
+					stageUsers[stage] = lastUser
+				}
+				image, alias := dockerFromImage(instruction.Args)
+				inherited, fromStage := stageUsers[strings.ToLower(image)]
+				finalFrom, lastUser, stage = &instructions[i], inherited, strings.ToLower(alias)
+				if fromStage || image == "scratch" || strings.Contains(image, "$") || strings.Contains(image, "@sha256:") {
+					continue
+				}
+				if tag := dockerImageTag(image); tag == "" || tag == "latest" {
+					finding(instruction, "latest-tag", "warn", SeverityMedium,
+						fmt.Sprintf("Base image %s is not pinned to a version (uses latest)", image),
+						"Pin the base image to a version tag or, better, an @sha256 digest")
+				}
+			case "USER":
+				lastUser = &instructions[i]
+			case "ADD":
+				for _, source := range strings.Fields(instruction.Args) {
+					if dockerRemoteURLPattern.MatchString(source) {
+						finding(instruction, "add-remote-url", "warn", SeverityMedium,
+							fmt.Sprintf("ADD downloads %s without checksum verification", source),
+							"Download with RUN curl and verify a checksum, or use ADD --checksum")
+						break
+					}
+				}
+			case "RUN":
+				if dockerPipeToShellPattern.MatchString(instruction.Args) {
+					finding(instruction, "pipe-to-shell", "fail", SeverityHigh,
+						"RUN pipes a downloaded script straight into a shell",
+						"Download the script, verify its checksum or signature, then run it")
+				}
+			}
+		}
+		
+		if finalFrom == nil {
+			continue
+		}
+		if lastUser == nil {
+			finding(*finalFrom, "root-user", "fail", SeverityHigh,
+				"Image runs as root: the final stage sets no USER",
+				"Add a USER instruction with a non-root user to the final stage")
+		} else if user := strings.SplitN(strings.TrimSpace(lastUser.Args), ":", 2)[0]; user == "root" || user == "0" {
+			finding(*lastUser, "root-user", "fail", SeverityHigh,
+				"Image runs as root: the final USER is root",
+				"Switch to a non-root user at the end of the final stage")
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No Dockerfile hardening issues detected",
+		})
+	}
+	
+	return results
+}
+
+// parseDockerfile splits a Dockerfile into instructions, joining lines ending
+// in a backslash and skipping comments and blank lines
+func parseDockerfile(content string) []dockerInstruction {
+	var instructions []dockerInstruction
+	var current *dockerInstruction
+	
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") || (trimmed == "" && current == nil) {
+			continue
+		}
+		
+		continued := strings.HasSuffix(trimmed, "\\")
+		trimmed = strings.TrimSuffix(trimmed, "\\")
+		
+		if current == nil {
+			fields := strings.SplitN(trimmed, " ", 2)
+			current = &dockerInstruction{Line: i + 1, Command: strings.ToUpper(fields[0])}
+			if len(fields) == 2 {
+				current.Args = strings.TrimSpace(fields[1])
+			}
+		} else {
+			current.Args = strings.TrimSpace(current.Args + " " + trimmed)
+		}
+		
+		if !continued {
+			instructions = append(instructions, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		instructions = append(instructions, *current)
+	}
+	
+	return instructions
+}
+
+// dockerFromImage returns the image and optional stage alias of FROM arguments,
+// e.g. "--platform=linux/amd64 node:20 AS build"
+func dockerFromImage(args string) (string, string) {
+	var fields []string
+	for _, field := range strings.Fields(args) {
+		if !strings.HasPrefix(field, "--") {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return "", ""
+	}
+	if len(fields) >= 3 && strings.EqualFold(fields[1], "as") {
+		return fields[0], fields[2]
+	}
+	return fields[0], ""
+}
+
+// dockerImageTag returns the tag of an image reference, ignoring a registry port
+func dockerImageTag(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
+
+// HighEntropySecretRule flags credentials in JSON, YAML and .env files. Values
+// matching a known credential format are high-confidence findings; values that
+// are merely random-looking are less certain and reported as warnings.
+// Placeholder files such as .env.example are expected to hold dummy values,
+// so anything found there is at most a low-severity warning.
+type HighEntropySecretRule struct {
+	// Threshold is the minimum Shannon entropy in bits per character; zero disables entropy detection
+	Threshold float64
+	// MinLength is the shortest value considered secret-like
+	MinLength int
+}
+
+var (
+	// configValuePattern captures the key and scalar value of a JSON or YAML assignment
+	configValuePattern = regexp.MustCompile(`^\s*(?:-\s+)?["']?([A-Za-z0-9_.-]+)["']?\s*[:=]\s*["']?([A-Za-z0-9+/=_.~-]+)["']?\s*,?\s*$`)
+	// envValuePattern captures KEY=value lines of a dotenv file
+	envValuePattern = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=\s*["']?([^"'\s#]*)["']?`)
+)
+
+// knownSecretPatterns match credential formats that are unambiguous on their own
+var knownSecretPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"AWS access key ID", regexp.MustCompile(`^(AKIA|ASIA)[0-9A-Z]{16}$`)},
+	{"GitHub token", regexp.MustCompile(`^(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})$`)},
+	{"Slack token", regexp.MustCompile(`^xox[abprs]-[A-Za-z0-9-]{10,}$`)},
+	{"Stripe live key", regexp.MustCompile(`^(sk|rk)_live_[A-Za-z0-9]{16,}$`)},
+	{"Google API key", regexp.MustCompile(`^AIza[0-9A-Za-z_-]{35}$`)},
+}
+
+// NewHighEntropySecretRule creates the rule with defaults tuned so hex digests,
+// UUIDs and ordinary words stay below the threshold
+func NewHighEntropySecretRule() *HighEntropySecretRule {
+	return &HighEntropySecretRule{
+		Threshold: 4.5,
+		MinLength: 20,
+	}
+}
+
+func (r *HighEntropySecretRule) Name() string {
+	return "secrets-high-entropy"
+}
+
+func (r *HighEntropySecretRule) Description() string {
+	return "Config and .env files should not contain values that look like credentials"
+}
+
+func (r *HighEntropySecretRule) DefaultSeverity() string {
+	return SeverityHigh
+}
+
+func (r *HighEntropySecretRule) Frameworks() map[string]string {
+	return map[string]string{
+		"SOC2": "CC6.1",
+	}
+}
+
+// Configure accepts threshold (bits per character, 0 disables entropy detection) and min_length
+func (r *HighEntropySecretRule) Configure(settings map[string]interface{}) error {
+	if err := checkSettingKeys(settings, "threshold", "min_length"); err != nil {
+		return err
+	}
+	
+	threshold, ok, err := settingNumber(settings, "threshold")
+	if err != nil {
+		return err
+	}
+	if ok {
+		if threshold < 0 || threshold > 8 {
+			return fmt.Errorf("threshold must be between 0 and 8 bits per character")
+		}
+		r.Threshold = threshold
+	}
+	
+	minLength, ok, err := settingNumber(settings, "min_length")
+	if err != nil {
+		return err
+	}
+	if ok {
+		if minLength < 1 || minLength != math.Trunc(minLength) {
+			return fmt.Errorf("min_length must be a positive whole number")
+		}
+		r.MinLength = int(minLength)
+	}
+	return nil
+}
+
+func (r *HighEntropySecretRule) Check(files map[string]string) []CheckResult {
+	var results []CheckResult
+	
+	for filename, content := range files {
+		env := isEnvFile(filename)
+		if !isConfigFile(filename) && !env {
+			continue
+		}
+		placeholder := isPlaceholderFile(filename)
+		
+		valuePattern := configValuePattern
+		if env {
+			valuePattern = envValuePattern
+		}
+		
+		lines := strings.Split(content, "\n")
+		for lineNum, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "#") {
+				continue
+			}
+			
+			match := valuePattern.FindStringSubmatch(line)
+			if match == nil || match[2] == "" {
+				continue
+			}
+			key, value := match[1], match[2]
+			
+			result := CheckResult{
+				RuleName:    r.Name(),
+				File:        filename,
+				Line:        lineNum + 1,
+				Remediation: "Move the value to a secrets manager or environment variable and reference it from the config",
+				Metadata: map[string]interface{}{
+					"key": key,
+				},
+			}
+			
+			// The value itself is never recorded: results end up in attestations
+			if kind := knownSecretKind(value); kind != "" {
+				result.Status = "fail"
+				result.Severity = SeverityHigh
+				result.Message = fmt.Sprintf("Value of %q matches the %s format", key, kind)
+				result.Metadata["secret_type"] = kind
+			} else if entropy := shannonEntropy(value); r.Threshold > 0 && len(value) >= r.MinLength && entropy >= r.Threshold {
+				result.Status = "warn"
+				result.Severity = SeverityMedium
+				result.Message = fmt.Sprintf("Value of %q looks like a secret (entropy %.1f bits/char)", key, entropy)
+				result.Metadata["entropy"] = math.Round(entropy*100) / 100
+			} else {
+				continue
+			}
+			
+			if placeholder {
+				result.Status = "warn"
+				result.Severity = SeverityLow
+				result.Message += " in a placeholder file"
+				result.Remediation = "Replace the value with an obvious placeholder such as changeme"
+			}
+			
+			results = append(results, result)
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  "No credentials found in config or .env files",
+		})
+	}
+	
+	return results
+}
+
+// knownSecretKind names the credential format value matches, or returns ""
+func knownSecretKind(value string) string {
+	for _, known := range knownSecretPatterns {
+		if known.pattern.MatchString(value) {
+			return known.name
+		}
+	}
+	return ""
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	
+	counts := make(map[rune]int)
+	total := 0
+	for _, c := range s {
+		counts[c]++
+		total++
+	}
+	
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// RequiredTagsRule checks that taggable AWS resources carry the tag keys an
+// organization requires for cost and ownership tracking. Requirements are
+// org-specific, so the rule does nothing until tags are configured.
+type RequiredTagsRule struct {
+	// Tags lists the tag keys every taggable resource must have
+	Tags []string
+}
+
+// taggableAWSResources are the AWS resource types the rule checks
+var taggableAWSResources = []string{
+	"aws_instance", "aws_launch_template", "aws_ebs_volume", "aws_eip",
+	"aws_s3_bucket", "aws_efs_file_system",
+	"aws_db_instance", "aws_rds_cluster", "aws_dynamodb_table", "aws_elasticache_cluster",
+	"aws_elasticache_replication_group", "aws_redshift_cluster", "aws_opensearch_domain",
+	"aws_lambda_function", "aws_ecs_cluster", "aws_ecs_service", "aws_ecs_task_definition",
+	"aws_eks_cluster", "aws_eks_node_group", "aws_ecr_repository",
+	"aws_vpc", "aws_subnet", "aws_security_group", "aws_nat_gateway", "aws_internet_gateway",
+	"aws_route_table", "aws_lb", "aws_alb", "aws_lb_target_group", "aws_cloudfront_distribution",
+	"aws_api_gateway_rest_api", "aws_apigatewayv2_api",
+	"aws_sqs_queue", "aws_sns_topic", "aws_kinesis_stream", "aws_sfn_state_machine",
+	"aws_kms_key", "aws_secretsmanager_secret", "aws_cloudwatch_log_group", "aws_iam_role",
+}
+
+// tfMapKey matches a key of an HCL object literal, quoted or bare
+var tfMapKey = regexp.MustCompile(`(?m)(?:^|[,{])\s*(?:"([^"]+)"|([A-Za-z_][\w.:/-]*))\s*[=:]`)
+
+func (r *RequiredTagsRule) Name() string {
+	return "tf-required-tags"
+}
+
+func (r *RequiredTagsRule) Description() string {
+	return "Taggable AWS resources should carry the tag keys required in mondrian.yaml"
+}
+
+func (r *RequiredTagsRule) DefaultSeverity() string {
+	return SeverityLow
+}
+
+// Configure accepts tags, the list of required tag keys, e.g. [Owner, Environment, CostCenter]
+func (r *RequiredTagsRule) Configure(settings map[string]interface{}) error {
+	if err := checkSettingKeys(settings, "tags"); err != nil {
+		return err
+	}
+	
+	var tags []string
+	ok, err := settingList(settings, "tags", func(item interface{}) bool {
+		tag, isString := item.(string)
+		tags = append(tags, tag)
+		return isString && tag != ""
+	})
+	if err != nil {
+		return err
+	}
+	if ok {
+		r.Tags = tags
+	}
+	return nil
+}
+
+func (r *RequiredTagsRule) Check(files map[string]string) []CheckResult {
+	if len(r.Tags) == 0 {
+		return nil
+	}
+	var results []CheckResult
+	
+	// Tags from the AWS provider's default_tags apply to every resource
+	defaultTags := make(map[string]bool)
+	for filename, content := range files {
+		if !isTerraformFile(filename) {
+			continue
+		}
+		for _, block := range parseTerraform(content) {
+			if block.Type != "provider" || len(block.Labels) == 0 || block.Labels[0] != "aws" {
+				continue
+			}
+			for _, defaults := range block.Children("default_tags") {
+				if tags, ok := defaults.Attr("tags"); ok {
+					keys, _ := tfMapKeys(tags)
+					for key := range keys {
+						defaultTags[key] = true
+					}
+				}
+			}
+		}
+	}
+	
+	for filename, blocks := range terraformResources(files, taggableAWSResources...) {
+		for _, block := range blocks {
+			present := make(map[string]bool)
+			if tags, ok := block.Attr("tags"); ok {
+				keys, literal := tfMapKeys(tags)
+				if !literal {
+					continue // tags computed from variables or merge() can't be checked statically
+				}
+				present = keys
+			}
+			
+			var missing []string
+			for _, tag := range r.Tags {
+				if !present[tag] && !defaultTags[tag] {
+					missing = append(missing, tag)
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			
+			results = append(results, CheckResult{
+				RuleName:    r.Name(),
+				Status:      "warn",
+				Severity:    SeverityLow,
+				Message:     fmt.Sprintf("Resource %s is missing required tags: %s", block.Address(), strings.Join(missing, ", ")),
+				File:        filename,
+				Line:        block.Line,
+				Remediation: "Add the missing keys to the resource's tags, or to default_tags in the AWS provider",
+				Metadata: map[string]interface{}{
+					"resource":     block.Address(),
+					"missing_tags": missing,
+				},
+			})
+		}
+	}
+	
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			RuleName: r.Name(),
+			Status:   "pass",
+			Message:  fmt.Sprintf("All taggable resources carry the required tags (%s)", strings.Join(r.Tags, ", ")),
+		})
+	}
+	
+	return results
+}
+
+// tfMapKeys returns the keys of an HCL object literal such as
+// { Owner = "team", "Cost-Center" = "42" }. literal is false when the value
+// is an expression, e.g. var.tags or merge(...), whose keys aren't known.
+func tfMapKeys(attr tfAttr) (keys map[string]bool, literal bool) {
+	value := strings.TrimSpace(attr.Value)
+	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") {
+		return nil, false
+	}
+	
+	keys = make(map[string]bool)
+	for _, match := range tfMapKey.FindAllStringSubmatch(value, -1) {
+		keys[match[1]+match[2]] = true
+	}
+	return keys, true
+}
+
+// checkRule runs rule.Check, turning a panic into a failing result so that
+// one broken rule, such as a third-party one, doesn't abort the whole run
+func checkRule(rule PolicyRule, files map[string]string) (results []CheckResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Debug("Rule panicked", "rule", rule.Name(), "stack", string(debug.Stack()))
+			results = []CheckResult{{
+				RuleName:    rule.Name(),
+				Status:      "fail",
+				Severity:    RuleSeverity(rule),
+				Message:     fmt.Sprintf("Rule %s panicked: %v", rule.Name(), r),
+				Remediation: "Report the crash to the rule's author, or disable the rule in " + ConfigFileName,
+			}}
+		}
+	}()
+	return rule.Check(files)
+}
+
+// Helper functions
+func isTerraformFile(filename string) bool {
+	ext := filepath.Ext(filename)
+	return ext == ".tf" || ext == ".tfvars"
+}
+
+func isConfigFile(filename string) bool {
+	ext := filepath.Ext(filename)
+	return ext == ".yml" || ext == ".yaml" || ext == ".json"
+}
+
+// isEnvFile matches dotenv files: .env, .env.production, app.env, .env.example
+func isEnvFile(filename string) bool {
+	base := filepath.Base(filename)
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// isPlaceholderFile matches templates committed in place of real config, e.g. .env.example
+func isPlaceholderFile(filename string) bool {
+	base := filepath.Base(filename)
+	for _, marker := range []string{".example", ".sample", ".template"} {
+		if strings.Contains(base, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDockerfile matches Dockerfile, Dockerfile.<variant> and <name>.Dockerfile
+func isDockerfile(filename string) bool {
+	base := filepath.Base(filename)
+	return base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile.") || strings.HasSuffix(base, ".Dockerfile")
+}
+
+func isGitHubActionFile(filename string) bool {
+	ext := filepath.Ext(filename)
+	return strings.Contains(filepath.ToSlash(filename), ".github/workflows/") && (ext == ".yml" || ext == ".yaml")
+}
+
+// FormatResults formats check results for display
+func FormatResults(results []CheckResult) string {
+	var output strings.Builder
+	
+	passCount := 0
+	failCount := 0
+	warnCount := 0
+	
+	for _, result := range results {
+		switch result.Status {
+		case "pass":
+			passCount++
+			fmt.Fprintf(&output, "✅ %s: %s\n", result.RuleName, result.Message)
+		case "fail":
+			failCount++
+			fmt.Fprintf(&output, "❌ [%s] %s: %s\n", resultSeverity(result), result.RuleName, result.Message)
+			if result.File != "" {
+				fmt.Fprintf(&output, "   📁 %s:%d\n", result.File, result.Line)
+			}
+			if result.Remediation != "" {
+				fmt.Fprintf(&output, "   💡 %s\n", result.Remediation)
+			}
+		case "warn":
+			warnCount++
+			fmt.Fprintf(&output, "⚠️  [%s] %s: %s\n", resultSeverity(result), result.RuleName, result.Message)
+		}
+	}
+	
+	fmt.Fprintf(&output, "\n📊 Summary: %d passed, %d failed, %d warnings\n", passCount, failCount, warnCount)
+	
+	if failCount > 0 {
+		fmt.Fprintf(&output, "\n🚫 Policy check failed - %d violations found\n", failCount)
+	} else {
+		fmt.Fprintf(&output, "\n✅ All policy checks passed!\n")
+	}
+	
+	return output.String()
+}
+
+// ToJSON converts results to JSON for attestation
+func ToJSON(results []CheckResult) ([]byte, error) {
 	return json.MarshalIndent(results, "", "  ")
+}
+
+// ToNDJSON renders results as newline-delimited JSON, one object per line
+func ToNDJSON(results []CheckResult) ([]byte, error) {
+	var out []byte
+	for _, result := range results {
+		line, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		out = append(append(out, line...), '\n')
+	}
+	return out, nil
 }
\ No newline at end of file
@@ -0,0 +1,1088 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// failures returns the non-passing results
+func failures(results []CheckResult) []CheckResult {
+	var failed []CheckResult
+	for _, result := range results {
+		if result.Status != "pass" {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// findingLines returns the lines of the non-passing results
+func findingLines(results []CheckResult) []int {
+	var lines []int
+	for _, result := range failures(results) {
+		lines = append(lines, result.Line)
+	}
+	return lines
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSecurityGroupOpenRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		lines   []int
+	}{
+		{
+			name: "open ingress",
+			content: `resource "aws_security_group" "web" {
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}`,
+			lines: []int{5},
+		},
+		{
+			name: "single-line ingress",
+			content: `resource "aws_security_group" "ssh" {
+  name = "ssh"
+  ingress { cidr_blocks = ["0.0.0.0/0"] }
+}`,
+			lines: []int{3},
+		},
+		{
+			name: "single-line ingress followed by a private one",
+			content: `resource "aws_security_group" "ssh" {
+  ingress { cidr_blocks = ["10.0.0.0/8"] }
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}`,
+			lines: []int{4},
+		},
+		{
+			name: "dynamic ingress with a literal open CIDR",
+			content: `resource "aws_security_group" "web" {
+  dynamic "ingress" {
+    for_each = [80, 443]
+    content {
+      from_port   = ingress.value
+      to_port     = ingress.value
+      protocol    = "tcp"
+      cidr_blocks = ["0.0.0.0/0"]
+    }
+  }
+}`,
+			lines: []int{8},
+		},
+		{
+			name: "dynamic ingress with iterator CIDRs",
+			content: `resource "aws_security_group" "web" {
+  dynamic "ingress" {
+    for_each = var.rules
+    content {
+      cidr_blocks = ingress.value.cidr_blocks
+    }
+  }
+}`,
+		},
+		{
+			name: "open egress only",
+			content: `resource "aws_security_group" "web" {
+  egress {
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  ingress {
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+}`,
+		},
+		{
+			name: "open IPv4 in ipv6_cidr_blocks list of a nested block",
+			content: `resource "aws_security_group" "web" {
+  ingress {
+    cidr_blocks      = ["10.0.0.0/8"]
+    ipv6_cidr_blocks = ["0.0.0.0/0"]
+  }
+}`,
+			lines: []int{4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := NewSecurityGroupOpenRule().Check(map[string]string{"main.tf": tt.content})
+			if got := findingLines(results); !equalInts(got, tt.lines) {
+				t.Errorf("findings on lines %v, want %v", got, tt.lines)
+			}
+			for _, result := range failures(results) {
+				if result.RuleName != "sg-no-open-ingress" || result.Severity != SeverityHigh || result.Metadata["resource"] == nil {
+					t.Errorf("finding %+v lacks the rule name, severity or resource", result)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityGroupOpenRuleSensitivePorts(t *testing.T) {
+	rule := NewSecurityGroupOpenRule()
+	if err := rule.Configure(map[string]interface{}{"sensitive_ports": []interface{}{22}}); err != nil {
+		t.Fatal(err)
+	}
+	content := `resource "aws_security_group" "web" {
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  ingress {
+    from_port   = 0
+    to_port     = 1024
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}`
+	results := failures(rule.Check(map[string]string{"main.tf": content}))
+	if len(results) != 1 || results[0].Line != 12 {
+		t.Fatalf("findings = %+v, want only the range covering port 22", results)
+	}
+	if want := "Security group allows ingress from 0.0.0.0/0 on sensitive port 22"; results[0].Message != want {
+		t.Errorf("message = %q, want %q", results[0].Message, want)
+	}
+}
+
+// readExample returns the content of a file in the repository's examples
+// directory, where bad-*.bak files hold configurations rules must flag
+func readExample(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "examples", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestIsSecretVariable(t *testing.T) {
+	tests := map[string]bool{
+		"db_password":         true,
+		"api_token":           true,
+		"secret_key":          true,
+		"api_key":             true,
+		"private_key_pem":     true,
+		"dbPassword":          true,
+		"GITHUB_TOKEN":        true,
+		"token_ttl":           false,
+		"secret_name":         false,
+		"password_min_length": false,
+		"kms_key_arn":         false,
+		"passwordless_login":  false,
+		"tokenizer_model":     false,
+		"region":              false,
+		"public_key":          false,
+		"ssh_key_path":        false,
+	}
+	for name, want := range tests {
+		if got := isSecretVariable(name); got != want {
+			t.Errorf("isSecretVariable(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestSensitiveVariableRule(t *testing.T) {
+	rule := &SensitiveVariableRule{}
+	if rule.DefaultSeverity() != SeverityHigh {
+		t.Errorf("default severity = %s, want high", rule.DefaultSeverity())
+	}
+
+	if failed := failures(rule.Check(map[string]string{"variables.tf": readExample(t, "good-variables.tf")})); len(failed) != 0 {
+		t.Errorf("sensitive-marked variable flagged: %+v", failed)
+	}
+
+	// api_token is neither sensitive nor free of a literal default
+	failed := failures(rule.Check(map[string]string{"variables.tf": readExample(t, "bad-variables.tf.bak")}))
+	if got := findingLines(failed); !equalInts(got, []int{3, 6}) {
+		t.Fatalf("findings on lines %v, want 3 and 6", got)
+	}
+	for _, result := range failed {
+		if result.Severity != SeverityHigh || result.Metadata["variable"] != "api_token" {
+			t.Errorf("finding %+v, want a high finding about api_token", result)
+		}
+	}
+}
+
+func TestSensitiveVariableRuleIgnoresSecretMetadata(t *testing.T) {
+	content := `variable "token_ttl" {
+  default = "3600"
+}
+
+variable "secret_name" {
+  default = "prod/db"
+}
+
+variable "password_min_length" {
+  default = 14
+}
+`
+	if failed := failures((&SensitiveVariableRule{}).Check(map[string]string{"variables.tf": content})); len(failed) != 0 {
+		t.Errorf("variables describing secrets flagged: %+v", failed)
+	}
+}
+
+func TestIsGitHubActionFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     bool
+	}{
+		{".github/workflows/deploy.yaml", true},
+		{".github/workflows/ci.yml", true},
+		{"services/api/.github/workflows/ci.yml", true},
+		{"docker-compose.yaml", false},
+		{"docker-compose.yml", false},
+		{"k8s/deployment.yaml", false},
+		{".github/dependabot.yml", false},
+		{".github/workflows/README.md", false},
+		{".github/workflows.yml", false},
+	}
+	for _, tt := range tests {
+		if got := isGitHubActionFile(tt.filename); got != tt.want {
+			t.Errorf("isGitHubActionFile(%q) = %v, want %v", tt.filename, got, tt.want)
+		}
+	}
+}
+
+// checksFound returns the metadata check of each non-passing result, in order
+func checksFound(results []CheckResult) []string {
+	var checks []string
+	for _, result := range failures(results) {
+		check, _ := result.Metadata["check"].(string)
+		checks = append(checks, fmt.Sprintf("%s:%d", check, result.Line))
+	}
+	return checks
+}
+
+func TestDockerfileRule(t *testing.T) {
+	rule := &DockerfileRule{}
+	if failed := failures(rule.Check(map[string]string{"Dockerfile": readExample(t, "good.Dockerfile")})); len(failed) != 0 {
+		t.Errorf("digest-pinned image with a non-root user flagged: %+v", failed)
+	}
+
+	results := rule.Check(map[string]string{"Dockerfile": readExample(t, "bad.Dockerfile.bak")})
+	want := []string{"latest-tag:3", "add-remote-url:5", "pipe-to-shell:6", "root-user:3"}
+	if got := checksFound(results); !slices.Equal(got, want) {
+		t.Errorf("findings %v, want %v", got, want)
+	}
+}
+
+func TestDockerfileRuleUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "final USER root",
+			content: "FROM alpine:3.20\nUSER app\nRUN make\nUSER root\n",
+			want:    []string{"root-user:4"},
+		},
+		{
+			name:    "numeric root with group",
+			content: "FROM alpine:3.20\nUSER 0:0\n",
+			want:    []string{"root-user:2"},
+		},
+		{
+			name:    "user set only in the build stage",
+			content: "FROM golang:1.25 AS build\nUSER builder\nFROM alpine:3.20\nCOPY --from=build /app /app\n",
+			want:    []string{"root-user:3"},
+		},
+		{
+			name:    "stage inherits the user of the stage it builds on",
+			content: "FROM alpine:3.20 AS base\nUSER app\nFROM base\nCOPY app /app\n",
+		},
+		{
+			name:    "untagged image and wget piped to sh",
+			content: "FROM ubuntu\nRUN wget -qO- https://get.example.com | sudo sh\nUSER app\n",
+			want:    []string{"latest-tag:1", "pipe-to-shell:2"},
+		},
+		{
+			name:    "build argument image and registry port",
+			content: "ARG BASE\nFROM $BASE\nFROM registry.local:5000/app:1.2\nUSER app\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := (&DockerfileRule{}).Check(map[string]string{"build/Dockerfile": tt.content})
+			if got := checksFound(results); !slices.Equal(got, tt.want) {
+				t.Errorf("findings %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIAMWildcardRule(t *testing.T) {
+	rule := &IAMWildcardRule{}
+	if failed := failures(rule.Check(map[string]string{"iam.tf": readExample(t, "good-iam-policy.tf")})); len(failed) != 0 {
+		t.Errorf("scoped policies and a wildcard Deny flagged: %+v", failed)
+	}
+
+	// The jsonencode admin policy, then the heredoc's two wildcard statements
+	failed := failures(rule.Check(map[string]string{"iam.tf": readExample(t, "bad-iam-policy.tf.bak")}))
+	if got := findingLines(failed); !equalInts(got, []int{8, 24, 29}) {
+		t.Fatalf("findings on lines %v, want 8, 24 and 29", got)
+	}
+	admin := failed[0]
+	if admin.Severity != SeverityCritical || admin.Metadata["resource"] != "aws_iam_policy.admin" ||
+		!slices.Equal(admin.Metadata["actions"].([]string), []string{"*"}) || !slices.Equal(admin.Metadata["resources"].([]string), []string{"*"}) {
+		t.Errorf("admin finding %+v, want a critical finding naming the wildcards", admin)
+	}
+	if got := failed[1].Metadata["actions"].([]string); !slices.Equal(got, []string{"ec2:StopInstances", "ec2:StartInstances"}) {
+		t.Errorf("wildcard resource finding names actions %v", got)
+	}
+	if failed[2].Severity != SeverityHigh || failed[2].Metadata["resource"] != "aws_iam_role_policy.ops" {
+		t.Errorf("wildcard action finding %+v, want a high finding on aws_iam_role_policy.ops", failed[2])
+	}
+}
+
+func TestIAMWildcardRulePolicyDocument(t *testing.T) {
+	content := `data "aws_iam_policy_document" "deploy" {
+  statement {
+    actions   = ["s3:GetObject"]
+    resources = ["arn:aws:s3:::deploy/*"]
+  }
+  statement {
+    actions   = ["*:*"]
+    resources = ["*"]
+  }
+}
+`
+	failed := failures((&IAMWildcardRule{}).Check(map[string]string{"iam.tf": content}))
+	if len(failed) != 1 || failed[0].Line != 6 || failed[0].Metadata["resource"] != "data.aws_iam_policy_document.deploy" {
+		t.Errorf("findings = %+v, want the admin statement on line 6", failed)
+	}
+}
+
+func TestWorkflowPermissionsRule(t *testing.T) {
+	rule := &WorkflowPermissionsRule{}
+	const workflow = ".github/workflows/release.yml"
+	if failed := failures(rule.Check(map[string]string{workflow: readExample(t, "good-workflow-permissions.yml")})); len(failed) != 0 {
+		t.Errorf("least-privilege workflow flagged: %+v", failed)
+	}
+
+	failed := failures(rule.Check(map[string]string{workflow: readExample(t, "bad-workflow-permissions.yml.bak")}))
+	if len(failed) != 2 {
+		t.Fatalf("findings = %+v, want write-all and the missing top-level block", failed)
+	}
+	if failed[0].Status != "fail" || failed[0].Line != 16 || failed[0].Metadata["scope"] != "in job label" {
+		t.Errorf("write-all finding %+v, want a failure on line 16 for job label", failed[0])
+	}
+	if failed[1].Status != "warn" || !slices.Equal(failed[1].Metadata["jobs"].([]string), []string{"build"}) {
+		t.Errorf("missing permissions finding %+v, want a warning naming job build", failed[1])
+	}
+
+	// Only files under .github/workflows are workflows
+	if failed := failures(rule.Check(map[string]string{"deploy/ci.yml": readExample(t, "bad-workflow-permissions.yml.bak")})); len(failed) != 0 {
+		t.Errorf("YAML outside .github/workflows flagged: %+v", failed)
+	}
+}
+
+func TestWorkflowPermissionsRuleStyles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		lines   []int
+	}{
+		{
+			name:    "inline mapping",
+			content: "on: push\npermissions: {contents: read}\njobs:\n  build:\n    runs-on: ubuntu-latest\n",
+		},
+		{
+			name:    "read-all",
+			content: "on: push\npermissions: read-all\njobs:\n  build:\n    runs-on: ubuntu-latest\n",
+		},
+		{
+			name:    "top-level write-all",
+			content: "on: push\npermissions: write-all\njobs:\n  build:\n    runs-on: ubuntu-latest\n",
+			lines:   []int{2},
+		},
+		{
+			name:    "every job scoped without a top-level block",
+			content: "on: push\njobs:\n  build:\n    permissions:\n      contents: read\n    runs-on: ubuntu-latest\n",
+		},
+		{
+			name:    "empty permissions",
+			content: "on: push\npermissions: {}\njobs:\n  build:\n    runs-on: ubuntu-latest\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := (&WorkflowPermissionsRule{}).Check(map[string]string{".github/workflows/ci.yml": tt.content})
+			if got := findingLines(results); !equalInts(got, tt.lines) {
+				t.Errorf("findings on lines %v, want %v", got, tt.lines)
+			}
+		})
+	}
+}
+
+func TestPinnedActionRule(t *testing.T) {
+	rule := &PinnedActionRule{}
+	const workflow = ".github/workflows/release.yml"
+	if failed := failures(rule.Check(map[string]string{workflow: readExample(t, "good-workflow-permissions.yml")})); len(failed) != 0 {
+		t.Errorf("actions/checkout pinned to a SHA flagged: %+v", failed)
+	}
+
+	failed := failures(rule.Check(map[string]string{workflow: readExample(t, "bad-workflow-permissions.yml.bak")}))
+	if got := findingLines(failed); !equalInts(got, []int{11, 18}) {
+		t.Fatalf("findings on lines %v, want 11 and 18", got)
+	}
+	if failed[0].Metadata["action"] != "actions/checkout" || failed[0].Metadata["ref"] != "v4" {
+		t.Errorf("metadata = %v, want actions/checkout at v4", failed[0].Metadata)
+	}
+}
+
+func TestPinnedActionRuleReferences(t *testing.T) {
+	const sha = "b4ffde65f46336ab88eb53be808477a3936bae11"
+	content := `on: push
+jobs:
+  shared:
+    uses: org/workflows/.github/workflows/test.yml@main
+  local:
+    uses: ./.github/workflows/lint.yml
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: ./actions/setup
+      - uses: docker://alpine:3.20
+      - uses: actions/setup-go@` + sha + `
+      - uses: actions/cache@` + sha[:7] + `
+      - uses: octo/action
+`
+	failed := failures((&PinnedActionRule{}).Check(map[string]string{".github/workflows/ci.yml": content}))
+	var refs []string
+	for _, result := range failed {
+		refs = append(refs, fmt.Sprintf("%d %s@%s", result.Line, result.Metadata["action"], result.Metadata["ref"]))
+	}
+	// A short SHA can later match another commit, so only a full one counts
+	want := []string{"4 org/workflows/.github/workflows/test.yml@main", "13 actions/cache@" + sha[:7], "14 octo/action@"}
+	if !slices.Equal(refs, want) {
+		t.Errorf("flagged %v, want %v", refs, want)
+	}
+	if len(failed) == 3 && failed[2].Message != "Action octo/action is not pinned to any ref" {
+		t.Errorf("message = %q for an action without a ref", failed[2].Message)
+	}
+}
+
+func TestDatabasePortExposureRule(t *testing.T) {
+	rule := &DatabasePortExposureRule{}
+	if failed := failures(rule.Check(map[string]string{"db.tf": readExample(t, "good-database-sg.tf")})); len(failed) != 0 {
+		t.Errorf("Postgres restricted to the VPC flagged: %+v", failed)
+	}
+
+	failed := failures(rule.Check(map[string]string{"db.tf": readExample(t, "bad-database-sg.tf.bak")}))
+	if got := findingLines(failed); !equalInts(got, []int{10, 20, 29}) {
+		t.Fatalf("findings on lines %v, want 10, 20 and 29", got)
+	}
+	for _, result := range failed {
+		if result.Severity != SeverityCritical {
+			t.Errorf("finding %+v is not critical", result)
+		}
+	}
+	if failed[0].Message != "Security group allows ingress from 0.0.0.0/0 to PostgreSQL (5432)" || !strings.HasPrefix(failed[0].Remediation, "Restrict PostgreSQL") {
+		t.Errorf("Postgres finding %q with remediation %q", failed[0].Message, failed[0].Remediation)
+	}
+	// 0-65535 covers every sensitive port
+	for _, service := range []string{"SSH (22)", "RDP (3389)", "MySQL (3306)", "PostgreSQL (5432)", "Redis (6379)", "MongoDB (27017)", "Elasticsearch (9200)"} {
+		if !strings.Contains(failed[1].Message, service) {
+			t.Errorf("all-ports finding %q does not name %s", failed[1].Message, service)
+		}
+	}
+	if failed[2].Metadata["resource"] != "aws_vpc_security_group_ingress_rule.ssh" || !strings.Contains(failed[2].Remediation, "bastion") {
+		t.Errorf("SSH finding %+v, want the IPv6 ingress rule with bastion remediation", failed[2])
+	}
+}
+
+func TestDatabasePortExposureRulePortRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int
+		flagged  bool
+	}{
+		{"HTTPS only", 443, 443, false},
+		{"range below SSH", 0, 21, false},
+		{"range covering MySQL", 3000, 4000, true},
+		{"range between Elasticsearch and MongoDB", 10000, 27016, false},
+		{"Elasticsearch", 9200, 9200, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := fmt.Sprintf(`resource "aws_security_group" "db" {
+  ingress {
+    from_port   = %d
+    to_port     = %d
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+`, tt.from, tt.to)
+			failed := failures((&DatabasePortExposureRule{}).Check(map[string]string{"main.tf": content}))
+			if flagged := len(failed) > 0; flagged != tt.flagged {
+				t.Errorf("ports %d-%d flagged = %v, want %v", tt.from, tt.to, flagged, tt.flagged)
+			}
+		})
+	}
+}
+
+func TestS3PublicBucketRule(t *testing.T) {
+	rule := &S3PublicBucketRule{}
+	if failed := failures(rule.Check(map[string]string{"s3.tf": readExample(t, "good-s3-acl.tf")})); len(failed) != 0 {
+		t.Errorf("public ACL neutralized by a public access block flagged: %+v", failed)
+	}
+
+	failed := failures(rule.Check(map[string]string{"s3.tf": readExample(t, "bad-s3-public-acl.tf.bak")}))
+	if got := findingLines(failed); !equalInts(got, []int{9, 16}) {
+		t.Fatalf("findings on lines %v, want the ACL on 9 and the policy statement on 16", got)
+	}
+	if failed[0].Metadata["resource"] != "aws_s3_bucket_acl.website" || failed[1].Metadata["resource"] != "aws_s3_bucket_policy.website" {
+		t.Errorf("findings name %v and %v", failed[0].Metadata["resource"], failed[1].Metadata["resource"])
+	}
+}
+
+func TestS3PublicBucketRuleFalsePositives(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		lines   []int
+	}{
+		{
+			name: "commented-out ACL",
+			content: `resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+  # acl = "public-read"
+  // acl = "public-read-write"
+  /* acl = "public-read" */
+}
+`,
+		},
+		{
+			name: "public-read outside an ACL",
+			content: `resource "aws_s3_bucket" "logs" {
+  bucket = "public-read-archive"
+  tags = {
+    Note = "never public-read"
+  }
+}
+`,
+		},
+		{
+			name: "access block only",
+			content: `resource "aws_s3_bucket_public_access_block" "logs" {
+  bucket                  = "logs"
+  block_public_acls       = true
+  restrict_public_buckets = true
+}
+`,
+		},
+		{
+			name: "account-wide block",
+			content: `resource "aws_s3_account_public_access_block" "all" {
+  block_public_acls  = true
+  ignore_public_acls = true
+}
+
+resource "aws_s3_bucket" "assets" {
+  acl = "public-read"
+}
+`,
+		},
+		{
+			name: "ACL block does not cover a public policy",
+			content: `resource "aws_s3_bucket" "assets" {
+  policy = jsonencode({
+    Statement = [{
+      Effect    = "Allow"
+      Principal = { AWS = "*" }
+      Action    = "s3:GetObject"
+    }]
+  })
+}
+
+resource "aws_s3_bucket_public_access_block" "assets" {
+  bucket            = aws_s3_bucket.assets.id
+  block_public_acls = true
+}
+`,
+			lines: []int{3},
+		},
+		{
+			name: "genuine public ACL",
+			content: `resource "aws_s3_bucket" "assets" {
+  acl = "public-read-write"
+}
+`,
+			lines: []int{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := (&S3PublicBucketRule{}).Check(map[string]string{"main.tf": tt.content})
+			if got := findingLines(results); !equalInts(got, tt.lines) {
+				t.Errorf("findings on lines %v, want %v", got, tt.lines)
+			}
+		})
+	}
+}
+
+func TestGCPPublicBucketRule(t *testing.T) {
+	rule := &GCPPublicBucketRule{}
+	if failed := failures(rule.Check(map[string]string{"main.tf": readExample(t, "good-gcs-private.tf")})); len(failed) != 0 {
+		t.Errorf("member granted to a service account flagged: %+v", failed)
+	}
+
+	failed := failures(rule.Check(map[string]string{"main.tf": readExample(t, "bad-gcs-public.tf.bak")}))
+	if got := findingLines(failed); !equalInts(got, []int{10, 16}) {
+		t.Fatalf("findings on lines %v, want 10 and 16", got)
+	}
+	for i, want := range []string{"allUsers", "allAuthenticatedUsers"} {
+		if !strings.HasSuffix(failed[i].Message, "to "+want) {
+			t.Errorf("finding %d = %q, want it to name %s", i, failed[i].Message, want)
+		}
+	}
+	if failed[0].Metadata["resource"] != "google_storage_bucket_iam_member.assets_public" {
+		t.Errorf("resource = %v, want google_storage_bucket_iam_member.assets_public", failed[0].Metadata["resource"])
+	}
+}
+
+func TestGCPPublicBucketRulePublicAccessPrevention(t *testing.T) {
+	content := `resource "google_storage_bucket" "locked" {
+  name                     = "acme-locked"
+  public_access_prevention = "enforced"
+}
+
+resource "google_storage_bucket_iam_member" "locked_public" {
+  bucket = google_storage_bucket.locked.name
+  role   = "roles/storage.objectViewer"
+  member = "allUsers"
+}
+`
+	rule := &GCPPublicBucketRule{}
+	if failed := failures(rule.Check(map[string]string{"main.tf": content})); len(failed) != 0 {
+		t.Errorf("grant GCS rejects on an enforced bucket flagged: %+v", failed)
+	}
+	if failed := failures(rule.Check(map[string]string{"notes.md": readExample(t, "bad-gcs-public.tf.bak")})); len(failed) != 0 {
+		t.Errorf("non-Terraform file flagged: %+v", failed)
+	}
+}
+
+func TestAzureStoragePublicRule(t *testing.T) {
+	rule := &AzureStoragePublicRule{}
+	if failed := failures(rule.Check(map[string]string{"main.tf": readExample(t, "good-azure-storage.tf")})); len(failed) != 0 {
+		t.Errorf("private account and container flagged: %+v", failed)
+	}
+
+	failed := failures(rule.Check(map[string]string{"main.tf": readExample(t, "bad-azure-storage-public.tf.bak")}))
+	if got := findingLines(failed); !equalInts(got, []int{8, 14}) {
+		t.Fatalf("findings on lines %v, want 8 and 14", got)
+	}
+	if failed[1].Metadata["resource"] != "azurerm_storage_container.uploads" {
+		t.Errorf("resource = %v, want azurerm_storage_container.uploads", failed[1].Metadata["resource"])
+	}
+}
+
+func TestAzureStoragePublicRuleAccountSettings(t *testing.T) {
+	content := `resource "azurerm_storage_account" "legacy" {
+  name                     = "acmelegacy"
+  allow_blob_public_access = true
+}
+
+resource "azurerm_storage_account" "locked" {
+  name                            = "acmelocked"
+  allow_nested_items_to_be_public = false
+}
+
+resource "azurerm_storage_container" "reports" {
+  name                  = "reports"
+  storage_account_name  = azurerm_storage_account.locked.name
+  container_access_type = "blob"
+}
+`
+	// The pre-3.0 flag is still public, and a locked account overrides
+	// its containers
+	failed := failures((&AzureStoragePublicRule{}).Check(map[string]string{"main.tf": content}))
+	if got := findingLines(failed); !equalInts(got, []int{3}) {
+		t.Errorf("findings on lines %v, want only the legacy account on 3", got)
+	}
+}
+
+func TestEncryptionAtRestRule(t *testing.T) {
+	rule := &EncryptionAtRestRule{}
+	if results := rule.Check(map[string]string{"main.tf": readExample(t, "good-storage-encryption.tf")}); len(results) != 1 || results[0].Status != "pass" {
+		t.Errorf("encrypted storage reported %+v, want a single pass", results)
+	}
+
+	// Explicitly unencrypted storage fails, while unset encryption is left
+	// to provider defaults and only warns
+	var got []string
+	for _, result := range rule.Check(map[string]string{"main.tf": readExample(t, "bad-storage-encryption.tf.bak")}) {
+		got = append(got, fmt.Sprintf("%d %s %s", result.Line, result.Status, result.Metadata["resource"]))
+	}
+	want := []string{
+		"7 fail aws_db_instance.orders",
+		"10 warn aws_rds_cluster.analytics",
+		"18 fail aws_ebs_volume.scratch",
+		"21 warn aws_ebs_volume.cache",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %q, want %q", got, want)
+	}
+}
+
+func TestEncryptionAtRestRuleInheritedEncryption(t *testing.T) {
+	content := `resource "aws_ebs_encryption_by_default" "this" {
+  enabled = true
+}
+
+resource "aws_ebs_volume" "cache" {
+  availability_zone = "us-east-1a"
+  size              = 50
+}
+
+resource "aws_db_instance" "replica" {
+  replicate_source_db = aws_db_instance.orders.identifier
+}
+
+resource "aws_db_instance" "configured" {
+  storage_encrypted = var.encrypted
+}
+`
+	if failed := failures((&EncryptionAtRestRule{}).Check(map[string]string{"main.tf": content})); len(failed) != 0 {
+		t.Errorf("storage encrypted by default, by its source or by a variable reported %+v", failed)
+	}
+}
+
+// probeRule runs probe in place of a real check
+type probeRule struct{ probe func() }
+
+func (probeRule) Name() string        { return "probe-rule" }
+func (probeRule) Description() string { return "Runs a probe between rules" }
+func (r probeRule) Check(files map[string]string) []CheckResult {
+	r.probe()
+	return []CheckResult{{RuleName: "probe-rule", Status: "pass", Message: "probed"}}
+}
+
+func TestRunChecksStreamsResults(t *testing.T) {
+	engine := NewPolicyEngine()
+	var streamed []CheckResult
+	engine.OnResult = func(result CheckResult) { streamed = append(streamed, result) }
+
+	// A rule's results are emitted before the next rule starts
+	var beforeProbe int
+	engine.Rules = []PolicyRule{&S3PublicBucketRule{}, probeRule{func() { beforeProbe = len(streamed) }}, &SecurityGroupOpenRule{}}
+	files := map[string]string{
+		"s3.tf": readExample(t, "bad-s3-public-acl.tf.bak"),
+		"sg.tf": readExample(t, "bad-database-sg.tf.bak"),
+	}
+	results, err := engine.RunChecks(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(streamed) != len(results) || !reflect.DeepEqual(streamed, results) {
+		t.Fatalf("streamed %d results, want the %d returned, in order", len(streamed), len(results))
+	}
+	s3 := 0
+	for _, result := range results {
+		if result.RuleName == "s3-no-public-buckets" {
+			s3++
+		}
+	}
+	if beforeProbe != s3 {
+		t.Errorf("%d results streamed before the next rule ran, want all %d of the first rule's", beforeProbe, s3)
+	}
+	for _, result := range streamed {
+		if result.Status != "pass" && (result.Fingerprint == "" || result.Severity == "") {
+			t.Errorf("streamed finding %+v before severity and fingerprint were applied", result)
+		}
+	}
+}
+
+func TestRunChecksCanceledMidRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ran := false
+	engine := NewPolicyEngine()
+	engine.Rules = []PolicyRule{probeRule{cancel}, probeRule{func() { ran = true }}}
+
+	// The rule running when ctx is canceled finishes, the rest are skipped
+	// and nothing gathered is returned
+	results, err := engine.RunChecks(ctx, map[string]string{"main.tf": ""})
+	if err != context.Canceled || results != nil {
+		t.Errorf("canceled run = %d results, %v, want nothing and context.Canceled", len(results), err)
+	}
+	if ran {
+		t.Error("a rule ran after the run was canceled")
+	}
+}
+
+func TestRDSPublicRule(t *testing.T) {
+	content := `resource "aws_db_instance" "public" {
+  engine              = "postgres"
+  publicly_accessible = true
+}
+
+resource "aws_db_instance" "private" {
+  engine              = "postgres"
+  publicly_accessible = false
+}
+
+resource "aws_db_snapshot" "shared" {
+  db_instance_identifier = aws_db_instance.private.identifier
+  shared_accounts        = ["all"]
+}
+
+resource "aws_rds_cluster_snapshot" "partner" {
+  shared_accounts = ["123456789012"]
+}
+`
+	rule := &RDSPublicRule{}
+	var got []string
+	for _, result := range failures(rule.Check(map[string]string{"main.tf": content})) {
+		got = append(got, fmt.Sprintf("%d %s %s", result.Line, result.Status, result.Metadata["resource"]))
+	}
+	want := []string{"3 fail aws_db_instance.public", "13 fail aws_db_snapshot.shared"}
+	if !slices.Equal(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+
+	private := `resource "aws_db_instance" "private" {
+  engine = "postgres"
+}
+`
+	if results := rule.Check(map[string]string{"main.tf": private}); len(results) != 1 || results[0].Status != "pass" {
+		t.Errorf("private instance reported %+v, want a single pass", results)
+	}
+}
+
+func TestRDSPublicRulePrivateSubnets(t *testing.T) {
+	network := `resource "aws_route_table" "private" {
+  vpc_id = aws_vpc.main.id
+}
+
+resource "aws_route_table" "public" {
+  vpc_id = aws_vpc.main.id
+  route {
+    cidr_block = "0.0.0.0/0"
+    gateway_id = aws_internet_gateway.main.id
+  }
+}
+
+resource "aws_subnet" "private_a" {
+  vpc_id = aws_vpc.main.id
+}
+
+resource "aws_subnet" "public_a" {
+  vpc_id = aws_vpc.main.id
+}
+
+resource "aws_route_table_association" "private_a" {
+  subnet_id      = aws_subnet.private_a.id
+  route_table_id = aws_route_table.private.id
+}
+
+resource "aws_route_table_association" "public_a" {
+  subnet_id      = aws_subnet.public_a.id
+  route_table_id = aws_route_table.public.id
+}
+
+resource "aws_db_subnet_group" "private" {
+  name       = "private"
+  subnet_ids = [aws_subnet.private_a.id]
+}
+
+resource "aws_db_subnet_group" "mixed" {
+  name       = "mixed"
+  subnet_ids = [aws_subnet.private_a.id, aws_subnet.public_a.id]
+}
+`
+	db := `resource "aws_db_instance" "internal" {
+  publicly_accessible  = true
+  db_subnet_group_name = aws_db_subnet_group.private.name
+}
+
+resource "aws_db_instance" "exposed" {
+  publicly_accessible  = true
+  db_subnet_group_name = aws_db_subnet_group.mixed.name
+}
+`
+	// Without a route to an internet gateway a public address is only a warning
+	results := failures((&RDSPublicRule{}).Check(map[string]string{"network.tf": network, "db.tf": db}))
+	if len(results) != 2 {
+		t.Fatalf("findings = %+v, want one per instance", results)
+	}
+	if results[0].Status != "warn" || results[0].Severity != SeverityMedium || results[0].Metadata["resource"] != "aws_db_instance.internal" {
+		t.Errorf("private subnet instance = %+v, want a medium warning", results[0])
+	}
+	if results[1].Status != "fail" || results[1].Severity != SeverityCritical || results[1].Metadata["resource"] != "aws_db_instance.exposed" {
+		t.Errorf("public subnet instance = %+v, want a critical failure", results[1])
+	}
+}
+
+func TestIMDSv2Rule(t *testing.T) {
+	content := `resource "aws_instance" "required" {
+  ami = "ami-123"
+  metadata_options {
+    http_tokens = "required"
+  }
+}
+
+resource "aws_instance" "optional" {
+  ami = "ami-123"
+  metadata_options {
+    http_tokens = "optional"
+  }
+}
+
+resource "aws_launch_template" "unset" {
+  image_id = "ami-123"
+}
+
+resource "aws_launch_template" "no_tokens" {
+  metadata_options {
+    http_put_response_hop_limit = 1
+  }
+}
+
+resource "aws_instance" "disabled" {
+  metadata_options {
+    http_endpoint = "disabled"
+    http_tokens   = "optional"
+  }
+}
+`
+	rule := &IMDSv2Rule{}
+	var got []string
+	for _, result := range failures(rule.Check(map[string]string{"main.tf": content})) {
+		got = append(got, fmt.Sprintf("%d %s %s", result.Line, result.Status, result.Metadata["resource"]))
+	}
+	want := []string{
+		"11 fail aws_instance.optional",
+		"15 warn aws_launch_template.unset",
+		"20 warn aws_launch_template.no_tokens",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+
+	required := map[string]string{"main.tf": content[:strings.Index(content, "\n\n")+1]}
+	if results := rule.Check(required); len(results) != 1 || results[0].Status != "pass" {
+		t.Errorf("instance requiring IMDSv2 reported %+v, want a single pass", results)
+	}
+}
+
+func TestIMDSv2RuleAccountDefault(t *testing.T) {
+	content := `resource "aws_ec2_instance_metadata_defaults" "account" {
+  http_tokens = "required"
+}
+
+resource "aws_instance" "unset" {
+  ami = "ami-123"
+}
+
+resource "aws_instance" "optional" {
+  metadata_options {
+    http_tokens = "optional"
+  }
+}
+`
+	// The account default covers unset instances but not an explicit opt-out
+	if got := findingLines((&IMDSv2Rule{}).Check(map[string]string{"main.tf": content})); !equalInts(got, []int{11}) {
+		t.Errorf("findings on lines %v, want only the explicit optional on 11", got)
+	}
+}
+
+func TestAccessKeyRotationRule(t *testing.T) {
+	content := `resource "aws_iam_access_key" "stored" {
+  user = aws_iam_user.ci.name
+}
+
+resource "aws_iam_access_key" "unrotated" {
+  user = aws_iam_user.ci.name
+}
+
+resource "aws_iam_access_key" "timed" {
+  user = aws_iam_user.ci.name
+  pgp_key = time_rotating.keys.id
+}
+
+resource "aws_secretsmanager_secret" "ci" {
+  name = "ci-key"
+}
+
+resource "aws_secretsmanager_secret_version" "ci" {
+  secret_id     = aws_secretsmanager_secret.ci.id
+  secret_string = aws_iam_access_key.stored.secret
+}
+
+resource "aws_secretsmanager_secret" "db" {
+  name = "db-password"
+}
+
+resource "aws_secretsmanager_secret_rotation" "ci" {
+  secret_id           = aws_secretsmanager_secret.ci.id
+  rotation_lambda_arn = aws_lambda_function.rotate.arn
+}
+
+resource "aws_secretsmanager_secret_rotation" "db" {
+  secret_id           = aws_secretsmanager_secret.db.id
+  rotation_lambda_arn = aws_lambda_function.rotate.arn
+}
+`
+	var got []string
+	for _, result := range failures((&AccessKeyRotationRule{}).Check(map[string]string{"main.tf": content})) {
+		got = append(got, fmt.Sprintf("%d %s %s", result.Line, result.Status, result.Metadata["resource"]))
+	}
+	// Rotating the unrelated db secret does not cover the unrotated key
+	want := []string{
+		"1 warn aws_iam_access_key.stored",
+		"5 fail aws_iam_access_key.unrotated",
+		"9 warn aws_iam_access_key.timed",
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+}
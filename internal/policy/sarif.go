@@ -0,0 +1,166 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"path/filepath"
+)
+
+// SARIF 2.1.0 structures, limited to the fields GitHub code scanning uses
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string                     `json:"name"`
+	Version        string                     `json:"version"`
+	InformationURI string                     `json:"informationUri"`
+	Rules          []sarifReportingDescriptor `json:"rules"`
+}
+
+type sarifReportingDescriptor struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// ToSARIF converts results to a SARIF 2.1.0 log for GitHub code scanning.
+// Passing results are omitted, as are findings without a file since code
+// scanning requires every result to have a location.
+func ToSARIF(results []CheckResult) ([]byte, error) {
+	return toSARIF(results, NewPolicyEngine().Rules)
+}
+
+func toSARIF(results []CheckResult, rules []PolicyRule) ([]byte, error) {
+	driver := sarifDriver{
+		Name:           "mondrian",
+		Version:        "v0.1.0",
+		InformationURI: "https://github.com/miqcie/mondrian",
+		Rules:          []sarifReportingDescriptor{},
+	}
+
+	ruleIndex := make(map[string]int)
+	addRule := func(name, description string) int {
+		if i, ok := ruleIndex[name]; ok {
+			return i
+		}
+		ruleIndex[name] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sarifReportingDescriptor{
+			ID:               name,
+			Name:             name,
+			ShortDescription: sarifMessage{Text: description},
+		})
+		return ruleIndex[name]
+	}
+
+	for _, rule := range rules {
+		addRule(rule.Name(), rule.Description())
+	}
+
+	sarifResults := []sarifResult{}
+	for _, result := range results {
+		level := sarifLevel(result.Status)
+		if level == "" || result.File == "" {
+			continue
+		}
+
+		line := result.Line
+		if line < 1 {
+			line = 1
+		}
+
+		sarifResults = append(sarifResults, sarifResult{
+			RuleID:    result.RuleName,
+			RuleIndex: addRule(result.RuleName, result.RuleName),
+			Level:     level,
+			Message:   sarifMessage{Text: result.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(result.File)},
+					Region:           sarifRegion{StartLine: line},
+				},
+			}},
+			PartialFingerprints: map[string]string{
+				"mondrianFingerprint/v1": result.fingerprint(),
+			},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: driver},
+			Results: sarifResults,
+		}},
+	}
+
+	return json.MarshalIndent(log, "", "  ")
+}
+
+// sarifLevel maps a result status to a SARIF level; pass results have none
+func sarifLevel(status string) string {
+	switch status {
+	case "fail":
+		return "error"
+	case "warn":
+		return "warning"
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, rewriting it under -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (rerun with -update to accept it):\n%s", path, got)
+	}
+}
+
+// goldenResults is a known run: findings at each status, one without a
+// file, one without a line and one from a rule the engine doesn't have
+func goldenResults() []CheckResult {
+	return []CheckResult{
+		{RuleName: "s3-no-public-buckets", Status: "fail", Severity: SeverityHigh, Message: "S3 bucket has public-read ACL", File: "main.tf", Line: 7},
+		{RuleName: "sg-no-open-ingress", Status: "warn", Severity: SeverityMedium, Message: "Security group allows ingress from 0.0.0.0/0", File: "modules/network/sg.tf", Line: 12},
+		{RuleName: "iam-no-wildcard-actions", Status: "pass", Message: "No wildcard IAM actions", File: "iam.tf", Line: 3},
+		{RuleName: "s3-no-public-buckets", Status: "fail", Severity: SeverityHigh, Message: "S3 bucket policy allows public access", File: "main.tf"},
+		{RuleName: "required-tags", Status: "fail", Message: "No Terraform files found"},
+		{RuleName: "custom-rule", Status: "fail", Message: "Custom finding", File: "custom.tf", Line: 2, Fingerprint: "0123456789abcdef"},
+	}
+}
+
+func TestToSARIFGolden(t *testing.T) {
+	rules := []PolicyRule{&S3PublicBucketRule{}, NewSecurityGroupOpenRule()}
+	got, err := toSARIF(goldenResults(), rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "results.sarif", got)
+}
+
+func TestToSARIFLevels(t *testing.T) {
+	data, err := ToSARIF(goldenResults())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log version %s with %d runs, want one 2.1.0 run", log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	levels := make(map[string]int)
+	for _, result := range run.Results {
+		levels[result.Level]++
+		if rule := run.Tool.Driver.Rules[result.RuleIndex]; rule.ID != result.RuleID {
+			t.Errorf("result %s points at rule %s", result.RuleID, rule.ID)
+		}
+		if result.Locations[0].PhysicalLocation.Region.StartLine < 1 {
+			t.Errorf("result %s has no start line", result.RuleID)
+		}
+	}
+	if levels["error"] != 3 || levels["warning"] != 1 || len(run.Results) != 4 {
+		t.Errorf("levels = %v, want 3 errors and 1 warning with passes and fileless findings omitted", levels)
+	}
+}
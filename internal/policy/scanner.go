@@ -17,60 +17,225 @@ limitations under the License.
 package policy
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 )
 
 type FileScanner struct {
 	rootDir string
+	
+	// Concurrency bounds how many files are read at once (runtime.NumCPU() when <= 0)
+	Concurrency int
+	// RespectGitignore skips paths ignored by .gitignore files under the root
+	RespectGitignore bool
+	// MaxFileSize skips files larger than this many bytes (no limit when <= 0)
+	MaxFileSize int64
+	
+	// Warnings collects files skipped by the last scan, for the caller to surface
+	Warnings []string
 }
 
+// DefaultMaxFileSize keeps huge generated files such as state dumps out of memory
+const DefaultMaxFileSize = 5 << 20
+
 func NewFileScanner(rootDir string) *FileScanner {
-	return &FileScanner{rootDir: rootDir}
+	return &FileScanner{
+		rootDir:          rootDir,
+		Concurrency:      runtime.NumCPU(),
+		RespectGitignore: true,
+		MaxFileSize:      DefaultMaxFileSize,
+	}
 }
 
-func (fs *FileScanner) ScanRelevantFiles() (map[string]string, error) {
-	files := make(map[string]string)
+// ScanRelevantFiles walks the root and reads every relevant file. It stops
+// and returns ctx's error once ctx is done, discarding what was read so far.
+func (fs *FileScanner) ScanRelevantFiles(ctx context.Context) (map[string]string, error) {
+	// Walk first to collect candidates, then read them in parallel
+	var paths []string
+	ignore := &gitignore{}
+	fs.Warnings = nil
 	
 	err := filepath.Walk(fs.rootDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		
+		relPath, err := filepath.Rel(fs.rootDir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
 		
 		// Skip hidden directories and common non-relevant dirs
-		if info.IsDir() {
-			dirName := info.Name()
-			if strings.HasPrefix(dirName, ".") && dirName != ".github" {
-				return filepath.SkipDir
+		if info.IsDir() && path != fs.rootDir && IsSkippedDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		
+		if fs.RespectGitignore {
+			if relPath != "." && ignore.ignored(relPath, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
 			}
-			if dirName == "node_modules" || dirName == "vendor" || dirName == ".terraform" {
-				return filepath.SkipDir
+			if info.IsDir() {
+				base := relPath
+				if base == "." {
+					base = ""
+				}
+				ignore.load(path, base)
 			}
 		}
 		
-		if !info.IsDir() && fs.isRelevantFile(path) {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			
-			// Use relative path as key
-			relPath, err := filepath.Rel(fs.rootDir, path)
-			if err != nil {
-				relPath = path
+		if !info.IsDir() && fs.IsRelevantFile(path) {
+			if fs.MaxFileSize > 0 && info.Size() > fs.MaxFileSize {
+				fs.Warnings = append(fs.Warnings, fmt.Sprintf("skipped %s: %d bytes exceeds the %d byte limit", relPath, info.Size(), fs.MaxFileSize))
+				return nil
 			}
-			
-			files[relPath] = string(content)
+			paths = append(paths, path)
 		}
 		
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 	
-	return files, err
+	return fs.readFiles(ctx, paths)
 }
 
-func (fs *FileScanner) isRelevantFile(path string) bool {
+// ScanPaths reads only the given paths, relative to the root, that a full
+// scan would pick up. Paths that no longer exist are skipped, since a diff
+// can name files deleted since its base. .gitignore is not consulted: the
+// paths are expected to come from git itself. Like ScanRelevantFiles, it
+// gives up with ctx's error once ctx is done.
+func (fs *FileScanner) ScanPaths(ctx context.Context, relPaths []string) (map[string]string, error) {
+	var paths []string
+	fs.Warnings = nil
+	
+	for _, relPath := range relPaths {
+		relPath = filepath.ToSlash(filepath.Clean(relPath))
+		if relPath == "." || strings.HasPrefix(relPath, "../") {
+			continue
+		}
+		
+		skipped := false
+		dirs := strings.Split(relPath, "/")
+		for _, dir := range dirs[:len(dirs)-1] {
+			if IsSkippedDir(dir) {
+				skipped = true
+				break
+			}
+		}
+		if skipped {
+			continue
+		}
+		
+		path := filepath.Join(fs.rootDir, filepath.FromSlash(relPath))
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() || !fs.IsRelevantFile(path) {
+			continue
+		}
+		if fs.MaxFileSize > 0 && info.Size() > fs.MaxFileSize {
+			fs.Warnings = append(fs.Warnings, fmt.Sprintf("skipped %s: %d bytes exceeds the %d byte limit", relPath, info.Size(), fs.MaxFileSize))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	
+	return fs.readFiles(ctx, paths)
+}
+
+// IsSkippedDir reports whether a directory is never scanned: hidden
+// directories other than .github, and dependency or tool caches
+func IsSkippedDir(name string) bool {
+	if strings.HasPrefix(name, ".") && name != ".github" {
+		return true
+	}
+	return name == "node_modules" || name == "vendor" || name == ".terraform"
+}
+
+// readFiles reads paths with a bounded pool of workers, keyed by path relative
+// to the root. Once ctx is done no further files are read.
+func (fs *FileScanner) readFiles(ctx context.Context, paths []string) (map[string]string, error) {
+	workers := fs.Concurrency
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	
+	files := make(map[string]string, len(paths))
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	jobs := make(chan string)
+	
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				content, err := os.ReadFile(path)
+				
+				// Use relative path as key
+				relPath, relErr := filepath.Rel(fs.rootDir, path)
+				if relErr != nil {
+					relPath = path
+				}
+				
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					files[relPath] = string(content)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	
+feed:
+	for _, path := range paths {
+		select {
+		case jobs <- path:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return files, nil
+}
+
+// IsRelevantFile reports whether a scan picks up the file at path, judging by its name
+func (fs *FileScanner) IsRelevantFile(path string) bool {
 	// Check file extensions we care about
 	ext := filepath.Ext(path)
 	
@@ -89,8 +254,10 @@ func (fs *FileScanner) isRelevantFile(path string) bool {
 	
 	// Check specific file patterns
 	fileName := filepath.Base(path)
+	if isEnvFile(fileName) || isDockerfile(fileName) {
+		return true
+	}
 	relevantFiles := []string{
-		"Dockerfile",
 		"docker-compose.yml",
 		"docker-compose.yaml",
 	}
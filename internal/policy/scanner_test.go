@@ -0,0 +1,192 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// writeTree writes files, keyed by slash-separated path, under dir
+func writeTree(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// syntheticTree writes n Terraform files spread over modules, with as many
+// irrelevant files alongside, and returns the relevant ones
+func syntheticTree(t testing.TB, dir string, n int) map[string]string {
+	t.Helper()
+	relevant := make(map[string]string, n)
+	all := make(map[string]string, 2*n)
+	for i := 0; i < n; i++ {
+		module := fmt.Sprintf("modules/m%03d", i/50)
+		tf := fmt.Sprintf("%s/resource%04d.tf", module, i)
+		relevant[tf] = fmt.Sprintf("resource \"aws_s3_bucket\" \"b%d\" {\n  bucket = \"bucket-%d\"\n}\n", i, i)
+		all[tf] = relevant[tf]
+		all[fmt.Sprintf("%s/notes%04d.txt", module, i)] = "not scanned\n"
+	}
+	writeTree(t, dir, all)
+	return relevant
+}
+
+func TestScanRelevantFilesConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	want := syntheticTree(t, dir, 300)
+
+	for _, concurrency := range []int{1, 4, 64, 0} {
+		scanner := NewFileScanner(dir)
+		scanner.Concurrency = concurrency
+		files, err := scanner.ScanRelevantFiles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !maps.Equal(files, want) {
+			t.Errorf("concurrency %d scanned %d files, want the %d Terraform files keyed by relative path", concurrency, len(files), len(want))
+		}
+	}
+}
+
+func TestScanRelevantFilesCanceled(t *testing.T) {
+	dir := t.TempDir()
+	syntheticTree(t, dir, 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if files, err := NewFileScanner(dir).ScanRelevantFiles(ctx); err != context.Canceled || files != nil {
+		t.Errorf("canceled scan = %d files, %v, want nothing and context.Canceled", len(files), err)
+	}
+}
+
+// cancelAfterContext cancels itself once Err has been polled n times, so a
+// scan is cut off partway through its walk
+type cancelAfterContext struct {
+	context.Context
+	cancel context.CancelFunc
+	n      atomic.Int32
+}
+
+func newCancelAfterContext(n int32) *cancelAfterContext {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &cancelAfterContext{Context: ctx, cancel: cancel}
+	c.n.Store(n)
+	return c
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.n.Add(-1) < 0 {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func TestScanRelevantFilesCanceledMidScan(t *testing.T) {
+	dir := t.TempDir()
+	syntheticTree(t, dir, 200)
+	scanner := NewFileScanner(dir)
+
+	ctx := newCancelAfterContext(50)
+	defer ctx.cancel()
+	if files, err := scanner.ScanRelevantFiles(ctx); err != context.Canceled || files != nil {
+		t.Errorf("scan canceled mid-walk = %d files, %v, want nothing and context.Canceled", len(files), err)
+	}
+	if ctx.n.Load() >= 0 {
+		t.Fatal("the scan finished before it was canceled")
+	}
+
+	// The scanner is left fit to scan again
+	files, err := scanner.ScanRelevantFiles(context.Background())
+	if err != nil || len(files) == 0 {
+		t.Errorf("rescan = %d files, %v, want the whole tree", len(files), err)
+	}
+}
+
+// BenchmarkScanRelevantFiles scans a synthetic tree of a few thousand files
+// reading one file at a time and with the default worker pool
+func BenchmarkScanRelevantFiles(b *testing.B) {
+	dir := b.TempDir()
+	syntheticTree(b, dir, 3000)
+
+	for _, bench := range []struct {
+		name        string
+		concurrency int
+	}{
+		{"sequential", 1},
+		{fmt.Sprintf("parallel-%d", runtime.NumCPU()), runtime.NumCPU()},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scanner := NewFileScanner(dir)
+				scanner.Concurrency = bench.concurrency
+				if _, err := scanner.ScanRelevantFiles(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestScanSkipsFilesOverMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"main.tf":           "resource \"aws_s3_bucket\" \"b\" {}\n",
+		"package-lock.json": strings.Repeat("x", 2048),
+	})
+
+	scanner := NewFileScanner(dir)
+	if scanner.MaxFileSize != DefaultMaxFileSize {
+		t.Errorf("MaxFileSize defaults to %d, want %d", scanner.MaxFileSize, DefaultMaxFileSize)
+	}
+	scanner.MaxFileSize = 1024
+	files, err := scanner.ScanRelevantFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files["package-lock.json"]; ok {
+		t.Errorf("package-lock.json over the %d byte limit was scanned", scanner.MaxFileSize)
+	}
+	if _, ok := files["main.tf"]; !ok {
+		t.Errorf("main.tf under the limit was skipped")
+	}
+	if want := "skipped package-lock.json: 2048 bytes exceeds the 1024 byte limit"; len(scanner.Warnings) != 1 || scanner.Warnings[0] != want {
+		t.Errorf("warnings = %q, want %q", scanner.Warnings, want)
+	}
+
+	// No limit scans everything, and a new scan clears old warnings
+	scanner.MaxFileSize = 0
+	if files, err = scanner.ScanRelevantFiles(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files["package-lock.json"]; !ok || len(scanner.Warnings) != 0 {
+		t.Errorf("unlimited scan skipped package-lock.json or kept warnings %q", scanner.Warnings)
+	}
+}
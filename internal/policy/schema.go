@@ -0,0 +1,261 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSchema is the JSON Schema for mondrian.yaml, which editors can use
+// for completion and which LoadConfig validates every config against
+//
+//go:embed config.schema.json
+var ConfigSchema []byte
+
+// schema holds the subset of JSON Schema that ConfigSchema uses
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+	Enum       []string           `json:"enum"`
+	MinLength  int                `json:"minLength"`
+	Defs       map[string]*schema `json:"$defs"`
+
+	// additionalProperties is either false or a schema
+	RawAdditional json.RawMessage `json:"additionalProperties"`
+	closed        bool
+	additional    *schema
+}
+
+var configSchema = mustParseSchema(ConfigSchema)
+
+func mustParseSchema(data []byte) *schema {
+	var root schema
+	if err := json.Unmarshal(data, &root); err != nil {
+		panic(fmt.Sprintf("invalid embedded config schema: %v", err))
+	}
+	root.resolveAdditional()
+	return &root
+}
+
+func (s *schema) resolveAdditional() {
+	if s == nil {
+		return
+	}
+	switch raw := strings.TrimSpace(string(s.RawAdditional)); raw {
+	case "", "true":
+	case "false":
+		s.closed = true
+	default:
+		s.additional = &schema{}
+		if err := json.Unmarshal(s.RawAdditional, s.additional); err != nil {
+			panic(fmt.Sprintf("invalid embedded config schema: %v", err))
+		}
+	}
+	for _, child := range []*schema{s.Items, s.additional} {
+		child.resolveAdditional()
+	}
+	for _, child := range s.Properties {
+		child.resolveAdditional()
+	}
+	for _, child := range s.Defs {
+		child.resolveAdditional()
+	}
+}
+
+// ValidateConfigSchema checks a mondrian.yaml document against ConfigSchema.
+// Every violation is reported with its line and the key path it occurs at,
+// e.g. "line 4: severity.tf-prevent-destroy-stateful: "hgh" is not one of
+// low, medium, high, critical".
+func ValidateConfigSchema(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return nil
+	}
+
+	var errs []error
+	configSchema.validate(root, configSchema, "", &errs)
+	return errors.Join(errs...)
+}
+
+func (s *schema) validate(node *yaml.Node, root *schema, path string, errs *[]error) {
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+		if def := root.Defs[name]; ok && def != nil {
+			def.validate(node, root, path, errs)
+		}
+	}
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	fail := func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		if path != "" {
+			message = path + ": " + message
+		}
+		*errs = append(*errs, fmt.Errorf("line %d: %s", node.Line, message))
+	}
+
+	if s.Type != "" {
+		if got := nodeType(node); got != s.Type {
+			if node.Kind == yaml.ScalarNode && got != "null" {
+				fail("expected %s, got %s %q", article(s.Type), got, node.Value)
+			} else {
+				fail("expected %s, got %s", article(s.Type), article(got))
+			}
+			return
+		}
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if len(s.Enum) > 0 {
+			found := false
+			for _, value := range s.Enum {
+				if node.Value == value {
+					found = true
+				}
+			}
+			if !found {
+				fail("%q is not one of %s", node.Value, strings.Join(s.Enum, ", "))
+			}
+		}
+		if len(node.Value) < s.MinLength {
+			fail("must not be empty")
+		}
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range node.Content {
+				s.Items.validate(item, root, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := key.Value
+			if path != "" {
+				childPath = path + "." + key.Value
+			}
+
+			if property, ok := s.Properties[key.Value]; ok {
+				property.validate(value, root, childPath, errs)
+			} else if s.additional != nil {
+				s.additional.validate(value, root, childPath, errs)
+			} else if s.closed {
+				message := fmt.Sprintf("unknown key %q", key.Value)
+				if path != "" {
+					message = path + ": " + message
+				}
+				if suggestion := closestKey(key.Value, s.Properties); suggestion != "" {
+					message += fmt.Sprintf(", did you mean %q?", suggestion)
+				} else {
+					message += fmt.Sprintf(" (expected one of: %s)", strings.Join(propertyNames(s.Properties), ", "))
+				}
+				*errs = append(*errs, fmt.Errorf("line %d: %s", key.Line, message))
+			}
+		}
+	}
+}
+
+// nodeType names a YAML node's JSON Schema type
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.Tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+func article(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	case "null":
+		return "nothing"
+	}
+	return "a " + typ
+}
+
+func propertyNames(properties map[string]*schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// closestKey returns the property within two edits of key, if any, to
+// suggest for a misspelling
+func closestKey(key string, properties map[string]*schema) string {
+	best, bestDistance := "", 3
+	for _, name := range propertyNames(properties) {
+		if d := editDistance(key, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
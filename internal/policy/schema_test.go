@@ -0,0 +1,108 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigSchemaAcceptsValidConfig(t *testing.T) {
+	config := `rules:
+  - s3-no-public-buckets
+  - sg-no-open-ingress
+severity:
+  sg-no-open-ingress: critical
+fail_on: medium
+ignore:
+  - "**/testdata/**"
+settings:
+  sg-no-open-ingress:
+    sensitive_ports: [22, 3389]
+allowlist:
+  - rule: s3-no-public-buckets
+    resource: aws_s3_bucket.website
+    reason: Static website
+plugins: rules
+policies: policies
+`
+	if err := ValidateConfigSchema([]byte(config)); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+	for _, empty := range []string{"", "# nothing configured\n", "~\n"} {
+		if err := ValidateConfigSchema([]byte(empty)); err != nil {
+			t.Errorf("empty config %q rejected: %v", empty, err)
+		}
+	}
+}
+
+func TestValidateConfigSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"misspelled key", "rules: []\nseverty:\n  s3-no-public-buckets: high\n", `line 2: unknown key "severty", did you mean "severity"?`},
+		{"unknown key", "colour: blue\n", `line 1: unknown key "colour" (expected one of: allowlist, fail_on`},
+		{"severity value", "severity:\n  s3-no-public-buckets: hgh\n", `line 2: severity.s3-no-public-buckets: "hgh" is not one of low, medium, high, critical`},
+		{"fail_on value", "fail_on: warn\n", `line 1: fail_on: "warn" is not one of low, medium, high, critical`},
+		{"rules type", "rules: s3-no-public-buckets\n", `line 1: rules: expected an array, got string "s3-no-public-buckets"`},
+		{"empty glob", "ignore:\n  - \"\"\n", `line 2: ignore[0]: must not be empty`},
+		{"settings type", "settings:\n  sg-no-open-ingress: 22\n", `line 2: settings.sg-no-open-ingress: expected an object, got integer "22"`},
+		{"allowlist key", "allowlist:\n  - resource: aws_s3_bucket.a\n    reasn: legacy\n", `line 3: allowlist[0]: unknown key "reasn", did you mean "reason"?`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfigSchema([]byte(tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfigSchemaReportsEveryMistake(t *testing.T) {
+	err := ValidateConfigSchema([]byte("fail_on: hihg\nignore: tests\nsevrity: {}\n"))
+	if err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 3 {
+		t.Errorf("got %d errors, want one per mistake:\n%v", len(lines), err)
+	}
+}
+
+func TestLoadConfigReportsSchemaErrors(t *testing.T) {
+	path := writeConfig(t, "rules: []\nseverty:\n  s3-no-public-buckets: high\n")
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "invalid config "+path) || !strings.Contains(err.Error(), `did you mean "severity"?`) {
+		t.Errorf("err = %v, want the misspelled key pinpointed", err)
+	}
+}
+
+func TestConfigSchemaIsValidJSON(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(ConfigSchema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, key := range []string{"rules", "severity", "fail_on", "ignore", "settings", "allowlist", "plugins", "policies"} {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("schema does not describe %q", key)
+		}
+	}
+}
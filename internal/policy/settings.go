@@ -0,0 +1,117 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConfigurableRule is a rule that accepts settings from the settings section
+// of mondrian.yaml. Configure is called once, before any check runs; settings
+// a rule does not know should be rejected so typos surface.
+type ConfigurableRule interface {
+	PolicyRule
+	Configure(settings map[string]interface{}) error
+}
+
+// ConfigureRules passes each rule its settings, keyed by rule name. Settings
+// for unknown rules or rules without settings are an error.
+func (pe *PolicyEngine) ConfigureRules(settings map[string]map[string]interface{}) error {
+	rules := make(map[string]PolicyRule, len(pe.Rules))
+	for _, rule := range pe.Rules {
+		rules[rule.Name()] = rule
+	}
+	
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	
+	for _, name := range names {
+		rule, ok := rules[name]
+		if !ok {
+			return fmt.Errorf("settings for unknown rule %q", name)
+		}
+		configurable, ok := rule.(ConfigurableRule)
+		if !ok {
+			return fmt.Errorf("rule %s has no settings", name)
+		}
+		if err := configurable.Configure(settings[name]); err != nil {
+			return fmt.Errorf("settings for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkSettingKeys rejects settings not in known
+func checkSettingKeys(settings map[string]interface{}, known ...string) error {
+	for key := range settings {
+		if !containsString(known, key) {
+			return fmt.Errorf("unknown setting %q (known settings: %s)", key, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// settingNumber reads a numeric setting
+func settingNumber(settings map[string]interface{}, key string) (float64, bool, error) {
+	value, ok := settings[key]
+	if !ok {
+		return 0, false, nil
+	}
+	switch v := value.(type) {
+	case int:
+		return float64(v), true, nil
+	case float64:
+		return v, true, nil
+	}
+	return 0, true, fmt.Errorf("%s must be a number", key)
+}
+
+// settingString reads a string setting
+func settingString(settings map[string]interface{}, key string) (string, bool, error) {
+	value, ok := settings[key]
+	if !ok {
+		return "", false, nil
+	}
+	s, isString := value.(string)
+	if !isString {
+		return "", true, fmt.Errorf("%s must be a string", key)
+	}
+	return s, true, nil
+}
+
+// settingList reads a list setting; each item is passed to convert
+func settingList(settings map[string]interface{}, key string, convert func(interface{}) bool) (bool, error) {
+	value, ok := settings[key]
+	if !ok {
+		return false, nil
+	}
+	items, isList := value.([]interface{})
+	if !isList {
+		return true, fmt.Errorf("%s must be a list", key)
+	}
+	for _, item := range items {
+		if !convert(item) {
+			return true, fmt.Errorf("invalid %s entry %v", key, item)
+		}
+	}
+	return true, nil
+}
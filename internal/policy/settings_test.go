@@ -0,0 +1,95 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestConfigSettingsReachRules(t *testing.T) {
+	files := map[string]string{"main.tf": `resource "aws_security_group" "web" {
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+`}
+	run := func(config string) []int {
+		t.Helper()
+		cfg, err := LoadConfig(writeConfig(t, config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		engine, err := NewPolicyEngineFromConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := engine.RunChecks(context.Background(), files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return findingLines(results)
+	}
+
+	if got := run("rules: [sg-no-open-ingress]\n"); !equalInts(got, []int{5, 10}) {
+		t.Errorf("default settings flagged lines %v, want both open rules", got)
+	}
+	configured := `rules: [sg-no-open-ingress]
+settings:
+  sg-no-open-ingress:
+    sensitive_ports: [22, 3389]
+`
+	if got := run(configured); !equalInts(got, []int{10}) {
+		t.Errorf("sensitive_ports [22, 3389] flagged lines %v, want only the SSH rule", got)
+	}
+}
+
+func TestLoadConfigRejectsInvalidSettings(t *testing.T) {
+	tests := map[string]string{
+		"unknown rule":          "nonexistent-rule:\n    threshold: 4",
+		"rule without settings": "s3-no-public-buckets:\n    threshold: 4",
+		"unknown setting":       "sg-no-open-ingress:\n    ports: [22]",
+		"not a list":            "sg-no-open-ingress:\n    sensitive_ports: 22",
+		"port out of range":     "sg-no-open-ingress:\n    sensitive_ports: [70000]",
+		"invalid CIDR":          "sg-no-open-ingress:\n    open_cidrs: [\"0.0.0.0\"]",
+	}
+	for name, settings := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadConfig(writeConfig(t, "settings:\n  "+settings+"\n")); err == nil {
+				t.Error("invalid settings were accepted")
+			}
+		})
+	}
+}
+
+func TestConfigureRulesNamesTheRule(t *testing.T) {
+	engine := NewPolicyEngine()
+	err := engine.ConfigureRules(map[string]map[string]interface{}{
+		"sg-no-open-ingress": {"sensitive_ports": []interface{}{"ssh"}},
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), "settings for sg-no-open-ingress:") {
+		t.Errorf("err = %v, want it to name sg-no-open-ingress", err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Finding severities, lowest first
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// DefaultFailOn is the minimum severity that fails a run unless configured otherwise
+const DefaultFailOn = SeverityHigh
+
+// Severities lists the valid severities, lowest first
+var Severities = []string{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateSeverity returns an error unless severity is one of Severities
+func ValidateSeverity(severity string) error {
+	if severityRank(severity) < 0 {
+		return fmt.Errorf("unknown severity %q (expected one of: %s)", severity, strings.Join(Severities, ", "))
+	}
+	return nil
+}
+
+// SeverityRule is a rule that declares the severity of its most serious
+// finding, shown by 'mondrian rules list'
+type SeverityRule interface {
+	PolicyRule
+	DefaultSeverity() string
+}
+
+// RuleSeverity returns the default severity of a rule, or high for rules that
+// don't declare one, matching the severity inferred for failing findings
+func RuleSeverity(rule PolicyRule) string {
+	if r, ok := rule.(SeverityRule); ok {
+		return r.DefaultSeverity()
+	}
+	return SeverityHigh
+}
+
+// resultSeverity returns the severity of a finding, inferring one from the
+// status for results produced without a severity
+func resultSeverity(result CheckResult) string {
+	if result.Severity != "" {
+		return result.Severity
+	}
+	if result.Status == "fail" {
+		return SeverityHigh
+	}
+	return SeverityMedium
+}
+
+// ApplyThreshold sets the status of every finding from its severity: findings
+// at or above failOn fail, the rest are warnings. Passing results are unchanged.
+func ApplyThreshold(results []CheckResult, failOn string) []CheckResult {
+	if failOn == "" {
+		failOn = DefaultFailOn
+	}
+	threshold := severityRank(failOn)
+	
+	for i, result := range results {
+		if result.Status == "pass" {
+			continue
+		}
+		results[i].Severity = resultSeverity(result)
+		if severityRank(results[i].Severity) >= threshold {
+			results[i].Status = "fail"
+		} else {
+			results[i].Status = "warn"
+		}
+	}
+	return results
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRequiredTagsRule(t *testing.T) {
+	files := map[string]string{"main.tf": `resource "aws_s3_bucket" "untagged_owner" {
+  bucket = "logs"
+  tags = {
+    Environment = "prod"
+    CostCenter  = "42"
+  }
+}
+
+resource "aws_s3_bucket" "tagged" {
+  bucket = "assets"
+  tags = {
+    Owner         = "platform"
+    "Environment" = "prod"
+    CostCenter    = "42"
+  }
+}
+
+resource "aws_iam_policy" "not_taggable_here" {
+  name = "p"
+}
+`}
+	rule := &RequiredTagsRule{Tags: []string{"Owner", "Environment", "CostCenter"}}
+
+	flagged := failures(rule.Check(files))
+	if len(flagged) != 1 {
+		t.Fatalf("findings = %+v, want only the bucket missing Owner", flagged)
+	}
+	finding := flagged[0]
+	if finding.Line != 1 || finding.Metadata["resource"] != "aws_s3_bucket.untagged_owner" {
+		t.Errorf("finding on line %d for %v, want aws_s3_bucket.untagged_owner on line 1", finding.Line, finding.Metadata["resource"])
+	}
+	if missing := finding.Metadata["missing_tags"]; !reflect.DeepEqual(missing, []string{"Owner"}) {
+		t.Errorf("missing_tags = %v, want [Owner]", missing)
+	}
+}
+
+func TestRequiredTagsRuleFullyTagged(t *testing.T) {
+	files := map[string]string{"main.tf": `resource "aws_instance" "web" {
+  ami  = "ami-123"
+  tags = { Owner = "platform", Environment = "prod" }
+}
+`}
+	rule := &RequiredTagsRule{Tags: []string{"Owner", "Environment"}}
+	results := rule.Check(files)
+	if len(results) != 1 || results[0].Status != "pass" {
+		t.Errorf("results = %+v, want a single pass", results)
+	}
+}
+
+func TestRequiredTagsRuleDefaultTagsAndExpressions(t *testing.T) {
+	files := map[string]string{"main.tf": `provider "aws" {
+  default_tags {
+    tags = {
+      Owner = "platform"
+    }
+  }
+}
+
+resource "aws_sqs_queue" "jobs" {
+  tags = {
+    Environment = "prod"
+  }
+}
+
+resource "aws_sns_topic" "alerts" {
+  tags = merge(var.common_tags, { Name = "alerts" })
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`}
+	rule := &RequiredTagsRule{Tags: []string{"Owner", "Environment"}}
+	flagged := failures(rule.Check(files))
+
+	// Owner comes from default_tags and merge() can't be checked statically,
+	// leaving only the VPC without Environment
+	if len(flagged) != 1 || flagged[0].Line != 19 {
+		t.Fatalf("findings = %+v, want the untagged VPC on line 19", flagged)
+	}
+	if missing := flagged[0].Metadata["missing_tags"]; !reflect.DeepEqual(missing, []string{"Environment"}) {
+		t.Errorf("missing_tags = %v, want [Environment]", missing)
+	}
+}
+
+func TestRequiredTagsRuleNoOpWithoutConfiguredTags(t *testing.T) {
+	files := map[string]string{"main.tf": `resource "aws_s3_bucket" "b" {
+  bucket = "b"
+}
+`}
+	if results := (&RequiredTagsRule{}).Check(files); len(results) != 0 {
+		t.Errorf("unconfigured rule returned %+v, want nothing", results)
+	}
+}
+
+func TestRequiredTagsRuleConfiguredFromConfig(t *testing.T) {
+	config := `rules: [tf-required-tags]
+settings:
+  tf-required-tags:
+    tags: [Owner, CostCenter]
+`
+	cfg, err := LoadConfig(writeConfig(t, config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewPolicyEngineFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := engine.RunChecks(context.Background(), map[string]string{"main.tf": `resource "aws_s3_bucket" "b" {
+  tags = { Owner = "platform" }
+}
+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := findingLines(results); !equalInts(got, []int{1}) {
+		t.Errorf("findings on lines %v, want the bucket missing CostCenter on line 1", got)
+	}
+
+	for _, settings := range []string{"tags: Owner", "tags: [Owner, \"\"]", "keys: [Owner]"} {
+		if _, err := LoadConfig(writeConfig(t, "settings:\n  tf-required-tags:\n    "+settings+"\n")); err == nil {
+			t.Errorf("settings %q were accepted", settings)
+		}
+	}
+}
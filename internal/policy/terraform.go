@@ -0,0 +1,486 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// tfBlock is a block in a Terraform file, e.g. resource "aws_s3_bucket" "logs" { ... }
+type tfBlock struct {
+	Type    string
+	Labels  []string
+	Line    int // 1-based line of the block header
+	EndLine int // 1-based line of the closing brace
+	Attrs   map[string]tfAttr
+	Blocks  []*tfBlock
+}
+
+// tfAttr is an attribute assignment inside a block. Value holds the raw
+// expression text, which may span several lines for lists, maps and heredocs.
+type tfAttr struct {
+	Value string
+	Line  int
+}
+
+var (
+	tfBlockHeader = regexp.MustCompile(`^([A-Za-z_][\w-]*)((?:\s+"[^"]*"|\s+[A-Za-z_][\w-]*)*)\s*\{`)
+	tfBlockLabel  = regexp.MustCompile(`"([^"]*)"|([A-Za-z_][\w-]*)`)
+	tfAttrLine    = regexp.MustCompile(`^([A-Za-z_][\w-]*)\s*=\s*(.*)$`)
+	tfHeredoc     = regexp.MustCompile(`<<-?\s*([A-Za-z_]\w*)\s*$`)
+)
+
+// parseTerraform extracts the top-level blocks of a Terraform file using the
+// HCL native syntax parser. Files HCL rejects, such as templates rendered into
+// .tf files or work in progress, fall back to a lightweight line-based parser
+// so they are still checked.
+func parseTerraform(content string) []*tfBlock {
+	src := []byte(content)
+	file, diags := hclparse.NewParser().ParseHCL(src, "main.tf")
+	if !diags.HasErrors() {
+		if body, ok := file.Body.(*hclsyntax.Body); ok {
+			_, blocks := convertHCLBody(body, src)
+			return blocks
+		}
+	}
+
+	_, blocks := parseTFBody(strings.Split(content, "\n"), 1)
+	return blocks
+}
+
+// convertHCLBody converts a parsed block body. Attribute values keep their
+// source text, so rules see expressions exactly as written.
+func convertHCLBody(body *hclsyntax.Body, src []byte) (map[string]tfAttr, []*tfBlock) {
+	attrs := make(map[string]tfAttr, len(body.Attributes))
+	for name, attr := range body.Attributes {
+		r := attr.Expr.Range()
+		attrs[name] = tfAttr{
+			Value: strings.TrimSpace(string(src[r.Start.Byte:r.End.Byte])),
+			Line:  attr.SrcRange.Start.Line,
+		}
+	}
+
+	var blocks []*tfBlock
+	for _, b := range body.Blocks {
+		block := &tfBlock{
+			Type:    b.Type,
+			Line:    b.TypeRange.Start.Line,
+			EndLine: b.CloseBraceRange.Start.Line,
+		}
+		if len(b.Labels) > 0 {
+			block.Labels = b.Labels
+		}
+		block.Attrs, block.Blocks = convertHCLBody(b.Body, src)
+		blocks = append(blocks, block)
+	}
+	return attrs, blocks
+}
+
+// parseTFBody is the line-based fallback parser for a block body. firstLine
+// is the 1-based line number of lines[0] in the original file.
+func parseTFBody(lines []string, firstLine int) (map[string]tfAttr, []*tfBlock) {
+	attrs := make(map[string]tfAttr)
+	var blocks []*tfBlock
+
+	for i := 0; i < len(lines); i++ {
+		line := stripTFComment(lines[i])
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if m := tfBlockHeader.FindStringSubmatch(trimmed); m != nil && !strings.Contains(m[0], "=") {
+			block := &tfBlock{
+				Type: m[1],
+				Line: firstLine + i,
+			}
+			for _, label := range tfBlockLabel.FindAllStringSubmatch(m[2], -1) {
+				if label[1] != "" || strings.HasPrefix(label[0], `"`) {
+					block.Labels = append(block.Labels, label[1])
+				} else {
+					block.Labels = append(block.Labels, label[2])
+				}
+			}
+
+			// Single-line block: type { key = value }. HCL allows at most one
+			// argument in this form.
+			rest := trimmed[len(m[0]):]
+			if braceDepth(rest) < 0 {
+				inner := rest[:strings.LastIndex(rest, "}")]
+				block.Attrs, block.Blocks = parseTFBody([]string{inner}, firstLine+i)
+				block.EndLine = block.Line
+				blocks = append(blocks, block)
+				continue
+			}
+
+			end := findBlockEnd(lines, i)
+			var body []string
+			if strings.TrimSpace(rest) != "" {
+				body = append(body, rest)
+			}
+			bodyStart := firstLine + i + 1
+			if len(body) > 0 {
+				bodyStart = firstLine + i
+			}
+			if end > i+1 {
+				body = append(body, lines[i+1:end]...)
+			}
+			block.Attrs, block.Blocks = parseTFBody(body, bodyStart)
+			block.EndLine = firstLine + end
+			blocks = append(blocks, block)
+			i = end
+			continue
+		}
+
+		if m := tfAttrLine.FindStringSubmatch(trimmed); m != nil {
+			value := m[2]
+			end := i
+			if h := tfHeredoc.FindStringSubmatch(value); h != nil {
+				for end = i + 1; end < len(lines); end++ {
+					if strings.TrimSpace(lines[end]) == h[1] {
+						break
+					}
+				}
+			} else if braceDepth(value)+bracketDepth(value)+parenDepth(value) > 0 {
+				depth := braceDepth(value) + bracketDepth(value) + parenDepth(value)
+				for end = i + 1; end < len(lines) && depth > 0; end++ {
+					l := stripTFComment(lines[end])
+					depth += braceDepth(l) + bracketDepth(l) + parenDepth(l)
+				}
+				end--
+			}
+			if end >= len(lines) {
+				end = len(lines) - 1
+			}
+			if end > i {
+				value = value + "\n" + strings.Join(lines[i+1:end+1], "\n")
+			}
+			attrs[m[1]] = tfAttr{Value: strings.TrimSpace(value), Line: firstLine + i}
+			i = end
+		}
+	}
+
+	return attrs, blocks
+}
+
+// findBlockEnd returns the index of the line that closes the block opened on lines[start]
+func findBlockEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		depth += braceDepth(stripTFComment(lines[i]))
+		if depth <= 0 {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// stripTFComment removes # and // comments that appear outside string literals
+func stripTFComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '"' && (i == 0 || line[i-1] != '\\') {
+			inString = !inString
+			continue
+		}
+		if inString {
+			continue
+		}
+		if c == '#' || (c == '/' && i+1 < len(line) && line[i+1] == '/') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func braceDepth(s string) int   { return delimiterDepth(s, '{', '}') }
+func bracketDepth(s string) int { return delimiterDepth(s, '[', ']') }
+func parenDepth(s string) int   { return delimiterDepth(s, '(', ')') }
+
+// delimiterDepth counts open minus close delimiters outside string literals
+func delimiterDepth(s string, open, close byte) int {
+	depth := 0
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' && (i == 0 || s[i-1] != '\\') {
+			inString = !inString
+			continue
+		}
+		if inString {
+			continue
+		}
+		switch c {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+	return depth
+}
+
+// Attr returns the named attribute of the block
+func (b *tfBlock) Attr(name string) (tfAttr, bool) {
+	attr, ok := b.Attrs[name]
+	return attr, ok
+}
+
+// Children returns the nested blocks of the given type
+func (b *tfBlock) Children(blockType string) []*tfBlock {
+	var children []*tfBlock
+	for _, child := range b.Blocks {
+		if child.Type == blockType {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// NestedBlocks returns the nested blocks of the given type along with the
+// content blocks of dynamic blocks generating that type. Attributes of dynamic
+// content usually reference the iterator and are not literals.
+func (b *tfBlock) NestedBlocks(blockType string) []*tfBlock {
+	var nested []*tfBlock
+	for _, child := range b.Blocks {
+		switch {
+		case child.Type == blockType:
+			nested = append(nested, child)
+		case child.Type == "dynamic" && len(child.Labels) == 1 && child.Labels[0] == blockType:
+			nested = append(nested, child.Children("content")...)
+		}
+	}
+	return nested
+}
+
+// References reports whether any attribute in the block, including nested
+// blocks, mentions the given text (e.g. "time_rotating.")
+func (b *tfBlock) References(text string) bool {
+	for _, attr := range b.Attrs {
+		if strings.Contains(attr.Value, text) {
+			return true
+		}
+	}
+	for _, child := range b.Blocks {
+		if child.References(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Address returns the Terraform address of a resource or data block, e.g. aws_s3_bucket.logs
+func (b *tfBlock) Address() string {
+	switch {
+	case b.Type == "resource" && len(b.Labels) == 2:
+		return b.Labels[0] + "." + b.Labels[1]
+	case b.Type == "data" && len(b.Labels) == 2:
+		return "data." + b.Labels[0] + "." + b.Labels[1]
+	case len(b.Labels) > 0:
+		return b.Type + "." + strings.Join(b.Labels, ".")
+	}
+	return b.Type
+}
+
+// String returns the attribute value with surrounding quotes removed
+func (a tfAttr) String() string {
+	v := strings.TrimSpace(a.Value)
+	if len(v) >= 2 && strings.HasPrefix(v, `"`) && strings.HasSuffix(v, `"`) {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// IsTrue reports whether the attribute is the literal true
+func (a tfAttr) IsTrue() bool {
+	return strings.TrimSpace(a.Value) == "true"
+}
+
+// IsStringLiteral reports whether the attribute is a plain quoted string without interpolation
+func (a tfAttr) IsStringLiteral() bool {
+	v := strings.TrimSpace(a.Value)
+	return len(v) >= 2 && strings.HasPrefix(v, `"`) && strings.HasSuffix(v, `"`) && !strings.Contains(v, "${")
+}
+
+// resourceAtLine returns the resource block enclosing the given 1-based line,
+// or nil when the line is outside any resource
+func resourceAtLine(blocks []*tfBlock, line int) *tfBlock {
+	for _, block := range blocks {
+		if block.Type == "resource" && line >= block.Line && line <= block.EndLine {
+			return block
+		}
+	}
+	return nil
+}
+
+// resourceByAddress returns the resource block with the given address, or nil
+func resourceByAddress(blocks []*tfBlock, address string) *tfBlock {
+	for _, block := range blocks {
+		if block.Type == "resource" && block.Address() == address {
+			return block
+		}
+	}
+	return nil
+}
+
+// resourceMetadata is the CheckResult.Metadata of a finding about block: the
+// flagged line, its address and, for resources, the resource type and name
+// that reports group findings by
+func resourceMetadata(block *tfBlock, lineContent string) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"resource": block.Address(),
+	}
+	if lineContent != "" {
+		metadata["line_content"] = strings.TrimSpace(lineContent)
+	}
+	if block.Type == "resource" && len(block.Labels) == 2 {
+		metadata["resource_type"] = block.Labels[0]
+		metadata["resource_name"] = block.Labels[1]
+	}
+	return metadata
+}
+
+// withResourceContext adds resource_type and resource_name to Terraform
+// findings from rules that don't report them, taken from the resource the
+// finding names in Metadata["resource"], else the one enclosing its line
+func withResourceContext(results []CheckResult, files map[string]string) []CheckResult {
+	parsed := make(map[string][]*tfBlock)
+	for i, result := range results {
+		if result.Status == "pass" || !strings.HasSuffix(result.File, ".tf") || result.Metadata["resource_type"] != nil {
+			continue
+		}
+		blocks, ok := parsed[result.File]
+		if !ok {
+			blocks = parseTerraform(files[result.File])
+			parsed[result.File] = blocks
+		}
+
+		var block *tfBlock
+		if address, _ := result.Metadata["resource"].(string); address != "" {
+			block = resourceByAddress(blocks, address)
+		}
+		if block == nil && result.Line > 0 {
+			block = resourceAtLine(blocks, result.Line)
+		}
+		if block == nil || len(block.Labels) != 2 {
+			continue
+		}
+
+		metadata := make(map[string]interface{}, len(result.Metadata)+2)
+		for k, v := range result.Metadata {
+			metadata[k] = v
+		}
+		metadata["resource_type"] = block.Labels[0]
+		metadata["resource_name"] = block.Labels[1]
+		results[i].Metadata = metadata
+	}
+	return results
+}
+
+var tfResourceRef = regexp.MustCompile(`\b([a-z][a-z0-9_]*)\.([A-Za-z_][\w-]*)\.`)
+
+// resourceTargets indexes resources of the given type by the resource their
+// attr points at. Both references (aws_s3_bucket.logs.id indexes as
+// "aws_s3_bucket.logs") and literal names ("my-bucket" indexes as "my-bucket")
+// are recorded, so callers can look a target up either way.
+func resourceTargets(files map[string]string, resourceType, attr string) map[string]*tfBlock {
+	targets := make(map[string]*tfBlock)
+	for _, blocks := range terraformResources(files, resourceType) {
+		for _, block := range blocks {
+			value, ok := block.Attr(attr)
+			if !ok {
+				continue
+			}
+			if value.IsStringLiteral() {
+				targets[value.String()] = block
+				continue
+			}
+			for _, ref := range tfResourceRef.FindAllStringSubmatch(value.Value, -1) {
+				targets[ref[1]+"."+ref[2]] = block
+			}
+		}
+	}
+	return targets
+}
+
+// resourceIndex indexes resources of the given type by address and, when
+// nameAttr is a literal, by name, for looking up the resource an attribute of
+// another resource points at with attrTarget
+func resourceIndex(files map[string]string, resourceType, nameAttr string) map[string]*tfBlock {
+	index := make(map[string]*tfBlock)
+	for _, blocks := range terraformResources(files, resourceType) {
+		for _, block := range blocks {
+			index[block.Address()] = block
+			if name, ok := block.Attr(nameAttr); ok && name.IsStringLiteral() {
+				index[name.String()] = block
+			}
+		}
+	}
+	return index
+}
+
+// targetedBy returns the block from targets that points at the resource, by
+// address or by its literal name attribute
+func targetedBy(targets map[string]*tfBlock, resource *tfBlock, nameAttr string) *tfBlock {
+	if target, ok := targets[resource.Address()]; ok {
+		return target
+	}
+	if name, ok := resource.Attr(nameAttr); ok && name.IsStringLiteral() {
+		return targets[name.String()]
+	}
+	return nil
+}
+
+// attrTarget returns the block from targets that the attribute points at, by
+// resource reference or literal name
+func attrTarget(targets map[string]*tfBlock, attr tfAttr) *tfBlock {
+	if attr.IsStringLiteral() {
+		return targets[attr.String()]
+	}
+	for _, ref := range tfResourceRef.FindAllStringSubmatch(attr.Value, -1) {
+		if target, ok := targets[ref[1]+"."+ref[2]]; ok {
+			return target
+		}
+	}
+	return nil
+}
+
+// terraformResources returns all resource blocks of the given types across the Terraform files
+func terraformResources(files map[string]string, types ...string) map[string][]*tfBlock {
+	wanted := make(map[string]bool)
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	resources := make(map[string][]*tfBlock)
+	for filename, content := range files {
+		if !isTerraformFile(filename) {
+			continue
+		}
+		for _, block := range parseTerraform(content) {
+			if block.Type == "resource" && len(block.Labels) == 2 && (len(wanted) == 0 || wanted[block.Labels[0]]) {
+				resources[filename] = append(resources[filename], block)
+			}
+		}
+	}
+	return resources
+}
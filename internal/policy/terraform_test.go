@@ -0,0 +1,222 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseTerraformBlocks(t *testing.T) {
+	content := `# networking
+resource "aws_security_group" "web" {
+  name = "web" # trailing comment
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    cidr_blocks = [
+      "10.0.0.0/8",
+    ]
+  }
+
+  tags = {
+    Team = "platform"
+  }
+}
+
+data "aws_iam_policy_document" "read" {
+  statement {
+    actions = ["s3:GetObject"]
+  }
+}
+`
+	blocks := parseTerraform(content)
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+
+	sg := blocks[0]
+	if sg.Address() != "aws_security_group.web" || sg.Line != 2 || sg.EndLine != 16 {
+		t.Errorf("block %s spans %d-%d, want aws_security_group.web 2-16", sg.Address(), sg.Line, sg.EndLine)
+	}
+	if name, _ := sg.Attr("name"); name.Value != `"web"` || name.Line != 3 {
+		t.Errorf("name = %q on line %d, want \"web\" on line 3 without the comment", name.Value, name.Line)
+	}
+	if tags, _ := sg.Attr("tags"); !strings.Contains(tags.Value, `Team = "platform"`) {
+		t.Errorf("tags = %q, want the whole map", tags.Value)
+	}
+
+	ingress := sg.Children("ingress")
+	if len(ingress) != 1 || ingress[0].Line != 5 || ingress[0].EndLine != 11 {
+		t.Fatalf("ingress blocks = %+v, want one spanning 5-11", ingress)
+	}
+	cidrs, _ := ingress[0].Attr("cidr_blocks")
+	if cidrs.Line != 8 || len(hclStrings(cidrs.Value)) != 1 {
+		t.Errorf("cidr_blocks = %q on line %d, want the multi-line list on line 8", cidrs.Value, cidrs.Line)
+	}
+
+	if blocks[1].Address() != "data.aws_iam_policy_document.read" {
+		t.Errorf("address = %s, want data.aws_iam_policy_document.read", blocks[1].Address())
+	}
+}
+
+func TestParseTerraformHeredoc(t *testing.T) {
+	content := `resource "aws_iam_policy" "p" {
+  policy = <<-EOT
+    {"Statement": []}
+  EOT
+  name = "p"
+}
+`
+	blocks := parseTerraform(content)
+	if len(blocks) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(blocks))
+	}
+	policy, _ := blocks[0].Attr("policy")
+	if !strings.HasPrefix(policy.Value, "<<-EOT") || !strings.HasSuffix(policy.Value, "EOT") || policy.Line != 2 {
+		t.Errorf("policy = %q on line %d, want the heredoc on line 2", policy.Value, policy.Line)
+	}
+	if name, _ := blocks[0].Attr("name"); name.Line != 5 {
+		t.Errorf("name on line %d, want 5", name.Line)
+	}
+}
+
+func TestParseTerraformSingleLineBlocks(t *testing.T) {
+	content := `resource "aws_security_group" "ssh" {
+  ingress { cidr_blocks = ["0.0.0.0/0"] }
+  egress {}
+}
+`
+	blocks := parseTerraform(content)
+	if len(blocks) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(blocks))
+	}
+	ingress := blocks[0].Children("ingress")
+	if len(ingress) != 1 || ingress[0].Line != 2 || ingress[0].EndLine != 2 {
+		t.Fatalf("ingress blocks = %+v, want one on line 2", ingress)
+	}
+	if cidrs, ok := ingress[0].Attr("cidr_blocks"); !ok || cidrs.Line != 2 {
+		t.Errorf("cidr_blocks = %+v, want it on line 2", cidrs)
+	}
+	if len(blocks[0].Children("egress")) != 1 {
+		t.Errorf("empty single-line egress block was not parsed")
+	}
+}
+
+func TestParseTerraformFallsBackOnInvalidHCL(t *testing.T) {
+	// A rendered template HCL rejects is still checked, line by line
+	content := `resource "aws_s3_bucket" "b" {
+  acl = "public-read"
+  tags = {{ .Tags }}
+}
+`
+	blocks := parseTerraform(content)
+	if len(blocks) != 1 || blocks[0].Address() != "aws_s3_bucket.b" {
+		t.Fatalf("blocks = %+v, want aws_s3_bucket.b", blocks)
+	}
+	if acl, _ := blocks[0].Attr("acl"); acl.String() != "public-read" || acl.Line != 2 {
+		t.Errorf("acl = %+v, want public-read on line 2", acl)
+	}
+}
+
+func TestNestedBlocksIncludesDynamicContent(t *testing.T) {
+	content := `resource "aws_security_group" "web" {
+  ingress {
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+  dynamic "ingress" {
+    for_each = var.ports
+    content {
+      from_port   = ingress.value
+      cidr_blocks = ["0.0.0.0/0"]
+    }
+  }
+  dynamic "egress" {
+    for_each = var.ports
+    content {}
+  }
+}
+`
+	blocks := parseTerraform(content)
+	nested := blocks[0].NestedBlocks("ingress")
+	if len(nested) != 2 {
+		t.Fatalf("got %d ingress blocks, want the static and the dynamic one", len(nested))
+	}
+	if cidrs, _ := nested[1].Attr("cidr_blocks"); cidrs.Line != 9 {
+		t.Errorf("dynamic content cidr_blocks on line %d, want 9", cidrs.Line)
+	}
+}
+
+func TestRunChecksAddsResourceAddressToFlaggedBucket(t *testing.T) {
+	content := `resource "aws_s3_bucket" "private" {
+  bucket = "internal"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "public-assets"
+  acl    = "public-read"
+}
+`
+	engine := NewPolicyEngine()
+	if err := engine.SelectRules([]string{"s3-no-public-buckets"}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := engine.RunChecks(context.Background(), map[string]string{"main.tf": content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagged := failures(results)
+	if len(flagged) != 1 {
+		t.Fatalf("findings = %+v, want the public bucket only", flagged)
+	}
+	metadata := flagged[0].Metadata
+	if metadata["resource"] != "aws_s3_bucket.assets" || metadata["resource_type"] != "aws_s3_bucket" || metadata["resource_name"] != "assets" {
+		t.Errorf("metadata = %v, want resource aws_s3_bucket.assets", metadata)
+	}
+}
+
+func TestWithResourceContext(t *testing.T) {
+	files := map[string]string{"main.tf": `resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "assets" {
+  tags = {
+    Public = "yes"
+  }
+}
+`}
+	results := withResourceContext([]CheckResult{
+		{Status: "fail", File: "main.tf", Line: 7},
+		{Status: "fail", File: "main.tf", Line: 1, Metadata: map[string]interface{}{"resource": "aws_s3_bucket.assets"}},
+		{Status: "fail", File: "main.tf", Line: 4},
+		{Status: "pass", File: "main.tf", Line: 2},
+	}, files)
+
+	want := []string{"assets", "assets", "", ""}
+	for i, result := range results {
+		name, _ := result.Metadata["resource_name"].(string)
+		if name != want[i] {
+			t.Errorf("result %d resource_name = %q, want %q", i, name, want[i])
+		}
+	}
+	if results[0].Metadata["resource_type"] != "aws_s3_bucket" {
+		t.Errorf("resource_type = %v, want aws_s3_bucket", results[0].Metadata["resource_type"])
+	}
+}
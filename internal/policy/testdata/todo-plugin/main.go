@@ -0,0 +1,92 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// todo-plugin is a trivial rule plugin providing no-todo-comments, which
+// flags TODO comments left in Terraform files. It panics on a file named
+// panic.tf, and TODO_PLUGIN_PROTOCOL overrides the protocol it reports.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type result struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: todo-plugin describe | check <rule>")
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "describe":
+		protocol := 1
+		if v, err := strconv.Atoi(os.Getenv("TODO_PLUGIN_PROTOCOL")); err == nil {
+			protocol = v
+		}
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"protocol": protocol,
+			"rules": []map[string]string{{
+				"name":        "no-todo-comments",
+				"description": "Terraform should not ship with TODO comments",
+				"severity":    "low",
+			}},
+		})
+	case "check":
+		var input struct {
+			Files map[string]string `json:"files"`
+		}
+		if err := json.NewDecoder(os.Stdin).Decode(&input); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		names := make([]string, 0, len(input.Files))
+		for name := range input.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		results := []result{}
+		for _, name := range names {
+			if name == "panic.tf" {
+				panic("cannot parse " + name)
+			}
+			if !strings.HasSuffix(name, ".tf") {
+				continue
+			}
+			for i, line := range strings.Split(input.Files[name], "\n") {
+				if strings.Contains(line, "TODO") {
+					results = append(results, result{Status: "fail", Message: "TODO comment", File: name, Line: i + 1})
+				}
+			}
+		}
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"results": results})
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+)
+
+// MaxAttestationSize bounds the body of POST /attestations
+const MaxAttestationSize = 10 << 20
+
+// TrustedKeyFile names the PEM public key in a repository's directory that
+// its attestations must be signed with
+const TrustedKeyFile = "public.pem"
+
+// repositoryPattern matches the repository names chains are stored under,
+// such as github.com/acme/infra. Every segment starts with a letter or digit,
+// so a name cannot climb out of the API root.
+var repositoryPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*(/[A-Za-z0-9][A-Za-z0-9._-]*)*$`)
+
+// API is the multi-repository evidence API behind 'mondrian serve --api'.
+// Signed attestations are posted by each repository's pipeline and appended to
+// that repository's chain, an ordinary evidence directory under the root named
+// after the repository, e.g. <root>/github.com/acme/infra. Only repositories
+// provisioned with their signing public key, <root>/<repository>/public.pem,
+// accept attestations, and only those signed with that key.
+//
+// Routes:
+//
+//	POST /attestations              ingest a signed attestation
+//	GET  /repos                     list repositories with a chain
+//	GET  /repos/{repository}/chain  a repository's chain, paged with offset and limit
+//
+// The API has no authentication of its own; put it behind a proxy that has.
+type API struct {
+	root string
+
+	// mu serializes ingestion, which reads and rewrites a chain
+	mu sync.Mutex
+}
+
+// ingestResponse reports where an ingested attestation landed
+type ingestResponse struct {
+	Repository string `json:"repository"`
+	Hash       string `json:"hash"`
+	Position   int    `json:"position"`
+	Length     int    `json:"length"`
+	Duplicate  bool   `json:"duplicate,omitempty"`
+}
+
+// NewAPI creates an API storing chains under root
+func NewAPI(root string) *API {
+	return &API{root: root}
+}
+
+// Handler returns the HTTP routes of the API
+func (a *API) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /attestations", a.handleIngest)
+	mux.HandleFunc("GET /repos", a.handleRepos)
+	mux.HandleFunc("GET /repos/{path...}", a.handleRepoChain)
+	return mux
+}
+
+// handleIngest verifies a signed attestation against its repository's
+// trusted key and appends it to the repository's chain. The key recorded in
+// the envelope proves nothing, as anyone can sign with a key of their own.
+// The attestation must extend the stored chain, so a
+// repository's chain is mirrored by posting its attestations in order; posting
+// one that is already stored is a no-op.
+func (a *API) handleIngest(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxAttestationSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("attestation exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	attestation, signed, err := evidence.ParseSignedAttestation(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	repository := attestation.Predicate.Repository
+	if !repositoryPattern.MatchString(repository) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("attestation repository %q is not a valid repository name", repository))
+		return
+	}
+
+	evidenceDir := a.repositoryDir(repository)
+	trustedKey, err := loadTrustedKey(evidenceDir)
+	if err != nil {
+		writeError(w, http.StatusForbidden, fmt.Errorf("repository %s does not accept attestations: %w", repository, err))
+		return
+	}
+	if err := evidence.VerifySignedAttestation(signed, trustedKey); err != nil {
+		writeError(w, http.StatusForbidden, fmt.Errorf("attestation is not signed by the trusted key of %s: %w", repository, err))
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	chainManager := evidence.NewChainManager(evidenceDir)
+	chainManager.PublicKey = trustedKey
+	chain, err := chainManager.LoadChain()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for i, entry := range chain.Attestations {
+		if entry.Hash == attestation.Hash {
+			writeJSON(w, http.StatusOK, ingestResponse{
+				Repository: repository,
+				Hash:       entry.Hash,
+				Position:   i + 1,
+				Length:     chain.Length,
+				Duplicate:  true,
+			})
+			return
+		}
+	}
+	if attestation.ParentHash != chain.Head {
+		writeError(w, http.StatusConflict, fmt.Errorf("attestation parent %q is not the head %q of the %s chain; post the missing attestations first", attestation.ParentHash, chain.Head, repository))
+		return
+	}
+
+	savedPath, err := evidence.SaveSignedAttestation(signed, evidenceDir, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if chain.ChainID == "" {
+		if chain, err = chainManager.LoadOrCreateChain(); err != nil {
+			os.Remove(savedPath)
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if err := chainManager.AppendAttestation(chain, attestation, filepath.Base(savedPath)); err != nil {
+		os.Remove(savedPath)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, ingestResponse{
+		Repository: repository,
+		Hash:       attestation.Hash,
+		Position:   chain.Length,
+		Length:     chain.Length,
+	})
+}
+
+func (a *API) handleRepos(w http.ResponseWriter, r *http.Request) {
+	repositories := []string{}
+	err := filepath.WalkDir(a.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != "chain.json" {
+			return nil
+		}
+		rel, err := filepath.Rel(a.root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		repositories = append(repositories, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	sort.Strings(repositories)
+
+	writeJSON(w, http.StatusOK, map[string][]string{"repositories": repositories})
+}
+
+// handleRepoChain serves /repos/{repository}/chain. Repository names contain
+// slashes, so they may be sent as is or escaped as %2F.
+func (a *API) handleRepoChain(w http.ResponseWriter, r *http.Request) {
+	repository, ok := strings.CutSuffix(r.PathValue("path"), "/chain")
+	if !ok || !repositoryPattern.MatchString(repository) {
+		http.NotFound(w, r)
+		return
+	}
+
+	evidenceDir := a.repositoryDir(repository)
+	if _, err := os.Stat(filepath.Join(evidenceDir, "chain.json")); err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no chain for repository %s", repository))
+		return
+	}
+
+	serveChain(w, r, evidence.NewChainManager(evidenceDir))
+}
+
+// repositoryDir is the evidence directory of a validated repository name
+func (a *API) repositoryDir(repository string) string {
+	return filepath.Join(a.root, filepath.FromSlash(repository))
+}
+
+// loadTrustedKey reads the public key a repository's attestations must be
+// signed with
+func loadTrustedKey(evidenceDir string) (*ecdsa.PublicKey, error) {
+	data, err := os.ReadFile(filepath.Join(evidenceDir, TrustedKeyFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no trusted public key has been provisioned at %s", TrustedKeyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return evidence.ParsePublicKeyPEM(string(data))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
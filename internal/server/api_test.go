@@ -0,0 +1,237 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+const testRepository = "github.com/acme/infra"
+
+// newTestAPI serves an API whose root has testRepository provisioned with
+// the public key of signer
+func newTestAPI(t *testing.T, signer *evidence.Signer) (*httptest.Server, string) {
+	t.Helper()
+	root := t.TempDir()
+	repoDir := filepath.Join(root, filepath.FromSlash(testRepository))
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.SavePublicKey(filepath.Join(repoDir, TrustedKeyFile)); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewAPI(root).Handler())
+	t.Cleanup(srv.Close)
+	return srv, root
+}
+
+func newTestSigner(t *testing.T) *evidence.Signer {
+	t.Helper()
+	signer, err := evidence.NewSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// signedAttestation returns the JSON of an attestation for repository,
+// chained after parentHash and signed by signer
+func signedAttestation(t *testing.T, signer *evidence.Signer, repository, parentHash string) ([]byte, string) {
+	t.Helper()
+	results := []policy.CheckResult{{RuleName: "s3-public-read", Status: "pass", Message: "ok"}}
+	attestation := evidence.NewAttestation(results, evidence.AttestationMetadata{
+		Repository: repository,
+		ParentHash: parentHash,
+	})
+	signed, err := signer.SignAttestation(attestation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, attestation.Hash
+}
+
+func postAttestation(t *testing.T, srv *httptest.Server, data []byte) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := http.Post(srv.URL+"/attestations", "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return resp.StatusCode, body
+}
+
+func getJSON(t *testing.T, url string, v interface{}) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("decoding %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestIngestAppendsToChain(t *testing.T) {
+	signer := newTestSigner(t)
+	srv, _ := newTestAPI(t, signer)
+
+	first, firstHash := signedAttestation(t, signer, testRepository, "")
+	status, body := postAttestation(t, srv, first)
+	if status != http.StatusCreated || body["position"] != 1.0 {
+		t.Fatalf("first ingest = %d %v, want 201 at position 1", status, body)
+	}
+	second, secondHash := signedAttestation(t, signer, testRepository, firstHash)
+	if status, body := postAttestation(t, srv, second); status != http.StatusCreated || body["length"] != 2.0 {
+		t.Fatalf("second ingest = %d %v, want 201 with length 2", status, body)
+	}
+
+	// Posting an attestation again is a no-op
+	status, body = postAttestation(t, srv, first)
+	if status != http.StatusOK || body["duplicate"] != true || body["length"] != 2.0 {
+		t.Errorf("duplicate ingest = %d %v, want 200 duplicate", status, body)
+	}
+
+	var chain evidence.EvidenceChain
+	if status := getJSON(t, srv.URL+"/repos/"+testRepository+"/chain", &chain); status != http.StatusOK {
+		t.Fatalf("GET chain = %d", status)
+	}
+	if chain.Length != 2 || chain.Genesis != firstHash || chain.Head != secondHash {
+		t.Errorf("chain length %d genesis %s head %s, want 2 %s %s", chain.Length, chain.Genesis, chain.Head, firstHash, secondHash)
+	}
+}
+
+func TestIngestRejectsUntrustedSigner(t *testing.T) {
+	srv, _ := newTestAPI(t, newTestSigner(t))
+
+	// Validly signed, but by a key the repository doesn't trust
+	data, _ := signedAttestation(t, newTestSigner(t), testRepository, "")
+	status, body := postAttestation(t, srv, data)
+	if status != http.StatusForbidden {
+		t.Fatalf("ingest signed by another key = %d %v, want 403", status, body)
+	}
+	if status := getJSON(t, srv.URL+"/repos/"+testRepository+"/chain", nil); status != http.StatusNotFound {
+		t.Errorf("GET chain after a rejected ingest = %d, want 404", status)
+	}
+}
+
+func TestIngestRejectsUnprovisionedRepository(t *testing.T) {
+	signer := newTestSigner(t)
+	srv, _ := newTestAPI(t, signer)
+
+	data, _ := signedAttestation(t, signer, "github.com/acme/other", "")
+	if status, body := postAttestation(t, srv, data); status != http.StatusForbidden {
+		t.Errorf("ingest for a repository without public.pem = %d %v, want 403", status, body)
+	}
+}
+
+func TestIngestRejectsInvalidAttestations(t *testing.T) {
+	signer := newTestSigner(t)
+	srv, _ := newTestAPI(t, signer)
+
+	tests := []struct {
+		name   string
+		data   func() []byte
+		status int
+	}{
+		{"malformed", func() []byte { return []byte("{") }, http.StatusBadRequest},
+		{"unsigned", func() []byte { return []byte(`{"hash": "abc"}`) }, http.StatusBadRequest},
+		{"bad repository", func() []byte {
+			data, _ := signedAttestation(t, signer, "../escape", "")
+			return data
+		}, http.StatusBadRequest},
+		{"not the head", func() []byte {
+			data, _ := signedAttestation(t, signer, testRepository, strings.Repeat("ab", 32))
+			return data
+		}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := postAttestation(t, srv, tt.data()); status != tt.status {
+				t.Errorf("status = %d %v, want %d", status, body, tt.status)
+			}
+		})
+	}
+}
+
+func TestIngestRejectsOversizedBody(t *testing.T) {
+	srv, _ := newTestAPI(t, newTestSigner(t))
+
+	if status, _ := postAttestation(t, srv, bytes.Repeat([]byte(" "), MaxAttestationSize+1)); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", status)
+	}
+}
+
+func TestReposAndPagedChain(t *testing.T) {
+	signer := newTestSigner(t)
+	srv, _ := newTestAPI(t, signer)
+
+	parent := ""
+	for i := 0; i < 3; i++ {
+		data, hash := signedAttestation(t, signer, testRepository, parent)
+		if status, body := postAttestation(t, srv, data); status != http.StatusCreated {
+			t.Fatalf("ingest %d = %d %v", i, status, body)
+		}
+		parent = hash
+	}
+
+	var repos map[string][]string
+	if status := getJSON(t, srv.URL+"/repos", &repos); status != http.StatusOK {
+		t.Fatalf("GET /repos = %d", status)
+	}
+	if got := repos["repositories"]; len(got) != 1 || got[0] != testRepository {
+		t.Errorf("repositories = %v, want [%s]", got, testRepository)
+	}
+
+	var page struct {
+		evidence.EvidenceChain
+		Offset     int  `json:"offset"`
+		NextOffset *int `json:"nextOffset"`
+	}
+	escaped := strings.ReplaceAll(testRepository, "/", "%2F")
+	if status := getJSON(t, srv.URL+"/repos/"+escaped+"/chain?offset=1&limit=1", &page); status != http.StatusOK {
+		t.Fatalf("GET paged chain = %d", status)
+	}
+	if len(page.Attestations) != 1 || page.Length != 3 || page.NextOffset == nil || *page.NextOffset != 2 {
+		t.Errorf("page has %d attestations of %d, next offset %v; want 1 of 3, next 2", len(page.Attestations), page.Length, page.NextOffset)
+	}
+
+	if status := getJSON(t, srv.URL+"/repos/github.com/acme/missing/chain", nil); status != http.StatusNotFound {
+		t.Errorf("GET missing chain = %d, want 404", status)
+	}
+}
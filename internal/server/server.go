@@ -0,0 +1,202 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server implements the read-only evidence viewer behind 'mondrian
+// serve' and the multi-repository evidence API behind 'mondrian serve --api'
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+)
+
+// Server renders the evidence chain of one evidence directory. The chain is
+// re-read on every request so new attestations show up without a restart;
+// signature verification results are cached across requests.
+type Server struct {
+	chainManager *evidence.ChainManager
+	cache        *evidence.VerificationCache
+}
+
+// New creates a viewer for the chain of chainManager, which holds the key to
+// decrypt attestations encrypted at rest when there are any
+func New(chainManager *evidence.ChainManager) *Server {
+	return &Server{
+		chainManager: chainManager,
+		cache:        evidence.NewVerificationCache(chainManager, evidence.DefaultCacheSize),
+	}
+}
+
+// Handler returns the HTTP routes of the viewer
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /attestation/{hash}", s.handleAttestation)
+	mux.HandleFunc("GET /api/chain", s.handleChain)
+	return mux
+}
+
+// entryView is a chain entry with its verification outcome
+type entryView struct {
+	evidence.ChainEntry
+	Position int
+	Problem  string
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	chain, err := s.chainManager.LoadChain()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	entries := make([]entryView, len(chain.Attestations))
+	for i, entry := range chain.Attestations {
+		entries[i] = entryView{ChainEntry: entry, Position: i + 1}
+		if err := s.cache.Verify(entry); err != nil {
+			entries[i].Problem = err.Error()
+		}
+	}
+	
+	var linkProblem string
+	if err := s.chainManager.VerifyChain(chain); err != nil {
+		linkProblem = err.Error()
+	}
+	
+	render(w, indexTemplate, map[string]interface{}{
+		"Chain":       chain,
+		"Entries":     entries,
+		"LinkProblem": linkProblem,
+	})
+}
+
+func (s *Server) handleAttestation(w http.ResponseWriter, r *http.Request) {
+	chain, err := s.chainManager.LoadChain()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	hash := r.PathValue("hash")
+	var match *evidence.ChainEntry
+	for i, entry := range chain.Attestations {
+		if strings.HasPrefix(entry.Hash, hash) {
+			if match != nil {
+				http.Error(w, "attestation hash prefix is ambiguous", http.StatusBadRequest)
+				return
+			}
+			match = &chain.Attestations[i]
+		}
+	}
+	if match == nil || hash == "" {
+		http.NotFound(w, r)
+		return
+	}
+	
+	attestation, _, err := s.chainManager.LoadAttestation(match.FilePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	var problem string
+	if err := s.cache.Verify(*match); err != nil {
+		problem = err.Error()
+	}
+	
+	render(w, attestationTemplate, map[string]interface{}{
+		"Entry":       match,
+		"Attestation": attestation,
+		"Problem":     problem,
+	})
+}
+
+func (s *Server) handleChain(w http.ResponseWriter, r *http.Request) {
+	serveChain(w, r, s.chainManager)
+}
+
+// chainPage is a window of a chain's entries, as served for the offset and
+// limit query parameters
+type chainPage struct {
+	*evidence.EvidenceChain
+	Offset     int  `json:"offset"`
+	Limit      int  `json:"limit,omitempty"`
+	NextOffset *int `json:"nextOffset,omitempty"`
+}
+
+// serveChain writes a chain as JSON: the whole chain, or with offset and
+// limit query parameters a page of its entries along with the chain header
+func serveChain(w http.ResponseWriter, r *http.Request, chainManager *evidence.ChainManager) {
+	query := r.URL.Query()
+	if !query.Has("offset") && !query.Has("limit") {
+		chain, err := chainManager.LoadChain()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, chain)
+		return
+	}
+	
+	offset, err := queryInt(query, "offset")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, err := queryInt(query, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	
+	chain, err := chainManager.LoadEntries(offset, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	
+	page := chainPage{EvidenceChain: chain, Offset: offset, Limit: limit}
+	if next := offset + len(chain.Attestations); limit > 0 && next < chain.Length {
+		page.NextOffset = &next
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// queryInt reads a non-negative integer query parameter, 0 when absent
+func queryInt(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+func render(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miqcie/mondrian/internal/evidence"
+	"github.com/miqcie/mondrian/internal/policy"
+)
+
+// newTestViewer serves the viewer over a chain of n attestations signed by
+// signer, returning the server and its evidence directory
+func newTestViewer(t *testing.T, signer *evidence.Signer, n int) (*httptest.Server, string, *evidence.EvidenceChain) {
+	t.Helper()
+	evidenceDir := t.TempDir()
+	chainManager := evidence.NewChainManager(evidenceDir)
+	chain, err := chainManager.LoadOrCreateChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		results := []policy.CheckResult{{RuleName: "s3-public-read", Status: "pass", Message: "ok"}}
+		attestation := evidence.NewAttestation(results, evidence.AttestationMetadata{ParentHash: chain.Head})
+		signed, err := signer.SignAttestation(attestation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path, err := evidence.SaveSignedAttestation(signed, evidenceDir, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chainManager.AddAttestation(chain, attestation, filepath.Base(path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chainManager.PublicKey = signer.GetPublicKey()
+
+	srv := httptest.NewServer(New(chainManager).Handler())
+	t.Cleanup(srv.Close)
+	return srv, evidenceDir, chain
+}
+
+// getPage returns the status and body of a viewer page
+func getPage(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestChainEndpoint(t *testing.T) {
+	srv, _, chain := newTestViewer(t, newTestSigner(t), 3)
+
+	var served evidence.EvidenceChain
+	if status := getJSON(t, srv.URL+"/api/chain", &served); status != http.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	if served.ChainID != chain.ChainID || served.Head != chain.Head || served.Length != 3 || len(served.Attestations) != 3 {
+		t.Errorf("served chain %s head %s with %d entries, want %s head %s with 3", served.ChainID, served.Head, len(served.Attestations), chain.ChainID, chain.Head)
+	}
+	for i, entry := range served.Attestations {
+		if entry.Hash != chain.Attestations[i].Hash || entry.ParentHash != chain.Attestations[i].ParentHash {
+			t.Errorf("entry %d = %+v, want %+v", i, entry, chain.Attestations[i])
+		}
+	}
+}
+
+func TestViewerWithoutEvidence(t *testing.T) {
+	// Neither a missing evidence directory nor an empty chain is an error
+	missing := evidence.NewChainManager(filepath.Join(t.TempDir(), "missing", "evidence"))
+	srv := httptest.NewServer(New(missing).Handler())
+	defer srv.Close()
+
+	var chain evidence.EvidenceChain
+	if status := getJSON(t, srv.URL+"/api/chain", &chain); status != http.StatusOK || chain.Length != 0 || len(chain.Attestations) != 0 {
+		t.Errorf("/api/chain = %d with %d entries, want 200 and an empty chain", status, len(chain.Attestations))
+	}
+	if status, body := getPage(t, srv.URL+"/"); status != http.StatusOK || !strings.Contains(body, "No attestations yet") {
+		t.Errorf("index = %d, want 200 saying there are no attestations", status)
+	}
+	if status, _ := getPage(t, srv.URL+"/attestation/abc"); status != http.StatusNotFound {
+		t.Errorf("attestation page = %d, want 404", status)
+	}
+}
+
+func TestIndexAndAttestationPages(t *testing.T) {
+	srv, evidenceDir, chain := newTestViewer(t, newTestSigner(t), 2)
+	head := chain.Attestations[1]
+
+	status, body := getPage(t, srv.URL+"/")
+	if status != http.StatusOK {
+		t.Fatalf("index = %d, want 200", status)
+	}
+	for _, entry := range chain.Attestations {
+		if !strings.Contains(body, "/attestation/"+entry.Hash) {
+			t.Errorf("index does not link attestation %s", entry.Hash)
+		}
+	}
+	if strings.Contains(body, "❌") {
+		t.Errorf("index reports a problem with a valid chain")
+	}
+
+	status, body = getPage(t, srv.URL+"/attestation/"+head.Hash[:12])
+	if status != http.StatusOK || !strings.Contains(body, head.Hash) || !strings.Contains(body, "s3-public-read") || !strings.Contains(body, "Signature and hash verified") {
+		t.Errorf("attestation page = %d, want the verified head with its results", status)
+	}
+
+	// Tampering shows on the next request, not after a restart
+	path := filepath.Join(evidenceDir, head.FilePath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), `"payload": "`, `"payload": "e30`, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, body := getPage(t, srv.URL+"/"); !strings.Contains(body, "❌") {
+		t.Errorf("index does not report the tampered attestation")
+	}
+
+	if status, _ := getPage(t, srv.URL+"/attestation/"+strings.Repeat("f", 64)); status != http.StatusNotFound {
+		t.Errorf("unknown attestation = %d, want 404", status)
+	}
+}
+
+func TestChainEndpointPages(t *testing.T) {
+	srv, _, chain := newTestViewer(t, newTestSigner(t), 5)
+
+	var whole evidence.EvidenceChain
+	if status := getJSON(t, srv.URL+"/api/chain", &whole); status != http.StatusOK || len(whole.Attestations) != 5 {
+		t.Fatalf("unpaged chain = %d with %d entries, want all 5", status, len(whole.Attestations))
+	}
+
+	tests := []struct {
+		query      string
+		first      int // position of the first entry in the page
+		entries    int
+		nextOffset int // 0 when there is no next page
+	}{
+		{"offset=0&limit=2", 0, 2, 2},
+		{"offset=2&limit=2", 2, 2, 4},
+		{"offset=4&limit=2", 4, 1, 0},
+		{"offset=5&limit=2", 0, 0, 0},
+		{"offset=99&limit=2", 0, 0, 0},
+		{"offset=3&limit=0", 3, 2, 0},
+		{"offset=1", 1, 4, 0},
+		{"limit=3", 0, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var page struct {
+				evidence.EvidenceChain
+				NextOffset *int `json:"nextOffset"`
+			}
+			if status := getJSON(t, srv.URL+"/api/chain?"+tt.query, &page); status != http.StatusOK {
+				t.Fatalf("status %d, want 200", status)
+			}
+			if page.Length != 5 || page.Head != chain.Head {
+				t.Errorf("page header = length %d head %s, want the whole chain's", page.Length, page.Head)
+			}
+			if len(page.Attestations) != tt.entries {
+				t.Fatalf("page has %d entries, want %d", len(page.Attestations), tt.entries)
+			}
+			if tt.entries > 0 && page.Attestations[0].Hash != chain.Attestations[tt.first].Hash {
+				t.Errorf("page starts at the wrong entry")
+			}
+			switch {
+			case tt.nextOffset == 0 && page.NextOffset != nil:
+				t.Errorf("next offset = %d, want none on the last page", *page.NextOffset)
+			case tt.nextOffset != 0 && (page.NextOffset == nil || *page.NextOffset != tt.nextOffset):
+				t.Errorf("next offset = %v, want %d", page.NextOffset, tt.nextOffset)
+			}
+		})
+	}
+}
+
+func TestChainEndpointRejectsInvalidWindows(t *testing.T) {
+	srv, _, _ := newTestViewer(t, newTestSigner(t), 1)
+
+	for _, query := range []string{"offset=-1", "limit=-5", "offset=two", "limit=1.5"} {
+		if status := getJSON(t, srv.URL+"/api/chain?"+query, nil); status != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400", query, status)
+		}
+	}
+}
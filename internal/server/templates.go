@@ -0,0 +1,107 @@
+/*
+Copyright 2025 Chris McConnell
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"html/template"
+	"time"
+)
+
+var funcs = template.FuncMap{
+	"short": func(hash string) string {
+		if len(hash) > 16 {
+			return hash[:16]
+		}
+		return hash
+	},
+	"time": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04:05 UTC")
+	},
+}
+
+const layout = `{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Mondrian Evidence Viewer</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; margin: 2rem auto; max-width: 960px; color: #1f2328; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid #d0d7de; vertical-align: top; }
+code { font-size: 0.9em; }
+.pass { color: #1a7f37; } .fail { color: #cf222e; } .warn { color: #9a6700; }
+.problem { color: #cf222e; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1><a href="/">Mondrian Evidence Viewer</a></h1>
+{{end}}`
+
+var indexTemplate = template.Must(template.New("index").Funcs(funcs).Parse(layout + `{{template "head"}}
+{{if not .Entries}}
+<p>No attestations yet. Run <code>mondrian attest</code> to start the evidence chain.</p>
+{{else}}
+<p>Chain <code>{{.Chain.ChainID}}</code> &middot; {{len .Entries}} attestations &middot; head <code>{{short .Chain.Head}}</code></p>
+{{if .LinkProblem}}<p class="problem">⚠️ Chain linkage broken: {{.LinkProblem}}</p>{{end}}
+<table>
+<tr><th>#</th><th>Timestamp</th><th>Status</th><th>Hash</th><th>Verified</th></tr>
+{{range .Entries}}
+<tr>
+<td>{{.Position}}</td>
+<td>{{time .Timestamp}}</td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td><a href="/attestation/{{.Hash}}"><code>{{short .Hash}}</code></a></td>
+<td>{{if .Problem}}<span class="problem">❌ {{.Problem}}</span>{{else}}<span class="pass">✅</span>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>`))
+
+var attestationTemplate = template.Must(template.New("attestation").Funcs(funcs).Parse(layout + `{{template "head"}}
+<h2>Attestation <code>{{short .Entry.Hash}}</code></h2>
+{{if .Problem}}<p class="problem">❌ Verification failed: {{.Problem}}</p>{{else}}<p class="pass">✅ Signature and hash verified</p>{{end}}
+{{with .Attestation}}
+<table>
+<tr><th>Hash</th><td><code>{{.Hash}}</code></td></tr>
+<tr><th>Parent</th><td><code>{{.ParentHash}}</code></td></tr>
+<tr><th>Timestamp</th><td>{{time .Timestamp}}</td></tr>
+<tr><th>Predicate</th><td><code>{{.PredicateType}}</code></td></tr>
+<tr><th>Repository</th><td>{{.Predicate.Repository}}</td></tr>
+<tr><th>Branch</th><td>{{.Predicate.Branch}}</td></tr>
+<tr><th>Commit</th><td><code>{{.Predicate.Commit}}</code></td></tr>
+<tr><th>Summary</th><td class="{{.Predicate.Summary.OverallStatus}}">{{.Predicate.Summary.OverallStatus}}: {{.Predicate.Summary.Passed}} passed, {{.Predicate.Summary.Failed}} failed, {{.Predicate.Summary.Warnings}} warnings</td></tr>
+</table>
+<h3>Subjects</h3>
+<ul>{{range .Subject}}<li><code>{{.Name}}</code>{{range $alg, $digest := .Digest}} <small>{{$alg}}:{{short $digest}}</small>{{end}}</li>{{end}}</ul>
+<h3>Results</h3>
+<table>
+<tr><th>Status</th><th>Severity</th><th>Rule</th><th>Message</th><th>Location</th></tr>
+{{range .Predicate.Results}}
+<tr>
+<td class="{{.Status}}">{{.Status}}</td>
+<td>{{.Severity}}</td>
+<td><code>{{.RuleName}}</code></td>
+<td>{{.Message}}</td>
+<td>{{if .File}}<code>{{.File}}{{if .Line}}:{{.Line}}{{end}}</code>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>`))